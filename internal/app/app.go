@@ -51,6 +51,10 @@ func Run(cfg *config.Config) error {
 		Repo:                  db,
 		Mailer:                mailer,
 		AccessTokenDurMinutes: cfg.AccessTokenLifetime,
+		TrashRetentionDays:    cfg.TrashRetentionDays,
+		BudgetAlertThresholds: cfg.BudgetAlertThresholds,
+		CurrencyProviders:     cfg.CurrencyProviders,
+		RateEvents:            cache.NewChannel(redis, "currency:rates"),
 		Models:                models,
 	}
 
@@ -60,7 +64,7 @@ func Run(cfg *config.Config) error {
 	}
 
 	l.Info("Initializing handlers...", zap.Int64("rate_limit_per_second", cfg.RateLimitPerSecond))
-	handlerV1 := v1.NewHandler(services, l, models, redis, cfg.RateLimitPerSecond)
+	handlerV1 := v1.NewHandler(services, l, models, redis, cfg.RateLimitPerSecond, cfg.AdminToken)
 	handlerOB := obhttp.NewHandler(services, l)
 
 	l.Info("Initializing routers...")
@@ -74,6 +78,12 @@ func Run(cfg *config.Config) error {
 	http.Handle("/docs/", docRouter)
 
 	go services.Currency.ScheduleCurrencyUpdates()
+	go services.Currency.BackfillHistory()
+	go services.Currency.ListenInvalidations()
+	go services.Recurring.ScheduleMaterialization()
+	go services.Trash.SchedulePurge()
+	go services.Budgets.ScheduleAlerts()
+	go services.NetWorth.ScheduleSnapshots()
 
 	l.Info("Serving...")
 	//changed tls hosting now everything works
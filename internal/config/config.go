@@ -8,21 +8,37 @@ import (
 	"gopkg.in/yaml.v3"
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
-	Host                string         `yaml:"host"`
-	Port                int            `yaml:"port"`
-	DBPassword          string         `yaml:"db_password"`
-	CrtPath             string         `yaml:"crt_path"`
-	KeyPath             string         `yaml:"key_path"`
-	SecretKey           []byte         `yaml:"secret_key"`
-	SecretRefreshKey    []byte         `yaml:"secret_refresh_key"`
-	CurrencyURL         string         `yaml:"currency_url"`
-	Rabbit              rabbit.Config  `yaml:"rabbit"`
-	AccessTokenLifetime int            `yaml:"access_token_dur_minutes"`
-	RateLimitPerSecond  int64          `yaml:"rate_limit_per_second"`
-	Redis               cache.RedisCfg `yaml:"redis"`
+	Host                  string             `yaml:"host"`
+	Port                  int                `yaml:"port"`
+	DBPassword            string             `yaml:"db_password"`
+	CrtPath               string             `yaml:"crt_path"`
+	KeyPath               string             `yaml:"key_path"`
+	SecretKey             []byte             `yaml:"secret_key"`
+	SecretRefreshKey      []byte             `yaml:"secret_refresh_key"`
+	CurrencyURL           string             `yaml:"currency_url"`
+	CurrencyProviders     []CurrencyProvider `yaml:"currency_providers"`
+	Rabbit                rabbit.Config      `yaml:"rabbit"`
+	AccessTokenLifetime   int                `yaml:"access_token_dur_minutes"`
+	RateLimitPerSecond    int64              `yaml:"rate_limit_per_second"`
+	Redis                 cache.RedisCfg     `yaml:"redis"`
+	TrashRetentionDays    int                `yaml:"trash_retention_days"`
+	BudgetAlertThresholds []int              `yaml:"budget_alert_thresholds"`
+	// AdminToken - токен служебных запросов в заголовке X-Admin-Token; пустой токен отключает их.
+	AdminToken string `yaml:"admin_token"`
+}
+
+// CurrencyProvider - настройка провайдера курсов. Провайдеры опрашиваются в порядке перечисления в конфиге.
+type CurrencyProvider struct {
+	// Type - cbr_json, cbr_xml, ecb или static.
+	Type string `yaml:"type"`
+	// URL - адрес источника; если не задан, используется адрес по умолчанию.
+	URL string `yaml:"url"`
+	// Path - путь к YAML-файлу с курсами для static.
+	Path string `yaml:"path"`
 }
 
 func New() (*Config, error) {
@@ -89,6 +105,15 @@ func processEnvironment(cfg *Config) error {
 		cfg.CurrencyURL = currencyURL
 	}
 
+	// CURRENCY_PROVIDERS - типы провайдеров курсов через запятую в порядке приоритета, с адресами по умолчанию.
+	if providersStr, exists := os.LookupEnv("CURRENCY_PROVIDERS"); exists {
+		var providers []CurrencyProvider
+		for _, part := range strings.Split(providersStr, ",") {
+			providers = append(providers, CurrencyProvider{Type: strings.TrimSpace(part)})
+		}
+		cfg.CurrencyProviders = providers
+	}
+
 	if rabbitUrl, exists := os.LookupEnv("RABBIT_URL"); exists {
 		cfg.Rabbit.URL = rabbitUrl
 	}
@@ -109,6 +134,30 @@ func processEnvironment(cfg *Config) error {
 		cfg.RateLimitPerSecond = int64(rate)
 	}
 
+	if retentionStr, exists := os.LookupEnv("TRASH_RETENTION_DAYS"); exists {
+		retention, err := strconv.Atoi(retentionStr)
+		if err != nil {
+			return fmt.Errorf("invalid trash retention days value: %w", err)
+		}
+		cfg.TrashRetentionDays = retention
+	}
+
+	if thresholdsStr, exists := os.LookupEnv("BUDGET_ALERT_THRESHOLDS"); exists {
+		var thresholds []int
+		for _, part := range strings.Split(thresholdsStr, ",") {
+			threshold, err := strconv.Atoi(strings.TrimSpace(part))
+			if err != nil {
+				return fmt.Errorf("invalid budget alert thresholds value: %w", err)
+			}
+			thresholds = append(thresholds, threshold)
+		}
+		cfg.BudgetAlertThresholds = thresholds
+	}
+
+	if adminToken, exists := os.LookupEnv("ADMIN_TOKEN"); exists {
+		cfg.AdminToken = adminToken
+	}
+
 	if redisURL, exists := os.LookupEnv("REDIS_URL"); exists {
 		cfg.Redis.URL = redisURL
 	}
@@ -1,43 +1,128 @@
 //go:build !exclude_swagger
 // +build !exclude_swagger
 
-// Package history provides operations archive functionality.
+// Package history provides the change history of financial records.
 package history
 
 import (
 	"encoding/json"
-	"github.com/go-chi/chi/v5"
+	"errors"
+	"fmt"
 	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
+	utility "github.com/wachrusz/Back-End-API/pkg/util"
 )
 
-func RegisterHandlers(router chi.Router) {
-	router.Get("/history/get", GetHistory)
-	router.Post("/history/add", AddHistoryEntry)
+type HistoryResponse struct {
+	Message    string                `json:"message"`
+	History    []models.HistoryEntry `json:"history"`
+	StatusCode int                   `json:"status_code"`
+}
+
+type handlers struct {
+	s History
+}
+
+// RegisterHandlers registers the history routes. Every route is wrapped in auth.
+func RegisterHandlers(router chi.Router, s History, auth func(http.HandlerFunc) http.HandlerFunc) {
+	h := &handlers{s: s}
+	router.Route("/history", func(r chi.Router) {
+		r.Get("/", auth(h.GetHistory))
+		r.Get("/record", auth(h.GetRecordHistory))
+	})
 }
 
+// GetHistory lists the change history of all records of the user.
+//
 // @Summary Get history entries
-// @Description Get a list of history entries.
+// @Description Get the changes of expenses, incomes, wealth funds, goals and accounts of the user, newest first. Each entry holds the state of the record before and after the change, the user who made it (empty for changes made by the server) and the device ID.
 // @Tags History
 // @Produce json
-// @Success 200 {array} string "List of history entries"
-// @Router /history/get [get]
-func GetHistory(w http.ResponseWriter, r *http.Request) {
-	//TODO Реализация логики получения истории
-
-	history := []string{}
-	json.NewEncoder(w).Encode(history)
+// @Param record_type query string false "Only records of this type: expense, income, wealth_fund, goal or account"
+// @Param limit query int false "Page size, 50 by default, at most 500"
+// @Param offset query int false "Number of entries to skip"
+// @Success 200 {object} HistoryResponse "Successfully got history"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid query parameters"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting history"
+// @Security JWT
+// @Router /history [get]
+func (h *handlers) GetHistory(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, false)
 }
 
-// @Summary Create history entry
-// @Description Create a new entry to the history.
+// GetRecordHistory lists the change history of a single record.
+//
+// @Summary Get history of a record
+// @Description Get all changes of one expense, income, wealth fund, goal or account of the user, newest first.
 // @Tags History
-// @Accept json
 // @Produce json
-// @Success 200 {string} string "History entry added successfully"
-// @Router /history/add [post]
-func AddHistoryEntry(w http.ResponseWriter, r *http.Request) {
-	//TODO Реализация логики добавления записи в историю
+// @Param record_type query string true "Record type: expense, income, wealth_fund, goal or account"
+// @Param id query string true "Record ID"
+// @Param limit query int false "Page size, 50 by default, at most 500"
+// @Param offset query int false "Number of entries to skip"
+// @Success 200 {object} HistoryResponse "Successfully got history"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid query parameters"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting history"
+// @Security JWT
+// @Router /history/record [get]
+func (h *handlers) GetRecordHistory(w http.ResponseWriter, r *http.Request) {
+	h.list(w, r, true)
+}
+
+func (h *handlers) list(w http.ResponseWriter, r *http.Request, byRecord bool) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		jsonresponse.SendErrorResponse(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := &models.HistoryFilter{
+		UserID:     userID,
+		RecordType: query.Get("record_type"),
+	}
+	if byRecord {
+		filter.RecordID = query.Get("id")
+		if filter.RecordType == "" || filter.RecordID == "" {
+			jsonresponse.SendErrorResponse(w, fmt.Errorf("record_type and id are required"), http.StatusBadRequest)
+			return
+		}
+	}
+	for param, dst := range map[string]*int{"limit": &filter.Limit, "offset": &filter.Offset} {
+		value := query.Get(param)
+		if value == "" {
+			continue
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			jsonresponse.SendErrorResponse(w, fmt.Errorf("invalid %s: %v", param, err), http.StatusBadRequest)
+			return
+		}
+		*dst = n
+	}
+
+	entries, err := h.s.List(filter)
+	if err != nil {
+		if errors.Is(err, myerrors.ErrValidation) {
+			jsonresponse.SendErrorResponse(w, fmt.Errorf("invalid history query: %v", err), http.StatusBadRequest)
+		} else {
+			jsonresponse.SendErrorResponse(w, fmt.Errorf("error getting history: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
 
-	w.WriteHeader(http.StatusOK)
-	w.Write([]byte("History entry added successfully"))
+	response := HistoryResponse{
+		Message:    "Successfully got history",
+		History:    entries,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
 }
@@ -0,0 +1,49 @@
+//go:build !exclude_swagger
+// +build !exclude_swagger
+
+package history
+
+import (
+	"fmt"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	repo "github.com/wachrusz/Back-End-API/internal/repository"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+const (
+	// DefaultLimit - размер страницы журнала, если он не задан в запросе.
+	DefaultLimit = 50
+	// MaxLimit - максимальный размер страницы журнала.
+	MaxLimit = 500
+)
+
+// History читает журнал изменений. Изменения записываются в журнал в репозитории, в той же транзакции,
+// что и само изменение.
+type History interface {
+	List(filter *models.HistoryFilter) ([]models.HistoryEntry, error)
+}
+
+type Service struct {
+	history repo.HistoryRepo
+}
+
+func NewService(hr repo.HistoryRepo) *Service {
+	return &Service{history: hr}
+}
+
+func (s *Service) List(filter *models.HistoryFilter) ([]models.HistoryEntry, error) {
+	if filter.RecordID != "" && filter.RecordType == "" {
+		return nil, fmt.Errorf("%w: record type is required with record id", myerrors.ErrValidation)
+	}
+	if filter.Limit <= 0 {
+		filter.Limit = DefaultLimit
+	}
+	if filter.Limit > MaxLimit {
+		return nil, fmt.Errorf("%w: limit must not exceed %d", myerrors.ErrValidation, MaxLimit)
+	}
+	if filter.Offset < 0 {
+		return nil, fmt.Errorf("%w: offset must not be negative", myerrors.ErrValidation)
+	}
+	return s.history.List(filter)
+}
@@ -19,6 +19,7 @@ func newRouter(h *v1.MyHandler, obh *obhttp.MyHandler) chi.Router {
 		h.RegisterUserHandlers(r)
 		h.RegisterProfileHandlers(r)
 		h.RegisterFinHealthHandlers(r)
+		h.RegisterHistoryHandlers(r)
 	})
 
 	//Open Banking Group
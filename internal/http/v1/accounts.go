@@ -50,7 +50,7 @@ func (h *MyHandler) AddConnectedAccountHandler(w http.ResponseWriter, r *http.Re
 
 	h.l.Debug(account.AccountNumber + account.AccountType + account.BankID + account.ID + account.UserID)
 
-	connectedAccountID, err := h.m.Accounts.Create(&account)
+	connectedAccountID, err := h.m.Accounts.Create(h.actor(r), &account)
 	if err != nil {
 		h.errResp(w, fmt.Errorf("error adding connected account: %v", err), http.StatusInternalServerError)
 		return
@@ -92,7 +92,7 @@ func (h *MyHandler) DeleteConnectedAccountHandler(w http.ResponseWriter, r *http
 		return
 	}
 
-	if err := h.m.Accounts.Delete(id.ID, userID); err != nil {
+	if err := h.m.Accounts.Delete(h.actor(r), id.ID, userID); err != nil {
 		if errors.Is(err, myerrors.ErrNotFound) {
 			h.errResp(w, fmt.Errorf("connected account not found: %v", err), http.StatusNotFound)
 		} else {
@@ -145,7 +145,7 @@ func (h *MyHandler) UpdateConnectedAccountHandler(w http.ResponseWriter, r *http
 	editedAccount.UserID = userID
 
 	// Attempt to update the account
-	if err := h.m.Accounts.Update(&editedAccount); err != nil {
+	if err := h.m.Accounts.Update(h.actor(r), &editedAccount); err != nil {
 		if errors.Is(err, myerrors.ErrNotFound) {
 			h.errResp(w, fmt.Errorf("connected account not found: %v", err), http.StatusNotFound)
 		} else {
@@ -0,0 +1,73 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"net/http"
+
+	utility "github.com/wachrusz/Back-End-API/pkg/util"
+)
+
+type AggregationResponse struct {
+	Message     string             `json:"message"`
+	Aggregation models.Aggregation `json:"aggregation"`
+	StatusCode  int                `json:"status_code"`
+}
+
+// AggregateTransactionsHandler aggregates incomes or expenses by time buckets and groups.
+//
+// @Summary Get the spending breakdown
+// @Description Get totals, transaction counts, averages and shares of incomes or expenses grouped by category, account, payee or tag and bucketed by day, week (starting on Monday), month, quarter or year. Amounts are converted to rubles at the exchange rate of the transaction date and then into the requested currency at the current rate. Each bucket lists its groups ordered by total with their share of the bucket total in percent; total holds the same numbers for the whole period. A transaction with several tags counts in each of its tags, and split expenses count in the category of each part, so shares of tags may add up to more than 100. Buckets without transactions are returned with zero totals. Dates of transactions are calendar dates; timezone defines the current date used for the default period: the last 12 buckets, or the last 30 days with bucket=none.
+// @Tags Analytics
+// @Produce json
+// @Param kind query string false "expense (default) or income"
+// @Param group_by query string false "category (default), account, payee, tag or none"
+// @Param bucket query string false "day, week, month (default), quarter, year or none"
+// @Param from query string false "First date (YYYY-MM-DD)"
+// @Param to query string false "Last date (YYYY-MM-DD), today by default"
+// @Param currency query string false "Currency code, RUB by default"
+// @Param timezone query string false "IANA timezone of the user, UTC by default"
+// @Success 200 {object} AggregationResponse "Successfully got the aggregation"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid query parameters"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting aggregation"
+// @Security JWT
+// @Router /analytics/aggregate [get]
+func (h *MyHandler) AggregateTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := models.AggregationFilter{
+		UserID:  userID,
+		Kind:    query.Get("kind"),
+		GroupBy: query.Get("group_by"),
+		Bucket:  query.Get("bucket"),
+		From:    query.Get("from"),
+		To:      query.Get("to"),
+	}
+
+	aggregation, err := h.s.Aggregation.Aggregate(&filter, query.Get("currency"), query.Get("timezone"))
+	if err != nil {
+		if errors.Is(err, myerrors.ErrValidation) {
+			h.errResp(w, fmt.Errorf("invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+		h.errResp(w, fmt.Errorf("error getting aggregation: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := AggregationResponse{
+		Message:     "Successfully got the aggregation",
+		Aggregation: *aggregation,
+		StatusCode:  http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
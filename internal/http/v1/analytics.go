@@ -8,6 +8,9 @@ import (
 	"github.com/wachrusz/Back-End-API/internal/repository/models"
 	"go.uber.org/zap"
 	"net/http"
+	"strconv"
+	"strings"
+	"time"
 
 	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
 	utility "github.com/wachrusz/Back-End-API/pkg/util"
@@ -31,12 +34,12 @@ type WealthFundRequest struct {
 // CreateExpenseHandler creates a new expense record in the database.
 //
 // @Summary Create a expense
-// @Description Create a new expense record.
+// @Description Create a new expense record. If it looks like an expense saved earlier (same amount and currency, close date, similar recipient and account), the expense is still created and the response holds a warning and the possible duplicates.
 // @Tags Analytics
 // @Accept json
 // @Produce json
 // @Param expense body ExpenseRequest true "Expense object"
-// @Success 201 {object} jsonresponse.IdResponse "Successfully created an expense"
+// @Success 201 {object} CreatedTransactionResponse "Successfully created an expense"
 // @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
 // @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
 // @Failure 500 {object} jsonresponse.ErrorResponse "Error creating expense"
@@ -64,18 +67,37 @@ func (h *MyHandler) CreateExpenseHandler(w http.ResponseWriter, r *http.Request)
 	// Assign user ID to the expense
 	expense.UserID = userID
 
+	// Fill in the category, tags and planned flag from the user's categorization rules
+	if err := h.s.Rules.CategorizeExpense(&expense); err != nil {
+		h.l.Warn("Error applying categorization rules", zap.Error(err))
+	}
+
+	// Look for the same transaction entered manually or imported earlier
+	duplicates := h.possibleDuplicates(userID, models.DuplicateRecord{
+		Kind:        models.KindExpense,
+		Date:        expense.Date,
+		Amount:      expense.Amount,
+		Currency:    expense.Currency,
+		Payee:       expense.SentTo,
+		BankAccount: expense.BankAccount,
+	})
+
 	// Create a new expense in the database
-	expenseID, err := h.m.Expenses.Create(&expense)
+	expenseID, err := h.m.Expenses.Create(h.actor(r), &expense)
 	if err != nil {
 		h.errResp(w, fmt.Errorf("error creating expense: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	// Send success response
-	response := jsonresponse.IdResponse{
-		Message:    "Successfully created an expense",
-		Id:         expenseID,
-		StatusCode: http.StatusCreated,
+	response := CreatedTransactionResponse{
+		Message:            "Successfully created an expense",
+		Id:                 expenseID,
+		PossibleDuplicates: duplicates,
+		StatusCode:         http.StatusCreated,
+	}
+	if len(duplicates) > 0 {
+		response.Warning = "possible duplicate of an existing expense"
 	}
 	w.WriteHeader(response.StatusCode)
 	json.NewEncoder(w).Encode(response)
@@ -86,7 +108,7 @@ func (h *MyHandler) CreateExpenseHandler(w http.ResponseWriter, r *http.Request)
 // UpdateExpenseHandler handles the update of an existing expense.
 //
 // @Summary Update the expense
-// @Description Update an existing expense. There is no need to fill user_id field.
+// @Description Update an existing expense. There is no need to fill user_id field. The amount of a split expense can't be changed until its splits are updated or removed.
 // @Tags Analytics
 // @Accept json
 // @Produce json
@@ -119,9 +141,11 @@ func (h *MyHandler) UpdateExpenseHandler(w http.ResponseWriter, r *http.Request)
 	expense.UserID = userID
 
 	// Attempt to update the account
-	if err := h.m.Expenses.Update(&expense); err != nil {
+	if err := h.m.Expenses.Update(h.actor(r), &expense); err != nil {
 		if errors.Is(err, myerrors.ErrNotFound) {
 			h.errResp(w, fmt.Errorf("expense not found: %v", err), http.StatusNotFound)
+		} else if errors.Is(err, myerrors.ErrValidation) {
+			h.errResp(w, fmt.Errorf("invalid expense: %v", err), http.StatusBadRequest)
 		} else {
 			h.errResp(w, fmt.Errorf("error updating expense: %v", err), http.StatusInternalServerError)
 		}
@@ -137,10 +161,101 @@ func (h *MyHandler) UpdateExpenseHandler(w http.ResponseWriter, r *http.Request)
 	json.NewEncoder(w).Encode(response)
 }
 
+// ExpenseSplitRequest is used for deserialization
+type ExpenseSplitRequest struct {
+	ExpenseID string                `json:"expense_id"`
+	Splits    []models.ExpenseSplit `json:"splits"`
+}
+
+// SplitExpenseHandler splits an expense across several categories.
+//
+// @Summary Split the expense
+// @Description Replace the parts of the expense. Each part has its own category, amount and note; the parts must sum to the expense amount. Analytics, financial health and the operations archive count the parts instead of the expense.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param split body ExpenseSplitRequest true "Expense parts"
+// @Success 200 {object} jsonresponse.SuccessResponse "Expense split successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Expense not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error splitting expense"
+// @Security JWT
+// @Router /analytics/expense/split [put]
+func (h *MyHandler) SplitExpenseHandler(w http.ResponseWriter, r *http.Request) {
+	var req ExpenseSplitRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+	if len(req.Splits) == 0 {
+		h.errResp(w, errors.New("invalid request payload: splits are empty"), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	h.setExpenseSplits(w, r, req.ExpenseID, userID, req.Splits, "Expense split successfully")
+}
+
+// UnsplitExpenseHandler removes the parts of a split expense.
+//
+// @Summary Remove the expense split
+// @Description Remove the parts of the expense, so it is counted in its own category again.
+// @Tags Analytics
+// @Param id body jsonresponse.IdRequest true "Expense id"
+// @Success 200 {object} jsonresponse.SuccessResponse "Expense split removed successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Expense not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error removing expense split"
+// @Security JWT
+// @Router /analytics/expense/split [delete]
+func (h *MyHandler) UnsplitExpenseHandler(w http.ResponseWriter, r *http.Request) {
+	var id jsonresponse.IdRequest
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	h.setExpenseSplits(w, r, id.ID, userID, nil, "Expense split removed successfully")
+}
+
+func (h *MyHandler) setExpenseSplits(w http.ResponseWriter, r *http.Request, expenseID, userID string, splits []models.ExpenseSplit, message string) {
+	if err := h.m.Expenses.SetSplits(h.actor(r), expenseID, userID, splits); err != nil {
+		switch {
+		case errors.Is(err, myerrors.ErrValidation):
+			h.errResp(w, fmt.Errorf("invalid split: %v", err), http.StatusBadRequest)
+		case errors.Is(err, myerrors.ErrNotFound):
+			h.errResp(w, fmt.Errorf("expense not found: %v", err), http.StatusNotFound)
+		default:
+			h.errResp(w, fmt.Errorf("error splitting expense: %v", err), http.StatusInternalServerError)
+		}
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    message,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
 // DeleteExpenseHandler handles the deletion of an existing expense.
 //
 // @Summary Delete the expense
-// @Description Delete the existing expense.
+// @Description Move the existing expense to the trash. It can be restored until the trash retention period expires.
 // @Tags Analytics
 // @Param ConnectedAccount body jsonresponse.IdRequest true "Expense id"
 // @Success 204 {object} jsonresponse.SuccessResponse "Expense deleted successfully"
@@ -164,7 +279,7 @@ func (h *MyHandler) DeleteExpenseHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := h.m.Expenses.Delete(id.ID, userID); err != nil {
+	if err := h.m.Expenses.Delete(h.actor(r), id.ID, userID); err != nil {
 		if errors.Is(err, myerrors.ErrNotFound) {
 			h.errResp(w, fmt.Errorf("expense not found: %v", err), http.StatusNotFound)
 		} else {
@@ -184,7 +299,7 @@ func (h *MyHandler) DeleteExpenseHandler(w http.ResponseWriter, r *http.Request)
 // CreateIncomeHandler creates a new income record in the database.
 //
 // @Summary Create an income
-// @Description Create a new income record.
+// @Description Create a new income record. If it looks like an income saved earlier (same amount and currency, close date, similar sender and account), the income is still created and the response holds a warning and the possible duplicates.
 // @Tags Analytics
 // @Accept json
 // @Produce json
@@ -217,18 +332,37 @@ func (h *MyHandler) CreateIncomeHandler(w http.ResponseWriter, r *http.Request)
 	// Assign user ID to the income
 	income.UserID = userID
 
+	// Fill in the category, tags and planned flag from the user's categorization rules
+	if err := h.s.Rules.CategorizeIncome(&income); err != nil {
+		h.l.Warn("Error applying categorization rules", zap.Error(err))
+	}
+
+	// Look for the same transaction entered manually or imported earlier
+	duplicates := h.possibleDuplicates(userID, models.DuplicateRecord{
+		Kind:        models.KindIncome,
+		Date:        income.Date,
+		Amount:      income.Amount,
+		Currency:    income.Currency,
+		Payee:       income.Sender,
+		BankAccount: income.BankAccount,
+	})
+
 	// Create a new income in the database
-	incomeID, err := h.m.Incomes.Create(&income)
+	incomeID, err := h.m.Incomes.Create(h.actor(r), &income)
 	if err != nil {
 		h.errResp(w, fmt.Errorf("error creating income: %v", err), http.StatusInternalServerError)
 		return
 	}
 
 	// Send success response
-	response := jsonresponse.IdResponse{
-		Message:    "Successfully created an income",
-		Id:         incomeID,
-		StatusCode: http.StatusCreated,
+	response := CreatedTransactionResponse{
+		Message:            "Successfully created an income",
+		Id:                 incomeID,
+		PossibleDuplicates: duplicates,
+		StatusCode:         http.StatusCreated,
+	}
+	if len(duplicates) > 0 {
+		response.Warning = "possible duplicate of an existing income"
 	}
 	w.WriteHeader(response.StatusCode)
 	json.NewEncoder(w).Encode(response)
@@ -272,7 +406,7 @@ func (h *MyHandler) UpdateIncomeHandler(w http.ResponseWriter, r *http.Request)
 	income.UserID = userID
 
 	// Attempt to update the account
-	if err := h.m.Incomes.Update(&income); err != nil {
+	if err := h.m.Incomes.Update(h.actor(r), &income); err != nil {
 		if errors.Is(err, myerrors.ErrNotFound) {
 			h.errResp(w, fmt.Errorf("income not found: %v", err), http.StatusNotFound)
 		} else {
@@ -293,7 +427,7 @@ func (h *MyHandler) UpdateIncomeHandler(w http.ResponseWriter, r *http.Request)
 // DeleteIncomeHandler handles the deletion of an existing income.
 //
 // @Summary Delete the income
-// @Description Delete the existing income.
+// @Description Move the existing income to the trash. It can be restored until the trash retention period expires.
 // @Tags Analytics
 // @Param ConnectedAccount body jsonresponse.IdRequest true "income id"
 // @Success 204 {object} jsonresponse.SuccessResponse "income deleted successfully"
@@ -317,7 +451,7 @@ func (h *MyHandler) DeleteIncomeHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	if err := h.m.Incomes.Delete(id.ID, userID); err != nil {
+	if err := h.m.Incomes.Delete(h.actor(r), id.ID, userID); err != nil {
 		if errors.Is(err, myerrors.ErrNotFound) {
 			h.errResp(w, fmt.Errorf("income not found: %v", err), http.StatusNotFound)
 		} else {
@@ -371,7 +505,7 @@ func (h *MyHandler) CreateWealthFundHandler(w http.ResponseWriter, r *http.Reque
 	wealthFund.UserID = userID
 
 	// Create a new wealth fund in the database
-	wealthFundID, err := h.m.WealthFunds.Create(&wealthFund)
+	wealthFundID, err := h.m.WealthFunds.Create(h.actor(r), &wealthFund)
 	if err != nil {
 		h.errResp(w, fmt.Errorf("error creating wealth fund: %v", err), http.StatusInternalServerError)
 		return
@@ -425,7 +559,7 @@ func (h *MyHandler) UpdateWealthFundHandler(w http.ResponseWriter, r *http.Reque
 	wealthFund.UserID = userID
 
 	// Attempt to update the account
-	if err := h.m.WealthFunds.Update(&wealthFund); err != nil {
+	if err := h.m.WealthFunds.Update(h.actor(r), &wealthFund); err != nil {
 		if errors.Is(err, myerrors.ErrNotFound) {
 			h.errResp(w, fmt.Errorf("wealth fund not found: %v", err), http.StatusNotFound)
 		} else {
@@ -446,7 +580,7 @@ func (h *MyHandler) UpdateWealthFundHandler(w http.ResponseWriter, r *http.Reque
 // DeleteWealthFundHandler handles the deletion of an existing wealth fund.
 //
 // @Summary Delete the wealth fund
-// @Description Delete the existing wealth fund.
+// @Description Move the existing wealth fund record to the trash. It can be restored until the trash retention period expires.
 // @Tags Analytics
 // @Param ConnectedAccount body jsonresponse.IdRequest true "wealth fund id"
 // @Success 204 {object} jsonresponse.SuccessResponse "wealth fund deleted successfully"
@@ -470,7 +604,7 @@ func (h *MyHandler) DeleteWealthFundHandler(w http.ResponseWriter, r *http.Reque
 		return
 	}
 
-	if err := h.m.WealthFunds.Delete(id.ID, userID); err != nil {
+	if err := h.m.WealthFunds.Delete(h.actor(r), id.ID, userID); err != nil {
 		if errors.Is(err, myerrors.ErrNotFound) {
 			h.errResp(w, fmt.Errorf("wealth fund not found: %v", err), http.StatusNotFound)
 		} else {
@@ -486,3 +620,289 @@ func (h *MyHandler) DeleteWealthFundHandler(w http.ResponseWriter, r *http.Reque
 	w.WriteHeader(response.StatusCode)
 	json.NewEncoder(w).Encode(response)
 }
+
+const (
+	defaultListLimit = 20
+	maxListLimit     = 100
+)
+
+type ExpenseListResponse struct {
+	Message    string                 `json:"message"`
+	Expenses   []models.Expense       `json:"expenses"`
+	Metadata   *jsonresponse.Metadata `json:"metadata"`
+	StatusCode int                    `json:"status_code"`
+}
+
+type IncomeListResponse struct {
+	Message    string                 `json:"message"`
+	Incomes    []models.Income        `json:"incomes"`
+	Metadata   *jsonresponse.Metadata `json:"metadata"`
+	StatusCode int                    `json:"status_code"`
+}
+
+type WealthFundListResponse struct {
+	Message     string                 `json:"message"`
+	WealthFunds []models.WealthFund    `json:"wealth_funds"`
+	Metadata    *jsonresponse.Metadata `json:"metadata"`
+	StatusCode  int                    `json:"status_code"`
+}
+
+// parseTransactionFilter reads the list filter from the query parameters.
+func parseTransactionFilter(r *http.Request, userID string) (*models.TransactionFilter, error) {
+	query := r.URL.Query()
+	filter := &models.TransactionFilter{
+		UserID:      userID,
+		DateFrom:    query.Get("date_from"),
+		DateTo:      query.Get("date_to"),
+		CategoryID:  query.Get("category_id"),
+		BankAccount: query.Get("bank_account"),
+		Currency:    query.Get("currency"),
+		Sort:        models.SortByDate,
+		Desc:        true,
+		Limit:       defaultListLimit,
+		Cursor:      query.Get("cursor"),
+	}
+
+	for _, date := range []string{filter.DateFrom, filter.DateTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse("2006-01-02", date); err != nil {
+			return nil, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", date)
+		}
+	}
+
+	for _, value := range query["tag_id"] {
+		for _, tagID := range strings.Split(value, ",") {
+			if _, err := strconv.ParseInt(tagID, 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid tag_id %q", tagID)
+			}
+			filter.TagIDs = append(filter.TagIDs, tagID)
+		}
+	}
+
+	if planned := query.Get("planned"); planned != "" {
+		value, err := strconv.ParseBool(planned)
+		if err != nil {
+			return nil, fmt.Errorf("invalid planned flag: %v", err)
+		}
+		filter.Planned = &value
+	}
+
+	for _, bound := range []struct {
+		name string
+		dst  **float64
+	}{
+		{"amount_min", &filter.AmountMin},
+		{"amount_max", &filter.AmountMax},
+	} {
+		raw := query.Get(bound.name)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s: %v", bound.name, err)
+		}
+		*bound.dst = &value
+	}
+	if filter.AmountMin != nil && filter.AmountMax != nil && *filter.AmountMin > *filter.AmountMax {
+		return nil, errors.New("amount_min is greater than amount_max")
+	}
+
+	switch sort := query.Get("sort"); sort {
+	case "":
+	case models.SortByDate, models.SortByAmount:
+		filter.Sort = sort
+	default:
+		return nil, fmt.Errorf("invalid sort %q, expected date or amount", sort)
+	}
+	switch order := query.Get("order"); order {
+	case "", "desc":
+	case "asc":
+		filter.Desc = false
+	default:
+		return nil, fmt.Errorf("invalid order %q, expected asc or desc", order)
+	}
+
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil || limit <= 0 {
+			return nil, fmt.Errorf("invalid limit %q", limitStr)
+		}
+		filter.Limit = min(limit, maxListLimit)
+	}
+
+	return filter, nil
+}
+
+// listErrResp maps list errors to http status codes.
+func (h *MyHandler) listErrResp(w http.ResponseWriter, err error, action string) {
+	if errors.Is(err, myerrors.ErrValidation) {
+		h.errResp(w, fmt.Errorf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	h.errResp(w, fmt.Errorf("error %s: %v", action, err), http.StatusInternalServerError)
+}
+
+// ListExpensesHandler lists the user's expenses.
+//
+// @Summary List expenses
+// @Description Get the user's expenses filtered by date range, category, connected account, currency, planned flag, amount range and tags. Pages are requested with the next_cursor from the metadata of the previous page.
+// @Tags Analytics
+// @Produce json
+// @Param date_from query string false "Start date (YYYY-MM-DD), inclusive"
+// @Param date_to query string false "End date (YYYY-MM-DD), inclusive"
+// @Param category_id query string false "Category id"
+// @Param bank_account query string false "Connected account number"
+// @Param currency query string false "Currency code"
+// @Param planned query bool false "Planned flag"
+// @Param amount_min query number false "Minimal amount"
+// @Param amount_max query number false "Maximal amount"
+// @Param tag_id query []string false "Tag ids, records with any of the tags are returned" collectionFormat(multi)
+// @Param sort query string false "Sort field: date (default) or amount"
+// @Param order query string false "Sort order: desc (default) or asc"
+// @Param limit query int false "Page size, 20 by default, at most 100"
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} ExpenseListResponse "Successfully got expenses"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting expenses"
+// @Security JWT
+// @Router /analytics/expense [get]
+func (h *MyHandler) ListExpensesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := parseTransactionFilter(r, userID)
+	if err != nil {
+		h.errResp(w, fmt.Errorf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	expenses, meta, err := h.m.Expenses.List(filter)
+	if err != nil {
+		h.listErrResp(w, err, "getting expenses")
+		return
+	}
+
+	response := ExpenseListResponse{
+		Message:    "Successfully got expenses",
+		Expenses:   expenses,
+		Metadata:   meta,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ListIncomesHandler lists the user's incomes.
+//
+// @Summary List incomes
+// @Description Get the user's incomes filtered by date range, category, connected account, currency, planned flag, amount range and tags. Pages are requested with the next_cursor from the metadata of the previous page.
+// @Tags Analytics
+// @Produce json
+// @Param date_from query string false "Start date (YYYY-MM-DD), inclusive"
+// @Param date_to query string false "End date (YYYY-MM-DD), inclusive"
+// @Param category_id query string false "Category id"
+// @Param bank_account query string false "Connected account number"
+// @Param currency query string false "Currency code"
+// @Param planned query bool false "Planned flag"
+// @Param amount_min query number false "Minimal amount"
+// @Param amount_max query number false "Maximal amount"
+// @Param tag_id query []string false "Tag ids, records with any of the tags are returned" collectionFormat(multi)
+// @Param sort query string false "Sort field: date (default) or amount"
+// @Param order query string false "Sort order: desc (default) or asc"
+// @Param limit query int false "Page size, 20 by default, at most 100"
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} IncomeListResponse "Successfully got incomes"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting incomes"
+// @Security JWT
+// @Router /analytics/income [get]
+func (h *MyHandler) ListIncomesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := parseTransactionFilter(r, userID)
+	if err != nil {
+		h.errResp(w, fmt.Errorf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	incomes, meta, err := h.m.Incomes.List(filter)
+	if err != nil {
+		h.listErrResp(w, err, "getting incomes")
+		return
+	}
+
+	response := IncomeListResponse{
+		Message:    "Successfully got incomes",
+		Incomes:    incomes,
+		Metadata:   meta,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ListWealthFundsHandler lists the user's wealth fund entries.
+//
+// @Summary List wealth fund entries
+// @Description Get the user's wealth fund entries filtered by date range, category, connected account, currency, planned flag, amount range and tags. Pages are requested with the next_cursor from the metadata of the previous page.
+// @Tags Analytics
+// @Produce json
+// @Param date_from query string false "Start date (YYYY-MM-DD), inclusive"
+// @Param date_to query string false "End date (YYYY-MM-DD), inclusive"
+// @Param category_id query string false "Category id"
+// @Param bank_account query string false "Connected account number"
+// @Param currency query string false "Currency code"
+// @Param planned query bool false "Planned flag"
+// @Param amount_min query number false "Minimal amount"
+// @Param amount_max query number false "Maximal amount"
+// @Param tag_id query []string false "Tag ids, records with any of the tags are returned" collectionFormat(multi)
+// @Param sort query string false "Sort field: date (default) or amount"
+// @Param order query string false "Sort order: desc (default) or asc"
+// @Param limit query int false "Page size, 20 by default, at most 100"
+// @Param cursor query string false "Cursor of the next page"
+// @Success 200 {object} WealthFundListResponse "Successfully got wealth fund entries"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting wealth fund entries"
+// @Security JWT
+// @Router /analytics/wealth_fund [get]
+func (h *MyHandler) ListWealthFundsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	filter, err := parseTransactionFilter(r, userID)
+	if err != nil {
+		h.errResp(w, fmt.Errorf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	wealthFunds, meta, err := h.m.WealthFunds.List(filter)
+	if err != nil {
+		h.listErrResp(w, err, "getting wealth fund entries")
+		return
+	}
+
+	response := WealthFundListResponse{
+		Message:     "Successfully got wealth fund entries",
+		WealthFunds: wealthFunds,
+		Metadata:    meta,
+		StatusCode:  http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,278 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"github.com/wachrusz/Back-End-API/internal/service/attachments"
+	"go.uber.org/zap"
+	"mime"
+	"net/http"
+	"strconv"
+
+	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
+	utility "github.com/wachrusz/Back-End-API/pkg/util"
+)
+
+// multipartOverhead is the room left for the form fields around the uploaded file.
+const multipartOverhead = 1 << 20
+
+type AttachmentResponse struct {
+	Message    string            `json:"message"`
+	Attachment models.Attachment `json:"attachment"`
+	StatusCode int               `json:"status_code"`
+}
+
+type AttachmentListResponse struct {
+	Message     string              `json:"message"`
+	Attachments []models.Attachment `json:"attachments"`
+	StatusCode  int                 `json:"status_code"`
+}
+
+type AttachmentUsageResponse struct {
+	Message    string `json:"message"`
+	Used       int64  `json:"used"`
+	Quota      int64  `json:"quota"`
+	StatusCode int    `json:"status_code"`
+}
+
+// attachmentErrResp maps attachment service errors to http status codes.
+func (h *MyHandler) attachmentErrResp(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, myerrors.ErrValidation):
+		h.errResp(w, fmt.Errorf("invalid attachment: %v", err), http.StatusBadRequest)
+	case errors.Is(err, myerrors.ErrNotFound):
+		h.errResp(w, fmt.Errorf("not found: %v", err), http.StatusNotFound)
+	case errors.Is(err, myerrors.ErrTooLarge):
+		h.errResp(w, err, http.StatusRequestEntityTooLarge)
+	case errors.Is(err, myerrors.ErrQuotaExceeded):
+		h.errResp(w, err, http.StatusForbidden)
+	default:
+		h.errResp(w, fmt.Errorf("error %s attachment: %v", action, err), http.StatusInternalServerError)
+	}
+}
+
+// UploadAttachmentHandler attaches a file to an expense, income or goal transaction.
+//
+// @Summary Upload an attachment
+// @Description Attach a receipt, warranty or invoice to an expense, income or goal transaction. Only JPEG, PNG, GIF, WebP images and PDF files are accepted; the type is detected from the file content. A thumbnail is generated for JPEG, PNG and GIF images. Files are limited to 10 MB and to 200 MB per user.
+// @Tags Analytics
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "File"
+// @Param owner_type formData string true "expense, income or goal_transaction"
+// @Param owner_id formData string true "Id of the expense, income or goal transaction"
+// @Success 201 {object} AttachmentResponse "Successfully uploaded an attachment"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 403 {object} jsonresponse.ErrorResponse "Storage quota exceeded"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Owner not found"
+// @Failure 413 {object} jsonresponse.ErrorResponse "File is too large"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error uploading attachment"
+// @Security JWT
+// @Router /analytics/attachment [post]
+func (h *MyHandler) UploadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, attachments.MaxFileSize+multipartOverhead)
+	if err := r.ParseMultipartForm(attachments.MaxFileSize + multipartOverhead); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			h.attachmentErrResp(w, fmt.Errorf("%w: maximum size is %d bytes", myerrors.ErrTooLarge, attachments.MaxFileSize), "uploading")
+			return
+		}
+		h.errResp(w, fmt.Errorf("invalid multipart form: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		h.errResp(w, fmt.Errorf("error retrieving the file: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.s.Attachments.Upload(userID, r.FormValue("owner_type"), r.FormValue("owner_id"), header.Filename, file)
+	if err != nil {
+		h.attachmentErrResp(w, err, "uploading")
+		return
+	}
+
+	response := AttachmentResponse{
+		Message:    "Successfully uploaded an attachment",
+		Attachment: *attachment,
+		StatusCode: http.StatusCreated,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+
+	h.l.Debug("Attachment uploaded successfully", zap.Int64("attachmentID", attachment.ID))
+}
+
+// ListAttachmentsHandler lists attachments of an expense, income or goal transaction.
+//
+// @Summary List attachments
+// @Description Get attachments of an expense, income or goal transaction without the file contents.
+// @Tags Analytics
+// @Produce json
+// @Param owner_type query string true "expense, income or goal_transaction"
+// @Param owner_id query string true "Id of the expense, income or goal transaction"
+// @Success 200 {object} AttachmentListResponse "Successfully got attachments"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting attachments"
+// @Security JWT
+// @Router /analytics/attachment [get]
+func (h *MyHandler) ListAttachmentsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	list, err := h.s.Attachments.List(userID, query.Get("owner_type"), query.Get("owner_id"))
+	if err != nil {
+		h.attachmentErrResp(w, err, "getting")
+		return
+	}
+
+	response := AttachmentListResponse{
+		Message:     "Successfully got attachments",
+		Attachments: list,
+		StatusCode:  http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// DownloadAttachmentHandler sends the attachment file or its thumbnail.
+//
+// @Summary Download an attachment
+// @Description Download the file or its JPEG thumbnail. Only the owner of the attachment can download it.
+// @Tags Analytics
+// @Produce octet-stream
+// @Param id query string true "Attachment id"
+// @Param thumbnail query bool false "Download the thumbnail instead of the file"
+// @Success 200 {file} file "Attachment content"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Attachment not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error downloading attachment"
+// @Security JWT
+// @Router /analytics/attachment/download [get]
+func (h *MyHandler) DownloadAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	id, err := strconv.ParseInt(r.URL.Query().Get("id"), 10, 64)
+	if err != nil {
+		h.errResp(w, fmt.Errorf("invalid attachment ID: %v", err), http.StatusBadRequest)
+		return
+	}
+	thumbnail, _ := strconv.ParseBool(r.URL.Query().Get("thumbnail"))
+
+	attachment, data, err := h.s.Attachments.Download(id, userID, thumbnail)
+	if err != nil {
+		h.attachmentErrResp(w, err, "downloading")
+		return
+	}
+
+	w.Header().Set("Content-Type", attachment.ContentType)
+	w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.Header().Set("Cache-Control", "private, max-age=3600")
+	if attachment.FileName != "" && !thumbnail {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("inline", map[string]string{"filename": attachment.FileName}))
+	}
+	w.WriteHeader(http.StatusOK)
+	w.Write(data)
+}
+
+// DeleteAttachmentHandler deletes an attachment.
+//
+// @Summary Delete the attachment
+// @Description Delete the attachment and free its space in the user quota.
+// @Tags Analytics
+// @Param id body jsonresponse.IdRequest true "Attachment id"
+// @Success 204 {object} jsonresponse.SuccessResponse "Attachment deleted successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Attachment not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error deleting attachment"
+// @Security JWT
+// @Router /analytics/attachment [delete]
+func (h *MyHandler) DeleteAttachmentHandler(w http.ResponseWriter, r *http.Request) {
+	var id jsonresponse.IdRequest
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	attachmentID, err := strconv.ParseInt(id.ID, 10, 64)
+	if err != nil {
+		h.errResp(w, fmt.Errorf("invalid attachment ID: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.s.Attachments.Delete(attachmentID, userID); err != nil {
+		h.attachmentErrResp(w, err, "deleting")
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    "Attachment deleted successfully",
+		StatusCode: http.StatusNoContent,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// AttachmentUsageHandler reports the storage used by the attachments of the user.
+//
+// @Summary Attachment storage usage
+// @Description Get the total size of the user's attachments and the quota, in bytes.
+// @Tags Analytics
+// @Produce json
+// @Success 200 {object} AttachmentUsageResponse "Successfully got storage usage"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting storage usage"
+// @Security JWT
+// @Router /analytics/attachment/usage [get]
+func (h *MyHandler) AttachmentUsageHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	used, quota, err := h.s.Attachments.Usage(userID)
+	if err != nil {
+		h.attachmentErrResp(w, err, "getting usage of")
+		return
+	}
+
+	response := AttachmentUsageResponse{
+		Message:    "Successfully got storage usage",
+		Used:       used,
+		Quota:      quota,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,135 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"net/http"
+
+	utility "github.com/wachrusz/Back-End-API/pkg/util"
+	"go.uber.org/zap"
+)
+
+// maxBatchSize limits the body of a batch request.
+const maxBatchSize = 5 << 20
+
+// Batch modes.
+const (
+	batchAtomic     = "atomic"
+	batchBestEffort = "best_effort"
+)
+
+// BatchRequest is used for deserialization
+type BatchRequest struct {
+	// Mode is "atomic" (default) or "best_effort".
+	Mode       string                  `json:"mode"`
+	Operations []models.BatchOperation `json:"operations"`
+}
+
+type BatchResponse struct {
+	Message    string               `json:"message"`
+	Committed  bool                 `json:"committed"`
+	Results    []models.BatchResult `json:"results"`
+	StatusCode int                  `json:"status_code"`
+}
+
+// BatchHandler applies a mixed list of expense, income and wealth fund operations.
+//
+// @Summary Apply a batch of operations
+// @Description Create, update and delete expenses, incomes and wealth fund records in one request. Each operation has an action (create, update, delete), a kind (expense, income, wealth_fund), the object of that kind for create and update or the id for delete, and an optional client_id echoed in the result. In the atomic mode (default) all operations are applied in a single transaction and nothing is saved if any of them fails; in the best_effort mode each operation is applied on its own. At most 500 operations per request.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param batch body BatchRequest true "Batch of operations"
+// @Success 200 {object} BatchResponse "All operations applied"
+// @Success 207 {object} BatchResponse "Some operations failed in the best_effort mode"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 422 {object} BatchResponse "Batch rolled back or all operations failed"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error applying batch"
+// @Security JWT
+// @Router /analytics/batch [post]
+func (h *MyHandler) BatchHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	var req BatchRequest
+	r.Body = http.MaxBytesReader(w, r.Body, maxBatchSize)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	var atomic bool
+	switch req.Mode {
+	case "", batchAtomic:
+		atomic = true
+	case batchBestEffort:
+	default:
+		h.errResp(w, fmt.Errorf("invalid mode %q, expected %s or %s", req.Mode, batchAtomic, batchBestEffort), http.StatusBadRequest)
+		return
+	}
+
+	// States before the change are read for the history; failed operations are not recorded.
+	// Created expenses and incomes get the category, tags and planned flag from the categorization rules.
+	for i := range req.Operations {
+		if op := &req.Operations[i]; op.Action == models.BatchCreate {
+			h.categorizeBatchOp(op, userID)
+		}
+	}
+
+	results, committed, err := h.s.Batch.Apply(h.actor(r), userID, req.Operations, atomic)
+	if errors.Is(err, myerrors.ErrValidation) {
+		h.errResp(w, fmt.Errorf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.errResp(w, fmt.Errorf("error applying batch: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	failed := 0
+	for _, result := range results {
+		if result.Status != models.BatchOK {
+			failed++
+		}
+	}
+
+	response := BatchResponse{
+		Message:    "Batch applied successfully",
+		Committed:  committed,
+		Results:    results,
+		StatusCode: http.StatusOK,
+	}
+	switch {
+	case failed == len(results) || !committed:
+		response.Message = "Batch was not applied"
+		response.StatusCode = http.StatusUnprocessableEntity
+	case failed > 0:
+		response.Message = "Batch applied partially"
+		response.StatusCode = http.StatusMultiStatus
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// categorizeBatchOp applies the categorization rules to an expense or income created by the batch.
+func (h *MyHandler) categorizeBatchOp(op *models.BatchOperation, userID string) {
+	var err error
+	switch {
+	case op.Kind == models.KindExpense && op.Expense != nil:
+		op.Expense.UserID = userID
+		err = h.s.Rules.CategorizeExpense(op.Expense)
+	case op.Kind == models.KindIncome && op.Income != nil:
+		op.Income.UserID = userID
+		err = h.s.Rules.CategorizeIncome(op.Income)
+	}
+	if err != nil {
+		h.l.Warn("Error applying categorization rules", zap.Error(err))
+	}
+}
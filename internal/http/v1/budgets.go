@@ -0,0 +1,252 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+
+	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
+	utility "github.com/wachrusz/Back-End-API/pkg/util"
+)
+
+// BudgetRequest is used for deserialization
+type BudgetRequest struct {
+	Budget models.Budget `json:"budget"`
+}
+
+type BudgetListResponse struct {
+	Message    string          `json:"message"`
+	Budgets    []models.Budget `json:"budgets"`
+	StatusCode int             `json:"status_code"`
+}
+
+type BudgetReportResponse struct {
+	Message    string              `json:"message"`
+	Report     models.BudgetReport `json:"report"`
+	StatusCode int                 `json:"status_code"`
+}
+
+// budgetErrResp maps budget service errors to http status codes.
+func (h *MyHandler) budgetErrResp(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, myerrors.ErrValidation):
+		h.errResp(w, fmt.Errorf("invalid budget: %v", err), http.StatusBadRequest)
+	case errors.Is(err, myerrors.ErrNotFound):
+		h.errResp(w, fmt.Errorf("not found: %v", err), http.StatusNotFound)
+	default:
+		h.errResp(w, fmt.Errorf("error %s budget: %v", action, err), http.StatusInternalServerError)
+	}
+}
+
+// ListBudgetsHandler lists budgets of the user.
+//
+// @Summary List budgets
+// @Description Get the monthly budgets of the user: the overall budget first, then category budgets by category name.
+// @Tags Analytics
+// @Produce json
+// @Success 200 {object} BudgetListResponse "Successfully got budgets"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting budgets"
+// @Security JWT
+// @Router /analytics/budget [get]
+func (h *MyHandler) ListBudgetsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	budgets, err := h.s.Budgets.ListByUserID(userID)
+	if err != nil {
+		h.budgetErrResp(w, err, "getting")
+		return
+	}
+
+	response := BudgetListResponse{
+		Message:    "Successfully got budgets",
+		Budgets:    budgets,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateBudgetHandler creates a monthly budget.
+//
+// @Summary Create a budget
+// @Description Create a monthly expense budget in rubles for an expense category, or the overall budget when category_id is empty. There is at most one budget per category. With rollover the unspent remainder of each month is added to the next one. alert_thresholds are the percents of the budget at which an email alert is sent, once per month each; the server defaults (80 and 100 unless configured otherwise) are used when empty.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param budget body BudgetRequest true "Budget object"
+// @Success 201 {object} jsonresponse.IdResponse "Successfully created a budget"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error creating budget"
+// @Security JWT
+// @Router /analytics/budget [post]
+func (h *MyHandler) CreateBudgetHandler(w http.ResponseWriter, r *http.Request) {
+	var req BudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	budget := req.Budget
+	budget.UserID = userID
+
+	id, err := h.s.Budgets.Create(&budget)
+	if err != nil {
+		h.budgetErrResp(w, err, "creating")
+		return
+	}
+
+	response := jsonresponse.IdResponse{
+		Message:    "Successfully created a budget",
+		Id:         id,
+		StatusCode: http.StatusCreated,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+
+	h.l.Debug("Budget created successfully", zap.Int64("budgetID", id))
+}
+
+// UpdateBudgetHandler updates a monthly budget.
+//
+// @Summary Update the budget
+// @Description Update the category, amount, rollover and alert thresholds of the budget.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param budget body BudgetRequest true "Budget object"
+// @Success 200 {object} jsonresponse.SuccessResponse "Budget updated successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Budget not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error updating budget"
+// @Security JWT
+// @Router /analytics/budget [put]
+func (h *MyHandler) UpdateBudgetHandler(w http.ResponseWriter, r *http.Request) {
+	var req BudgetRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	budget := req.Budget
+	budget.UserID = userID
+
+	if err := h.s.Budgets.Update(&budget); err != nil {
+		h.budgetErrResp(w, err, "updating")
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    "Budget updated successfully",
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteBudgetHandler deletes a monthly budget.
+//
+// @Summary Delete the budget
+// @Description Delete the budget. Expenses are not affected.
+// @Tags Analytics
+// @Param id body jsonresponse.IdRequest true "Budget id"
+// @Success 204 {object} jsonresponse.SuccessResponse "Budget deleted successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Budget not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error deleting budget"
+// @Security JWT
+// @Router /analytics/budget [delete]
+func (h *MyHandler) DeleteBudgetHandler(w http.ResponseWriter, r *http.Request) {
+	var id jsonresponse.IdRequest
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.s.Budgets.Delete(id.ID, userID); err != nil {
+		h.budgetErrResp(w, err, "deleting")
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    "Budget deleted successfully",
+		StatusCode: http.StatusNoContent,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// BudgetReportHandler reports how the budgets are used in a month.
+//
+// @Summary Get the budget report
+// @Description Get spent, remaining and projected amounts in rubles for every budget of the user in a calendar month, the current one by default. available is the budget plus the remainder carried over from previous months; projected continues the current spending pace to the end of the month; status is ok, warning (the lowest alert threshold is reached) or exceeded.
+// @Tags Analytics
+// @Produce json
+// @Param month query string false "Month (YYYY-MM), current month by default"
+// @Success 200 {object} BudgetReportResponse "Successfully got the budget report"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid month"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting budget report"
+// @Security JWT
+// @Router /analytics/budget/report [get]
+func (h *MyHandler) BudgetReportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	month := time.Now()
+	if value := r.URL.Query().Get("month"); value != "" {
+		parsed, err := time.Parse("2006-01", value)
+		if err != nil {
+			h.errResp(w, fmt.Errorf("invalid month %q, expected YYYY-MM", value), http.StatusBadRequest)
+			return
+		}
+		month = parsed
+	}
+
+	report, err := h.s.Budgets.Report(userID, month)
+	if err != nil {
+		h.budgetErrResp(w, err, "getting report of")
+		return
+	}
+
+	response := BudgetReportResponse{
+		Message:    "Successfully got the budget report",
+		Report:     *report,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
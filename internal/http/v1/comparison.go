@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"net/http"
+	"strconv"
+
+	utility "github.com/wachrusz/Back-End-API/pkg/util"
+)
+
+type ComparisonResponse struct {
+	Message    string            `json:"message"`
+	Comparison models.Comparison `json:"comparison"`
+	StatusCode int               `json:"status_code"`
+}
+
+// ComparePeriodsHandler compares incomes or expenses of a period with a baseline by category.
+//
+// @Summary Compare periods
+// @Description Compare incomes or expenses per category of a month (current by default) or of arbitrary dates with a baseline: the previous period (default), the same period last year or the average of the 3, 6 or 12 preceding periods. For a month the preceding periods are calendar months, for dates they are periods of the same length. Returns the absolute and percentage deltas of the total and of every category; delta_percent is null when the baseline is zero. contribution is the share of the category in the total delta in percent; top_increases and top_decreases list the categories that changed the most. Amounts are converted to rubles at the exchange rate of the transaction date and then into the requested currency at the current rate.
+// @Tags Analytics
+// @Produce json
+// @Param kind query string false "expense (default) or income"
+// @Param month query string false "Month (YYYY-MM), current month by default"
+// @Param from query string false "First date (YYYY-MM-DD) of a custom period, used with to instead of month"
+// @Param to query string false "Last date (YYYY-MM-DD) of a custom period"
+// @Param baseline query string false "previous (default), last_year, avg3, avg6 or avg12"
+// @Param currency query string false "Currency code, RUB by default"
+// @Param top query int false "Number of top increases and decreases, 5 by default, at most 50"
+// @Success 200 {object} ComparisonResponse "Successfully compared periods"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid query parameters"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error comparing periods"
+// @Security JWT
+// @Router /analytics/compare [get]
+func (h *MyHandler) ComparePeriodsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	filter := models.ComparisonFilter{
+		UserID:   userID,
+		Kind:     query.Get("kind"),
+		Month:    query.Get("month"),
+		From:     query.Get("from"),
+		To:       query.Get("to"),
+		Baseline: query.Get("baseline"),
+		Currency: query.Get("currency"),
+	}
+	if value := query.Get("top"); value != "" {
+		top, err := strconv.Atoi(value)
+		if err != nil || top <= 0 {
+			h.errResp(w, fmt.Errorf("invalid top %q", value), http.StatusBadRequest)
+			return
+		}
+		filter.Top = top
+	}
+
+	comparison, err := h.s.Comparison.Compare(&filter)
+	if err != nil {
+		if errors.Is(err, myerrors.ErrValidation) {
+			h.errResp(w, fmt.Errorf("invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+		h.errResp(w, fmt.Errorf("error comparing periods: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := ComparisonResponse{
+		Message:    "Successfully compared periods",
+		Comparison: *comparison,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
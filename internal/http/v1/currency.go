@@ -0,0 +1,172 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"net/http"
+	"strconv"
+)
+
+type CurrenciesResponse struct {
+	Message    string            `json:"message"`
+	Currencies []models.Currency `json:"currencies"`
+	Version    int64             `json:"version"`
+	StatusCode int               `json:"status_code"`
+}
+
+type ConversionResponse struct {
+	Message    string            `json:"message"`
+	Conversion models.Conversion `json:"conversion"`
+	StatusCode int               `json:"status_code"`
+}
+
+type RefreshRatesResponse struct {
+	Message    string `json:"message"`
+	Version    int64  `json:"version"`
+	StatusCode int    `json:"status_code"`
+}
+
+type RateHistoryResponse struct {
+	Message     string             `json:"message"`
+	RateHistory models.RateHistory `json:"rate_history"`
+	StatusCode  int                `json:"status_code"`
+}
+
+func (h *MyHandler) currencyErrResp(w http.ResponseWriter, err error, action string) {
+	if errors.Is(err, myerrors.ErrValidation) {
+		h.errResp(w, fmt.Errorf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	h.errResp(w, fmt.Errorf("error %s: %v", action, err), http.StatusInternalServerError)
+}
+
+// ListCurrenciesHandler lists the supported currencies.
+//
+// @Summary List currencies
+// @Description List the supported currencies with their codes, names and nominal. value is the current rate in rubles for nominal units of the currency; provider and updated_at tell where and when the rate was fetched. The ruble is listed first. version changes whenever the exchange rates of the server are updated.
+// @Tags App
+// @Produce json
+// @Success 200 {object} CurrenciesResponse "Successfully got currencies"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting currencies"
+// @Security JWT
+// @Router /app/currency [get]
+func (h *MyHandler) ListCurrenciesHandler(w http.ResponseWriter, r *http.Request) {
+	version := h.s.Currency.Version()
+	currencies, err := h.s.Currency.Currencies()
+	if err != nil {
+		h.currencyErrResp(w, err, "getting currencies")
+		return
+	}
+
+	response := CurrenciesResponse{
+		Message:    "Successfully got currencies",
+		Currencies: currencies,
+		Version:    version,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ConvertCurrencyHandler converts an amount between two currencies.
+//
+// @Summary Convert an amount
+// @Description Convert an amount from one currency into another at the exchange rates of a date, today by default. On weekends and holidays the rate of the last business day applies; dates before the rate history use its earliest rate. rate is the price of one unit of from in to; converted is rounded to 2 decimals.
+// @Tags App
+// @Produce json
+// @Param amount query number true "Amount in the from currency"
+// @Param from query string true "Currency code of the amount"
+// @Param to query string true "Currency code to convert into"
+// @Param date query string false "Date of the rates, YYYY-MM-DD"
+// @Success 200 {object} ConversionResponse "Successfully converted"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid query parameters"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error converting"
+// @Security JWT
+// @Router /app/currency/convert [get]
+func (h *MyHandler) ConvertCurrencyHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	amount, err := strconv.ParseFloat(query.Get("amount"), 64)
+	if err != nil {
+		h.errResp(w, fmt.Errorf("invalid amount %q", query.Get("amount")), http.StatusBadRequest)
+		return
+	}
+
+	conversion, err := h.s.Currency.Convert(amount, query.Get("from"), query.Get("to"), query.Get("date"))
+	if err != nil {
+		h.currencyErrResp(w, err, "converting")
+		return
+	}
+
+	response := ConversionResponse{
+		Message:    "Successfully converted",
+		Conversion: *conversion,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// CurrencyRateHistoryHandler returns the daily rates of a currency pair.
+//
+// @Summary Get the rate history of a currency pair
+// @Description Get the rate of a currency pair for every day of a period, the last 30 days by default. rate is the price of one unit of from in to. On weekends and holidays the rate of the last business day applies. The period must be shorter than the stored rate history of 1825 days.
+// @Tags App
+// @Produce json
+// @Param from query string true "Base currency code"
+// @Param to query string true "Quote currency code"
+// @Param date_from query string false "First day of the period, YYYY-MM-DD"
+// @Param date_to query string false "Last day of the period, YYYY-MM-DD, today by default"
+// @Success 200 {object} RateHistoryResponse "Successfully got the rate history"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid query parameters"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting rate history"
+// @Security JWT
+// @Router /app/currency/history [get]
+func (h *MyHandler) CurrencyRateHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	history, err := h.s.Currency.RateHistory(query.Get("from"), query.Get("to"), query.Get("date_from"), query.Get("date_to"))
+	if err != nil {
+		h.currencyErrResp(w, err, "getting rate history")
+		return
+	}
+
+	response := RateHistoryResponse{
+		Message:     "Successfully got the rate history",
+		RateHistory: *history,
+		StatusCode:  http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// RefreshCurrencyRatesHandler fetches the exchange rates outside the schedule.
+//
+// @Summary Refresh exchange rates
+// @Description Fetch the exchange rates from the configured providers now instead of waiting for the daily update. Other instances of the server reload the new rates. Requires the admin token in the X-Admin-Token header. Returns the version of the new rates.
+// @Tags App
+// @Produce json
+// @Param X-Admin-Token header string true "Admin token"
+// @Success 200 {object} RefreshRatesResponse "Successfully refreshed exchange rates"
+// @Failure 403 {object} jsonresponse.ErrorResponse "Admin token required"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error refreshing exchange rates"
+// @Router /app/currency/refresh [post]
+func (h *MyHandler) RefreshCurrencyRatesHandler(w http.ResponseWriter, r *http.Request) {
+	version, err := h.s.Currency.Refresh()
+	if err != nil {
+		h.currencyErrResp(w, err, "refreshing exchange rates")
+		return
+	}
+
+	response := RefreshRatesResponse{
+		Message:    "Successfully refreshed exchange rates",
+		Version:    version,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
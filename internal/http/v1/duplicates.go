@@ -0,0 +1,198 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"go.uber.org/zap"
+	"net/http"
+
+	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
+	utility "github.com/wachrusz/Back-End-API/pkg/util"
+)
+
+// CreatedTransactionResponse is returned when an expense or income is created.
+type CreatedTransactionResponse struct {
+	Message string `json:"message"`
+	Id      int64  `json:"id"`
+	// Warning is set when the transaction looks like one saved earlier.
+	Warning            string                  `json:"warning,omitempty"`
+	PossibleDuplicates []models.DuplicateMatch `json:"possible_duplicates,omitempty"`
+	StatusCode         int                     `json:"status_code"`
+}
+
+// DuplicateMergeRequest is used for deserialization
+type DuplicateMergeRequest struct {
+	// Kind is expense or income.
+	Kind string `json:"kind"`
+	// KeepID is the transaction that stays.
+	KeepID string `json:"keep_id"`
+	// DuplicateID is the transaction that is moved to the trash.
+	DuplicateID string `json:"duplicate_id"`
+}
+
+// DuplicateDismissRequest is used for deserialization
+type DuplicateDismissRequest struct {
+	// Kind is expense or income.
+	Kind     string `json:"kind"`
+	FirstID  string `json:"first_id"`
+	SecondID string `json:"second_id"`
+}
+
+type DuplicateListResponse struct {
+	Message    string                 `json:"message"`
+	Duplicates []models.DuplicatePair `json:"duplicates"`
+	StatusCode int                    `json:"status_code"`
+}
+
+// possibleDuplicates looks for saved transactions similar to a new one. The check is best effort:
+// errors are logged and never fail the request.
+func (h *MyHandler) possibleDuplicates(userID string, record models.DuplicateRecord) []models.DuplicateMatch {
+	matches, err := h.s.Duplicates.Check(userID, []models.DuplicateRecord{record})
+	if err != nil {
+		h.l.Warn("Error checking for duplicates", zap.String("kind", record.Kind), zap.Error(err))
+		return nil
+	}
+	return matches[0]
+}
+
+// duplicateErrResp maps duplicate service errors to http status codes.
+func (h *MyHandler) duplicateErrResp(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, myerrors.ErrValidation):
+		h.errResp(w, fmt.Errorf("invalid request: %v", err), http.StatusBadRequest)
+	case errors.Is(err, myerrors.ErrNotFound):
+		h.errResp(w, fmt.Errorf("not found: %v", err), http.StatusNotFound)
+	default:
+		h.errResp(w, fmt.Errorf("error %s duplicates: %v", action, err), http.StatusInternalServerError)
+	}
+}
+
+// ListDuplicatesHandler lists likely duplicate transactions of the user.
+//
+// @Summary List likely duplicates
+// @Description Get pairs of expenses or incomes that look like the same transaction, most similar first. Transactions in a pair have the same kind and currency, amounts that differ by at most 1% and dates at most 3 days apart; the score from 0 to 1 also weighs the recipient or sender and the account. Only the last 90 days are checked unless a period is given. Pairs dismissed earlier are not listed.
+// @Tags Analytics
+// @Produce json
+// @Param kind query string false "expense or income, both by default"
+// @Param date_from query string false "Start date (YYYY-MM-DD)"
+// @Param date_to query string false "End date (YYYY-MM-DD)"
+// @Param currency query string false "Only transactions in this currency"
+// @Success 200 {object} DuplicateListResponse "Successfully got duplicates"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid query parameters"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting duplicates"
+// @Security JWT
+// @Router /analytics/duplicate [get]
+func (h *MyHandler) ListDuplicatesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	pairs, err := h.s.Duplicates.List(&models.DuplicateFilter{
+		UserID:   userID,
+		Kind:     query.Get("kind"),
+		DateFrom: query.Get("date_from"),
+		DateTo:   query.Get("date_to"),
+		Currency: query.Get("currency"),
+	})
+	if err != nil {
+		h.duplicateErrResp(w, err, "getting")
+		return
+	}
+
+	response := DuplicateListResponse{
+		Message:    "Successfully got duplicates",
+		Duplicates: pairs,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// MergeDuplicatesHandler merges two duplicate transactions.
+//
+// @Summary Merge duplicates
+// @Description Keep one transaction of a duplicate pair and move the other one to the trash. Tags, attachments and the bank transaction id of the duplicate are moved to the kept transaction, and the duplicate is removed from the operations archive and analytics.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param merge body DuplicateMergeRequest true "Transactions to merge"
+// @Success 200 {object} jsonresponse.SuccessResponse "Duplicates merged successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Transaction not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error merging duplicates"
+// @Security JWT
+// @Router /analytics/duplicate/merge [post]
+func (h *MyHandler) MergeDuplicatesHandler(w http.ResponseWriter, r *http.Request) {
+	var req DuplicateMergeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.s.Duplicates.Merge(h.actor(r), userID, req.Kind, req.KeepID, req.DuplicateID); err != nil {
+		h.duplicateErrResp(w, err, "merging")
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    "Duplicates merged successfully",
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// DismissDuplicatesHandler marks two transactions as not duplicates.
+//
+// @Summary Dismiss duplicates
+// @Description Mark a pair of transactions as different, so that it is no longer listed as a likely duplicate. Both transactions are kept.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param dismiss body DuplicateDismissRequest true "Transactions that are not duplicates"
+// @Success 200 {object} jsonresponse.SuccessResponse "Duplicates dismissed successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Transaction not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error dismissing duplicates"
+// @Security JWT
+// @Router /analytics/duplicate/dismiss [post]
+func (h *MyHandler) DismissDuplicatesHandler(w http.ResponseWriter, r *http.Request) {
+	var req DuplicateDismissRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.s.Duplicates.Dismiss(userID, req.Kind, req.FirstID, req.SecondID); err != nil {
+		h.duplicateErrResp(w, err, "dismissing")
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    "Duplicates dismissed successfully",
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
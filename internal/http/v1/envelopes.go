@@ -0,0 +1,369 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"go.uber.org/zap"
+	"net/http"
+	"time"
+
+	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
+	utility "github.com/wachrusz/Back-End-API/pkg/util"
+)
+
+// EnvelopeRequest is used for deserialization
+type EnvelopeRequest struct {
+	Envelope models.Envelope `json:"envelope"`
+}
+
+// EnvelopeMoveRequest is used for deserialization
+type EnvelopeMoveRequest struct {
+	Movement models.EnvelopeMovement `json:"movement"`
+}
+
+// EnvelopeCloseRequest is used for deserialization
+type EnvelopeCloseRequest struct {
+	// Month is YYYY-MM.
+	Month string `json:"month"`
+}
+
+type EnvelopeListResponse struct {
+	Message    string            `json:"message"`
+	Envelopes  []models.Envelope `json:"envelopes"`
+	StatusCode int               `json:"status_code"`
+}
+
+type EnvelopeMovementsResponse struct {
+	Message    string                    `json:"message"`
+	Movements  []models.EnvelopeMovement `json:"movements"`
+	StatusCode int                       `json:"status_code"`
+}
+
+type EnvelopeReportResponse struct {
+	Message    string                `json:"message"`
+	Report     models.EnvelopeReport `json:"report"`
+	StatusCode int                   `json:"status_code"`
+}
+
+// envelopeErrResp maps envelope service errors to http status codes.
+func (h *MyHandler) envelopeErrResp(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, myerrors.ErrValidation):
+		h.errResp(w, fmt.Errorf("invalid request: %v", err), http.StatusBadRequest)
+	case errors.Is(err, myerrors.ErrNotFound):
+		h.errResp(w, fmt.Errorf("not found: %v", err), http.StatusNotFound)
+	default:
+		h.errResp(w, fmt.Errorf("error %s: %v", action, err), http.StatusInternalServerError)
+	}
+}
+
+// envelopeMonth parses the month query parameter, the current month by default.
+func envelopeMonth(r *http.Request) (time.Time, error) {
+	value := r.URL.Query().Get("month")
+	if value == "" {
+		return time.Now(), nil
+	}
+	month, err := time.Parse("2006-01", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid month %q, expected YYYY-MM", value)
+	}
+	return month, nil
+}
+
+// ListEnvelopesHandler lists envelopes of the user.
+//
+// @Summary List envelopes
+// @Description Get the envelopes of the user ordered by category name.
+// @Tags Analytics
+// @Produce json
+// @Success 200 {object} EnvelopeListResponse "Successfully got envelopes"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting envelopes"
+// @Security JWT
+// @Router /analytics/envelope [get]
+func (h *MyHandler) ListEnvelopesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	envelopes, err := h.s.Envelopes.ListByUserID(userID)
+	if err != nil {
+		h.envelopeErrResp(w, err, "getting envelopes")
+		return
+	}
+
+	response := EnvelopeListResponse{
+		Message:    "Successfully got envelopes",
+		Envelopes:  envelopes,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateEnvelopeHandler creates an envelope for an expense category.
+//
+// @Summary Create an envelope
+// @Description Create an envelope for an expense category. Expenses of the category draw the envelope down. Envelope budgeting is optional: it starts with the first envelope and does not change incomes or expenses.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param envelope body EnvelopeRequest true "Envelope object"
+// @Success 201 {object} jsonresponse.IdResponse "Successfully created an envelope"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error creating envelope"
+// @Security JWT
+// @Router /analytics/envelope [post]
+func (h *MyHandler) CreateEnvelopeHandler(w http.ResponseWriter, r *http.Request) {
+	var req EnvelopeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	envelope := req.Envelope
+	envelope.UserID = userID
+
+	id, err := h.s.Envelopes.Create(&envelope)
+	if err != nil {
+		h.envelopeErrResp(w, err, "creating envelope")
+		return
+	}
+
+	response := jsonresponse.IdResponse{
+		Message:    "Successfully created an envelope",
+		Id:         id,
+		StatusCode: http.StatusCreated,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+
+	h.l.Debug("Envelope created successfully", zap.Int64("envelopeID", id))
+}
+
+// DeleteEnvelopeHandler deletes an envelope.
+//
+// @Summary Delete the envelope
+// @Description Delete the envelope with its movements and carried balances; the money assigned to it becomes available to assign again. Expenses are not affected.
+// @Tags Analytics
+// @Param id body jsonresponse.IdRequest true "Envelope id"
+// @Success 204 {object} jsonresponse.SuccessResponse "Envelope deleted successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Envelope not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error deleting envelope"
+// @Security JWT
+// @Router /analytics/envelope [delete]
+func (h *MyHandler) DeleteEnvelopeHandler(w http.ResponseWriter, r *http.Request) {
+	var id jsonresponse.IdRequest
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.s.Envelopes.Delete(id.ID, userID); err != nil {
+		h.envelopeErrResp(w, err, "deleting envelope")
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    "Envelope deleted successfully",
+		StatusCode: http.StatusNoContent,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// EnvelopeReportHandler reports envelope balances for a month.
+//
+// @Summary Get envelope balances
+// @Description Get the envelope budget of the user for a month, the current one by default. Each envelope balance is the amount carried from the closed previous month plus the money assigned in the month minus the expenses of its category. available_to_assign is the amount carried from the previous month plus the incomes of the month, minus the money assigned to envelopes and the expenses in categories without an envelope; it is negative when more money is assigned than there is. Amounts are in rubles.
+// @Tags Analytics
+// @Produce json
+// @Param month query string false "Month (YYYY-MM), current month by default"
+// @Success 200 {object} EnvelopeReportResponse "Successfully got envelope balances"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid month"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting envelope balances"
+// @Security JWT
+// @Router /analytics/envelope/report [get]
+func (h *MyHandler) EnvelopeReportHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	month, err := envelopeMonth(r)
+	if err != nil {
+		h.errResp(w, err, http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.s.Envelopes.Report(userID, month)
+	if err != nil {
+		h.envelopeErrResp(w, err, "getting envelope balances")
+		return
+	}
+
+	response := EnvelopeReportResponse{
+		Message:    "Successfully got envelope balances",
+		Report:     *report,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// MoveEnvelopeMoneyHandler assigns money to envelopes or moves it between them.
+//
+// @Summary Move money between envelopes
+// @Description Move money in a month (YYYY-MM, the current one by default). Without from_envelope_id the money is assigned from the amount available to assign; without to_envelope_id it is returned there; with both it is moved between envelopes. Money can't be moved in a closed month.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param movement body EnvelopeMoveRequest true "Movement object"
+// @Success 201 {object} jsonresponse.IdResponse "Successfully moved money"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Envelope not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error moving money"
+// @Security JWT
+// @Router /analytics/envelope/move [post]
+func (h *MyHandler) MoveEnvelopeMoneyHandler(w http.ResponseWriter, r *http.Request) {
+	var req EnvelopeMoveRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	movement := req.Movement
+	movement.UserID = userID
+
+	id, err := h.s.Envelopes.Move(&movement)
+	if err != nil {
+		h.envelopeErrResp(w, err, "moving money")
+		return
+	}
+
+	response := jsonresponse.IdResponse{
+		Message:    "Successfully moved money",
+		Id:         id,
+		StatusCode: http.StatusCreated,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ListEnvelopeMovementsHandler lists money movements of a month.
+//
+// @Summary List envelope movements
+// @Description Get the money movements of the user in a month, newest first.
+// @Tags Analytics
+// @Produce json
+// @Param month query string false "Month (YYYY-MM), current month by default"
+// @Success 200 {object} EnvelopeMovementsResponse "Successfully got movements"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid month"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting movements"
+// @Security JWT
+// @Router /analytics/envelope/movement [get]
+func (h *MyHandler) ListEnvelopeMovementsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	month, err := envelopeMonth(r)
+	if err != nil {
+		h.errResp(w, err, http.StatusBadRequest)
+		return
+	}
+
+	movements, err := h.s.Envelopes.Movements(userID, month)
+	if err != nil {
+		h.envelopeErrResp(w, err, "getting movements")
+		return
+	}
+
+	response := EnvelopeMovementsResponse{
+		Message:    "Successfully got movements",
+		Movements:  movements,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// CloseEnvelopeMonthHandler closes a month and carries balances forward.
+//
+// @Summary Close the envelope month
+// @Description Close a month and carry its balances to the next month: positive envelope balances stay in the envelopes, overspent envelopes and the amount left to assign are carried to the amount available to assign. Months are closed in order and a future month can't be closed. A closed month can't be changed; the returned report is the final state of the month.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param month body EnvelopeCloseRequest true "Month to close"
+// @Success 200 {object} EnvelopeReportResponse "Month closed successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error closing month"
+// @Security JWT
+// @Router /analytics/envelope/close [post]
+func (h *MyHandler) CloseEnvelopeMonthHandler(w http.ResponseWriter, r *http.Request) {
+	var req EnvelopeCloseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	month, err := time.Parse("2006-01", req.Month)
+	if err != nil {
+		h.errResp(w, fmt.Errorf("invalid month %q, expected YYYY-MM", req.Month), http.StatusBadRequest)
+		return
+	}
+
+	report, err := h.s.Envelopes.Close(userID, month)
+	if err != nil {
+		h.envelopeErrResp(w, err, "closing month")
+		return
+	}
+
+	response := EnvelopeReportResponse{
+		Message:    "Month closed successfully",
+		Report:     *report,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,79 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"net/http"
+	"strconv"
+
+	utility "github.com/wachrusz/Back-End-API/pkg/util"
+)
+
+type ForecastResponse struct {
+	Message    string          `json:"message"`
+	Forecast   models.Forecast `json:"forecast"`
+	StatusCode int             `json:"status_code"`
+}
+
+// CashFlowForecastHandler forecasts daily account balances.
+//
+// @Summary Get the cash-flow forecast
+// @Description Project the daily balance of every connected account and the total balance for the next days, starting tomorrow. The current account_state of the accounts is the starting balance; every day adds planned incomes and expenses, upcoming occurrences of active recurring series and the average discretionary spend of the weekday over the last 12 weeks (actual expenses not created by recurring series). Transactions whose account is not connected count only in the total. Accounts are forecast in their own currency, the total in rubles. warnings list every series whose balance falls below the threshold, with the first such day and the lowest balance.
+// @Tags Analytics
+// @Produce json
+// @Param days query int false "Number of days to forecast, 30 by default, at most 365"
+// @Param threshold query number false "Low-balance threshold, 0 by default"
+// @Success 200 {object} ForecastResponse "Successfully got the forecast"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid query parameters"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting forecast"
+// @Security JWT
+// @Router /analytics/forecast [get]
+func (h *MyHandler) CashFlowForecastHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	var days int
+	if value := r.URL.Query().Get("days"); value != "" {
+		parsed, err := strconv.Atoi(value)
+		if err != nil || parsed <= 0 {
+			h.errResp(w, fmt.Errorf("invalid days %q", value), http.StatusBadRequest)
+			return
+		}
+		days = parsed
+	}
+
+	var threshold float64
+	if value := r.URL.Query().Get("threshold"); value != "" {
+		parsed, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			h.errResp(w, fmt.Errorf("invalid threshold %q", value), http.StatusBadRequest)
+			return
+		}
+		threshold = parsed
+	}
+
+	forecast, err := h.s.Forecast.Forecast(userID, days, threshold)
+	if err != nil {
+		if errors.Is(err, myerrors.ErrValidation) {
+			h.errResp(w, fmt.Errorf("invalid request: %v", err), http.StatusBadRequest)
+			return
+		}
+		h.errResp(w, fmt.Errorf("error getting forecast: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := ForecastResponse{
+		Message:    "Successfully got the forecast",
+		Forecast:   *forecast,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
@@ -16,9 +16,10 @@ type MyHandler struct {
 	m            *repository.Models
 	rdb          *redis.Client
 	rateLimitCfg int64
+	adminToken   string
 }
 
-func NewHandler(services *service.Services, logger *zap.Logger, models *repository.Models, cache *redis.Client, rateLimit int64) *MyHandler {
+func NewHandler(services *service.Services, logger *zap.Logger, models *repository.Models, cache *redis.Client, rateLimit int64, adminToken string) *MyHandler {
 	if rateLimit <= 0 {
 		rateLimit = 10
 	}
@@ -29,6 +30,7 @@ func NewHandler(services *service.Services, logger *zap.Logger, models *reposito
 		m:            models,
 		rateLimitCfg: rateLimit,
 		rdb:          cache,
+		adminToken:   adminToken,
 	}
 }
 
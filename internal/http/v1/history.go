@@ -0,0 +1,19 @@
+package v1
+
+import (
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"net/http"
+
+	utility "github.com/wachrusz/Back-End-API/pkg/util"
+)
+
+// actor returns the user and the device that make the request, as recorded in the history of the records they change.
+// The device ID is taken from the access token, or from the X-Device-ID header for older tokens.
+func (h *MyHandler) actor(r *http.Request) models.Actor {
+	userID, _ := utility.GetUserIDFromContext(r.Context())
+	deviceID, ok := utility.GetDeviceIDFromContext(r.Context())
+	if !ok || deviceID == "" {
+		deviceID, _ = utility.GetDeviceIDFromRequest(r)
+	}
+	return models.Actor{UserID: userID, DeviceID: deviceID}
+}
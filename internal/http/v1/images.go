@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"github.com/gorilla/mux"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
 	"github.com/wachrusz/Back-End-API/internal/service/user"
 	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
 	utility "github.com/wachrusz/Back-End-API/pkg/util"
@@ -28,7 +29,14 @@ func (h *MyHandler) UploadAvatarHandler(w http.ResponseWriter, r *http.Request)
 	defer file.Close()
 
 	encryptedID, err := h.s.Users.UploadAvatar(userID, file)
-	if err != nil {
+	switch {
+	case errors.Is(err, myerrors.ErrValidation):
+		jsonresponse.SendErrorResponse(w, err, http.StatusBadRequest)
+		return
+	case errors.Is(err, myerrors.ErrTooLarge):
+		jsonresponse.SendErrorResponse(w, err, http.StatusRequestEntityTooLarge)
+		return
+	case err != nil:
 		jsonresponse.SendErrorResponse(w, err, http.StatusInternalServerError)
 		return
 	}
@@ -0,0 +1,375 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+
+	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
+	utility "github.com/wachrusz/Back-End-API/pkg/util"
+)
+
+// maxImportSize - максимальный размер загружаемой выписки.
+const maxImportSize = 10 << 20
+
+// ImportProfileRequest is used for deserialization
+type ImportProfileRequest struct {
+	Profile models.ImportProfile `json:"profile"`
+}
+
+type ImportProfilesResponse struct {
+	Message    string                 `json:"message"`
+	Profiles   []models.ImportProfile `json:"profiles"`
+	StatusCode int                    `json:"status_code"`
+}
+
+type ImportResponse struct {
+	Message    string               `json:"message"`
+	Result     *models.ImportResult `json:"result"`
+	StatusCode int                  `json:"status_code"`
+}
+
+// importErrResp maps import service errors to http status codes.
+func (h *MyHandler) importErrResp(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, myerrors.ErrValidation):
+		h.errResp(w, fmt.Errorf("invalid import request: %v", err), http.StatusBadRequest)
+	case errors.Is(err, myerrors.ErrNotFound):
+		h.errResp(w, fmt.Errorf("not found: %v", err), http.StatusNotFound)
+	default:
+		h.errResp(w, fmt.Errorf("error %s: %v", action, err), http.StatusInternalServerError)
+	}
+}
+
+// sendImportResult sends the import result. When the import was rolled back the result
+// is still sent, so the client can see on which row it failed.
+func (h *MyHandler) sendImportResult(w http.ResponseWriter, result *models.ImportResult, err error) {
+	response := ImportResponse{
+		Message:    "Successfully imported",
+		Result:     result,
+		StatusCode: http.StatusCreated,
+	}
+	switch {
+	case err != nil:
+		h.l.Error("Import rolled back", zap.Error(err))
+		response.Message = err.Error()
+		response.StatusCode = http.StatusUnprocessableEntity
+	case result.DryRun:
+		response.Message = "Successfully parsed, nothing was imported"
+		response.StatusCode = http.StatusOK
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ImportCSVHandler imports incomes and expenses from a CSV file.
+//
+// @Summary Import CSV
+// @Description Import incomes and expenses from a CSV file using a saved import profile (profile_id) or an inline mapping (mapping, JSON). With dry_run=true the file is only parsed and the preview is returned. Otherwise all valid rows are saved in one transaction; invalid rows are reported and skipped. Rows that look like transactions saved earlier are still imported and list them in possible_duplicates.
+// @Tags Import
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "CSV file"
+// @Param profile_id formData string false "Saved import profile id"
+// @Param mapping formData string false "Column mapping (JSON), used when profile_id is empty"
+// @Param dry_run query bool false "Only parse the file and return the preview"
+// @Success 200 {object} ImportResponse "Preview of the parsed rows"
+// @Success 201 {object} ImportResponse "Successfully imported"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Import profile not found"
+// @Failure 422 {object} ImportResponse "Import rolled back"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error importing"
+// @Security JWT
+// @Router /analytics/import/csv [post]
+func (h *MyHandler) ImportCSVHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		h.errResp(w, fmt.Errorf("invalid multipart form: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		h.errResp(w, fmt.Errorf("error retrieving the file: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	var mapping models.CSVMapping
+	if profileIDStr := r.FormValue("profile_id"); profileIDStr != "" {
+		profileID, err := strconv.ParseInt(profileIDStr, 10, 64)
+		if err != nil {
+			h.errResp(w, fmt.Errorf("invalid profile ID: %v", err), http.StatusBadRequest)
+			return
+		}
+		profile, err := h.s.Importer.GetProfile(profileID, userID)
+		if err != nil {
+			h.importErrResp(w, err, "getting import profile")
+			return
+		}
+		mapping = profile.Mapping
+	} else if err := json.Unmarshal([]byte(r.FormValue("mapping")), &mapping); err != nil {
+		h.errResp(w, fmt.Errorf("invalid mapping: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	result, err := h.s.Importer.ImportCSV(h.actor(r), userID, file, &mapping, dryRun)
+	if result == nil {
+		h.importErrResp(w, err, "importing csv")
+		return
+	}
+
+	h.sendImportResult(w, result, err)
+}
+
+// ImportStatementHandler imports incomes and expenses from a bank statement.
+//
+// @Summary Import a bank statement
+// @Description Import incomes and expenses from an OFX (1.x SGML or 2.x XML), QIF, ISO 20022 camt.053 or SWIFT MT940 statement into the connected account. Only booked entries are imported; debits become expenses and credits become incomes. Transactions already imported into this account (same bank id) are skipped. When the statement has a closing balance in the account currency, the account state is set to it, unless the account was already updated from a statement with a later balance date; then closing_balance.skipped is true. With dry_run=true the file is only parsed and the preview is returned. Rows that look like transactions saved earlier, for example entered manually, are still imported and list them in possible_duplicates.
+// @Tags Import
+// @Accept multipart/form-data
+// @Produce json
+// @Param file formData file true "Statement file"
+// @Param account_id formData string true "Connected account id"
+// @Param format formData string false "Statement format: ofx, qif, camt053 or mt940. Detected from the file when empty"
+// @Param date_format formData string false "QIF date format, e.g. DD.MM.YYYY"
+// @Param category_map formData string false "Statement category to category id map (JSON)"
+// @Param default_expense_category formData string false "Category id for expenses without a matched category"
+// @Param default_income_category formData string false "Category id for incomes without a matched category"
+// @Param dry_run query bool false "Only parse the file and return the preview"
+// @Success 200 {object} ImportResponse "Preview of the parsed transactions"
+// @Success 201 {object} ImportResponse "Successfully imported"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Connected account not found"
+// @Failure 422 {object} ImportResponse "Import rolled back"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error importing"
+// @Security JWT
+// @Router /analytics/import/statement [post]
+func (h *MyHandler) ImportStatementHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportSize)
+	if err := r.ParseMultipartForm(maxImportSize); err != nil {
+		h.errResp(w, fmt.Errorf("invalid multipart form: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	file, _, err := r.FormFile("file")
+	if err != nil {
+		h.errResp(w, fmt.Errorf("error retrieving the file: %v", err), http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+
+	opts := models.StatementOptions{
+		Format:                 r.FormValue("format"),
+		AccountID:              r.FormValue("account_id"),
+		DateFormat:             r.FormValue("date_format"),
+		DefaultExpenseCategory: r.FormValue("default_expense_category"),
+		DefaultIncomeCategory:  r.FormValue("default_income_category"),
+	}
+	if categoryMap := r.FormValue("category_map"); categoryMap != "" {
+		if err := json.Unmarshal([]byte(categoryMap), &opts.CategoryMap); err != nil {
+			h.errResp(w, fmt.Errorf("invalid category map: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	dryRun, _ := strconv.ParseBool(r.URL.Query().Get("dry_run"))
+
+	result, err := h.s.Importer.ImportStatement(h.actor(r), userID, file, &opts, dryRun)
+	if result == nil {
+		h.importErrResp(w, err, "importing statement")
+		return
+	}
+
+	h.sendImportResult(w, result, err)
+}
+
+// ListImportProfilesHandler lists saved import profiles.
+//
+// @Summary List import profiles
+// @Description Get saved CSV column mappings of the user.
+// @Tags Import
+// @Produce json
+// @Success 200 {object} ImportProfilesResponse "Successfully got import profiles"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting import profiles"
+// @Security JWT
+// @Router /analytics/import/profiles [get]
+func (h *MyHandler) ListImportProfilesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	profiles, err := h.s.Importer.ListProfiles(userID)
+	if err != nil {
+		h.importErrResp(w, err, "getting import profiles")
+		return
+	}
+
+	response := ImportProfilesResponse{
+		Message:    "Successfully got import profiles",
+		Profiles:   profiles,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateImportProfileHandler saves a new import profile.
+//
+// @Summary Create an import profile
+// @Description Save a named CSV column mapping for later imports.
+// @Tags Import
+// @Accept json
+// @Produce json
+// @Param profile body ImportProfileRequest true "Import profile object"
+// @Success 201 {object} jsonresponse.IdResponse "Successfully created an import profile"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error creating import profile"
+// @Security JWT
+// @Router /analytics/import/profiles [post]
+func (h *MyHandler) CreateImportProfileHandler(w http.ResponseWriter, r *http.Request) {
+	var req ImportProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	profile := req.Profile
+	profile.UserID = userID
+
+	id, err := h.s.Importer.CreateProfile(&profile)
+	if err != nil {
+		h.importErrResp(w, err, "creating import profile")
+		return
+	}
+
+	response := jsonresponse.IdResponse{
+		Message:    "Successfully created an import profile",
+		Id:         id,
+		StatusCode: http.StatusCreated,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// UpdateImportProfileHandler updates an import profile.
+//
+// @Summary Update the import profile
+// @Description Update the name and the mapping of the import profile.
+// @Tags Import
+// @Accept json
+// @Produce json
+// @Param profile body ImportProfileRequest true "Import profile object"
+// @Success 200 {object} jsonresponse.SuccessResponse "Import profile updated successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Import profile not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error updating import profile"
+// @Security JWT
+// @Router /analytics/import/profiles [put]
+func (h *MyHandler) UpdateImportProfileHandler(w http.ResponseWriter, r *http.Request) {
+	var req ImportProfileRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	profile := req.Profile
+	profile.UserID = userID
+
+	if err := h.s.Importer.UpdateProfile(&profile); err != nil {
+		h.importErrResp(w, err, "updating import profile")
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    "Import profile updated successfully",
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteImportProfileHandler deletes an import profile.
+//
+// @Summary Delete the import profile
+// @Description Delete the saved import profile.
+// @Tags Import
+// @Param id body jsonresponse.IdRequest true "Import profile id"
+// @Success 204 {object} jsonresponse.SuccessResponse "Import profile deleted successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Import profile not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error deleting import profile"
+// @Security JWT
+// @Router /analytics/import/profiles [delete]
+func (h *MyHandler) DeleteImportProfileHandler(w http.ResponseWriter, r *http.Request) {
+	var id jsonresponse.IdRequest
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	profileID, err := strconv.ParseInt(id.ID, 10, 64)
+	if err != nil {
+		h.errResp(w, fmt.Errorf("invalid profile ID: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.s.Importer.DeleteProfile(profileID, userID); err != nil {
+		h.importErrResp(w, err, "deleting import profile")
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    "Successfully deleted import profile",
+		StatusCode: http.StatusNoContent,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
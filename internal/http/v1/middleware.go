@@ -2,6 +2,7 @@ package v1
 
 import (
 	"context"
+	"crypto/subtle"
 	"fmt"
 	"github.com/dgrijalva/jwt-go"
 	"github.com/wachrusz/Back-End-API/pkg/encryption"
@@ -145,7 +146,11 @@ func (h *MyHandler) AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 			return
 		}
 
-		r = r.WithContext(setUserIDInContext(r.Context(), userID))
+		ctx := setUserIDInContext(r.Context(), userID)
+		if deviceID, ok := claims["device_id"].(string); ok {
+			ctx = setDeviceIDInContext(ctx, deviceID)
+		}
+		r = r.WithContext(ctx)
 		//h.s.Users.UpdateLastActivity(userID)
 
 		next.ServeHTTP(w, r)
@@ -155,3 +160,20 @@ func (h *MyHandler) AuthMiddleware(next http.HandlerFunc) http.HandlerFunc {
 func setUserIDInContext(ctx context.Context, userID string) context.Context {
 	return context.WithValue(ctx, "userID", userID)
 }
+
+func setDeviceIDInContext(ctx context.Context, deviceID string) context.Context {
+	return context.WithValue(ctx, "device_id", deviceID)
+}
+
+// AdminMiddleware допускает только запросы с токеном администратора в заголовке X-Admin-Token.
+// Если токен не настроен, служебные запросы запрещены.
+func (h *MyHandler) AdminMiddleware(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.Header.Get("X-Admin-Token")
+		if h.adminToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.adminToken)) != 1 {
+			jsonresponse.SendErrorResponse(w, fmt.Errorf("admin token required"), http.StatusForbidden)
+			return
+		}
+		next(w, r)
+	}
+}
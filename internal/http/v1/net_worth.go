@@ -0,0 +1,156 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"go.uber.org/zap"
+	"net/http"
+
+	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
+	utility "github.com/wachrusz/Back-End-API/pkg/util"
+)
+
+// BaseCurrencyRequest is used for deserialization
+type BaseCurrencyRequest struct {
+	Currency string `json:"currency"`
+}
+
+type NetWorthHistoryResponse struct {
+	Message    string                 `json:"message"`
+	History    models.NetWorthHistory `json:"history"`
+	StatusCode int                    `json:"status_code"`
+}
+
+type NetWorthBackfillResponse struct {
+	Message    string `json:"message"`
+	Days       int    `json:"days"`
+	StatusCode int    `json:"status_code"`
+}
+
+// netWorthErrResp maps net worth service errors to http status codes.
+func (h *MyHandler) netWorthErrResp(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, myerrors.ErrValidation):
+		h.errResp(w, fmt.Errorf("invalid request: %v", err), http.StatusBadRequest)
+	case errors.Is(err, myerrors.ErrNotFound):
+		h.errResp(w, fmt.Errorf("not found: %v", err), http.StatusNotFound)
+	default:
+		h.errResp(w, fmt.Errorf("error %s: %v", action, err), http.StatusInternalServerError)
+	}
+}
+
+// NetWorthHistoryHandler returns the net worth history of the user.
+//
+// @Summary Get the net worth history
+// @Description Get daily net worth snapshots of the user by day, week (starting on Monday) or month; a week or month point is the last snapshot of the period. Net worth is the balance of connected accounts plus liquid and illiquid wealth fund assets minus wealth fund loans, in the base currency of the user. Snapshots are updated hourly; the first request restores the history from past transactions. The default period is the last 30 days, 12 weeks or 12 months.
+// @Tags Analytics
+// @Produce json
+// @Param resolution query string false "day (default), week or month"
+// @Param from query string false "First date (YYYY-MM-DD)"
+// @Param to query string false "Last date (YYYY-MM-DD), today by default"
+// @Success 200 {object} NetWorthHistoryResponse "Successfully got the net worth history"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid query parameters"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting net worth history"
+// @Security JWT
+// @Router /analytics/net_worth [get]
+func (h *MyHandler) NetWorthHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	history, err := h.s.NetWorth.History(userID, query.Get("resolution"), query.Get("from"), query.Get("to"))
+	if err != nil {
+		h.netWorthErrResp(w, err, "getting net worth history")
+		return
+	}
+
+	response := NetWorthHistoryResponse{
+		Message:    "Successfully got the net worth history",
+		History:    *history,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// SetBaseCurrencyHandler sets the base currency of the user.
+//
+// @Summary Set the base currency
+// @Description Set the currency in which the net worth of the user is shown, RUB by default.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param currency body BaseCurrencyRequest true "Currency code"
+// @Success 200 {object} jsonresponse.SuccessResponse "Base currency updated successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error updating base currency"
+// @Security JWT
+// @Router /analytics/net_worth/currency [put]
+func (h *MyHandler) SetBaseCurrencyHandler(w http.ResponseWriter, r *http.Request) {
+	var req BaseCurrencyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.s.NetWorth.SetBaseCurrency(userID, req.Currency); err != nil {
+		h.netWorthErrResp(w, err, "updating base currency")
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    "Base currency updated successfully",
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// BackfillNetWorthHandler restores the net worth history from past transactions.
+//
+// @Summary Restore the net worth history
+// @Description Recalculate all net worth snapshots of the user from past transactions, up to 10 years back. Account balances of past days are the current balances minus later incomes plus later expenses and transfers of the connected accounts; wealth fund assets and loans are the sums of their records up to the day. Transactions are converted to rubles at the exchange rate of their date, current account balances at the current rates.
+// @Tags Analytics
+// @Produce json
+// @Success 200 {object} NetWorthBackfillResponse "Net worth history restored successfully"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error restoring net worth history"
+// @Security JWT
+// @Router /analytics/net_worth/backfill [post]
+func (h *MyHandler) BackfillNetWorthHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	days, err := h.s.NetWorth.Backfill(userID)
+	if err != nil {
+		h.netWorthErrResp(w, err, "restoring net worth history")
+		return
+	}
+
+	response := NetWorthBackfillResponse{
+		Message:    "Net worth history restored successfully",
+		Days:       days,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+
+	h.l.Debug("Net worth history restored", zap.String("userID", userID), zap.Int("days", days))
+}
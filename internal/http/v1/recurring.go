@@ -0,0 +1,308 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+
+	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
+	utility "github.com/wachrusz/Back-End-API/pkg/util"
+)
+
+// RecurringRequest is used for deserialization
+type RecurringRequest struct {
+	Series models.RecurringSeries `json:"series"`
+}
+
+// RecurringSkipRequest is used for deserialization
+type RecurringSkipRequest struct {
+	ID   string `json:"id"`
+	Date string `json:"date"`
+}
+
+type RecurringListResponse struct {
+	Message    string                   `json:"message"`
+	Series     []models.RecurringSeries `json:"series"`
+	StatusCode int                      `json:"status_code"`
+}
+
+// recurringErrResp maps recurring service errors to http status codes.
+func (h *MyHandler) recurringErrResp(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, myerrors.ErrValidation):
+		h.errResp(w, fmt.Errorf("invalid recurring series: %v", err), http.StatusBadRequest)
+	case errors.Is(err, myerrors.ErrNotFound):
+		h.errResp(w, fmt.Errorf("recurring series not found: %v", err), http.StatusNotFound)
+	case errors.Is(err, myerrors.ErrConflict):
+		h.errResp(w, fmt.Errorf("error %s recurring series: %v", action, err), http.StatusConflict)
+	default:
+		h.errResp(w, fmt.Errorf("error %s recurring series: %v", action, err), http.StatusInternalServerError)
+	}
+}
+
+// ListRecurringHandler lists recurring series of the user.
+//
+// @Summary List recurring series
+// @Description Get all recurring incomes and expenses of the user.
+// @Tags Analytics
+// @Produce json
+// @Success 200 {object} RecurringListResponse "Successfully got recurring series"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting recurring series"
+// @Security JWT
+// @Router /analytics/recurring [get]
+func (h *MyHandler) ListRecurringHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	series, err := h.s.Recurring.ListByUserID(userID)
+	if err != nil {
+		h.recurringErrResp(w, err, "getting")
+		return
+	}
+
+	response := RecurringListResponse{
+		Message:    "Successfully got recurring series",
+		Series:     series,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateRecurringHandler creates a new recurring series.
+//
+// @Summary Create a recurring series
+// @Description Create a recurring income or expense. Kind is "expense" or "income", frequency is one of daily, weekly, monthly, nth_weekday. Occurrences are materialized into incomes/expenses when they come due.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param series body RecurringRequest true "Recurring series object"
+// @Success 201 {object} jsonresponse.IdResponse "Successfully created a recurring series"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error creating recurring series"
+// @Security JWT
+// @Router /analytics/recurring [post]
+func (h *MyHandler) CreateRecurringHandler(w http.ResponseWriter, r *http.Request) {
+	h.l.Debug("Creating a new recurring series...")
+
+	var req RecurringRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	series := req.Series
+	series.UserID = userID
+
+	id, err := h.s.Recurring.Create(&series)
+	if err != nil {
+		h.recurringErrResp(w, err, "creating")
+		return
+	}
+
+	response := jsonresponse.IdResponse{
+		Message:    "Successfully created a recurring series",
+		Id:         id,
+		StatusCode: http.StatusCreated,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+
+	h.l.Debug("Recurring series created successfully", zap.Int64("seriesID", id))
+}
+
+// UpdateRecurringHandler edits future occurrences of a recurring series.
+//
+// @Summary Update the recurring series
+// @Description Change the template and the rule of future occurrences. Already materialized incomes/expenses are not changed. Kind can't be changed.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param series body RecurringRequest true "Recurring series object"
+// @Success 200 {object} jsonresponse.SuccessResponse "Recurring series updated successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Recurring series not found"
+// @Failure 409 {object} jsonresponse.ErrorResponse "Recurring series was materialized concurrently"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error updating recurring series"
+// @Security JWT
+// @Router /analytics/recurring [put]
+func (h *MyHandler) UpdateRecurringHandler(w http.ResponseWriter, r *http.Request) {
+	h.l.Debug("Updating recurring series...")
+
+	var req RecurringRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	series := req.Series
+	series.UserID = userID
+
+	if err := h.s.Recurring.Update(&series); err != nil {
+		h.recurringErrResp(w, err, "updating")
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    "Recurring series updated successfully",
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteRecurringHandler deletes a recurring series.
+//
+// @Summary Delete the recurring series
+// @Description Delete the recurring series. Already materialized incomes/expenses are kept.
+// @Tags Analytics
+// @Param id body jsonresponse.IdRequest true "Recurring series id"
+// @Success 204 {object} jsonresponse.SuccessResponse "Recurring series deleted successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Recurring series not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error deleting recurring series"
+// @Security JWT
+// @Router /analytics/recurring [delete]
+func (h *MyHandler) DeleteRecurringHandler(w http.ResponseWriter, r *http.Request) {
+	h.recurringIDAction(w, r, "deleting", "Successfully deleted recurring series", http.StatusNoContent, h.s.Recurring.Delete)
+}
+
+// PauseRecurringHandler pauses a recurring series.
+//
+// @Summary Pause the recurring series
+// @Description Stop materializing occurrences of the series until it is resumed.
+// @Tags Analytics
+// @Param id body jsonresponse.IdRequest true "Recurring series id"
+// @Success 200 {object} jsonresponse.SuccessResponse "Recurring series paused successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Recurring series not found"
+// @Failure 409 {object} jsonresponse.ErrorResponse "Recurring series was materialized concurrently"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error pausing recurring series"
+// @Security JWT
+// @Router /analytics/recurring/pause [post]
+func (h *MyHandler) PauseRecurringHandler(w http.ResponseWriter, r *http.Request) {
+	h.recurringIDAction(w, r, "pausing", "Recurring series paused successfully", http.StatusOK, h.s.Recurring.Pause)
+}
+
+// ResumeRecurringHandler resumes a paused recurring series.
+//
+// @Summary Resume the recurring series
+// @Description Resume the paused series. Occurrences that fell on the pause are not materialized.
+// @Tags Analytics
+// @Param id body jsonresponse.IdRequest true "Recurring series id"
+// @Success 200 {object} jsonresponse.SuccessResponse "Recurring series resumed successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Recurring series not found"
+// @Failure 409 {object} jsonresponse.ErrorResponse "Recurring series was materialized concurrently"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error resuming recurring series"
+// @Security JWT
+// @Router /analytics/recurring/resume [post]
+func (h *MyHandler) ResumeRecurringHandler(w http.ResponseWriter, r *http.Request) {
+	h.recurringIDAction(w, r, "resuming", "Recurring series resumed successfully", http.StatusOK, h.s.Recurring.Resume)
+}
+
+func (h *MyHandler) recurringIDAction(w http.ResponseWriter, r *http.Request, action, message string, status int,
+	f func(id int64, userID string) error) {
+	var id jsonresponse.IdRequest
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	seriesID, err := strconv.ParseInt(id.ID, 10, 64)
+	if err != nil {
+		h.errResp(w, fmt.Errorf("invalid series ID: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	if err := f(seriesID, userID); err != nil {
+		h.recurringErrResp(w, err, action)
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    message,
+		StatusCode: status,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// SkipRecurringHandler skips a single future occurrence of a recurring series.
+//
+// @Summary Skip an occurrence
+// @Description Skip one future occurrence of the series. The date must be an occurrence date (YYYY-MM-DD).
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param skip body RecurringSkipRequest true "Series id and occurrence date"
+// @Success 200 {object} jsonresponse.SuccessResponse "Occurrence skipped successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Recurring series not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error skipping occurrence"
+// @Security JWT
+// @Router /analytics/recurring/skip [post]
+func (h *MyHandler) SkipRecurringHandler(w http.ResponseWriter, r *http.Request) {
+	var req RecurringSkipRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	seriesID, err := strconv.ParseInt(req.ID, 10, 64)
+	if err != nil {
+		h.errResp(w, fmt.Errorf("invalid series ID: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.s.Recurring.Skip(seriesID, userID, req.Date); err != nil {
+		h.recurringErrResp(w, err, "skipping occurrence of")
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    "Occurrence skipped successfully",
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
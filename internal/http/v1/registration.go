@@ -2,6 +2,7 @@ package v1
 
 import (
 	"github.com/go-chi/chi/v5"
+	"github.com/wachrusz/Back-End-API/internal/history"
 )
 
 func (h *MyHandler) RegisterHandler(r chi.Router) {
@@ -17,26 +18,133 @@ func (h *MyHandler) RegisterHandler(r chi.Router) {
 			r.Delete("/", h.AuthMiddleware(h.DeleteConnectedAccountHandler))
 			r.Put("/", h.AuthMiddleware(h.UpdateConnectedAccountHandler))
 		})
+
+		r.Route("/currency", func(r chi.Router) {
+			r.Get("/", h.AuthMiddleware(h.ListCurrenciesHandler))
+			r.Get("/convert", h.AuthMiddleware(h.ConvertCurrencyHandler))
+			r.Get("/history", h.AuthMiddleware(h.CurrencyRateHistoryHandler))
+			r.Post("/refresh", h.AdminMiddleware(h.RefreshCurrencyRatesHandler))
+		})
 	})
 
 	r.Route("/analytics", func(r chi.Router) {
 		r.Route("/income", func(r chi.Router) {
+			r.Get("/", h.AuthMiddleware(h.ListIncomesHandler))
 			r.Post("/", h.AuthMiddleware(h.CreateIncomeHandler))
 			r.Put("/", h.AuthMiddleware(h.UpdateIncomeHandler))
 			r.Delete("/", h.AuthMiddleware(h.DeleteIncomeHandler))
 		})
 
 		r.Route("/expense", func(r chi.Router) {
+			r.Get("/", h.AuthMiddleware(h.ListExpensesHandler))
 			r.Post("/", h.AuthMiddleware(h.CreateExpenseHandler))
 			r.Put("/", h.AuthMiddleware(h.UpdateExpenseHandler))
 			r.Delete("/", h.AuthMiddleware(h.DeleteExpenseHandler))
+			r.Put("/split", h.AuthMiddleware(h.SplitExpenseHandler))
+			r.Delete("/split", h.AuthMiddleware(h.UnsplitExpenseHandler))
 		})
 
 		r.Route("/wealth_fund", func(r chi.Router) {
+			r.Get("/", h.AuthMiddleware(h.ListWealthFundsHandler))
 			r.Post("/", h.AuthMiddleware(h.CreateWealthFundHandler))
 			r.Put("/", h.AuthMiddleware(h.UpdateWealthFundHandler))
 			r.Delete("/", h.AuthMiddleware(h.DeleteWealthFundHandler))
 		})
+
+		r.Route("/recurring", func(r chi.Router) {
+			r.Get("/", h.AuthMiddleware(h.ListRecurringHandler))
+			r.Post("/", h.AuthMiddleware(h.CreateRecurringHandler))
+			r.Put("/", h.AuthMiddleware(h.UpdateRecurringHandler))
+			r.Delete("/", h.AuthMiddleware(h.DeleteRecurringHandler))
+			r.Post("/pause", h.AuthMiddleware(h.PauseRecurringHandler))
+			r.Post("/resume", h.AuthMiddleware(h.ResumeRecurringHandler))
+			r.Post("/skip", h.AuthMiddleware(h.SkipRecurringHandler))
+		})
+
+		r.Route("/transfer", func(r chi.Router) {
+			r.Get("/", h.AuthMiddleware(h.ListTransfersHandler))
+			r.Post("/", h.AuthMiddleware(h.CreateTransferHandler))
+			r.Delete("/", h.AuthMiddleware(h.DeleteTransferHandler))
+		})
+
+		r.Get("/search", h.AuthMiddleware(h.SearchTransactionsHandler))
+		r.Get("/forecast", h.AuthMiddleware(h.CashFlowForecastHandler))
+		r.Get("/aggregate", h.AuthMiddleware(h.AggregateTransactionsHandler))
+		r.Get("/compare", h.AuthMiddleware(h.ComparePeriodsHandler))
+		r.Post("/batch", h.AuthMiddleware(h.BatchHandler))
+
+		r.Route("/trash", func(r chi.Router) {
+			r.Get("/", h.AuthMiddleware(h.ListTrashHandler))
+			r.Delete("/", h.AuthMiddleware(h.PurgeTrashHandler))
+			r.Post("/restore", h.AuthMiddleware(h.RestoreTrashHandler))
+		})
+
+		r.Route("/tag", func(r chi.Router) {
+			r.Get("/", h.AuthMiddleware(h.ListTagsHandler))
+			r.Post("/", h.AuthMiddleware(h.CreateTagHandler))
+			r.Put("/", h.AuthMiddleware(h.UpdateTagHandler))
+			r.Delete("/", h.AuthMiddleware(h.DeleteTagHandler))
+			r.Put("/assign", h.AuthMiddleware(h.AssignTagsHandler))
+			r.Get("/summary", h.AuthMiddleware(h.TagSummaryHandler))
+		})
+
+		r.Route("/rule", func(r chi.Router) {
+			r.Get("/", h.AuthMiddleware(h.ListRulesHandler))
+			r.Post("/", h.AuthMiddleware(h.CreateRuleHandler))
+			r.Put("/", h.AuthMiddleware(h.UpdateRuleHandler))
+			r.Delete("/", h.AuthMiddleware(h.DeleteRuleHandler))
+			r.Post("/apply", h.AuthMiddleware(h.ApplyRulesHandler))
+		})
+
+		r.Route("/budget", func(r chi.Router) {
+			r.Get("/", h.AuthMiddleware(h.ListBudgetsHandler))
+			r.Post("/", h.AuthMiddleware(h.CreateBudgetHandler))
+			r.Put("/", h.AuthMiddleware(h.UpdateBudgetHandler))
+			r.Delete("/", h.AuthMiddleware(h.DeleteBudgetHandler))
+			r.Get("/report", h.AuthMiddleware(h.BudgetReportHandler))
+		})
+
+		r.Route("/envelope", func(r chi.Router) {
+			r.Get("/", h.AuthMiddleware(h.ListEnvelopesHandler))
+			r.Post("/", h.AuthMiddleware(h.CreateEnvelopeHandler))
+			r.Delete("/", h.AuthMiddleware(h.DeleteEnvelopeHandler))
+			r.Get("/report", h.AuthMiddleware(h.EnvelopeReportHandler))
+			r.Post("/move", h.AuthMiddleware(h.MoveEnvelopeMoneyHandler))
+			r.Get("/movement", h.AuthMiddleware(h.ListEnvelopeMovementsHandler))
+			r.Post("/close", h.AuthMiddleware(h.CloseEnvelopeMonthHandler))
+		})
+
+		r.Route("/net_worth", func(r chi.Router) {
+			r.Get("/", h.AuthMiddleware(h.NetWorthHistoryHandler))
+			r.Put("/currency", h.AuthMiddleware(h.SetBaseCurrencyHandler))
+			r.Post("/backfill", h.AuthMiddleware(h.BackfillNetWorthHandler))
+		})
+
+		r.Route("/duplicate", func(r chi.Router) {
+			r.Get("/", h.AuthMiddleware(h.ListDuplicatesHandler))
+			r.Post("/merge", h.AuthMiddleware(h.MergeDuplicatesHandler))
+			r.Post("/dismiss", h.AuthMiddleware(h.DismissDuplicatesHandler))
+		})
+
+		r.Route("/attachment", func(r chi.Router) {
+			r.Get("/", h.AuthMiddleware(h.ListAttachmentsHandler))
+			r.Post("/", h.AuthMiddleware(h.UploadAttachmentHandler))
+			r.Delete("/", h.AuthMiddleware(h.DeleteAttachmentHandler))
+			r.Get("/download", h.AuthMiddleware(h.DownloadAttachmentHandler))
+			r.Get("/usage", h.AuthMiddleware(h.AttachmentUsageHandler))
+		})
+
+		r.Route("/import", func(r chi.Router) {
+			r.Post("/csv", h.AuthMiddleware(h.ImportCSVHandler))
+			r.Post("/statement", h.AuthMiddleware(h.ImportStatementHandler))
+
+			r.Route("/profiles", func(r chi.Router) {
+				r.Get("/", h.AuthMiddleware(h.ListImportProfilesHandler))
+				r.Post("/", h.AuthMiddleware(h.CreateImportProfileHandler))
+				r.Put("/", h.AuthMiddleware(h.UpdateImportProfileHandler))
+				r.Delete("/", h.AuthMiddleware(h.DeleteImportProfileHandler))
+			})
+		})
 	})
 
 	r.Route("/tracker/goal", func(r chi.Router) {
@@ -113,6 +221,10 @@ func (h *MyHandler) RegisterUserHandlers(router chi.Router) {
 	router.Get("/dev/confirmation-code/get", h.GetConfirmationCodeTestHandler)
 }
 
+func (h *MyHandler) RegisterHistoryHandlers(router chi.Router) {
+	history.RegisterHandlers(router, h.s.History, h.AuthMiddleware)
+}
+
 func (h *MyHandler) RegisterProfileHandlers(router chi.Router) {
 	// Profile routes
 	router.Route("/profile", func(r chi.Router) {
@@ -0,0 +1,275 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"go.uber.org/zap"
+	"net/http"
+
+	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
+	utility "github.com/wachrusz/Back-End-API/pkg/util"
+)
+
+// RuleRequest is used for deserialization
+type RuleRequest struct {
+	Rule models.CategorizationRule `json:"rule"`
+}
+
+// RuleApplyRequest selects past transactions to re-apply the rules to.
+type RuleApplyRequest struct {
+	// Kind is expense, income or empty for both.
+	Kind     string `json:"kind"`
+	DateFrom string `json:"date_from"`
+	DateTo   string `json:"date_to"`
+	// Preview only returns the changes without saving them.
+	Preview bool `json:"preview"`
+}
+
+type RuleListResponse struct {
+	Message    string                      `json:"message"`
+	Rules      []models.CategorizationRule `json:"rules"`
+	StatusCode int                         `json:"status_code"`
+}
+
+type RuleApplyResponse struct {
+	Message    string              `json:"message"`
+	Preview    bool                `json:"preview"`
+	Changes    []models.RuleChange `json:"changes"`
+	StatusCode int                 `json:"status_code"`
+}
+
+// ruleErrResp maps rule service errors to http status codes.
+func (h *MyHandler) ruleErrResp(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, myerrors.ErrValidation):
+		h.errResp(w, fmt.Errorf("invalid rule: %v", err), http.StatusBadRequest)
+	case errors.Is(err, myerrors.ErrNotFound):
+		h.errResp(w, fmt.Errorf("not found: %v", err), http.StatusNotFound)
+	default:
+		h.errResp(w, fmt.Errorf("error %s rule: %v", action, err), http.StatusInternalServerError)
+	}
+}
+
+// ListRulesHandler lists categorization rules of the user.
+//
+// @Summary List categorization rules
+// @Description Get the categorization rules of the user in the order they are applied: by priority, then by creation.
+// @Tags Analytics
+// @Produce json
+// @Success 200 {object} RuleListResponse "Successfully got rules"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting rules"
+// @Security JWT
+// @Router /analytics/rule [get]
+func (h *MyHandler) ListRulesHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	rules, err := h.s.Rules.ListByUserID(userID)
+	if err != nil {
+		h.ruleErrResp(w, err, "getting")
+		return
+	}
+
+	response := RuleListResponse{
+		Message:    "Successfully got rules",
+		Rules:      rules,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateRuleHandler creates a categorization rule.
+//
+// @Summary Create a categorization rule
+// @Description Create a rule that fills in new expenses and incomes, including imported ones. Conditions: kind, payee (case-insensitive substring of the recipient or sender), pattern (case-insensitive regular expression over the counterparty and the statement text), amount_min, amount_max, bank_account and currency; empty conditions are not checked and at least one is required. Actions: category_id (requires kind), tag_ids and planned; at least one is required. Rules are checked by priority (lower first); the first matching rule sets the category and the planned flag, tags of all matching rules are added. A category chosen by the user or mapped during import is never replaced. Rules are enabled unless enabled is false.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param rule body RuleRequest true "Rule object"
+// @Success 201 {object} jsonresponse.IdResponse "Successfully created a rule"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error creating rule"
+// @Security JWT
+// @Router /analytics/rule [post]
+func (h *MyHandler) CreateRuleHandler(w http.ResponseWriter, r *http.Request) {
+	// New rules are enabled unless the request says otherwise.
+	req := RuleRequest{Rule: models.CategorizationRule{Enabled: true}}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	rule := req.Rule
+	rule.UserID = userID
+
+	id, err := h.s.Rules.Create(&rule)
+	if err != nil {
+		h.ruleErrResp(w, err, "creating")
+		return
+	}
+
+	response := jsonresponse.IdResponse{
+		Message:    "Successfully created a rule",
+		Id:         id,
+		StatusCode: http.StatusCreated,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+
+	h.l.Debug("Rule created successfully", zap.Int64("ruleID", id))
+}
+
+// UpdateRuleHandler replaces a categorization rule.
+//
+// @Summary Update the categorization rule
+// @Description Replace the conditions, actions, priority and enabled flag of the rule. Transactions categorized earlier are not changed; use /analytics/rule/apply for that.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param rule body RuleRequest true "Rule object"
+// @Success 200 {object} jsonresponse.SuccessResponse "Rule updated successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Rule not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error updating rule"
+// @Security JWT
+// @Router /analytics/rule [put]
+func (h *MyHandler) UpdateRuleHandler(w http.ResponseWriter, r *http.Request) {
+	var req RuleRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	rule := req.Rule
+	rule.UserID = userID
+
+	if err := h.s.Rules.Update(&rule); err != nil {
+		h.ruleErrResp(w, err, "updating")
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    "Rule updated successfully",
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteRuleHandler deletes a categorization rule.
+//
+// @Summary Delete the categorization rule
+// @Description Delete the rule. Transactions categorized by it are kept as they are.
+// @Tags Analytics
+// @Param id body jsonresponse.IdRequest true "Rule id"
+// @Success 204 {object} jsonresponse.SuccessResponse "Rule deleted successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Rule not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error deleting rule"
+// @Security JWT
+// @Router /analytics/rule [delete]
+func (h *MyHandler) DeleteRuleHandler(w http.ResponseWriter, r *http.Request) {
+	var id jsonresponse.IdRequest
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.s.Rules.Delete(id.ID, userID); err != nil {
+		h.ruleErrResp(w, err, "deleting")
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    "Rule deleted successfully",
+		StatusCode: http.StatusNoContent,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// ApplyRulesHandler re-applies the categorization rules to past transactions.
+//
+// @Summary Re-apply categorization rules
+// @Description Apply the enabled rules of the user to past expenses and incomes, optionally limited by kind and period. Unlike new transactions, the category and the planned flag are replaced by the matching rule; tags are only added. Split expenses are skipped. With preview set to true the changes are returned without saving them. At most 10000 transactions are processed in one request; narrow the period if there are more.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param apply body RuleApplyRequest true "Transactions to re-categorize"
+// @Success 200 {object} RuleApplyResponse "Rules applied successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error applying rules"
+// @Security JWT
+// @Router /analytics/rule/apply [post]
+func (h *MyHandler) ApplyRulesHandler(w http.ResponseWriter, r *http.Request) {
+	var req RuleApplyRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	changes, err := h.s.Rules.Preview(&models.RuleApplyFilter{
+		UserID:   userID,
+		Kind:     req.Kind,
+		DateFrom: req.DateFrom,
+		DateTo:   req.DateTo,
+	})
+	if err != nil {
+		h.ruleErrResp(w, err, "applying")
+		return
+	}
+
+	response := RuleApplyResponse{
+		Message:    "Rules previewed successfully",
+		Preview:    req.Preview,
+		Changes:    changes,
+		StatusCode: http.StatusOK,
+	}
+
+	if !req.Preview {
+		if err := h.s.Rules.Apply(h.actor(r), userID, changes); err != nil {
+			h.ruleErrResp(w, err, "applying")
+			return
+		}
+		response.Message = "Rules applied successfully"
+	}
+
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
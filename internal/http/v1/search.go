@@ -0,0 +1,79 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"net/http"
+	"strconv"
+
+	utility "github.com/wachrusz/Back-End-API/pkg/util"
+)
+
+type SearchResponse struct {
+	Message    string                `json:"message"`
+	Results    []models.SearchResult `json:"results"`
+	StatusCode int                   `json:"status_code"`
+}
+
+// SearchTransactionsHandler searches the user's incomes and expenses.
+//
+// @Summary Search transactions
+// @Description Full-text search over incomes and expenses using Russian and English dictionaries. The query is matched against the payee or sender, income descriptions, notes of split expenses and category names; payee names are also matched fuzzily, and numbers in the query are matched against amounts. Results are ordered by relevance; matches in the snippet are wrapped in <b></b>.
+// @Tags Analytics
+// @Produce json
+// @Param q query string true "Search query"
+// @Param kind query string false "Search only expense or income"
+// @Param date_from query string false "Start date (YYYY-MM-DD), inclusive"
+// @Param date_to query string false "End date (YYYY-MM-DD), inclusive"
+// @Param limit query int false "Number of results, 20 by default, at most 100"
+// @Success 200 {object} SearchResponse "Successfully searched transactions"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error searching transactions"
+// @Security JWT
+// @Router /analytics/search [get]
+func (h *MyHandler) SearchTransactionsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	search := &models.SearchQuery{
+		UserID:   userID,
+		Text:     query.Get("q"),
+		Kind:     query.Get("kind"),
+		DateFrom: query.Get("date_from"),
+		DateTo:   query.Get("date_to"),
+	}
+	if limitStr := query.Get("limit"); limitStr != "" {
+		limit, err := strconv.Atoi(limitStr)
+		if err != nil {
+			h.errResp(w, fmt.Errorf("invalid limit %q", limitStr), http.StatusBadRequest)
+			return
+		}
+		search.Limit = limit
+	}
+
+	results, err := h.s.Search.Search(search)
+	if errors.Is(err, myerrors.ErrValidation) {
+		h.errResp(w, fmt.Errorf("invalid request: %v", err), http.StatusBadRequest)
+		return
+	}
+	if err != nil {
+		h.errResp(w, fmt.Errorf("error searching transactions: %v", err), http.StatusInternalServerError)
+		return
+	}
+
+	response := SearchResponse{
+		Message:    "Successfully searched transactions",
+		Results:    results,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
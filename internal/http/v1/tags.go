@@ -0,0 +1,294 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"go.uber.org/zap"
+	"net/http"
+
+	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
+	utility "github.com/wachrusz/Back-End-API/pkg/util"
+)
+
+// TagRequest is used for deserialization
+type TagRequest struct {
+	Tag models.Tag `json:"tag"`
+}
+
+// TagAssignRequest is used for deserialization
+type TagAssignRequest struct {
+	Kind   string   `json:"kind"`
+	ID     string   `json:"id"`
+	TagIDs []string `json:"tag_ids"`
+}
+
+type TagListResponse struct {
+	Message    string       `json:"message"`
+	Tags       []models.Tag `json:"tags"`
+	StatusCode int          `json:"status_code"`
+}
+
+type TagSummaryResponse struct {
+	Message    string            `json:"message"`
+	Summary    models.TagSummary `json:"summary"`
+	StatusCode int               `json:"status_code"`
+}
+
+// tagErrResp maps tag service errors to http status codes.
+func (h *MyHandler) tagErrResp(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, myerrors.ErrValidation):
+		h.errResp(w, fmt.Errorf("invalid tag: %v", err), http.StatusBadRequest)
+	case errors.Is(err, myerrors.ErrNotFound):
+		h.errResp(w, fmt.Errorf("not found: %v", err), http.StatusNotFound)
+	default:
+		h.errResp(w, fmt.Errorf("error %s tag: %v", action, err), http.StatusInternalServerError)
+	}
+}
+
+// ListTagsHandler lists tags of the user.
+//
+// @Summary List tags
+// @Description Get all tags of the user ordered by name.
+// @Tags Analytics
+// @Produce json
+// @Success 200 {object} TagListResponse "Successfully got tags"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting tags"
+// @Security JWT
+// @Router /analytics/tag [get]
+func (h *MyHandler) ListTagsHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	tags, err := h.s.Tags.ListByUserID(userID)
+	if err != nil {
+		h.tagErrResp(w, err, "getting")
+		return
+	}
+
+	response := TagListResponse{
+		Message:    "Successfully got tags",
+		Tags:       tags,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateTagHandler creates a new tag.
+//
+// @Summary Create a tag
+// @Description Create a tag. Names are unique per user regardless of case; color is optional and uses the #RRGGBB format.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param tag body TagRequest true "Tag object"
+// @Success 201 {object} jsonresponse.IdResponse "Successfully created a tag"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error creating tag"
+// @Security JWT
+// @Router /analytics/tag [post]
+func (h *MyHandler) CreateTagHandler(w http.ResponseWriter, r *http.Request) {
+	var req TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	tag := req.Tag
+	tag.UserID = userID
+
+	id, err := h.s.Tags.Create(&tag)
+	if err != nil {
+		h.tagErrResp(w, err, "creating")
+		return
+	}
+
+	response := jsonresponse.IdResponse{
+		Message:    "Successfully created a tag",
+		Id:         id,
+		StatusCode: http.StatusCreated,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+
+	h.l.Debug("Tag created successfully", zap.Int64("tagID", id))
+}
+
+// UpdateTagHandler renames or recolors a tag.
+//
+// @Summary Update the tag
+// @Description Update the name and color of the tag.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param tag body TagRequest true "Tag object"
+// @Success 200 {object} jsonresponse.SuccessResponse "Tag updated successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Tag not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error updating tag"
+// @Security JWT
+// @Router /analytics/tag [put]
+func (h *MyHandler) UpdateTagHandler(w http.ResponseWriter, r *http.Request) {
+	var req TagRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	tag := req.Tag
+	tag.UserID = userID
+
+	if err := h.s.Tags.Update(&tag); err != nil {
+		h.tagErrResp(w, err, "updating")
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    "Tag updated successfully",
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// DeleteTagHandler deletes a tag.
+//
+// @Summary Delete the tag
+// @Description Delete the tag and remove it from all records. The records themselves are kept.
+// @Tags Analytics
+// @Param id body jsonresponse.IdRequest true "Tag id"
+// @Success 204 {object} jsonresponse.SuccessResponse "Tag deleted successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Tag not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error deleting tag"
+// @Security JWT
+// @Router /analytics/tag [delete]
+func (h *MyHandler) DeleteTagHandler(w http.ResponseWriter, r *http.Request) {
+	var id jsonresponse.IdRequest
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.s.Tags.Delete(id.ID, userID); err != nil {
+		h.tagErrResp(w, err, "deleting")
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    "Tag deleted successfully",
+		StatusCode: http.StatusNoContent,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// AssignTagsHandler replaces the tags of an expense, income or wealth fund record.
+//
+// @Summary Set tags of a record
+// @Description Replace the tags of an expense, income or wealth fund record. Kind is "expense", "income" or "wealth_fund"; an empty tag_ids list removes all tags.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param tags body TagAssignRequest true "Record and its tags"
+// @Success 200 {object} jsonresponse.SuccessResponse "Tags set successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Record or tag not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error setting tags"
+// @Security JWT
+// @Router /analytics/tag/assign [put]
+func (h *MyHandler) AssignTagsHandler(w http.ResponseWriter, r *http.Request) {
+	var req TagAssignRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.s.Tags.Assign(req.Kind, req.ID, userID, req.TagIDs); err != nil {
+		h.tagErrResp(w, err, "assigning")
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    "Tags set successfully",
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// TagSummaryHandler aggregates records with a tag.
+//
+// @Summary Tag summary
+// @Description Get the totals of actual (not planned) expenses, incomes and wealth fund records with the tag: per kind, per original currency and per month. Amounts are converted to the requested currency.
+// @Tags Analytics
+// @Produce json
+// @Param tag_id query string true "Tag id"
+// @Param currency query string false "Currency of the totals, RUB by default"
+// @Param date_from query string false "Start date (YYYY-MM-DD), inclusive"
+// @Param date_to query string false "End date (YYYY-MM-DD), inclusive"
+// @Success 200 {object} TagSummaryResponse "Successfully got tag summary"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Tag not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting tag summary"
+// @Security JWT
+// @Router /analytics/tag/summary [get]
+func (h *MyHandler) TagSummaryHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	query := r.URL.Query()
+	summary, err := h.s.Tags.Summary(query.Get("tag_id"), userID, query.Get("currency"), query.Get("date_from"), query.Get("date_to"))
+	if err != nil {
+		h.tagErrResp(w, err, "summarizing")
+		return
+	}
+
+	response := TagSummaryResponse{
+		Message:    "Successfully got tag summary",
+		Summary:    *summary,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
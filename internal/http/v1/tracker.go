@@ -61,7 +61,7 @@ func (h *MyHandler) CreateGoalHandler(w http.ResponseWriter, r *http.Request) {
 	goal.UserID = userID
 
 	// Create a new goal in the database
-	goalID, err := h.s.Goals.Create(&goal)
+	goalID, err := h.s.Goals.Create(h.actor(r), &goal)
 	if err != nil {
 		h.errResp(w, fmt.Errorf("error creating goal: %v", err), http.StatusInternalServerError)
 		return
@@ -123,7 +123,7 @@ func (h *MyHandler) UpdateGoalHandler(w http.ResponseWriter, r *http.Request) {
 	goal.UserID = userID
 
 	// Create a new goal in the database
-	if err := h.s.Goals.Update(&goal); err != nil {
+	if err := h.s.Goals.Update(h.actor(r), &goal); err != nil {
 		if errors.Is(err, myerrors.ErrNotFound) {
 			h.errResp(w, fmt.Errorf("expense not found: %v", err), http.StatusNotFound)
 		} else {
@@ -147,7 +147,7 @@ func (h *MyHandler) UpdateGoalHandler(w http.ResponseWriter, r *http.Request) {
 // DeleteGoalHandler handles the deletion of an existing goal.
 //
 // @Summary Delete the goal
-// @Description Delete the existing goal.
+// @Description Move the existing goal to the trash. It can be restored until the trash retention period expires.
 // @Tags Tracker
 // @Param ConnectedAccount body jsonresponse.IdRequest true "goal id"
 // @Success 204 {object} jsonresponse.SuccessResponse "goal deleted successfully"
@@ -183,7 +183,7 @@ func (h *MyHandler) DeleteGoalHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.s.Goals.Delete(goalID, userID); err != nil {
+	if err := h.s.Goals.Delete(h.actor(r), goalID, userID); err != nil {
 		if errors.Is(err, myerrors.ErrNotFound) {
 			h.errResp(w, fmt.Errorf("goal not found: %v", err), http.StatusNotFound)
 		} else {
@@ -245,7 +245,7 @@ func (h *MyHandler) GetGoalDetailsHandler(w http.ResponseWriter, r *http.Request
 		return
 	}
 
-	details, err := h.s.Goals.Details(goalID, userID)
+	details, err := h.s.Goals.Details(h.actor(r), goalID, userID)
 	if err != nil {
 		if errors.Is(err, myerrors.ErrNotFound) {
 			h.errResp(w, fmt.Errorf("goal not found: %v", err), http.StatusNotFound)
@@ -302,7 +302,7 @@ func (h *MyHandler) CreateGoalTransactionHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	details, err := h.s.Goals.NewTransaction(&tr.Transaction, userID)
+	details, err := h.s.Goals.NewTransaction(h.actor(r), &tr.Transaction, userID)
 	if err != nil {
 		if errors.Is(err, myerrors.ErrNotFound) {
 			h.errResp(w, fmt.Errorf("goal not found: %v", err), http.StatusNotFound)
@@ -0,0 +1,166 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"go.uber.org/zap"
+	"net/http"
+	"strconv"
+
+	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
+	utility "github.com/wachrusz/Back-End-API/pkg/util"
+)
+
+// TransferRequest is used for deserialization
+type TransferRequest struct {
+	Transfer models.Transfer `json:"transfer"`
+}
+
+type TransferListResponse struct {
+	Message    string            `json:"message"`
+	Transfers  []models.Transfer `json:"transfers"`
+	StatusCode int               `json:"status_code"`
+}
+
+// transferErrResp maps transfer service errors to http status codes.
+func (h *MyHandler) transferErrResp(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, myerrors.ErrValidation):
+		h.errResp(w, fmt.Errorf("invalid transfer: %v", err), http.StatusBadRequest)
+	case errors.Is(err, myerrors.ErrNotFound):
+		h.errResp(w, fmt.Errorf("not found: %v", err), http.StatusNotFound)
+	default:
+		h.errResp(w, fmt.Errorf("error %s transfer: %v", action, err), http.StatusInternalServerError)
+	}
+}
+
+// ListTransfersHandler lists transfers of the user.
+//
+// @Summary List transfers
+// @Description Get all transfers between connected accounts of the user, newest first.
+// @Tags Analytics
+// @Produce json
+// @Success 200 {object} TransferListResponse "Successfully got transfers"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting transfers"
+// @Security JWT
+// @Router /analytics/transfer [get]
+func (h *MyHandler) ListTransfersHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	transfers, err := h.s.Transfers.ListByUserID(userID)
+	if err != nil {
+		h.transferErrResp(w, err, "getting")
+		return
+	}
+
+	response := TransferListResponse{
+		Message:    "Successfully got transfers",
+		Transfers:  transfers,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// CreateTransferHandler creates a transfer between two connected accounts.
+//
+// @Summary Create a transfer
+// @Description Move money between two connected accounts of the user. Currencies are taken from the accounts. For different currencies pass to_amount or rate, otherwise the current exchange rate is applied. Transfers are not counted as incomes or expenses; both account states are updated.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param transfer body TransferRequest true "Transfer object"
+// @Success 201 {object} jsonresponse.IdResponse "Successfully created a transfer"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Account not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error creating transfer"
+// @Security JWT
+// @Router /analytics/transfer [post]
+func (h *MyHandler) CreateTransferHandler(w http.ResponseWriter, r *http.Request) {
+	h.l.Debug("Creating a new transfer...")
+
+	var req TransferRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	transfer := req.Transfer
+	transfer.UserID = userID
+
+	id, err := h.s.Transfers.Create(h.actor(r), &transfer)
+	if err != nil {
+		h.transferErrResp(w, err, "creating")
+		return
+	}
+
+	response := jsonresponse.IdResponse{
+		Message:    "Successfully created a transfer",
+		Id:         id,
+		StatusCode: http.StatusCreated,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+
+	h.l.Debug("Transfer created successfully", zap.Int64("transferID", id))
+}
+
+// DeleteTransferHandler deletes a transfer.
+//
+// @Summary Delete the transfer
+// @Description Delete the transfer and revert the states of both accounts.
+// @Tags Analytics
+// @Param id body jsonresponse.IdRequest true "Transfer id"
+// @Success 204 {object} jsonresponse.SuccessResponse "Transfer deleted successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Transfer not found"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error deleting transfer"
+// @Security JWT
+// @Router /analytics/transfer [delete]
+func (h *MyHandler) DeleteTransferHandler(w http.ResponseWriter, r *http.Request) {
+	var id jsonresponse.IdRequest
+	if err := json.NewDecoder(r.Body).Decode(&id); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	transferID, err := strconv.ParseInt(id.ID, 10, 64)
+	if err != nil {
+		h.errResp(w, fmt.Errorf("invalid transfer ID: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.s.Transfers.Delete(h.actor(r), transferID, userID); err != nil {
+		h.transferErrResp(w, err, "deleting")
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    "Transfer deleted successfully",
+		StatusCode: http.StatusNoContent,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
@@ -0,0 +1,152 @@
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"net/http"
+
+	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
+	utility "github.com/wachrusz/Back-End-API/pkg/util"
+)
+
+// TrashItemRequest identifies a record in the trash.
+type TrashItemRequest struct {
+	// Kind is one of expense, income, wealth_fund, goal.
+	Kind string `json:"kind"`
+	ID   string `json:"id"`
+}
+
+type TrashListResponse struct {
+	Message    string             `json:"message"`
+	Items      []models.TrashItem `json:"items"`
+	StatusCode int                `json:"status_code"`
+}
+
+// trashErrResp maps trash service errors to http status codes.
+func (h *MyHandler) trashErrResp(w http.ResponseWriter, err error, action string) {
+	switch {
+	case errors.Is(err, myerrors.ErrValidation):
+		h.errResp(w, fmt.Errorf("invalid trash item: %v", err), http.StatusBadRequest)
+	case errors.Is(err, myerrors.ErrNotFound):
+		h.errResp(w, fmt.Errorf("not found: %v", err), http.StatusNotFound)
+	default:
+		h.errResp(w, fmt.Errorf("error %s trash: %v", action, err), http.StatusInternalServerError)
+	}
+}
+
+// ListTrashHandler lists deleted records of the user.
+//
+// @Summary List the trash
+// @Description Get deleted expenses, incomes, wealth fund records and goals of the user, most recently deleted first. Records stay in the trash until expires_at and are then deleted permanently. Records in the trash are not counted in lists, analytics, search or financial health.
+// @Tags Analytics
+// @Produce json
+// @Success 200 {object} TrashListResponse "Successfully got the trash"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error getting trash"
+// @Security JWT
+// @Router /analytics/trash [get]
+func (h *MyHandler) ListTrashHandler(w http.ResponseWriter, r *http.Request) {
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	items, err := h.s.Trash.List(userID)
+	if err != nil {
+		h.trashErrResp(w, err, "getting")
+		return
+	}
+
+	response := TrashListResponse{
+		Message:    "Successfully got the trash",
+		Items:      items,
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// RestoreTrashHandler restores a deleted record.
+//
+// @Summary Restore a record from the trash
+// @Description Undo the deletion of an expense, income, wealth fund record or goal that is still in the trash.
+// @Tags Analytics
+// @Accept json
+// @Produce json
+// @Param item body TrashItemRequest true "Record to restore"
+// @Success 200 {object} jsonresponse.SuccessResponse "Record restored successfully"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Record not found in the trash"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error restoring record"
+// @Security JWT
+// @Router /analytics/trash/restore [post]
+func (h *MyHandler) RestoreTrashHandler(w http.ResponseWriter, r *http.Request) {
+	var req TrashItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.s.Trash.Restore(h.actor(r), req.Kind, req.ID, userID); err != nil {
+		h.trashErrResp(w, err, "restoring from")
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    "Record restored successfully",
+		StatusCode: http.StatusOK,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
+
+// PurgeTrashHandler permanently deletes a record from the trash.
+//
+// @Summary Delete a record permanently
+// @Description Permanently delete an expense, income, wealth fund record or goal from the trash. This cannot be undone.
+// @Tags Analytics
+// @Accept json
+// @Param item body TrashItemRequest true "Record to delete"
+// @Success 204 {object} jsonresponse.SuccessResponse "Record deleted permanently"
+// @Failure 400 {object} jsonresponse.ErrorResponse "Invalid request payload"
+// @Failure 401 {object} jsonresponse.ErrorResponse "User not authenticated"
+// @Failure 404 {object} jsonresponse.ErrorResponse "Record not found in the trash"
+// @Failure 500 {object} jsonresponse.ErrorResponse "Error deleting record"
+// @Security JWT
+// @Router /analytics/trash [delete]
+func (h *MyHandler) PurgeTrashHandler(w http.ResponseWriter, r *http.Request) {
+	var req TrashItemRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		h.errResp(w, fmt.Errorf("invalid request payload: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	userID, ok := utility.GetUserIDFromContext(r.Context())
+	if !ok {
+		h.errResp(w, fmt.Errorf("user not authenticated"), http.StatusUnauthorized)
+		return
+	}
+
+	if err := h.s.Trash.Purge(h.actor(r), req.Kind, req.ID, userID); err != nil {
+		h.trashErrResp(w, err, "purging")
+		return
+	}
+
+	response := jsonresponse.SuccessResponse{
+		Message:    "Record deleted permanently",
+		StatusCode: http.StatusNoContent,
+	}
+	w.WriteHeader(response.StatusCode)
+	json.NewEncoder(w).Encode(response)
+}
@@ -16,4 +16,8 @@ var (
 	ErrExpiredCode    = errors.New("expired code")
 	ErrNotFound       = errors.New("not found")
 	ErrDualSession    = errors.New("you've already been logged in with your device. try to login again")
+	ErrValidation     = errors.New("validation failed")
+	ErrTooLarge       = errors.New("file is too large")
+	ErrQuotaExceeded  = errors.New("storage quota exceeded")
+	ErrConflict       = errors.New("conflict")
 )
@@ -0,0 +1,133 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+type AggregationModel struct {
+	DB *mydb.Database
+}
+
+// aggregationSource - выборка операций одного вида: таблица в рублях, таблица категорий, таблица тегов
+// и её колонка, колонка получателя/отправителя.
+type aggregationSource struct {
+	view, categories, tags, tagColumn, payee string
+}
+
+var aggregationSources = map[string]aggregationSource{
+	models.KindExpense: {"expense_in_rubles", "expense_categories", "expense_tags", "expense_id", "sent_to"},
+	models.KindIncome:  {"income_in_rubles", "income_categories", "income_tags", "income_id", "sender"},
+}
+
+// aggregationBuckets - начало интервала для даты операции. Недели начинаются с понедельника.
+var aggregationBuckets = map[string]string{
+	models.BucketNone:    "$2::date",
+	models.BucketDay:     "v.date",
+	models.BucketWeek:    "date_trunc('week', v.date)::date",
+	models.BucketMonth:   "date_trunc('month', v.date)::date",
+	models.BucketQuarter: "date_trunc('quarter', v.date)::date",
+	models.BucketYear:    "date_trunc('year', v.date)::date",
+}
+
+// aggregationGroup возвращает ключ, название группы и нужные для них соединения.
+func aggregationGroup(groupBy string, src aggregationSource) (key, name, joins string, ok bool) {
+	switch groupBy {
+	case models.GroupByNone:
+		return "''", "''", "", true
+	case models.GroupByCategory:
+		return "COALESCE(v.category::text, '')", "COALESCE(c.name, '')",
+			"LEFT JOIN " + src.categories + " c ON c.id = v.category", true
+	case models.GroupByAccount:
+		return "COALESCE(v.connected_account, '')", "COALESCE(a.name, '')",
+			`LEFT JOIN LATERAL (
+				SELECT name FROM connected_accounts
+				WHERE user_id = v.user_id AND account_number = v.connected_account
+				ORDER BY id LIMIT 1
+			) a ON true`, true
+	case models.GroupByPayee:
+		payee := "COALESCE(NULLIF(v." + src.payee + ", 'blank'), '')"
+		return payee, payee, "", true
+	case models.GroupByTag:
+		return "COALESCE(g.id::text, '')", "COALESCE(g.name, '')",
+			"LEFT JOIN " + src.tags + " t ON t." + src.tagColumn + " = v.id LEFT JOIN tags g ON g.id = t.tag_id", true
+	}
+	return "", "", "", false
+}
+
+// Aggregate суммирует операции пользователя в рублях по интервалам и группам. Кроме строк по интервалу и группе
+// возвращаются итоги интервалов, итоги групп за весь период и общий итог. Операции считаются по id без повторов,
+// поэтому расход с несколькими частями или тегами учитывается в количестве каждой своей группы один раз.
+// Расход с несколькими тегами входит в группу каждого тега, поэтому итоги интервалов и общий итог считаются
+// без соединения с группами, и каждая операция входит в них один раз. Запланированные операции и записи
+// в корзине не учитываются.
+func (m *AggregationModel) Aggregate(filter *models.AggregationFilter) ([]models.AggregationRow, error) {
+	src, ok := aggregationSources[filter.Kind]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown kind %q", myerrors.ErrValidation, filter.Kind)
+	}
+	bucket, ok := aggregationBuckets[filter.Bucket]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown bucket %q", myerrors.ErrValidation, filter.Bucket)
+	}
+	key, name, joins, ok := aggregationGroup(filter.GroupBy, src)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown group_by %q", myerrors.ErrValidation, filter.GroupBy)
+	}
+
+	where := "WHERE v.user_id = $1 AND NOT COALESCE(v.planned, false) AND v.date >= $2::date AND v.date <= $3::date"
+	query := `SELECT GROUPING(s.bucket) = 1, false, s.bucket, s.key, MAX(s.name),
+			COALESCE(SUM(s.amount), 0), COUNT(DISTINCT s.id)
+		FROM (
+			SELECT v.id, ` + bucket + ` AS bucket, ` + key + ` AS key, ` + name + ` AS name, v.amount_in_rubles AS amount
+			FROM ` + src.view + ` v
+			` + joins + `
+			` + where + `
+		) s
+		GROUP BY GROUPING SETS ((s.bucket, s.key), (s.key))
+		UNION ALL
+		SELECT GROUPING(s.bucket) = 1, true, s.bucket, NULL::text, NULL::text,
+			COALESCE(SUM(s.amount), 0), COUNT(DISTINCT s.id)
+		FROM (
+			SELECT v.id, ` + bucket + ` AS bucket, v.amount_in_rubles AS amount
+			FROM ` + src.view + ` v
+			` + where + `
+		) s
+		GROUP BY GROUPING SETS ((s.bucket), ())`
+
+	rows, err := m.DB.Query(query, filter.UserID, filter.From, filter.To)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	result := make([]models.AggregationRow, 0)
+	for rows.Next() {
+		var row models.AggregationRow
+		var bucketDate *time.Time
+		var rowKey, rowName *string
+		err := rows.Scan(&row.AllBuckets, &row.AllGroups, &bucketDate, &rowKey, &rowName, &row.Amount, &row.Count)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		if bucketDate != nil {
+			row.Bucket = bucketDate.Format("2006-01-02")
+		}
+		if rowKey != nil {
+			row.Key = *rowKey
+		}
+		if rowName != nil {
+			row.Name = *rowName
+		}
+		result = append(result, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return result, nil
+}
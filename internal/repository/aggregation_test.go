@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+// testDB подключается к базе с применёнными миграциями из TEST_DATABASE_URL. Без неё тест пропускается.
+func testDB(t *testing.T) *mydb.Database {
+	t.Helper()
+	url := os.Getenv("TEST_DATABASE_URL")
+	if url == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := mydb.Init(url)
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(db.Close)
+	return db
+}
+
+// testUser создаёт пользователя, записи которого удаляются после теста.
+func testUser(t *testing.T, db *mydb.Database) string {
+	t.Helper()
+	var id int64
+	email := fmt.Sprintf("aggregation-%d@example.com", time.Now().UnixNano())
+	if err := db.QueryRow("INSERT INTO users (email, hashed_password) VALUES ($1, '') RETURNING id", email).Scan(&id); err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	t.Cleanup(func() {
+		for _, query := range []string{
+			"DELETE FROM record_history WHERE user_id = $1",
+			"DELETE FROM operations WHERE user_id = $1",
+			"DELETE FROM expense WHERE user_id = $1",
+			"DELETE FROM tags WHERE user_id = $1",
+			"DELETE FROM connected_accounts WHERE user_id = $1",
+			"DELETE FROM users WHERE id = $1",
+		} {
+			if _, err := db.Exec(query, id); err != nil {
+				t.Errorf("cleanup: %v", err)
+			}
+		}
+	})
+	return fmt.Sprint(id)
+}
+
+func TestAggregateByTagCountsTotalsOnce(t *testing.T) {
+	db := testDB(t)
+	userID := testUser(t, db)
+
+	var first, second int64
+	for name, id := range map[string]*int64{"first": &first, "second": &second} {
+		if err := db.QueryRow("INSERT INTO tags (user_id, name) VALUES ($1, $2) RETURNING id", userID, name).Scan(id); err != nil {
+			t.Fatalf("create tag: %v", err)
+		}
+	}
+
+	expenses := &ExpenseModel{DB: db}
+	for _, expense := range []models.Expense{
+		{Amount: 100, Date: "2024-03-10", UserID: userID, CategoryID: "1", Currency: "RUB",
+			Tags: []models.Tag{{ID: fmt.Sprint(first)}, {ID: fmt.Sprint(second)}}},
+		{Amount: 50, Date: "2024-03-20", UserID: userID, CategoryID: "1", Currency: "RUB"},
+		{Amount: 1000, Date: "2024-03-25", UserID: userID, CategoryID: "1", Currency: "RUB", Planned: true},
+	} {
+		if _, err := expenses.Create(models.Actor{}, &expense); err != nil {
+			t.Fatalf("create expense: %v", err)
+		}
+	}
+
+	rows, err := (&AggregationModel{DB: db}).Aggregate(&models.AggregationFilter{
+		UserID:  userID,
+		Kind:    models.KindExpense,
+		GroupBy: models.GroupByTag,
+		Bucket:  models.BucketMonth,
+		From:    "2024-03-01",
+		To:      "2024-03-31",
+	})
+	if err != nil {
+		t.Fatalf("Aggregate: %v", err)
+	}
+
+	type total struct {
+		amount float64
+		count  int
+	}
+	want := map[string]total{
+		"total":                       {150, 2},
+		"bucket":                      {150, 2},
+		"group " + fmt.Sprint(first):  {100, 1},
+		"group " + fmt.Sprint(second): {100, 1},
+		"group ":                      {50, 1},
+	}
+	got := make(map[string]total)
+	for _, row := range rows {
+		switch {
+		case row.AllBuckets && row.AllGroups:
+			got["total"] = total{row.Amount, row.Count}
+		case row.AllGroups:
+			got["bucket"] = total{row.Amount, row.Count}
+		case row.AllBuckets:
+			got["group "+row.Key] = total{row.Amount, row.Count}
+		}
+	}
+	for key, w := range want {
+		if got[key] != w {
+			t.Errorf("%s = %+v, want %+v", key, got[key], w)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+// Package repository provides basic financial repository functionality.
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+type AttachmentModel struct {
+	DB *mydb.Database
+}
+
+// attachmentOwners сопоставляет тип владельца вложения колонке таблицы attachments
+// и запросу, проверяющему, что владелец принадлежит пользователю.
+var attachmentOwners = map[string]struct {
+	column string
+	exists string
+}{
+	models.KindExpense: {
+		column: "expense_id",
+		exists: "SELECT EXISTS (SELECT 1 FROM expense WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)",
+	},
+	models.KindIncome: {
+		column: "income_id",
+		exists: "SELECT EXISTS (SELECT 1 FROM income WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)",
+	},
+	models.KindGoalTransaction: {
+		column: "goal_transaction_id",
+		exists: `SELECT EXISTS (SELECT 1 FROM goal_transactions t JOIN goals g ON g.id = t.goal_id
+			WHERE t.id = $1 AND g.user_id = $2 AND g.deleted_at IS NULL)`,
+	},
+}
+
+const attachmentColumns = `id, user_id,
+	CASE WHEN expense_id IS NOT NULL THEN 'expense' WHEN income_id IS NOT NULL THEN 'income' ELSE 'goal_transaction' END,
+	COALESCE(expense_id, income_id, goal_transaction_id), file_name, content_type, size, thumbnail IS NOT NULL, created_at`
+
+func scanAttachment(row rowScanner) (*models.Attachment, error) {
+	var a models.Attachment
+	var createdAt time.Time
+	err := row.Scan(&a.ID, &a.UserID, &a.OwnerType, &a.OwnerID, &a.FileName, &a.ContentType, &a.Size, &a.HasThumbnail, &createdAt)
+	if err != nil {
+		return nil, err
+	}
+	a.CreatedAt = createdAt.Format(time.RFC3339)
+	return &a, nil
+}
+
+// Create сохраняет вложение, если владелец принадлежит пользователю и после загрузки
+// суммарный объём его файлов не превысит quota байт. Строка пользователя блокируется,
+// чтобы параллельные загрузки не обошли квоту.
+func (m *AttachmentModel) Create(attachment *models.Attachment, data, thumbnail []byte, quota int64) (id int64, err error) {
+	owner, ok := attachmentOwners[attachment.OwnerType]
+	if !ok {
+		return 0, fmt.Errorf("%w: unknown owner type %q", myerrors.ErrValidation, attachment.OwnerType)
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	if _, err = tx.Exec("SELECT id FROM users WHERE id = $1 FOR UPDATE", attachment.UserID); err != nil {
+		return 0, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	var exists bool
+	if err = tx.QueryRow(owner.exists, attachment.OwnerID, attachment.UserID).Scan(&exists); err != nil {
+		return 0, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if !exists {
+		return 0, fmt.Errorf("%w: no %s found with id %s for user %s", myerrors.ErrNotFound,
+			attachment.OwnerType, attachment.OwnerID, attachment.UserID)
+	}
+
+	var used int64
+	err = tx.QueryRow("SELECT COALESCE(SUM(size), 0) FROM attachments WHERE user_id = $1", attachment.UserID).Scan(&used)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if used+attachment.Size > quota {
+		return 0, fmt.Errorf("%w: %d of %d bytes used", myerrors.ErrQuotaExceeded, used, quota)
+	}
+
+	var createdAt time.Time
+	err = tx.QueryRow(`INSERT INTO attachments (user_id, `+owner.column+`, file_name, content_type, size, data, thumbnail)
+		VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id, created_at`,
+		attachment.UserID, attachment.OwnerID, attachment.FileName, attachment.ContentType, attachment.Size, data, thumbnail).
+		Scan(&id, &createdAt)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	attachment.ID = id
+	attachment.HasThumbnail = thumbnail != nil
+	attachment.CreatedAt = createdAt.Format(time.RFC3339)
+
+	return id, nil
+}
+
+// ListByOwner возвращает вложения расхода, дохода или транзакции цели без содержимого файлов.
+func (m *AttachmentModel) ListByOwner(userID, ownerType, ownerID string) ([]models.Attachment, error) {
+	owner, ok := attachmentOwners[ownerType]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown owner type %q", myerrors.ErrValidation, ownerType)
+	}
+
+	rows, err := m.DB.Query("SELECT "+attachmentColumns+" FROM attachments WHERE user_id = $1 AND "+owner.column+" = $2 ORDER BY id",
+		userID, ownerID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	var attachments []models.Attachment
+	for rows.Next() {
+		attachment, err := scanAttachment(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		attachments = append(attachments, *attachment)
+	}
+
+	return attachments, rows.Err()
+}
+
+// Content возвращает вложение пользователя вместе с файлом или его миниатюрой.
+func (m *AttachmentModel) Content(id int64, userID string, thumbnail bool) (*models.Attachment, []byte, error) {
+	column := "data"
+	if thumbnail {
+		column = "thumbnail"
+	}
+
+	var a models.Attachment
+	var createdAt time.Time
+	var data []byte
+	err := m.DB.QueryRow("SELECT "+attachmentColumns+", "+column+" FROM attachments WHERE id = $1 AND user_id = $2", id, userID).
+		Scan(&a.ID, &a.UserID, &a.OwnerType, &a.OwnerID, &a.FileName, &a.ContentType, &a.Size, &a.HasThumbnail, &createdAt, &data)
+	if errors.Is(err, sql.ErrNoRows) || err == nil && data == nil {
+		return nil, nil, fmt.Errorf("%w: no attachment found with id %d for user %s", myerrors.ErrNotFound, id, userID)
+	}
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	a.CreatedAt = createdAt.Format(time.RFC3339)
+
+	return &a, data, nil
+}
+
+func (m *AttachmentModel) Delete(id int64, userID string) error {
+	result, err := m.DB.Exec("DELETE FROM attachments WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: no attachment found with id %d for user %s", myerrors.ErrNotFound, id, userID)
+	}
+	return nil
+}
+
+// Usage возвращает суммарный объём вложений пользователя в байтах.
+func (m *AttachmentModel) Usage(userID string) (int64, error) {
+	var used int64
+	err := m.DB.QueryRow("SELECT COALESCE(SUM(size), 0) FROM attachments WHERE user_id = $1", userID).Scan(&used)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	return used, nil
+}
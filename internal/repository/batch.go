@@ -0,0 +1,153 @@
+// Package repository provides basic financial repository functionality.
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+type BatchModel struct {
+	DB *mydb.Database
+}
+
+// Apply выполняет операции пакета и возвращает id записи и ошибку по каждой операции.
+// В атомарном режиме все операции выполняются в одной транзакции; каждая операция выполняется
+// под своей точкой сохранения, чтобы собрать ошибки всех операций, и при любой ошибке
+// транзакция откатывается (committed = false). Иначе каждая операция выполняется в своей транзакции.
+func (m *BatchModel) Apply(actor models.Actor, userID string, ops []models.BatchOperation, atomic bool) (ids []string, errs []error, committed bool, err error) {
+	ids = make([]string, len(ops))
+	errs = make([]error, len(ops))
+
+	if !atomic {
+		for i := range ops {
+			ids[i], errs[i] = m.applyInTx(actor, userID, &ops[i])
+		}
+		return ids, errs, true, nil
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return nil, nil, false, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	failed := false
+	for i := range ops {
+		if _, err := tx.Exec("SAVEPOINT batch_item"); err != nil {
+			_ = tx.Rollback()
+			return nil, nil, false, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+
+		ids[i], errs[i] = applyBatchOperation(tx, actor, userID, &ops[i])
+
+		release := "RELEASE SAVEPOINT batch_item"
+		if errs[i] != nil {
+			failed = true
+			release = "ROLLBACK TO SAVEPOINT batch_item"
+		}
+		if _, err := tx.Exec(release); err != nil {
+			_ = tx.Rollback()
+			return nil, nil, false, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+	}
+
+	if failed {
+		return ids, errs, false, tx.Rollback()
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, nil, false, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	return ids, errs, true, nil
+}
+
+func (m *BatchModel) applyInTx(actor models.Actor, userID string, op *models.BatchOperation) (id string, err error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else if err = tx.Commit(); err != nil {
+			err = fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+	}()
+
+	return applyBatchOperation(tx, actor, userID, op)
+}
+
+func applyBatchOperation(q querier, actor models.Actor, userID string, op *models.BatchOperation) (string, error) {
+	var id int64
+	var err error
+
+	switch op.Kind + "/" + op.Action {
+	case models.KindExpense + "/" + models.BatchCreate:
+		op.Expense.UserID = userID
+		id, err = insertExpense(q, actor, op.Expense)
+	case models.KindExpense + "/" + models.BatchUpdate:
+		op.Expense.UserID = userID
+		return op.Expense.ID, batchErr(updateExpense(q, actor, op.Expense))
+	case models.KindExpense + "/" + models.BatchDelete:
+		return op.ID, batchErr(deleteExpense(q, actor, op.ID, userID))
+
+	case models.KindIncome + "/" + models.BatchCreate:
+		op.Income.UserID = userID
+		id, err = insertIncome(q, actor, op.Income)
+	case models.KindIncome + "/" + models.BatchUpdate:
+		op.Income.UserID = userID
+		return op.Income.ID, batchErr(updateIncome(q, actor, op.Income))
+	case models.KindIncome + "/" + models.BatchDelete:
+		return op.ID, batchErr(deleteIncome(q, actor, op.ID, userID))
+
+	case models.KindWealthFund + "/" + models.BatchCreate:
+		op.WealthFund.UserID = userID
+		id, err = insertWealthFund(q, actor, op.WealthFund)
+	case models.KindWealthFund + "/" + models.BatchUpdate:
+		op.WealthFund.UserID = userID
+		return op.WealthFund.ID, batchErr(updateWealthFund(q, actor, op.WealthFund))
+	case models.KindWealthFund + "/" + models.BatchDelete:
+		return op.ID, batchErr(deleteWealthFund(q, actor, op.ID, userID))
+
+	default:
+		return "", fmt.Errorf("%w: unknown operation %s %s", myerrors.ErrValidation, op.Action, op.Kind)
+	}
+
+	if err != nil {
+		return "", batchErr(err)
+	}
+	return strconv.FormatInt(id, 10), nil
+}
+
+// batchErr приводит ошибку операции к ErrValidation, ErrNotFound или ErrInternal.
+// Ошибки разбора даты и данных, нарушения ограничений и ссылок считаются ошибками валидации.
+func batchErr(err error) error {
+	if err == nil || errors.Is(err, myerrors.ErrValidation) || errors.Is(err, myerrors.ErrNotFound) {
+		return err
+	}
+
+	var parseErr *time.ParseError
+	if errors.As(err, &parseErr) {
+		return fmt.Errorf("%w: invalid date: %v", myerrors.ErrValidation, err)
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code.Class() {
+		case "22", "23":
+			return fmt.Errorf("%w: %s", myerrors.ErrValidation, pqErr.Message)
+		}
+	}
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: %v", myerrors.ErrNotFound, err)
+	}
+	if errors.Is(err, myerrors.ErrInternal) {
+		return err
+	}
+	return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+}
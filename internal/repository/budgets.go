@@ -0,0 +1,202 @@
+// Package repository provides basic financial repository functionality.
+package repository
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+type BudgetModel struct {
+	DB *mydb.Database
+}
+
+// budgetErr переводит нарушение уникальности бюджета категории в ошибку валидации.
+func budgetErr(err error) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return fmt.Errorf("%w: a budget for this category already exists", myerrors.ErrValidation)
+	}
+	return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+}
+
+// budgetThresholds приводит пустой список порогов к пустому массиву, а не к NULL.
+func budgetThresholds(thresholds []int) any {
+	values := make([]int64, 0, len(thresholds))
+	for _, t := range thresholds {
+		values = append(values, int64(t))
+	}
+	return pq.Array(values)
+}
+
+func (m *BudgetModel) Create(budget *models.Budget) (int64, error) {
+	var id int64
+	err := m.DB.QueryRow(`INSERT INTO budgets (user_id, category_id, amount, rollover, alert_thresholds)
+		VALUES ($1, NULLIF($2, '')::integer, $3, $4, $5) RETURNING id`,
+		budget.UserID, budget.CategoryID, budget.Amount, budget.Rollover, budgetThresholds(budget.AlertThresholds)).Scan(&id)
+	if err != nil {
+		return 0, budgetErr(err)
+	}
+	return id, nil
+}
+
+func (m *BudgetModel) Update(budget *models.Budget) error {
+	result, err := m.DB.Exec(`UPDATE budgets SET category_id = NULLIF($1, '')::integer, amount = $2, rollover = $3, alert_thresholds = $4
+		WHERE id = $5 AND user_id = $6`,
+		budget.CategoryID, budget.Amount, budget.Rollover, budgetThresholds(budget.AlertThresholds), budget.ID, budget.UserID)
+	if err != nil {
+		return budgetErr(err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: no budget found with id %s for user %s", myerrors.ErrNotFound, budget.ID, budget.UserID)
+	}
+
+	return nil
+}
+
+func (m *BudgetModel) Delete(id, userID string) error {
+	result, err := m.DB.Exec("DELETE FROM budgets WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: no budget found with id %s for user %s", myerrors.ErrNotFound, id, userID)
+	}
+
+	return nil
+}
+
+// ListByUserID возвращает бюджеты пользователя: сначала общий, затем по названию категории.
+func (m *BudgetModel) ListByUserID(userID string) ([]models.Budget, error) {
+	rows, err := m.DB.Query(`SELECT b.id, COALESCE(b.category_id::text, ''), COALESCE(c.name, ''), b.amount, b.rollover, b.alert_thresholds, b.created_at
+		FROM budgets b
+		LEFT JOIN expense_categories c ON c.id = b.category_id
+		WHERE b.user_id = $1
+		ORDER BY b.category_id IS NOT NULL, c.name, b.id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	budgets := make([]models.Budget, 0)
+	for rows.Next() {
+		var budget models.Budget
+		var thresholds pq.Int64Array
+		var createdAt time.Time
+		if err := rows.Scan(&budget.ID, &budget.CategoryID, &budget.CategoryName, &budget.Amount, &budget.Rollover, &thresholds, &createdAt); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		budget.UserID = userID
+		for _, t := range thresholds {
+			budget.AlertThresholds = append(budget.AlertThresholds, int(t))
+		}
+		budget.CreatedAt = createdAt.Format(time.RFC3339)
+		budgets = append(budgets, budget)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return budgets, nil
+}
+
+// Spending возвращает расходы пользователя в рублях по месяцам и категориям с from до to (не включая to).
+// Части разделённых расходов учитываются в своих категориях, запланированные расходы и записи в корзине
+// не учитываются.
+func (m *BudgetModel) Spending(userID string, from, to time.Time) ([]models.BudgetSpending, error) {
+	rows, err := m.DB.Query(`SELECT date_trunc('month', date)::date, COALESCE(category::text, ''), COALESCE(SUM(amount_in_rubles), 0)
+		FROM expense_in_rubles
+		WHERE user_id = $1 AND NOT COALESCE(planned, false) AND date >= $2 AND date < $3
+		GROUP BY 1, 2`, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	spending := make([]models.BudgetSpending, 0)
+	for rows.Next() {
+		var s models.BudgetSpending
+		var month time.Time
+		if err := rows.Scan(&month, &s.CategoryID, &s.Amount); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		s.Month = month.Format("2006-01-02")
+		spending = append(spending, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return spending, nil
+}
+
+// UsersWithBudgets возвращает id пользователей, у которых есть бюджеты.
+func (m *BudgetModel) UsersWithBudgets() ([]string, error) {
+	rows, err := m.DB.Query("SELECT DISTINCT user_id::text FROM budgets")
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	users := make([]string, 0)
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		users = append(users, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return users, nil
+}
+
+// UserEmail возвращает адрес, на который отправляются уведомления пользователя.
+func (m *BudgetModel) UserEmail(userID string) (string, error) {
+	var email string
+	if err := m.DB.QueryRow("SELECT email FROM users WHERE id = $1", userID).Scan(&email); err != nil {
+		return "", fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	return email, nil
+}
+
+// MarkAlert отмечает уведомление о пороге бюджета за период как отправленное.
+// Возвращает false, если оно уже было отправлено.
+func (m *BudgetModel) MarkAlert(budgetID, period string, threshold int) (bool, error) {
+	result, err := m.DB.Exec("INSERT INTO budget_alerts (budget_id, period, threshold) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING",
+		budgetID, period, threshold)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	return rowsAffected > 0, nil
+}
+
+// UnmarkAlert снимает отметку, если уведомление не удалось отправить, чтобы отправить его позже.
+func (m *BudgetModel) UnmarkAlert(budgetID, period string, threshold int) error {
+	_, err := m.DB.Exec("DELETE FROM budget_alerts WHERE budget_id = $1 AND period = $2 AND threshold = $3", budgetID, period, threshold)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+func TestBudgetSpendingSkipsPlanned(t *testing.T) {
+	db := testDB(t)
+	userID := testUser(t, db)
+
+	expenses := &ExpenseModel{DB: db}
+	for _, expense := range []models.Expense{
+		{Amount: 300, Date: "2024-03-05", UserID: userID, CategoryID: "1", Currency: "RUB"},
+		{Amount: 1000, Date: "2024-03-25", Planned: true, UserID: userID, CategoryID: "1", Currency: "RUB"},
+	} {
+		if _, err := expenses.Create(models.Actor{}, &expense); err != nil {
+			t.Fatalf("create expense: %v", err)
+		}
+	}
+
+	from := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	spending, err := (&BudgetModel{DB: db}).Spending(userID, from, from.AddDate(0, 1, 0))
+	if err != nil {
+		t.Fatalf("Spending: %v", err)
+	}
+
+	if len(spending) != 1 || spending[0].CategoryID != "1" || spending[0].Amount != 300 {
+		t.Errorf("spending = %+v, want 300 in category 1 without the planned expense", spending)
+	}
+}
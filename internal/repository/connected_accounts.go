@@ -1,7 +1,10 @@
 package repository
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
+	"strconv"
 
 	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
 	"github.com/wachrusz/Back-End-API/internal/myerrors"
@@ -12,22 +15,35 @@ type AccountModel struct {
 	DB *mydb.Database
 }
 
-func (m *AccountModel) Create(account *models.ConnectedAccount) (int64, error) {
-	var connectedAccountID int64
-	err := m.DB.QueryRow(
-		`INSERT INTO connected_accounts 
-		(user_id, bank_id, account_number, account_type, state, name, currency, created_at, updated_at) 
-		VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW()) RETURNING id`,
-		account.UserID, account.BankID, account.AccountNumber, account.AccountType, account.AccountState, account.AccountName, account.AccountCurrency).Scan(&connectedAccountID)
-	if err != nil {
-		return 0, err
-	}
-	return connectedAccountID, nil
+func (m *AccountModel) Create(actor models.Actor, account *models.ConnectedAccount) (connectedAccountID int64, err error) {
+	err = inTx(m.DB, func(tx *sql.Tx) error {
+		err := tx.QueryRow(
+			`INSERT INTO connected_accounts 
+			(user_id, bank_id, account_number, account_type, state, name, currency, created_at, updated_at) 
+			VALUES ($1, $2, $3, $4, $5, $6, $7, NOW(), NOW()) RETURNING id`,
+			account.UserID, account.BankID, account.AccountNumber, account.AccountType, account.AccountState, account.AccountName, account.AccountCurrency).Scan(&connectedAccountID)
+		if err != nil {
+			return err
+		}
+		return recordChange(tx, actor, models.KindAccount, strconv.FormatInt(connectedAccountID, 10), account.UserID, models.ActionCreate, nil)
+	})
+	return connectedAccountID, err
+}
 
+func (m *AccountModel) Delete(actor models.Actor, id, userID string) error {
+	return inTx(m.DB, func(tx *sql.Tx) error {
+		return deleteAccount(tx, actor, id, userID)
+	})
 }
 
-func (m *AccountModel) Delete(id, userID string) error {
-	result, err := m.DB.Exec("DELETE FROM connected_accounts WHERE id = $1 AND user_id = $2", id, userID)
+// deleteAccount удаляет счёт пользователя в транзакции q и записывает удаление в журнал.
+func deleteAccount(q querier, actor models.Actor, id, userID string) error {
+	before, err := snapshot(q, models.KindAccount, id, userID)
+	if err != nil {
+		return err
+	}
+
+	result, err := q.Exec("DELETE FROM connected_accounts WHERE id = $1 AND user_id = $2", id, userID)
 	if err != nil {
 		// Возвращаем обернутую ошибку, если запрос завершился с ошибкой
 		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
@@ -45,11 +61,23 @@ func (m *AccountModel) Delete(id, userID string) error {
 		return fmt.Errorf("%w: no account found with id %s for user %s", myerrors.ErrNotFound, id, userID)
 	}
 
-	return nil
+	return recordChange(q, actor, models.KindAccount, id, userID, models.ActionDelete, before)
 }
 
-func (m *AccountModel) Update(account *models.ConnectedAccount) error {
-	result, err := m.DB.Exec("UPDATE connected_accounts SET bank_id=$1, account_number=$2, account_type=$3, updated_at=NOW() WHERE id = $4 AND user_id = $5",
+func (m *AccountModel) Update(actor models.Actor, account *models.ConnectedAccount) error {
+	return inTx(m.DB, func(tx *sql.Tx) error {
+		return updateAccount(tx, actor, account)
+	})
+}
+
+// updateAccount обновляет счёт пользователя в транзакции q и записывает изменение в журнал.
+func updateAccount(q querier, actor models.Actor, account *models.ConnectedAccount) error {
+	before, err := snapshot(q, models.KindAccount, account.ID, account.UserID)
+	if err != nil {
+		return err
+	}
+
+	result, err := q.Exec("UPDATE connected_accounts SET bank_id=$1, account_number=$2, account_type=$3, updated_at=NOW() WHERE id = $4 AND user_id = $5",
 		account.BankID, account.AccountNumber, account.AccountType, account.ID, account.UserID)
 
 	if err != nil {
@@ -66,5 +94,22 @@ func (m *AccountModel) Update(account *models.ConnectedAccount) error {
 		return fmt.Errorf("%w: no account found with id %s for user %s", myerrors.ErrNotFound, account.ID, account.UserID)
 	}
 
-	return nil
+	return recordChange(q, actor, models.KindAccount, account.ID, account.UserID, models.ActionUpdate, before)
+}
+
+func (m *AccountModel) Get(id, userID string) (*models.ConnectedAccount, error) {
+	var account models.ConnectedAccount
+	err := m.DB.QueryRow(
+		`SELECT id, user_id, bank_id, name, account_number, account_type, state, currency
+		FROM connected_accounts WHERE id = $1 AND user_id = $2`, id, userID).
+		Scan(&account.ID, &account.UserID, &account.BankID, &account.AccountName, &account.AccountNumber,
+			&account.AccountType, &account.AccountState, &account.AccountCurrency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: no account found with id %s for user %s", myerrors.ErrNotFound, id, userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return &account, nil
 }
@@ -0,0 +1,227 @@
+// Package repository provides basic financial repository functionality.
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+type DuplicateModel struct {
+	DB *mydb.Database
+}
+
+// duplicateSources - откуда берутся операции для поиска дубликатов.
+var duplicateSources = map[string]struct {
+	table string
+	payee string
+}{
+	models.KindExpense: {table: "expense", payee: "sent_to"},
+	models.KindIncome:  {table: "income", payee: "sender"},
+}
+
+// Records возвращает операции пользователя по фильтру, не больше limit, упорядоченные по дате.
+// Записи в корзине не учитываются.
+func (m *DuplicateModel) Records(filter *models.DuplicateFilter, limit int) ([]models.DuplicateRecord, error) {
+	args := []any{filter.UserID}
+	conditions := ""
+	if filter.DateFrom != "" {
+		args = append(args, filter.DateFrom)
+		conditions += fmt.Sprintf(" AND date >= $%d", len(args))
+	}
+	if filter.DateTo != "" {
+		args = append(args, filter.DateTo)
+		conditions += fmt.Sprintf(" AND date <= $%d", len(args))
+	}
+	if filter.Currency != "" {
+		args = append(args, filter.Currency)
+		conditions += fmt.Sprintf(" AND currency_code = $%d", len(args))
+	}
+
+	query := ""
+	for _, kind := range []string{models.KindExpense, models.KindIncome} {
+		if filter.Kind != "" && filter.Kind != kind {
+			continue
+		}
+		source := duplicateSources[kind]
+		if query != "" {
+			query += " UNION ALL "
+		}
+		query += fmt.Sprintf(`SELECT '%s' AS kind, id, date, amount, COALESCE(currency_code, 'RUB'), COALESCE(%s, ''), COALESCE(connected_account, '')
+			FROM %s WHERE user_id = $1 AND deleted_at IS NULL`, kind, source.payee, source.table) + conditions
+	}
+	if query == "" {
+		return nil, fmt.Errorf("%w: unknown kind %q, expected expense or income", myerrors.ErrValidation, filter.Kind)
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY date, kind, id LIMIT $%d", len(args))
+
+	rows, err := m.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	records := make([]models.DuplicateRecord, 0)
+	for rows.Next() {
+		var record models.DuplicateRecord
+		var date time.Time
+		if err := rows.Scan(&record.Kind, &record.ID, &date, &record.Amount, &record.Currency, &record.Payee, &record.BankAccount); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		record.Date = date.Format("2006-01-02")
+		records = append(records, record)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return records, nil
+}
+
+// Dismissals возвращает пары, которые пользователь отметил как не дубликаты.
+func (m *DuplicateModel) Dismissals(userID string) ([]models.DuplicateDismissal, error) {
+	rows, err := m.DB.Query("SELECT kind, first_id::text, second_id::text FROM duplicate_dismissals WHERE user_id = $1", userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	dismissals := make([]models.DuplicateDismissal, 0)
+	for rows.Next() {
+		var d models.DuplicateDismissal
+		if err := rows.Scan(&d.Kind, &d.FirstID, &d.SecondID); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		dismissals = append(dismissals, d)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return dismissals, nil
+}
+
+// Dismiss запоминает, что две операции пользователя не дубликаты. Повторная отметка не ошибка.
+func (m *DuplicateModel) Dismiss(userID, kind, firstID, secondID string) error {
+	source, ok := duplicateSources[kind]
+	if !ok {
+		return fmt.Errorf("%w: unknown kind %q, expected expense or income", myerrors.ErrValidation, kind)
+	}
+
+	var found int
+	err := m.DB.QueryRow("SELECT COUNT(*) FROM "+source.table+" WHERE id = ANY($1::int[]) AND user_id = $2",
+		pq.Array([]string{firstID, secondID}), userID).Scan(&found)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if found != 2 {
+		return fmt.Errorf("%w: no %s pair found with ids %s and %s for user %s", myerrors.ErrNotFound, kind, firstID, secondID, userID)
+	}
+
+	_, err = m.DB.Exec(`INSERT INTO duplicate_dismissals (user_id, kind, first_id, second_id)
+		VALUES ($1, $2, LEAST($3::integer, $4::integer), GREATEST($3::integer, $4::integer)) ON CONFLICT DO NOTHING`,
+		userID, kind, firstID, secondID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	return nil
+}
+
+// Merge объединяет дубликат duplicateID с операцией keepID: теги, вложения и идентификатор операции в банке
+// переносятся на keepID, запись дубликата удаляется из архива операций, а сам дубликат переносится в корзину.
+// Изменение keepID и удаление дубликата записываются в журнал.
+func (m *DuplicateModel) Merge(actor models.Actor, userID, kind, keepID, duplicateID string) (err error) {
+	source, ok := duplicateSources[kind]
+	if !ok {
+		return fmt.Errorf("%w: unknown kind %q, expected expense or income", myerrors.ErrValidation, kind)
+	}
+	t, err := lookupTaggable(kind)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var found int
+	err = tx.QueryRow(`SELECT COUNT(*) FROM (SELECT id FROM `+source.table+`
+			WHERE id = ANY($1::int[]) AND user_id = $2 AND deleted_at IS NULL FOR UPDATE) locked`,
+		pq.Array([]string{keepID, duplicateID}), userID).Scan(&found)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if found != 2 {
+		return fmt.Errorf("%w: no %s pair found with ids %s and %s for user %s", myerrors.ErrNotFound, kind, keepID, duplicateID, userID)
+	}
+
+	keepBefore, err := snapshot(tx, kind, keepID, userID)
+	if err != nil {
+		return err
+	}
+
+	var dupID int64
+	var amount float64
+	var date time.Time
+	var category string
+	var externalID, account sql.NullString
+	err = tx.QueryRow("UPDATE "+source.table+` d SET external_id = NULL
+			FROM `+source.table+` old WHERE d.id = old.id AND d.id = $1
+			RETURNING d.id, d.amount, d.date, d.category::text, old.external_id, old.connected_account`, duplicateID).
+		Scan(&dupID, &amount, &date, &category, &externalID, &account)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	// Идентификатор операции в банке нужен, чтобы повторный импорт выписки не создал дубликат снова.
+	if externalID.Valid {
+		_, err = tx.Exec("UPDATE "+source.table+` SET external_id = $1
+			WHERE id = $2 AND external_id IS NULL AND connected_account IS NOT DISTINCT FROM $3`,
+			externalID.String, keepID, account)
+		if err != nil {
+			return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+	}
+
+	_, err = tx.Exec("INSERT INTO "+t.tagTable+" ("+t.column+", tag_id) SELECT $1, tag_id FROM "+t.tagTable+" WHERE "+t.column+" = $2 ON CONFLICT DO NOTHING",
+		keepID, duplicateID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	_, err = tx.Exec("UPDATE attachments SET "+t.column+" = $1 WHERE "+t.column+" = $2 AND user_id = $3", keepID, duplicateID, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	switch kind {
+	case models.KindExpense:
+		if err = deleteExpenseOperations(tx, userID, dupID, amount, date, category); err != nil {
+			return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		err = deleteExpense(tx, actor, duplicateID, userID)
+	case models.KindIncome:
+		if err = deleteIncomeOperations(tx, userID, dupID, amount, date, category); err != nil {
+			return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		err = deleteIncome(tx, actor, duplicateID, userID)
+	}
+	if err != nil {
+		return err
+	}
+
+	return recordChange(tx, actor, kind, keepID, userID, models.ActionUpdate, keepBefore)
+}
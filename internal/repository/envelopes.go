@@ -0,0 +1,290 @@
+// Package repository provides basic financial repository functionality.
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+type EnvelopeModel struct {
+	DB *mydb.Database
+}
+
+func (m *EnvelopeModel) Create(envelope *models.Envelope) (int64, error) {
+	var id int64
+	err := m.DB.QueryRow("INSERT INTO envelopes (user_id, category_id) VALUES ($1, $2) RETURNING id",
+		envelope.UserID, envelope.CategoryID).Scan(&id)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return 0, fmt.Errorf("%w: an envelope for this category already exists", myerrors.ErrValidation)
+		}
+		return 0, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	return id, nil
+}
+
+// Delete удаляет конверт вместе с его перемещениями и перенесёнными остатками.
+func (m *EnvelopeModel) Delete(id, userID string) error {
+	result, err := m.DB.Exec("DELETE FROM envelopes WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: no envelope found with id %s for user %s", myerrors.ErrNotFound, id, userID)
+	}
+
+	return nil
+}
+
+// ListByUserID возвращает конверты пользователя по названию категории.
+func (m *EnvelopeModel) ListByUserID(userID string) ([]models.Envelope, error) {
+	rows, err := m.DB.Query(`SELECT e.id, e.category_id::text, COALESCE(c.name, ''), e.created_at
+		FROM envelopes e
+		LEFT JOIN expense_categories c ON c.id = e.category_id
+		WHERE e.user_id = $1
+		ORDER BY c.name, e.id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	envelopes := make([]models.Envelope, 0)
+	for rows.Next() {
+		var envelope models.Envelope
+		var createdAt time.Time
+		if err := rows.Scan(&envelope.ID, &envelope.CategoryID, &envelope.CategoryName, &createdAt); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		envelope.UserID = userID
+		envelope.CreatedAt = createdAt.Format(time.RFC3339)
+		envelopes = append(envelopes, envelope)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return envelopes, nil
+}
+
+// CreateMovement сохраняет перемещение денег. Перемещения в закрытом месяце не допускаются.
+func (m *EnvelopeModel) CreateMovement(movement *models.EnvelopeMovement) (id int64, err error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var closed bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM envelope_closings WHERE user_id = $1 AND month = $2)",
+		movement.UserID, movement.Month).Scan(&closed)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if closed {
+		return 0, fmt.Errorf("%w: month %s is closed", myerrors.ErrValidation, movement.Month[:7])
+	}
+
+	for _, envelopeID := range []string{movement.FromEnvelopeID, movement.ToEnvelopeID} {
+		if envelopeID == "" {
+			continue
+		}
+		var found bool
+		err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM envelopes WHERE id = $1 AND user_id = $2)", envelopeID, movement.UserID).Scan(&found)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		if !found {
+			return 0, fmt.Errorf("%w: no envelope found with id %s for user %s", myerrors.ErrNotFound, envelopeID, movement.UserID)
+		}
+	}
+
+	err = tx.QueryRow(`INSERT INTO envelope_movements (user_id, month, from_envelope_id, to_envelope_id, amount, note)
+		VALUES ($1, $2, NULLIF($3, '')::integer, NULLIF($4, '')::integer, $5, $6) RETURNING id`,
+		movement.UserID, movement.Month, movement.FromEnvelopeID, movement.ToEnvelopeID, movement.Amount, movement.Note).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	return id, nil
+}
+
+// Movements возвращает перемещения пользователя за месяц, сначала новые.
+func (m *EnvelopeModel) Movements(userID string, month time.Time) ([]models.EnvelopeMovement, error) {
+	rows, err := m.DB.Query(`SELECT id, COALESCE(from_envelope_id::text, ''), COALESCE(to_envelope_id::text, ''), amount, note, created_at
+		FROM envelope_movements WHERE user_id = $1 AND month = $2
+		ORDER BY created_at DESC, id DESC`, userID, month)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	movements := make([]models.EnvelopeMovement, 0)
+	for rows.Next() {
+		var movement models.EnvelopeMovement
+		var createdAt time.Time
+		if err := rows.Scan(&movement.ID, &movement.FromEnvelopeID, &movement.ToEnvelopeID, &movement.Amount, &movement.Note, &createdAt); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		movement.UserID = userID
+		movement.Month = month.Format("2006-01-02")
+		movement.CreatedAt = createdAt.Format(time.RFC3339)
+		movements = append(movements, movement)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return movements, nil
+}
+
+// Activity возвращает доходы, расходы по категориям и перемещения пользователя за месяц в рублях.
+// Запланированные операции и записи в корзине не учитываются.
+func (m *EnvelopeModel) Activity(userID string, month time.Time) (*models.EnvelopeActivity, error) {
+	from, to := month, month.AddDate(0, 1, 0)
+	activity := &models.EnvelopeActivity{
+		Assigned: make(map[string]float64),
+		Spent:    make(map[string]float64),
+	}
+
+	err := m.DB.QueryRow(`SELECT COALESCE(SUM(amount_in_rubles), 0) FROM income_in_rubles
+		WHERE user_id = $1 AND NOT COALESCE(planned, false) AND date >= $2 AND date < $3`,
+		userID, from, to).Scan(&activity.Income)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	rows, err := m.DB.Query(`SELECT COALESCE(category::text, ''), COALESCE(SUM(amount_in_rubles), 0)
+		FROM expense_in_rubles WHERE user_id = $1 AND NOT COALESCE(planned, false) AND date >= $2 AND date < $3
+		GROUP BY 1`, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var category string
+		var amount float64
+		if err := rows.Scan(&category, &amount); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		activity.Spent[category] += amount
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	movements, err := m.Movements(userID, month)
+	if err != nil {
+		return nil, err
+	}
+	for _, movement := range movements {
+		if movement.FromEnvelopeID == "" {
+			activity.FromPool += movement.Amount
+		} else {
+			activity.Assigned[movement.FromEnvelopeID] -= movement.Amount
+		}
+		if movement.ToEnvelopeID == "" {
+			activity.FromPool -= movement.Amount
+		} else {
+			activity.Assigned[movement.ToEnvelopeID] += movement.Amount
+		}
+	}
+
+	return activity, nil
+}
+
+// Closing возвращает итоги закрытого месяца или nil, если месяц не закрыт.
+func (m *EnvelopeModel) Closing(userID string, month time.Time) (*models.EnvelopeClosing, error) {
+	closing := &models.EnvelopeClosing{
+		Month:    month.Format("2006-01-02"),
+		Balances: make(map[string]float64),
+	}
+	err := m.DB.QueryRow("SELECT to_assign FROM envelope_closings WHERE user_id = $1 AND month = $2", userID, month).Scan(&closing.ToAssign)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	rows, err := m.DB.Query(`SELECT b.envelope_id::text, b.balance FROM envelope_balances b
+		JOIN envelopes e ON e.id = b.envelope_id
+		WHERE e.user_id = $1 AND b.month = $2`, userID, month)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var envelopeID string
+		var balance float64
+		if err := rows.Scan(&envelopeID, &balance); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		closing.Balances[envelopeID] = balance
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return closing, nil
+}
+
+// HasClosingBefore сообщает, закрывал ли пользователь месяцы раньше month.
+func (m *EnvelopeModel) HasClosingBefore(userID string, month time.Time) (bool, error) {
+	var exists bool
+	err := m.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM envelope_closings WHERE user_id = $1 AND month < $2)", userID, month).Scan(&exists)
+	if err != nil {
+		return false, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	return exists, nil
+}
+
+// Close сохраняет итоги месяца в одной транзакции.
+func (m *EnvelopeModel) Close(userID string, closing *models.EnvelopeClosing) (err error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	_, err = tx.Exec("INSERT INTO envelope_closings (user_id, month, to_assign) VALUES ($1, $2, $3)", userID, closing.Month, closing.ToAssign)
+	if err != nil {
+		var pqErr *pq.Error
+		if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+			return fmt.Errorf("%w: month %s is already closed", myerrors.ErrValidation, closing.Month[:7])
+		}
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	for envelopeID, balance := range closing.Balances {
+		_, err = tx.Exec("INSERT INTO envelope_balances (envelope_id, month, balance) VALUES ($1, $2, $3)", envelopeID, closing.Month, balance)
+		if err != nil {
+			return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,33 @@
+package repository
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+func TestEnvelopeActivitySkipsPlanned(t *testing.T) {
+	db := testDB(t)
+	userID := testUser(t, db)
+
+	expenses := &ExpenseModel{DB: db}
+	for _, expense := range []models.Expense{
+		{Amount: 300, Date: "2024-03-05", UserID: userID, CategoryID: "1", Currency: "RUB"},
+		{Amount: 1000, Date: "2024-03-25", Planned: true, UserID: userID, CategoryID: "1", Currency: "RUB"},
+	} {
+		if _, err := expenses.Create(models.Actor{}, &expense); err != nil {
+			t.Fatalf("create expense: %v", err)
+		}
+	}
+
+	month := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	activity, err := (&EnvelopeModel{DB: db}).Activity(userID, month)
+	if err != nil {
+		t.Fatalf("Activity: %v", err)
+	}
+
+	if len(activity.Spent) != 1 || activity.Spent["1"] != 300 {
+		t.Errorf("spent = %v, want 300 in category 1 without the planned expense", activity.Spent)
+	}
+}
@@ -7,6 +7,8 @@ import (
 	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
 	"github.com/wachrusz/Back-End-API/internal/myerrors"
 	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
+	"strconv"
 	"time"
 )
 
@@ -14,31 +16,54 @@ type ExpenseModel struct {
 	DB *mydb.Database
 }
 
-func (m *ExpenseModel) Create(expense *models.Expense) (int64, error) {
+func (m *ExpenseModel) Create(actor models.Actor, expense *models.Expense) (id int64, err error) {
+	err = inTx(m.DB, func(tx *sql.Tx) error {
+		id, err = insertExpense(tx, actor, expense)
+		return err
+	})
+	return id, err
+}
+
+// insertExpense создаёт расход с его тегами и запись в архиве операций в транзакции q и записывает создание в журнал.
+func insertExpense(q querier, actor models.Actor, expense *models.Expense) (int64, error) {
 	parsedDate, err := time.Parse("2006-01-02", expense.Date)
 	if err != nil {
 		return 0, err
 	}
 
 	var expenseID int64
-	err = m.DB.QueryRow("INSERT INTO expense (amount, date, planned, user_id, category, sent_to, connected_account, currency_code) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
-		expense.Amount, parsedDate, expense.Planned, expense.UserID, expense.CategoryID, expense.SentTo, expense.BankAccount, expense.Currency).Scan(&expenseID)
+	err = q.QueryRow("INSERT INTO expense (amount, date, planned, user_id, category, sent_to, connected_account, currency_code, recurring_id, external_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, '')) RETURNING id",
+		expense.Amount, parsedDate, expense.Planned, expense.UserID, expense.CategoryID, expense.SentTo, expense.BankAccount, expense.Currency, expense.RecurringID, expense.ExternalID).Scan(&expenseID)
 
 	if err != nil {
 		return 0, err
 	}
 
-	_, err = m.DB.Exec("INSERT INTO operations (user_id, description, amount, date, category, operation_type) VALUES ($1, $2, $3, $4, $5, $6)",
-		expense.UserID, "Расход", expense.Amount, parsedDate, expense.CategoryID, expense.CategoryID)
+	err = insertExpenseOperation(q, expense.UserID, expenseID, expense.Amount, parsedDate, expense.CategoryID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = addTags(q, models.KindExpense, expenseID, expense.UserID, tagIDs(expense.Tags)); err != nil {
+		return 0, err
+	}
 
+	err = recordChange(q, actor, models.KindExpense, strconv.FormatInt(expenseID, 10), expense.UserID, models.ActionCreate, nil)
 	if err != nil {
 		return 0, err
 	}
 	return expenseID, nil
 }
 
+// insertExpenseOperation добавляет расход или часть разделённого расхода в архив операций.
+func insertExpenseOperation(q querier, userID string, expenseID int64, amount float64, date time.Time, categoryID string) error {
+	_, err := q.Exec("INSERT INTO operations (user_id, description, amount, date, category, operation_type, expense_id) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		userID, "Расход", amount, date, categoryID, categoryID, expenseID)
+	return err
+}
+
 func (m *ExpenseModel) ListByUserID(userID string) ([]models.Expense, error) {
-	rows, err := m.DB.Query("SELECT id, amount, date, planned, category, sent_to, connected_account, currency_code FROM expense WHERE user_id = $1", userID)
+	rows, err := m.DB.Query("SELECT id, amount, date, planned, category, sent_to, connected_account, currency_code FROM expense WHERE user_id = $1 AND deleted_at IS NULL", userID)
 	if err != nil {
 		return nil, err
 	}
@@ -63,7 +88,7 @@ func (m *ExpenseModel) GetForMonth(userID string, month time.Month, year int) (f
 			COALESCE(SUM(amount), 0) AS total_expense,
 			COALESCE(SUM(CASE WHEN planned THEN amount ELSE 0 END), 0) AS planned_expense
 		FROM expense
-		WHERE user_id = $1
+		WHERE user_id = $1 AND deleted_at IS NULL
 		AND EXTRACT(MONTH FROM date) = $2
 		AND EXTRACT(YEAR FROM date) = $3
 	`
@@ -104,8 +129,20 @@ func (m *ExpenseModel) GetMonthlyIncrease(userID string) (int, int, error) {
 	return int(((currentMonthExpense / previousMonthExpense) - 1) * 100), int(((currentMonthPlanned / previousMonthExpense) - 1) * 100), nil
 }
 
-func (m *ExpenseModel) Delete(id, userID string) error {
-	result, err := m.DB.Exec("DELETE FROM expense WHERE id = $1 AND user_id = $2", id, userID)
+func (m *ExpenseModel) Delete(actor models.Actor, id, userID string) error {
+	return inTx(m.DB, func(tx *sql.Tx) error {
+		return deleteExpense(tx, actor, id, userID)
+	})
+}
+
+// deleteExpense переносит расход пользователя в корзину в транзакции q и записывает удаление в журнал.
+func deleteExpense(q querier, actor models.Actor, id, userID string) error {
+	before, err := snapshot(q, models.KindExpense, id, userID)
+	if err != nil {
+		return err
+	}
+
+	result, err := q.Exec("UPDATE expense SET deleted_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL", id, userID)
 	if err != nil {
 		// Возвращаем обернутую ошибку, если запрос завершился с ошибкой
 		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
@@ -123,11 +160,33 @@ func (m *ExpenseModel) Delete(id, userID string) error {
 		return fmt.Errorf("%w: no expense found with id %s for user %s", myerrors.ErrNotFound, id, userID)
 	}
 
-	return nil
+	return recordChange(q, actor, models.KindExpense, id, userID, models.ActionDelete, before)
+}
+
+func (m *ExpenseModel) Update(actor models.Actor, expense *models.Expense) error {
+	return inTx(m.DB, func(tx *sql.Tx) error {
+		return updateExpense(tx, actor, expense)
+	})
 }
 
-func (m *ExpenseModel) Update(expense *models.Expense) error {
-	q := `
+// updateExpense обновляет расход пользователя в транзакции q и записывает изменение в журнал.
+func updateExpense(q querier, actor models.Actor, expense *models.Expense) error {
+	before, err := snapshot(q, models.KindExpense, expense.ID, expense.UserID)
+	if err != nil {
+		return err
+	}
+
+	// Сумма разделённого расхода должна совпадать с суммой его частей.
+	var splitTotal sql.NullFloat64
+	err = q.QueryRow("SELECT SUM(amount) FROM expense_splits WHERE expense_id = $1", expense.ID).Scan(&splitTotal)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if splitTotal.Valid && !amountsEqual(splitTotal.Float64, expense.Amount) {
+		return fmt.Errorf("%w: expense is split, its amount must stay %.2f; update the splits first", myerrors.ErrValidation, splitTotal.Float64)
+	}
+
+	query := `
 		UPDATE expense SET 
 		   amount=$1, 
 		   date=$2, 
@@ -136,9 +195,9 @@ func (m *ExpenseModel) Update(expense *models.Expense) error {
 		   sent_to=$5, 
 		   connected_account=$6, 
 		   currency_code=$7 
-	   WHERE id=$8 AND user_id=$9`
+	   WHERE id=$8 AND user_id=$9 AND deleted_at IS NULL`
 
-	result, err := m.DB.Exec(q, expense.Amount, expense.Date, expense.Planned, expense.CategoryID,
+	result, err := q.Exec(query, expense.Amount, expense.Date, expense.Planned, expense.CategoryID,
 		expense.SentTo, expense.BankAccount, expense.Currency, expense.ID, expense.UserID)
 
 	if err != nil {
@@ -155,5 +214,76 @@ func (m *ExpenseModel) Update(expense *models.Expense) error {
 		return fmt.Errorf("%w: no account found with id %s for user %s", myerrors.ErrNotFound, expense.ID, expense.UserID)
 	}
 
-	return nil
+	return recordChange(q, actor, models.KindExpense, expense.ID, expense.UserID, models.ActionUpdate, before)
+}
+
+// List возвращает страницу расходов пользователя по фильтру.
+func (m *ExpenseModel) List(filter *models.TransactionFilter) ([]models.Expense, *jsonresponse.Metadata, error) {
+	q := &listQuery{
+		table:          "expense",
+		kind:           models.KindExpense,
+		columns:        "id, amount, date, planned, category, sent_to, connected_account, currency_code, recurring_id, COALESCE(external_id, '')",
+		categoryColumn: "category",
+	}
+	q.filter(filter)
+
+	var total int
+	if err := m.DB.QueryRow(q.count(), q.args...).Scan(&total); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	query, err := q.page(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := m.DB.Query(query, q.args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	expenses := make([]models.Expense, 0, filter.Limit+1)
+	keys := make([]string, 0, filter.Limit+1)
+	for rows.Next() {
+		var expense models.Expense
+		var date time.Time
+		var key string
+		if err := rows.Scan(&expense.ID, &expense.Amount, &date, &expense.Planned, &expense.CategoryID, &expense.SentTo,
+			&expense.BankAccount, &expense.Currency, &expense.RecurringID, &expense.ExternalID, &key); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		expense.Date = date.Format("2006-01-02")
+		expense.UserID = filter.UserID
+		expenses = append(expenses, expense)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	meta := pageMetadata(filter, total, len(expenses), func(i int) (string, string) {
+		return keys[i], expenses[i].ID
+	})
+	if len(expenses) > filter.Limit {
+		expenses = expenses[:filter.Limit]
+	}
+
+	ids := make([]string, len(expenses))
+	for i := range expenses {
+		ids[i] = expenses[i].ID
+	}
+	splits, err := m.splitsByExpense(ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	tags, err := tagsByRecord(m.DB, models.KindExpense, ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	for i := range expenses {
+		expenses[i].Splits = splits[expenses[i].ID]
+		expenses[i].Tags = tags[expenses[i].ID]
+	}
+
+	return expenses, meta, nil
 }
@@ -0,0 +1,155 @@
+// Package repository provides basic financial repository functionality.
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+// amountsEqual сравнивает суммы с точностью до копейки.
+func amountsEqual(a, b float64) bool {
+	return math.Abs(a-b) < 0.005
+}
+
+// SetSplits заменяет части расхода. Части должны в сумме давать сумму расхода; пустой список
+// отменяет разделение. Записи архива операций заменяются частями (или возвращается исходная запись).
+func (m *ExpenseModel) SetSplits(actor models.Actor, expenseID, userID string, splits []models.ExpenseSplit) (err error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var (
+		id       int64
+		amount   float64
+		date     time.Time
+		category string
+	)
+	err = tx.QueryRow("SELECT id, amount, date, category FROM expense WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL FOR UPDATE", expenseID, userID).
+		Scan(&id, &amount, &date, &category)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: no expense found with id %s for user %s", myerrors.ErrNotFound, expenseID, userID)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	if err = validateSplits(amount, splits); err != nil {
+		return err
+	}
+
+	before, err := snapshot(tx, models.KindExpense, expenseID, userID)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.Exec("DELETE FROM expense_splits WHERE expense_id = $1", id); err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if err = deleteExpenseOperations(tx, userID, id, amount, date, category); err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	if len(splits) == 0 {
+		if err = insertExpenseOperation(tx, userID, id, amount, date, category); err != nil {
+			return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+	}
+
+	for i := range splits {
+		split := &splits[i]
+		err = tx.QueryRow("INSERT INTO expense_splits (expense_id, category, amount, note) VALUES ($1, $2, $3, $4) RETURNING id",
+			id, split.CategoryID, split.Amount, split.Note).Scan(&split.ID)
+		if err != nil {
+			return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		if err = insertExpenseOperation(tx, userID, id, split.Amount, date, split.CategoryID); err != nil {
+			return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+	}
+
+	return recordChange(tx, actor, models.KindExpense, expenseID, userID, models.ActionUpdate, before)
+}
+
+func validateSplits(amount float64, splits []models.ExpenseSplit) error {
+	if len(splits) == 0 {
+		return nil
+	}
+	if len(splits) < 2 {
+		return fmt.Errorf("%w: a split needs at least two parts", myerrors.ErrValidation)
+	}
+
+	var total float64
+	for _, split := range splits {
+		if split.CategoryID == "" {
+			return fmt.Errorf("%w: split category is empty", myerrors.ErrValidation)
+		}
+		if split.Amount <= 0 {
+			return fmt.Errorf("%w: split amount must be positive", myerrors.ErrValidation)
+		}
+		total += split.Amount
+	}
+	if !amountsEqual(total, amount) {
+		return fmt.Errorf("%w: splits sum to %.2f, but the expense is %.2f", myerrors.ErrValidation, total, amount)
+	}
+	return nil
+}
+
+// deleteExpenseOperations удаляет записи архива операций расхода. Записи, созданные до появления
+// связи с расходом, находятся по совпадению пользователя, суммы, даты и категории.
+func deleteExpenseOperations(q querier, userID string, expenseID int64, amount float64, date time.Time, category string) error {
+	result, err := q.Exec("DELETE FROM operations WHERE expense_id = $1", expenseID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+
+	_, err = q.Exec(`DELETE FROM operations WHERE ctid = (
+			SELECT ctid FROM operations
+			WHERE user_id = $1 AND expense_id IS NULL AND description = 'Расход' AND amount = $2 AND date = $3 AND category = $4
+			LIMIT 1)`,
+		userID, amount, date, category)
+	return err
+}
+
+// splitsByExpense загружает части расходов по их id.
+func (m *ExpenseModel) splitsByExpense(ids []string) (map[string][]models.ExpenseSplit, error) {
+	splits := make(map[string][]models.ExpenseSplit)
+	if len(ids) == 0 {
+		return splits, nil
+	}
+
+	rows, err := m.DB.Query("SELECT expense_id, id, category, amount, note FROM expense_splits WHERE expense_id = ANY($1::int[]) ORDER BY id",
+		pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var expenseID string
+		var split models.ExpenseSplit
+		if err := rows.Scan(&expenseID, &split.ID, &split.CategoryID, &split.Amount, &split.Note); err != nil {
+			return nil, err
+		}
+		splits[expenseID] = append(splits[expenseID], split)
+	}
+
+	return splits, rows.Err()
+}
@@ -0,0 +1,150 @@
+// Package repository provides basic financial repository functionality.
+package repository
+
+import (
+	"encoding/base64"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
+)
+
+// listQuery собирает запрос списка операций по фильтру с пагинацией по ключу (sort, id).
+type listQuery struct {
+	table          string
+	columns        string
+	categoryColumn string
+	// kind - вид записей для фильтра по тегам (models.KindExpense и т.д.).
+	kind string
+
+	where []string
+	args  []any
+}
+
+func (q *listQuery) arg(v any) string {
+	q.args = append(q.args, v)
+	return "$" + strconv.Itoa(len(q.args))
+}
+
+func (q *listQuery) filter(f *models.TransactionFilter) {
+	q.where = append(q.where, "user_id = "+q.arg(f.UserID), "deleted_at IS NULL")
+	if f.DateFrom != "" {
+		q.where = append(q.where, "date >= "+q.arg(f.DateFrom))
+	}
+	if f.DateTo != "" {
+		q.where = append(q.where, "date <= "+q.arg(f.DateTo))
+	}
+	if f.CategoryID != "" {
+		q.where = append(q.where, q.categoryColumn+" = "+q.arg(f.CategoryID))
+	}
+	if f.BankAccount != "" {
+		q.where = append(q.where, "connected_account = "+q.arg(f.BankAccount))
+	}
+	if f.Currency != "" {
+		q.where = append(q.where, "currency_code = "+q.arg(strings.ToUpper(f.Currency)))
+	}
+	if f.Planned != nil {
+		q.where = append(q.where, "planned = "+q.arg(*f.Planned))
+	}
+	if f.AmountMin != nil {
+		q.where = append(q.where, "amount >= "+q.arg(*f.AmountMin))
+	}
+	if f.AmountMax != nil {
+		q.where = append(q.where, "amount <= "+q.arg(*f.AmountMax))
+	}
+	if len(f.TagIDs) > 0 {
+		t := taggables[q.kind]
+		q.where = append(q.where, fmt.Sprintf("id IN (SELECT %s FROM %s WHERE tag_id = ANY(%s::int[]))",
+			t.column, t.tagTable, q.arg(pq.Array(f.TagIDs))))
+	}
+}
+
+func (q *listQuery) count() string {
+	return fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", q.table, strings.Join(q.where, " AND "))
+}
+
+// page добавляет условие курсора и возвращает запрос страницы. Выбирается на одну запись больше,
+// чтобы понять, есть ли следующая страница. После колонок q.columns выбирается значение поля сортировки
+// в виде текста - из него pageMetadata строит курсор, поэтому сумма в курсоре совпадает с суммой в базе.
+func (q *listQuery) page(f *models.TransactionFilter) (string, error) {
+	sortColumn := "date"
+	if f.Sort == models.SortByAmount {
+		sortColumn = "amount"
+	}
+	op, order := ">", "ASC"
+	if f.Desc {
+		op, order = "<", "DESC"
+	}
+
+	where := q.where
+	if f.Cursor != "" {
+		value, id, err := decodeCursor(f.Cursor, sortColumn)
+		if err != nil {
+			return "", err
+		}
+		cast := "::date"
+		if sortColumn == "amount" {
+			cast = "::numeric"
+		}
+		where = append(where, fmt.Sprintf("(%s, id) %s (%s%s, %s)", sortColumn, op, q.arg(value), cast, q.arg(id)))
+	}
+
+	return fmt.Sprintf("SELECT %s, %s::text FROM %s WHERE %s ORDER BY %s %s, id %s LIMIT %s",
+		q.columns, sortColumn, q.table, strings.Join(where, " AND "), sortColumn, order, order, q.arg(f.Limit+1)), nil
+}
+
+// cursorAmount - сумма в курсоре: десятичная запись без экспоненты, как её выводит Postgres.
+var cursorAmount = regexp.MustCompile(`^-?[0-9]+(\.[0-9]+)?$`)
+
+// encodeCursor кодирует значение поля сортировки и id последней записи страницы.
+func encodeCursor(key, id string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(key + "|" + id))
+}
+
+// decodeCursor возвращает значение поля сортировки в виде строки, которая сравнивается с колонкой
+// после приведения типа в базе, и id.
+func decodeCursor(cursor, sortColumn string) (string, int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: invalid cursor", myerrors.ErrValidation)
+	}
+
+	value, idStr, ok := strings.Cut(string(raw), "|")
+	if !ok {
+		return "", 0, fmt.Errorf("%w: invalid cursor", myerrors.ErrValidation)
+	}
+	id, err := strconv.ParseInt(idStr, 10, 64)
+	if err != nil {
+		return "", 0, fmt.Errorf("%w: invalid cursor", myerrors.ErrValidation)
+	}
+
+	if sortColumn == "amount" {
+		if !cursorAmount.MatchString(value) {
+			return "", 0, fmt.Errorf("%w: cursor does not match the sort", myerrors.ErrValidation)
+		}
+		return value, id, nil
+	}
+	if _, err := time.Parse("2006-01-02", value); err != nil {
+		return "", 0, fmt.Errorf("%w: cursor does not match the sort", myerrors.ErrValidation)
+	}
+	return value, id, nil
+}
+
+// pageMetadata заполняет метаданные страницы. last возвращает значение поля сортировки, выбранное page,
+// и id записи i.
+func pageMetadata(f *models.TransactionFilter, total, fetched int, last func(i int) (string, string)) *jsonresponse.Metadata {
+	meta := &jsonresponse.Metadata{
+		PageSize:     f.Limit,
+		TotalRecords: total,
+	}
+	if fetched > f.Limit {
+		meta.NextCursor = encodeCursor(last(f.Limit - 1))
+	}
+	return meta
+}
@@ -0,0 +1,69 @@
+package repository
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+func TestCursor(t *testing.T) {
+	tests := []struct {
+		name       string
+		key        string
+		sortColumn string
+		wantErr    bool
+	}{
+		{name: "date", key: "2024-03-10", sortColumn: "date"},
+		{name: "amount keeps exact decimal", key: "12345678901234567.89", sortColumn: "amount"},
+		{name: "negative amount", key: "-0.10", sortColumn: "amount"},
+		{name: "integer amount", key: "100", sortColumn: "amount"},
+		{name: "amount with exponent", key: "1e+21", sortColumn: "amount", wantErr: true},
+		{name: "amount NaN", key: "NaN", sortColumn: "amount", wantErr: true},
+		{name: "date cursor for amount sort", key: "2024-03-10", sortColumn: "amount", wantErr: true},
+		{name: "amount cursor for date sort", key: "100", sortColumn: "date", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			value, id, err := decodeCursor(encodeCursor(tt.key, "42"), tt.sortColumn)
+			if tt.wantErr {
+				if !errors.Is(err, myerrors.ErrValidation) {
+					t.Fatalf("decodeCursor = %q, %v, want validation error", value, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("decodeCursor: %v", err)
+			}
+			if value != tt.key || id != 42 {
+				t.Errorf("decodeCursor = %q %d, want %q 42", value, id, tt.key)
+			}
+		})
+	}
+}
+
+func TestPageAmountCursor(t *testing.T) {
+	q := &listQuery{table: "expense", columns: "id, amount"}
+	q.filter(&models.TransactionFilter{UserID: "1"})
+
+	query, err := q.page(&models.TransactionFilter{
+		Sort:   models.SortByAmount,
+		Desc:   true,
+		Limit:  20,
+		Cursor: encodeCursor("0.30", "7"),
+	})
+	if err != nil {
+		t.Fatalf("page: %v", err)
+	}
+
+	want := "SELECT id, amount, amount::text FROM expense WHERE user_id = $1 AND deleted_at IS NULL" +
+		" AND (amount, id) < ($2::numeric, $3) ORDER BY amount DESC, id DESC LIMIT $4"
+	if query != want {
+		t.Errorf("page =\n%s\nwant\n%s", query, want)
+	}
+	if q.args[1] != "0.30" {
+		t.Errorf("cursor amount arg = %#v, want \"0.30\"", q.args[1])
+	}
+}
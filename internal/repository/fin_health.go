@@ -31,7 +31,7 @@ func CreateFinHealth(finHealth *FinHealth) error {
 
 func getIncomeScore(userID string) int {
 	query := `
-		SELECT id, amount FROM income WHERE user_id = $1;
+		SELECT id, amount FROM income WHERE user_id = $1 AND deleted_at IS NULL;
 	`
 
 	rows, err := mydb.Database.Query(query, userID)
@@ -0,0 +1,101 @@
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+type ForecastModel struct {
+	DB *mydb.Database
+}
+
+// Accounts возвращает подключённые счета пользователя с текущими остатками.
+func (m *ForecastModel) Accounts(userID string) ([]models.ConnectedAccount, error) {
+	rows, err := m.DB.Query(`SELECT id, name, account_number, account_type, state, currency
+		FROM connected_accounts WHERE user_id = $1 ORDER BY id`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	accounts := make([]models.ConnectedAccount, 0)
+	for rows.Next() {
+		var account models.ConnectedAccount
+		err := rows.Scan(&account.ID, &account.AccountName, &account.AccountNumber, &account.AccountType,
+			&account.AccountState, &account.AccountCurrency)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		account.UserID = userID
+		accounts = append(accounts, account)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return accounts, nil
+}
+
+// Planned возвращает запланированные доходы и расходы пользователя с датами в [from, to).
+// Записи в корзине не учитываются.
+func (m *ForecastModel) Planned(userID string, from, to time.Time) ([]models.ForecastFlow, error) {
+	rows, err := m.DB.Query(`SELECT 'expense', date, amount, currency_code, connected_account FROM expense
+		WHERE user_id = $1 AND planned AND deleted_at IS NULL AND date >= $2 AND date < $3
+		UNION ALL
+		SELECT 'income', date, amount, currency_code, connected_account FROM income
+		WHERE user_id = $1 AND planned AND deleted_at IS NULL AND date >= $2 AND date < $3
+		ORDER BY 2`, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	flows := make([]models.ForecastFlow, 0)
+	for rows.Next() {
+		var flow models.ForecastFlow
+		var date time.Time
+		if err := rows.Scan(&flow.Kind, &date, &flow.Amount, &flow.Currency, &flow.BankAccount); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		flow.Date = date.Format("2006-01-02")
+		flows = append(flows, flow)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return flows, nil
+}
+
+// WeekdaySpend возвращает необязательные расходы пользователя за [from, to) по счетам и дням недели в рублях.
+// Необязательными считаются фактические расходы, не созданные повторяющимися сериями.
+func (m *ForecastModel) WeekdaySpend(userID string, from, to time.Time) ([]models.ForecastSpend, error) {
+	rows, err := m.DB.Query(`SELECT COALESCE(v.connected_account, ''), EXTRACT(DOW FROM v.date)::integer, SUM(v.amount_in_rubles)
+		FROM expense_in_rubles v
+		JOIN expense e ON e.id = v.id
+		WHERE v.user_id = $1 AND v.date >= $2 AND v.date < $3
+			AND NOT COALESCE(v.planned, false) AND e.recurring_id IS NULL
+		GROUP BY 1, 2`, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	spend := make([]models.ForecastSpend, 0)
+	for rows.Next() {
+		var s models.ForecastSpend
+		if err := rows.Scan(&s.BankAccount, &s.Weekday, &s.Amount); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		spend = append(spend, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return spend, nil
+}
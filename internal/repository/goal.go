@@ -2,11 +2,13 @@
 package repository
 
 import (
+	"database/sql"
 	"fmt"
 	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
 	"github.com/wachrusz/Back-End-API/internal/myerrors"
 	"github.com/wachrusz/Back-End-API/internal/repository/models"
 	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
+	"strconv"
 )
 
 type GoalModel struct {
@@ -17,18 +19,33 @@ type GoalTransactionModel struct {
 	DB *mydb.Database
 }
 
-func (m *GoalModel) Create(goal *models.Goal) (int64, error) {
-	var goalID int64
-	err := m.DB.QueryRow("INSERT INTO goals (amount, currency_code, user_id, name, months) VALUES ($1, $2, $3, $4, $5) RETURNING id",
-		goal.Amount, goal.Currency, goal.UserID, goal.Name, goal.Months).Scan(&goalID)
+func (m *GoalModel) Create(actor models.Actor, goal *models.Goal) (goalID int64, err error) {
+	err = inTx(m.DB, func(tx *sql.Tx) error {
+		err := tx.QueryRow("INSERT INTO goals (amount, currency_code, user_id, name, months) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+			goal.Amount, goal.Currency, goal.UserID, goal.Name, goal.Months).Scan(&goalID)
+		if err != nil {
+			return err
+		}
+		return recordChange(tx, actor, models.KindGoal, strconv.FormatInt(goalID, 10), strconv.FormatInt(goal.UserID, 10), models.ActionCreate, nil)
+	})
+	return goalID, err
+}
+
+func (m *GoalModel) Update(actor models.Actor, goal *models.Goal) error {
+	return inTx(m.DB, func(tx *sql.Tx) error {
+		return updateGoal(tx, actor, goal)
+	})
+}
+
+// updateGoal обновляет цель пользователя в транзакции q и записывает изменение в журнал.
+func updateGoal(q querier, actor models.Actor, goal *models.Goal) error {
+	id, userID := strconv.FormatInt(goal.ID, 10), strconv.FormatInt(goal.UserID, 10)
+	before, err := snapshot(q, models.KindGoal, id, userID)
 	if err != nil {
-		return 0, err
+		return err
 	}
-	return goalID, nil
-}
 
-func (m *GoalModel) Update(goal *models.Goal) error {
-	result, err := m.DB.Exec(`
+	result, err := q.Exec(`
 		UPDATE goals 
 		SET 
 			amount = $1,
@@ -37,7 +54,7 @@ func (m *GoalModel) Update(goal *models.Goal) error {
             months = $4,
             is_exceeded = $5,
             is_completed = $6
-		WHERE id = $7 AND user_id = $8
+		WHERE id = $7 AND user_id = $8 AND deleted_at IS NULL
 	`, goal.Amount, goal.Currency, goal.Name, goal.Months, goal.IsExceeded, goal.IsCompleted, goal.ID, goal.UserID)
 
 	if err != nil {
@@ -54,11 +71,23 @@ func (m *GoalModel) Update(goal *models.Goal) error {
 		return fmt.Errorf("%w: no goal found with id %d for user %d", myerrors.ErrNotFound, goal.ID, goal.UserID)
 	}
 
-	return nil
+	return recordChange(q, actor, models.KindGoal, id, userID, models.ActionUpdate, before)
+}
+
+func (m *GoalModel) Delete(actor models.Actor, id int64, userID int64) error {
+	return inTx(m.DB, func(tx *sql.Tx) error {
+		return deleteGoal(tx, actor, id, userID)
+	})
 }
 
-func (m *GoalModel) Delete(id int64, userID int64) error {
-	result, err := m.DB.Exec("DELETE FROM goals WHERE id = $1 AND user_id = $2", id, userID)
+// deleteGoal переносит цель пользователя в корзину в транзакции q и записывает удаление в журнал.
+func deleteGoal(q querier, actor models.Actor, id int64, userID int64) error {
+	before, err := snapshot(q, models.KindGoal, strconv.FormatInt(id, 10), strconv.FormatInt(userID, 10))
+	if err != nil {
+		return err
+	}
+
+	result, err := q.Exec("UPDATE goals SET deleted_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL", id, userID)
 	if err != nil {
 		// Возвращаем обернутую ошибку, если запрос завершился с ошибкой
 		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
@@ -76,11 +105,11 @@ func (m *GoalModel) Delete(id int64, userID int64) error {
 		return fmt.Errorf("%w: no goal found with id %d for user %d", myerrors.ErrNotFound, id, userID)
 	}
 
-	return nil
+	return recordChange(q, actor, models.KindGoal, strconv.FormatInt(id, 10), strconv.FormatInt(userID, 10), models.ActionDelete, before)
 }
 
 func (m *GoalModel) ListByUserID(userID int64) ([]models.Goal, error) {
-	rows, err := m.DB.Query("SELECT id, amount, currency_code, name, months, is_exceeded, is_completed, start_date FROM goals WHERE user_id = $1", userID)
+	rows, err := m.DB.Query("SELECT id, amount, currency_code, name, months, is_exceeded, is_completed, start_date FROM goals WHERE user_id = $1 AND deleted_at IS NULL", userID)
 	if err != nil {
 		return nil, err
 	}
@@ -100,7 +129,9 @@ func (m *GoalModel) ListByUserID(userID int64) ([]models.Goal, error) {
 	return goals, nil
 }
 
-func (m *GoalModel) Details(id int64, userID int64) (*models.GoalDetails, error) {
+// Details возвращает цель с накопленной суммой и рассчитанным платежом. Если цель впервые достигнута или
+// просрочена, отметка сохраняется от имени actor.
+func (m *GoalModel) Details(actor models.Actor, id int64, userID int64) (*models.GoalDetails, error) {
 	var d models.GoalDetails
 	q := `
 	SELECT
@@ -116,19 +147,11 @@ func (m *GoalModel) Details(id int64, userID int64) (*models.GoalDetails, error)
 		-- Количество месяцев, прошедших с start_date
 		EXTRACT(YEAR FROM AGE(CURRENT_DATE, g.start_date)) * 12 + 
 		EXTRACT(MONTH FROM AGE(CURRENT_DATE, g.start_date)) AS months_passed,
-		-- Общая конвертированная сумма всех транзакций
+		-- Общая конвертированная сумма всех транзакций по курсам на дату транзакции
 		COALESCE(SUM(
 			CASE
 				WHEN gt.currency_code = g.currency_code THEN gt.amount
-				ELSE gt.amount * COALESCE(
-					(SELECT er.rate_to_ruble
-					 FROM exchange_rates er
-					 WHERE er.currency_code = gt.currency_code),
-					1) / COALESCE(
-					(SELECT er.rate_to_ruble
-					 FROM exchange_rates er
-					 WHERE er.currency_code = g.currency_code),
-					1)
+				ELSE gt.amount * rate_to_ruble_on(gt.currency_code, gt.date::date) / rate_to_ruble_on(g.currency_code, gt.date::date)
 			END), 0) AS converted_amount,
 		-- Конвертированная сумма транзакций за последний месяц
 		COALESCE(SUM(
@@ -138,21 +161,13 @@ func (m *GoalModel) Details(id int64, userID int64) (*models.GoalDetails, error)
 				THEN 
 					CASE
 						WHEN gt.currency_code = g.currency_code THEN gt.amount
-						ELSE gt.amount * COALESCE(
-							(SELECT er.rate_to_ruble
-							 FROM exchange_rates er
-							 WHERE er.currency_code = gt.currency_code),
-							1) / COALESCE(
-							(SELECT er.rate_to_ruble
-							 FROM exchange_rates er
-							 WHERE er.currency_code = g.currency_code),
-							1)
+						ELSE gt.amount * rate_to_ruble_on(gt.currency_code, gt.date::date) / rate_to_ruble_on(g.currency_code, gt.date::date)
 					END
 				ELSE 0
 			END), 0) AS last_month_converted_amount
 	FROM goals g
 	LEFT JOIN goal_transactions gt ON g.id = gt.goal_id AND gt.planned = false
-	WHERE g.id = $1 AND g.user_id = $2
+	WHERE g.id = $1 AND g.user_id = $2 AND g.deleted_at IS NULL
 	GROUP BY g.id;
 	`
 
@@ -165,10 +180,11 @@ func (m *GoalModel) Details(id int64, userID int64) (*models.GoalDetails, error)
 
 	d.Goal.ID = id
 
-	if d.Goal.Amount <= d.Gathered {
+	if !d.Goal.IsCompleted && d.Goal.Amount <= d.Gathered {
 		d.Goal.IsCompleted = true
-		cp := d.Goal
-		go m.Update(&cp)
+		if err := m.Update(actor, &d.Goal); err != nil {
+			return nil, err
+		}
 	}
 
 	if d.Goal.IsCompleted {
@@ -177,8 +193,9 @@ func (m *GoalModel) Details(id int64, userID int64) (*models.GoalDetails, error)
 
 	if !d.Goal.IsExceeded && d.Goal.Months <= d.Month {
 		d.Goal.IsExceeded = true
-		cp := d.Goal
-		go m.Update(&cp)
+		if err := m.Update(actor, &d.Goal); err != nil {
+			return nil, err
+		}
 	}
 
 	if d.Goal.IsExceeded {
@@ -193,7 +210,9 @@ func (m *GoalModel) Details(id int64, userID int64) (*models.GoalDetails, error)
 	return &d, nil
 }
 
-func (m *GoalTransactionModel) Create(transaction *models.GoalTransaction, userID int64) (int64, error) {
+// Create добавляет перевод на цель. Перевод меняет накопленную сумму цели, поэтому записывается в журнал
+// как изменение цели.
+func (m *GoalTransactionModel) Create(actor models.Actor, transaction *models.GoalTransaction, userID int64) (transactionId int64, err error) {
 	tx, err := m.DB.Begin()
 	if err != nil {
 		return 0, err
@@ -207,7 +226,7 @@ func (m *GoalTransactionModel) Create(transaction *models.GoalTransaction, userI
 	}()
 
 	var exists bool
-	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM goals WHERE id = $1 AND user_id = $2)", transaction.GoalID, userID).Scan(&exists)
+	err = tx.QueryRow("SELECT EXISTS(SELECT 1 FROM goals WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)", transaction.GoalID, userID).Scan(&exists)
 	if err != nil {
 		return 0, err
 	}
@@ -216,7 +235,12 @@ func (m *GoalTransactionModel) Create(transaction *models.GoalTransaction, userI
 		return 0, fmt.Errorf("%w: goal with ID %d does not exist for user %d", myerrors.ErrNotFound, transaction.GoalID, userID)
 	}
 
-	var transactionId int64
+	goalID, owner := strconv.FormatInt(transaction.GoalID, 10), strconv.FormatInt(userID, 10)
+	before, err := snapshot(tx, models.KindGoal, goalID, owner)
+	if err != nil {
+		return 0, err
+	}
+
 	err = tx.QueryRow(`
         INSERT INTO goal_transactions(goal_id, amount, planned, currency_code, connected_account) 
         VALUES ($1, $2, $3, $4, $5) 
@@ -227,6 +251,9 @@ func (m *GoalTransactionModel) Create(transaction *models.GoalTransaction, userI
 		return 0, err
 	}
 
+	if err = recordChange(tx, actor, models.KindGoal, goalID, owner, models.ActionUpdate, before); err != nil {
+		return 0, err
+	}
 	return transactionId, nil
 }
 
@@ -253,7 +280,7 @@ func (m *GoalModel) TrackerInfo(userID int64, limit, offset int) ([]*models.Goal
 	goalRows, err := tx.Query(`
 		SELECT COUNT(*) OVER(), id, amount, currency_code, name, months, is_exceeded, is_completed, start_date 
 		FROM goals 
-		WHERE user_id=$1
+		WHERE user_id=$1 AND deleted_at IS NULL
 		LIMIT $2 OFFSET $3`, userID, limit, offset,
 	)
 
@@ -0,0 +1,151 @@
+// Package repository provides basic financial repository functionality.
+package repository
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+type HistoryModel struct {
+	DB *mydb.Database
+}
+
+// historyTables - таблицы записей, изменения которых попадают в журнал.
+var historyTables = map[string]string{
+	models.KindExpense:    "expense",
+	models.KindIncome:     "income",
+	models.KindWealthFund: "wealth_fund",
+	models.KindGoal:       "goals",
+	models.KindAccount:    "connected_accounts",
+}
+
+func lookupHistoryTable(kind string) (string, error) {
+	table, ok := historyTables[kind]
+	if !ok {
+		return "", fmt.Errorf("%w: unknown record type %q, expected expense, income, wealth_fund, goal or account", myerrors.ErrValidation, kind)
+	}
+	return table, nil
+}
+
+// stateExpr возвращает выражение, которое строит JSON-состояние записи t вида kind.
+func stateExpr(kind string) string {
+	switch kind {
+	case models.KindExpense:
+		// Части разделённого расхода хранятся отдельно, но меняются вместе с расходом.
+		return `to_jsonb(t) || jsonb_build_object('splits', COALESCE(
+			(SELECT jsonb_agg(to_jsonb(s) ORDER BY s.id) FROM expense_splits s WHERE s.expense_id = t.id), '[]'::jsonb))`
+	case models.KindGoal:
+		// Накопленная сумма цели меняется переводами на цель.
+		return `to_jsonb(t) || jsonb_build_object('accumulated', COALESCE(
+			(SELECT SUM(gt.amount) FROM goal_transactions gt WHERE gt.goal_id = t.id AND NOT gt.planned), 0))`
+	default:
+		return "to_jsonb(t)"
+	}
+}
+
+// snapshot возвращает текущее состояние записи пользователя в виде JSON, включая записи в корзине.
+// Для несуществующей записи возвращает nil.
+func snapshot(q querier, kind, id, userID string) (json.RawMessage, error) {
+	table, err := lookupHistoryTable(kind)
+	if err != nil {
+		return nil, err
+	}
+
+	var state []byte
+	err = q.QueryRow("SELECT "+stateExpr(kind)+" FROM "+table+" t WHERE t.id = $1 AND t.user_id = $2", id, userID).Scan(&state)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	return state, nil
+}
+
+// recordChange записывает в журнал изменение записи пользователя userID. Вызывается в той же транзакции q,
+// что и само изменение, поэтому запись журнала сохраняется только вместе с ним. Состояние после изменения
+// читается из q; для окончательного удаления оно пустое. Обновление, не изменившее запись, не записывается.
+func recordChange(q querier, actor models.Actor, kind, id, userID, action string, before json.RawMessage) error {
+	var after json.RawMessage
+	if action != models.ActionPurge {
+		var err error
+		if after, err = snapshot(q, kind, id, userID); err != nil {
+			return err
+		}
+	}
+	// jsonb выводится в каноническом виде, поэтому одинаковые состояния совпадают побайтно.
+	if action == models.ActionUpdate && bytes.Equal(before, after) {
+		return nil
+	}
+
+	_, err := q.Exec(`INSERT INTO record_history (user_id, record_type, record_id, action, before, after, actor_id, device_id)
+		VALUES ($1, $2, $3, $4, $5, $6, NULLIF($7, '')::integer, $8)`,
+		userID, kind, id, action, nullJSON(before), nullJSON(after), actor.UserID, actor.DeviceID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	return nil
+}
+
+// nullJSON передаёт пустое состояние как NULL, а не как пустую строку, которую jsonb не принимает.
+func nullJSON(state json.RawMessage) any {
+	if len(state) == 0 {
+		return nil
+	}
+	return string(state)
+}
+
+// List возвращает записи журнала пользователя по фильтру, сначала новые.
+func (m *HistoryModel) List(filter *models.HistoryFilter) ([]models.HistoryEntry, error) {
+	query := `SELECT id, record_type, record_id::text, action, before, after, COALESCE(actor_id::text, ''), device_id, created_at
+		FROM record_history WHERE user_id = $1`
+	args := []any{filter.UserID}
+	if filter.RecordType != "" {
+		if _, err := lookupHistoryTable(filter.RecordType); err != nil {
+			return nil, err
+		}
+		args = append(args, filter.RecordType)
+		query += fmt.Sprintf(" AND record_type = $%d", len(args))
+	}
+	if filter.RecordID != "" {
+		args = append(args, filter.RecordID)
+		query += fmt.Sprintf(" AND record_id::text = $%d", len(args))
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := m.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	entries := make([]models.HistoryEntry, 0)
+	for rows.Next() {
+		var entry models.HistoryEntry
+		var before, after []byte
+		var createdAt time.Time
+		if err := rows.Scan(&entry.ID, &entry.RecordType, &entry.RecordID, &entry.Action, &before, &after,
+			&entry.ActorID, &entry.DeviceID, &createdAt); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		entry.UserID = filter.UserID
+		entry.Before = before
+		entry.After = after
+		entry.CreatedAt = createdAt.Format(time.RFC3339)
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return entries, nil
+}
@@ -0,0 +1,271 @@
+// Package repository provides basic financial repository functionality.
+package repository
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+type ImportModel struct {
+	DB *mydb.Database
+}
+
+func (m *ImportModel) CreateProfile(profile *models.ImportProfile) (int64, error) {
+	mapping, err := json.Marshal(profile.Mapping)
+	if err != nil {
+		return 0, err
+	}
+
+	var id int64
+	err = m.DB.QueryRow("INSERT INTO import_profiles (user_id, name, mapping) VALUES ($1, $2, $3) RETURNING id",
+		profile.UserID, profile.Name, mapping).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+func (m *ImportModel) UpdateProfile(profile *models.ImportProfile) error {
+	mapping, err := json.Marshal(profile.Mapping)
+	if err != nil {
+		return err
+	}
+
+	result, err := m.DB.Exec("UPDATE import_profiles SET name = $1, mapping = $2 WHERE id = $3 AND user_id = $4",
+		profile.Name, mapping, profile.ID, profile.UserID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: no import profile found with id %d for user %s", myerrors.ErrNotFound, profile.ID, profile.UserID)
+	}
+
+	return nil
+}
+
+func (m *ImportModel) DeleteProfile(id int64, userID string) error {
+	result, err := m.DB.Exec("DELETE FROM import_profiles WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: no import profile found with id %d for user %s", myerrors.ErrNotFound, id, userID)
+	}
+
+	return nil
+}
+
+func (m *ImportModel) GetProfile(id int64, userID string) (*models.ImportProfile, error) {
+	var profile models.ImportProfile
+	var mapping []byte
+
+	err := m.DB.QueryRow("SELECT id, user_id, name, mapping FROM import_profiles WHERE id = $1 AND user_id = $2", id, userID).
+		Scan(&profile.ID, &profile.UserID, &profile.Name, &mapping)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: no import profile found with id %d for user %s", myerrors.ErrNotFound, id, userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	if err := json.Unmarshal(mapping, &profile.Mapping); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return &profile, nil
+}
+
+func (m *ImportModel) ListProfiles(userID string) ([]models.ImportProfile, error) {
+	rows, err := m.DB.Query("SELECT id, user_id, name, mapping FROM import_profiles WHERE user_id = $1 ORDER BY name", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var profiles []models.ImportProfile
+	for rows.Next() {
+		var profile models.ImportProfile
+		var mapping []byte
+		if err := rows.Scan(&profile.ID, &profile.UserID, &profile.Name, &mapping); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(mapping, &profile.Mapping); err != nil {
+			return nil, err
+		}
+		profiles = append(profiles, profile)
+	}
+
+	return profiles, rows.Err()
+}
+
+// Categories возвращает категории расходов и доходов пользователя в виде "название в нижнем регистре" -> id.
+func (m *ImportModel) Categories(userID string) (map[string]string, map[string]string, error) {
+	load := func(table string) (map[string]string, error) {
+		rows, err := m.DB.Query("SELECT id, name FROM "+table+" WHERE user_id = $1", userID)
+		if err != nil {
+			return nil, err
+		}
+		defer rows.Close()
+
+		categories := make(map[string]string)
+		for rows.Next() {
+			var id, name string
+			if err := rows.Scan(&id, &name); err != nil {
+				return nil, err
+			}
+			categories[strings.ToLower(strings.TrimSpace(name))] = id
+		}
+		return categories, rows.Err()
+	}
+
+	expense, err := load("expense_categories")
+	if err != nil {
+		return nil, nil, err
+	}
+	income, err := load("income_categories")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return expense, income, nil
+}
+
+// ExternalIDs возвращает банковские идентификаторы уже импортированных операций счёта.
+func (m *ImportModel) ExternalIDs(userID, bankAccount string) (map[string]bool, error) {
+	rows, err := m.DB.Query(`SELECT external_id FROM expense WHERE user_id = $1 AND connected_account = $2 AND external_id IS NOT NULL
+		UNION SELECT external_id FROM income WHERE user_id = $1 AND connected_account = $2 AND external_id IS NOT NULL`,
+		userID, bankAccount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := make(map[string]bool)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids[id] = true
+	}
+
+	return ids, rows.Err()
+}
+
+// Commit сохраняет строки без ошибок, кроме уже импортированных ранее, в одной транзакции. Если передан остаток,
+// в той же транзакции обновляется состояние подключённого счёта, если счёт ещё не обновлён по более новой
+// выписке; иначе остаток отмечается как пропущенный. При ошибке базы данных транзакция
+// откатывается, а ошибка записывается в строку, на которой она произошла.
+func (m *ImportModel) Commit(actor models.Actor, userID string, rows []*models.ImportRow, balance *models.AccountBalance) (err error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+			for _, row := range rows {
+				row.ID = 0
+			}
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	for _, row := range rows {
+		if row.Error != "" || row.Duplicate {
+			continue
+		}
+
+		switch row.Kind {
+		case models.KindExpense:
+			row.ID, err = insertExpense(tx, actor, &models.Expense{
+				Amount:      row.Amount,
+				Date:        row.Date,
+				UserID:      userID,
+				CategoryID:  row.CategoryID,
+				SentTo:      row.Counterparty,
+				BankAccount: row.BankAccount,
+				Currency:    row.Currency,
+				ExternalID:  row.ExternalID,
+				Planned:     row.Planned,
+				Tags:        rowTags(row.TagIDs),
+			})
+		case models.KindIncome:
+			row.ID, err = insertIncome(tx, actor, &models.Income{
+				Amount:      row.Amount,
+				Date:        row.Date,
+				UserID:      userID,
+				CategoryID:  row.CategoryID,
+				Sender:      row.Counterparty,
+				BankAccount: row.BankAccount,
+				Currency:    row.Currency,
+				ExternalID:  row.ExternalID,
+				Planned:     row.Planned,
+				Tags:        rowTags(row.TagIDs),
+			})
+		default:
+			err = fmt.Errorf("unknown kind %q", row.Kind)
+		}
+
+		if err != nil {
+			row.Error = err.Error()
+			return fmt.Errorf("line %d: %w", row.Line, err)
+		}
+	}
+
+	if balance != nil {
+		before, err := snapshot(tx, models.KindAccount, balance.AccountID, userID)
+		if err != nil {
+			return fmt.Errorf("account state: %w", err)
+		}
+		// Остаток без даты нельзя сравнить с датой уже записанного, поэтому он записывается, только пока её нет.
+		result, err := tx.Exec(`UPDATE connected_accounts SET state = $1, state_date = COALESCE($2::date, state_date), updated_at = NOW()
+			WHERE id = $3 AND user_id = $4 AND (state_date IS NULL OR $2::date >= state_date)`,
+			balance.State, nullDate(balance.Date), balance.AccountID, userID)
+		if err != nil {
+			return fmt.Errorf("account state: %w", err)
+		}
+		updated, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("account state: %w", err)
+		}
+		if updated == 0 {
+			balance.Skipped = true
+			return nil
+		}
+		err = recordChange(tx, actor, models.KindAccount, balance.AccountID, userID, models.ActionUpdate, before)
+		if err != nil {
+			return fmt.Errorf("account state: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func rowTags(ids []string) []models.Tag {
+	tags := make([]models.Tag, 0, len(ids))
+	for _, id := range ids {
+		tags = append(tags, models.Tag{ID: id})
+	}
+	return tags
+}
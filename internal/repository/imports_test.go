@@ -0,0 +1,51 @@
+package repository
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+func TestImportCommitSkipsStaleBalance(t *testing.T) {
+	db := testDB(t)
+	userID := testUser(t, db)
+
+	id, err := (&AccountModel{DB: db}).Create(models.Actor{}, &models.ConnectedAccount{
+		UserID: userID, AccountNumber: "40817810000000000001", AccountName: "card", AccountCurrency: "RUB",
+	})
+	if err != nil {
+		t.Fatalf("create account: %v", err)
+	}
+	accountID := fmt.Sprint(id)
+
+	imports := &ImportModel{DB: db}
+	tests := []struct {
+		name        string
+		balance     models.AccountBalance
+		wantSkipped bool
+		wantState   float64
+	}{
+		{name: "first statement", balance: models.AccountBalance{State: 500, Date: "2024-03-31"}, wantState: 500},
+		{name: "older statement", balance: models.AccountBalance{State: 100, Date: "2024-02-29"}, wantSkipped: true, wantState: 500},
+		{name: "undated balance", balance: models.AccountBalance{State: 200}, wantSkipped: true, wantState: 500},
+		{name: "same day statement", balance: models.AccountBalance{State: 700, Date: "2024-03-31"}, wantState: 700},
+		{name: "newer statement", balance: models.AccountBalance{State: 900, Date: "2024-04-30"}, wantState: 900},
+	}
+
+	for _, tt := range tests {
+		balance := tt.balance
+		balance.AccountID = accountID
+		if err := imports.Commit(models.Actor{}, userID, nil, &balance); err != nil {
+			t.Fatalf("%s: Commit: %v", tt.name, err)
+		}
+
+		var state float64
+		if err := db.QueryRow("SELECT state FROM connected_accounts WHERE id = $1", id).Scan(&state); err != nil {
+			t.Fatalf("%s: read state: %v", tt.name, err)
+		}
+		if balance.Skipped != tt.wantSkipped || state != tt.wantState {
+			t.Errorf("%s: skipped = %v, state = %v, want %v, %v", tt.name, balance.Skipped, state, tt.wantSkipped, tt.wantState)
+		}
+	}
+}
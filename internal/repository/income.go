@@ -7,7 +7,9 @@ import (
 	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
 	"github.com/wachrusz/Back-End-API/internal/myerrors"
 	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
 	"log"
+	"strconv"
 	"time"
 )
 
@@ -15,7 +17,16 @@ type IncomeModel struct {
 	DB *mydb.Database
 }
 
-func (m *IncomeModel) Create(income *models.Income) (int64, error) {
+func (m *IncomeModel) Create(actor models.Actor, income *models.Income) (id int64, err error) {
+	err = inTx(m.DB, func(tx *sql.Tx) error {
+		id, err = insertIncome(tx, actor, income)
+		return err
+	})
+	return id, err
+}
+
+// insertIncome создаёт доход с его тегами и запись в архиве операций в транзакции q и записывает создание в журнал.
+func insertIncome(q querier, actor models.Actor, income *models.Income) (int64, error) {
 	parsedDate, err := time.Parse("2006-01-02", income.Date)
 	if err != nil {
 		log.Println("Error parsing date:", err)
@@ -23,21 +34,49 @@ func (m *IncomeModel) Create(income *models.Income) (int64, error) {
 	}
 
 	var incomeID int64
-	err = m.DB.QueryRow("INSERT INTO income (amount, date, planned, user_id, category, sender, connected_account, currency_code) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
-		income.Amount, parsedDate, income.Planned, income.UserID, income.CategoryID, income.Sender, income.BankAccount, income.Currency).Scan(&incomeID)
+	err = q.QueryRow("INSERT INTO income (amount, date, planned, user_id, category, sender, connected_account, currency_code, recurring_id, external_id) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NULLIF($10, '')) RETURNING id",
+		income.Amount, parsedDate, income.Planned, income.UserID, income.CategoryID, income.Sender, income.BankAccount, income.Currency, income.RecurringID, income.ExternalID).Scan(&incomeID)
 	if err != nil {
 		return 0, err
 	}
-	_, err = m.DB.Exec("INSERT INTO operations (user_id, description, amount, date, category, operation_type) VALUES ($1, $2, $3, $4, $5, $6)",
-		income.UserID, "Доход", income.Amount, parsedDate, income.CategoryID, income.CategoryID)
+	_, err = q.Exec("INSERT INTO operations (user_id, description, amount, date, category, operation_type, income_id) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		income.UserID, "Доход", income.Amount, parsedDate, income.CategoryID, income.CategoryID, incomeID)
+	if err != nil {
+		return 0, err
+	}
+
+	if err = addTags(q, models.KindIncome, incomeID, income.UserID, tagIDs(income.Tags)); err != nil {
+		return 0, err
+	}
+
+	err = recordChange(q, actor, models.KindIncome, strconv.FormatInt(incomeID, 10), income.UserID, models.ActionCreate, nil)
 	if err != nil {
 		return 0, err
 	}
 	return incomeID, nil
 }
 
+// deleteIncomeOperations удаляет записи архива операций дохода. Записи, созданные до появления
+// связи с доходом, находятся по совпадению пользователя, суммы, даты и категории.
+func deleteIncomeOperations(q querier, userID string, incomeID int64, amount float64, date time.Time, category string) error {
+	result, err := q.Exec("DELETE FROM operations WHERE income_id = $1", incomeID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err != nil || n > 0 {
+		return err
+	}
+
+	_, err = q.Exec(`DELETE FROM operations WHERE ctid = (
+			SELECT ctid FROM operations
+			WHERE user_id = $1 AND income_id IS NULL AND description = 'Доход' AND amount = $2 AND date = $3 AND category = $4
+			LIMIT 1)`,
+		userID, amount, date, category)
+	return err
+}
+
 func (m *IncomeModel) ListByUserID(userID string) ([]models.Income, error) {
-	rows, err := m.DB.Query("SELECT id, amount, date, planned, category, sender, connected_account, currency_code FROM income WHERE user_id = $1", userID)
+	rows, err := m.DB.Query("SELECT id, amount, date, planned, category, sender, connected_account, currency_code FROM income WHERE user_id = $1 AND deleted_at IS NULL", userID)
 	if err != nil {
 		return nil, err
 	}
@@ -62,7 +101,7 @@ func (m *IncomeModel) ListByMonth(userID string, month time.Month, year int) (fl
 			COALESCE(SUM(amount), 0) AS total_income,
 			COALESCE(SUM(CASE WHEN planned THEN amount ELSE 0 END), 0) AS planned_income
 		FROM income
-		WHERE user_id = $1
+		WHERE user_id = $1 AND deleted_at IS NULL
 		AND EXTRACT(MONTH FROM date) = $2
 		AND EXTRACT(YEAR FROM date) = $3
 	`
@@ -103,8 +142,20 @@ func (m *IncomeModel) GetMonthlyIncomeIncrease(userID string) (int, int, error)
 	return int(((currentMonthIncome / previousMonthIncome) - 1) * 100), int(((currentMonthPlanned / currentMonthIncome) - 1) * 100), nil
 }
 
-func (m *IncomeModel) Delete(id, userID string) error {
-	result, err := m.DB.Exec("DELETE FROM income WHERE id = $1 AND user_id = $2", id, userID)
+func (m *IncomeModel) Delete(actor models.Actor, id, userID string) error {
+	return inTx(m.DB, func(tx *sql.Tx) error {
+		return deleteIncome(tx, actor, id, userID)
+	})
+}
+
+// deleteIncome переносит доход пользователя в корзину в транзакции q и записывает удаление в журнал.
+func deleteIncome(q querier, actor models.Actor, id, userID string) error {
+	before, err := snapshot(q, models.KindIncome, id, userID)
+	if err != nil {
+		return err
+	}
+
+	result, err := q.Exec("UPDATE income SET deleted_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL", id, userID)
 	if err != nil {
 		// Возвращаем обернутую ошибку, если запрос завершился с ошибкой
 		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
@@ -122,11 +173,23 @@ func (m *IncomeModel) Delete(id, userID string) error {
 		return fmt.Errorf("%w: no income found with id %s for user %s", myerrors.ErrNotFound, id, userID)
 	}
 
-	return nil
+	return recordChange(q, actor, models.KindIncome, id, userID, models.ActionDelete, before)
 }
 
-func (m *IncomeModel) Update(income *models.Income) error {
-	q := `
+func (m *IncomeModel) Update(actor models.Actor, income *models.Income) error {
+	return inTx(m.DB, func(tx *sql.Tx) error {
+		return updateIncome(tx, actor, income)
+	})
+}
+
+// updateIncome обновляет доход пользователя в транзакции q и записывает изменение в журнал.
+func updateIncome(q querier, actor models.Actor, income *models.Income) error {
+	before, err := snapshot(q, models.KindIncome, income.ID, income.UserID)
+	if err != nil {
+		return err
+	}
+
+	query := `
 		UPDATE income SET 
 			amount = $1, 
 			date = $2, 
@@ -135,9 +198,9 @@ func (m *IncomeModel) Update(income *models.Income) error {
 			sender = $5, 
 			connected_account = $6, 
 			currency_code = $7
-		WHERE id = $8 AND user_id = $9`
+		WHERE id = $8 AND user_id = $9 AND deleted_at IS NULL`
 
-	result, err := m.DB.Exec(q, income.Amount, income.Date, income.Planned, income.CategoryID,
+	result, err := q.Exec(query, income.Amount, income.Date, income.Planned, income.CategoryID,
 		income.Sender, income.BankAccount, income.Currency, income.ID, income.UserID)
 
 	if err != nil {
@@ -154,5 +217,71 @@ func (m *IncomeModel) Update(income *models.Income) error {
 		return fmt.Errorf("%w: no income found with id %s for user %s", myerrors.ErrNotFound, income.ID, income.UserID)
 	}
 
-	return nil
+	return recordChange(q, actor, models.KindIncome, income.ID, income.UserID, models.ActionUpdate, before)
+}
+
+// List возвращает страницу доходов пользователя по фильтру.
+func (m *IncomeModel) List(filter *models.TransactionFilter) ([]models.Income, *jsonresponse.Metadata, error) {
+	q := &listQuery{
+		table:          "income",
+		kind:           models.KindIncome,
+		columns:        "id, amount, date, planned, category, sender, connected_account, currency_code, recurring_id, COALESCE(external_id, '')",
+		categoryColumn: "category",
+	}
+	q.filter(filter)
+
+	var total int
+	if err := m.DB.QueryRow(q.count(), q.args...).Scan(&total); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	query, err := q.page(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := m.DB.Query(query, q.args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	incomes := make([]models.Income, 0, filter.Limit+1)
+	keys := make([]string, 0, filter.Limit+1)
+	for rows.Next() {
+		var income models.Income
+		var date time.Time
+		var key string
+		if err := rows.Scan(&income.ID, &income.Amount, &date, &income.Planned, &income.CategoryID, &income.Sender,
+			&income.BankAccount, &income.Currency, &income.RecurringID, &income.ExternalID, &key); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		income.Date = date.Format("2006-01-02")
+		income.UserID = filter.UserID
+		incomes = append(incomes, income)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	meta := pageMetadata(filter, total, len(incomes), func(i int) (string, string) {
+		return keys[i], incomes[i].ID
+	})
+	if len(incomes) > filter.Limit {
+		incomes = incomes[:filter.Limit]
+	}
+
+	ids := make([]string, len(incomes))
+	for i := range incomes {
+		ids[i] = incomes[i].ID
+	}
+	tags, err := tagsByRecord(m.DB, models.KindIncome, ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	for i := range incomes {
+		incomes[i].Tags = tags[incomes[i].ID]
+	}
+
+	return incomes, meta, nil
 }
@@ -0,0 +1,79 @@
+package models
+
+// Группировки операций в агрегации.
+const (
+	GroupByNone     = "none"
+	GroupByCategory = "category"
+	GroupByAccount  = "account"
+	GroupByPayee    = "payee"
+	GroupByTag      = "tag"
+)
+
+// Интервалы агрегации.
+const (
+	BucketNone    = "none"
+	BucketDay     = "day"
+	BucketWeek    = "week"
+	BucketMonth   = "month"
+	BucketQuarter = "quarter"
+	BucketYear    = "year"
+)
+
+// AggregationFilter - параметры агрегации. Даты в формате 2006-01-02, обе включительно.
+type AggregationFilter struct {
+	UserID  string
+	Kind    string
+	GroupBy string
+	Bucket  string
+	From    string
+	To      string
+}
+
+// AggregationRow - итог в рублях по интервалу и группе. AllBuckets и AllGroups отмечают строки,
+// просуммированные по всем интервалам или по всем группам.
+type AggregationRow struct {
+	Bucket     string
+	Key        string
+	Name       string
+	Amount     float64
+	Count      int
+	AllBuckets bool
+	AllGroups  bool
+}
+
+// AggregationGroup - итог группы. Суммы в валюте агрегации.
+type AggregationGroup struct {
+	// Key - id категории, номер счёта, получатель/отправитель или id тега; пустой, если не указан.
+	Key     string  `json:"key"`
+	Name    string  `json:"name"`
+	Total   float64 `json:"total"`
+	Count   int     `json:"count"`
+	Average float64 `json:"average"`
+	// Share - доля итога группы в итоге интервала в процентах.
+	Share float64 `json:"share"`
+}
+
+// AggregationBucket - итог за интервал с разбивкой по группам.
+type AggregationBucket struct {
+	// Start и End - первый и последний день интервала.
+	Start   string             `json:"start"`
+	End     string             `json:"end"`
+	Total   float64            `json:"total"`
+	Count   int                `json:"count"`
+	Average float64            `json:"average"`
+	Groups  []AggregationGroup `json:"groups"`
+}
+
+// Aggregation - итоги доходов или расходов по интервалам и группам.
+type Aggregation struct {
+	Kind     string `json:"kind"`
+	GroupBy  string `json:"group_by"`
+	Bucket   string `json:"bucket"`
+	From     string `json:"from"`
+	To       string `json:"to"`
+	Currency string `json:"currency"`
+	Timezone string `json:"timezone"`
+	// Total - итог за весь период, его Groups - итоги групп за весь период.
+	Total   AggregationBucket   `json:"total"`
+	Buckets []AggregationBucket `json:"buckets"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// KindGoalTransaction - владелец вложения: транзакция цели. Расходы и доходы используют KindExpense и KindIncome.
+const KindGoalTransaction = "goal_transaction"
+
+// Attachment - файл (фото чека, гарантийный талон, счёт), прикреплённый к расходу, доходу или транзакции цели.
+// Содержимое файла в структуру не входит и отдаётся только по ссылке на скачивание.
+type Attachment struct {
+	ID           int64  `json:"id"`
+	UserID       string `json:"user_id"`
+	OwnerType    string `json:"owner_type"`
+	OwnerID      string `json:"owner_id"`
+	FileName     string `json:"file_name"`
+	ContentType  string `json:"content_type"`
+	Size         int64  `json:"size"`
+	HasThumbnail bool   `json:"has_thumbnail"`
+	URL          string `json:"url"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	CreatedAt    string `json:"created_at"`
+}
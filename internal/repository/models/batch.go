@@ -0,0 +1,50 @@
+package models
+
+// Действия пакетной операции.
+const (
+	BatchCreate = "create"
+	BatchUpdate = "update"
+	BatchDelete = "delete"
+)
+
+// Статусы результата пакетной операции.
+const (
+	BatchOK     = "ok"
+	BatchFailed = "failed"
+	// BatchRolledBack - операция выполнилась бы, но атомарный пакет откатился из-за ошибки в другой операции.
+	BatchRolledBack = "rolled_back"
+)
+
+// Коды ошибок пакетной операции.
+const (
+	BatchErrValidation = "validation"
+	BatchErrNotFound   = "not_found"
+	BatchErrInternal   = "internal"
+)
+
+// BatchOperation - одна операция пакета над расходом, доходом или записью фонда благосостояния.
+// Для create и update заполняется объект, соответствующий Kind; для delete - ID.
+type BatchOperation struct {
+	Action string `json:"action"`
+	Kind   string `json:"kind"`
+	// ClientID - произвольный идентификатор клиента, возвращается в результате как есть.
+	ClientID   string      `json:"client_id,omitempty"`
+	ID         string      `json:"id,omitempty"`
+	Expense    *Expense    `json:"expense,omitempty"`
+	Income     *Income     `json:"income,omitempty"`
+	WealthFund *WealthFund `json:"wealth_fund,omitempty"`
+}
+
+// BatchResult - результат операции пакета с тем же индексом.
+type BatchResult struct {
+	Index    int         `json:"index"`
+	ClientID string      `json:"client_id,omitempty"`
+	Status   string      `json:"status"`
+	ID       string      `json:"id,omitempty"`
+	Error    *BatchError `json:"error,omitempty"`
+}
+
+type BatchError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
@@ -0,0 +1,58 @@
+package models
+
+// Budget - месячный бюджет расходов пользователя по категории или общий, если CategoryID пуст.
+// Суммы в рублях.
+type Budget struct {
+	ID         string `json:"id"`
+	UserID     string `json:"user_id,omitempty"`
+	CategoryID string `json:"category_id,omitempty"`
+	// CategoryName заполняется при чтении бюджетов.
+	CategoryName string  `json:"category_name,omitempty"`
+	Amount       float64 `json:"amount"`
+	// Rollover - неизрасходованный остаток месяца добавляется к бюджету следующего месяца.
+	Rollover bool `json:"rollover"`
+	// AlertThresholds - пороги уведомлений в процентах; если пусто, действуют пороги из конфигурации.
+	AlertThresholds []int  `json:"alert_thresholds,omitempty"`
+	CreatedAt       string `json:"created_at,omitempty"`
+}
+
+// BudgetSpending - расходы пользователя по категории за месяц в рублях.
+type BudgetSpending struct {
+	// Month - первый день месяца.
+	Month      string
+	CategoryID string
+	Amount     float64
+}
+
+// BudgetStatus - состояние бюджета за период.
+const (
+	BudgetOK       = "ok"
+	BudgetWarning  = "warning"
+	BudgetExceeded = "exceeded"
+)
+
+// BudgetLine - исполнение бюджета за период.
+type BudgetLine struct {
+	BudgetID     string  `json:"budget_id"`
+	CategoryID   string  `json:"category_id,omitempty"`
+	CategoryName string  `json:"category_name,omitempty"`
+	Amount       float64 `json:"amount"`
+	// Carryover - остаток, перенесённый с прошлых месяцев.
+	Carryover float64 `json:"carryover"`
+	// Available - бюджет периода с учётом перенесённого остатка.
+	Available float64 `json:"available"`
+	Spent     float64 `json:"spent"`
+	Remaining float64 `json:"remaining"`
+	// Projected - ожидаемые расходы к концу периода при текущем темпе трат.
+	Projected float64 `json:"projected"`
+	// Percent - доля израсходованного бюджета в процентах.
+	Percent float64 `json:"percent"`
+	Status  string  `json:"status"`
+}
+
+// BudgetReport - исполнение бюджетов пользователя за месяц.
+type BudgetReport struct {
+	PeriodStart string       `json:"period_start"`
+	PeriodEnd   string       `json:"period_end"`
+	Budgets     []BudgetLine `json:"budgets"`
+}
@@ -0,0 +1,59 @@
+package models
+
+// Базы сравнения периодов.
+const (
+	BaselinePrevious = "previous"
+	BaselineLastYear = "last_year"
+	BaselineAvg3     = "avg3"
+	BaselineAvg6     = "avg6"
+	BaselineAvg12    = "avg12"
+)
+
+// ComparisonFilter - параметры сравнения. Период задаётся месяцем (2006-01) или датами From и To (2006-01-02).
+type ComparisonFilter struct {
+	UserID   string
+	Kind     string
+	Month    string
+	From     string
+	To       string
+	Baseline string
+	Currency string
+	// Top - сколько категорий вернуть в наибольших ростах и снижениях.
+	Top int
+}
+
+// ComparisonPeriod - сравниваемый период. Для средних Periods - число периодов, по которым считается среднее.
+type ComparisonPeriod struct {
+	From    string `json:"from"`
+	To      string `json:"to"`
+	Periods int    `json:"periods"`
+}
+
+// ComparisonLine - изменение итога категории. DeltaPercent пуст, если в базе сравнения сумма нулевая.
+type ComparisonLine struct {
+	CategoryID   string   `json:"category_id"`
+	CategoryName string   `json:"category_name"`
+	Current      float64  `json:"current"`
+	Baseline     float64  `json:"baseline"`
+	Delta        float64  `json:"delta"`
+	DeltaPercent *float64 `json:"delta_percent"`
+	// Contribution - доля изменения категории в общем изменении в процентах.
+	Contribution float64 `json:"contribution"`
+}
+
+// Comparison - сравнение доходов или расходов за период с базой сравнения по категориям.
+type Comparison struct {
+	Kind           string           `json:"kind"`
+	Baseline       string           `json:"baseline"`
+	Currency       string           `json:"currency"`
+	Period         ComparisonPeriod `json:"period"`
+	BaselinePeriod ComparisonPeriod `json:"baseline_period"`
+	Current        float64          `json:"current"`
+	BaselineTotal  float64          `json:"baseline_total"`
+	Delta          float64          `json:"delta"`
+	DeltaPercent   *float64         `json:"delta_percent"`
+	// Categories - все категории по убыванию абсолютного изменения.
+	Categories   []ComparisonLine `json:"categories"`
+	TopIncreases []ComparisonLine `json:"top_increases"`
+	TopDecreases []ComparisonLine `json:"top_decreases"`
+}
@@ -0,0 +1,37 @@
+package models
+
+// Currency - поддерживаемая валюта и её текущий курс к рублю за Nominal единиц.
+type Currency struct {
+	Code    string  `json:"code"`
+	NumCode string  `json:"num_code"`
+	Name    string  `json:"name"`
+	Nominal int     `json:"nominal"`
+	Value   float64 `json:"value"`
+	// Provider - провайдер последнего курса, UpdatedAt - время его получения (RFC 3339), пусто для рубля.
+	Provider  string `json:"provider,omitempty"`
+	UpdatedAt string `json:"updated_at,omitempty"`
+}
+
+// Conversion - пересчёт суммы из валюты From в валюту To по курсу на дату Date.
+type Conversion struct {
+	From   string  `json:"from"`
+	To     string  `json:"to"`
+	Date   string  `json:"date"`
+	Amount float64 `json:"amount"`
+	// Rate - сколько единиц To стоит одна единица From.
+	Rate      float64 `json:"rate"`
+	Converted float64 `json:"converted"`
+}
+
+// RatePoint - курс пары валют на дату.
+type RatePoint struct {
+	Date string  `json:"date"`
+	Rate float64 `json:"rate"`
+}
+
+// RateHistory - курсы пары валют по дням: сколько единиц To стоит одна единица From.
+type RateHistory struct {
+	From  string      `json:"from"`
+	To    string      `json:"to"`
+	Rates []RatePoint `json:"rates"`
+}
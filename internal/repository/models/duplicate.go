@@ -0,0 +1,43 @@
+package models
+
+// DuplicateRecord - расход или доход, который сравнивается с другими при поиске дубликатов.
+// ID пустой у операции, которая ещё не сохранена.
+type DuplicateRecord struct {
+	Kind        string  `json:"kind"`
+	ID          string  `json:"id,omitempty"`
+	Date        string  `json:"date"`
+	Amount      float64 `json:"amount"`
+	Currency    string  `json:"currency"`
+	Payee       string  `json:"payee"`
+	BankAccount string  `json:"bank_account,omitempty"`
+}
+
+// DuplicateMatch - сохранённая операция, похожая на проверяемую. Score от 0 до 1.
+type DuplicateMatch struct {
+	DuplicateRecord
+	Score float64 `json:"score"`
+}
+
+// DuplicatePair - пара вероятных дубликатов; First создан раньше Second.
+type DuplicatePair struct {
+	Kind   string          `json:"kind"`
+	First  DuplicateRecord `json:"first"`
+	Second DuplicateRecord `json:"second"`
+	Score  float64         `json:"score"`
+}
+
+// DuplicateFilter - среди каких операций искать дубликаты.
+type DuplicateFilter struct {
+	UserID   string
+	Kind     string
+	DateFrom string
+	DateTo   string
+	Currency string
+}
+
+// DuplicateDismissal - пара операций, отмеченная пользователем как не дубликаты.
+type DuplicateDismissal struct {
+	Kind     string
+	FirstID  string
+	SecondID string
+}
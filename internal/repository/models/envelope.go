@@ -0,0 +1,72 @@
+package models
+
+// Envelope - конверт для расходов категории в режиме конвертного бюджета. Суммы в рублях.
+type Envelope struct {
+	ID         string `json:"id"`
+	UserID     string `json:"user_id,omitempty"`
+	CategoryID string `json:"category_id"`
+	// CategoryName заполняется при чтении конвертов.
+	CategoryName string `json:"category_name,omitempty"`
+	CreatedAt    string `json:"created_at,omitempty"`
+}
+
+// EnvelopeMovement - перемещение денег в месяце Month. Пустой FromEnvelopeID означает деньги
+// из суммы к распределению, пустой ToEnvelopeID - возврат в неё.
+type EnvelopeMovement struct {
+	ID             string  `json:"id"`
+	UserID         string  `json:"user_id,omitempty"`
+	Month          string  `json:"month"`
+	FromEnvelopeID string  `json:"from_envelope_id,omitempty"`
+	ToEnvelopeID   string  `json:"to_envelope_id,omitempty"`
+	Amount         float64 `json:"amount"`
+	Note           string  `json:"note,omitempty"`
+	CreatedAt      string  `json:"created_at,omitempty"`
+}
+
+// EnvelopeClosing - закрытый месяц: сумма к распределению и остатки конвертов, перенесённые на следующий месяц.
+type EnvelopeClosing struct {
+	Month    string
+	ToAssign float64
+	// Balances - перенесённые остатки по id конверта.
+	Balances map[string]float64
+}
+
+// EnvelopeActivity - данные месяца, из которых считаются остатки конвертов.
+type EnvelopeActivity struct {
+	Income float64
+	// Assigned - сумма перемещений в конверт за вычетом перемещений из него, по id конверта.
+	Assigned map[string]float64
+	// FromPool - сколько распределено из суммы к распределению за вычетом возвратов в неё.
+	FromPool float64
+	// Spent - расходы по id категории.
+	Spent map[string]float64
+}
+
+// EnvelopeLine - состояние конверта за месяц.
+type EnvelopeLine struct {
+	EnvelopeID   string  `json:"envelope_id"`
+	CategoryID   string  `json:"category_id"`
+	CategoryName string  `json:"category_name,omitempty"`
+	Carried      float64 `json:"carried"`
+	Assigned     float64 `json:"assigned"`
+	Spent        float64 `json:"spent"`
+	Balance      float64 `json:"balance"`
+}
+
+// EnvelopeReport - конвертный бюджет пользователя за месяц.
+type EnvelopeReport struct {
+	Month  string `json:"month"`
+	Closed bool   `json:"closed"`
+	// PreviousClosed - закрыт ли предыдущий месяц. Если нет, остатки прошлого месяца не переносятся.
+	PreviousClosed bool `json:"previous_closed"`
+	// CarriedToAssign - нераспределённая сумма, перенесённая с прошлого месяца.
+	CarriedToAssign float64 `json:"carried_to_assign"`
+	Income          float64 `json:"income"`
+	Assigned        float64 `json:"assigned"`
+	// UnbudgetedSpent - расходы в категориях без конверта; они уменьшают сумму к распределению.
+	UnbudgetedSpent float64 `json:"unbudgeted_spent"`
+	// AvailableToAssign - сколько ещё можно распределить по конвертам; отрицательное значение
+	// означает, что распределено больше, чем есть.
+	AvailableToAssign float64        `json:"available_to_assign"`
+	Envelopes         []EnvelopeLine `json:"envelopes"`
+}
@@ -10,4 +10,21 @@ type Expense struct {
 	SentTo      string  `json:"sent_to"`
 	BankAccount string  `json:"bank_account"`
 	Currency    string  `json:"currency"`
+	RecurringID *int64  `json:"recurring_id,omitempty"`
+	// ExternalID - идентификатор операции в банке (FITID), по нему отсекаются повторные импорты.
+	ExternalID string `json:"external_id,omitempty"`
+	// Splits - части разделённого расхода. Их сумма равна Amount.
+	Splits []ExpenseSplit `json:"splits,omitempty"`
+	// SplitID заполняется, когда расход раскрыт в одну из своих частей (Amount и CategoryID берутся из части).
+	SplitID int64 `json:"split_id,omitempty"`
+	// Tags - пользовательские теги расхода.
+	Tags []Tag `json:"tags,omitempty"`
+}
+
+// ExpenseSplit - часть разделённого расхода со своей категорией.
+type ExpenseSplit struct {
+	ID         int64   `json:"id"`
+	CategoryID string  `json:"category_id"`
+	Amount     float64 `json:"amount"`
+	Note       string  `json:"note"`
 }
@@ -0,0 +1,30 @@
+package models
+
+// Поля сортировки списков операций.
+const (
+	SortByDate   = "date"
+	SortByAmount = "amount"
+)
+
+// TransactionFilter - фильтр, сортировка и курсор для списков расходов, доходов и фонда благосостояния.
+// Пустые поля не фильтруют.
+type TransactionFilter struct {
+	UserID      string
+	DateFrom    string
+	DateTo      string
+	CategoryID  string
+	BankAccount string
+	Currency    string
+	Planned     *bool
+	AmountMin   *float64
+	AmountMax   *float64
+	// TagIDs оставляет записи, у которых есть хотя бы один из тегов.
+	TagIDs []string
+	// Sort - поле сортировки (SortByDate или SortByAmount), при равенстве записи упорядочиваются по id.
+	Sort string
+	Desc bool
+	// Limit - размер страницы.
+	Limit int
+	// Cursor - курсор из метаданных предыдущей страницы.
+	Cursor string
+}
@@ -0,0 +1,64 @@
+package models
+
+// ForecastFlow - запланированная операция, учитываемая в прогнозе.
+type ForecastFlow struct {
+	Date        string
+	Kind        string
+	Amount      float64
+	Currency    string
+	BankAccount string
+}
+
+// ForecastSpend - необязательные расходы по счёту в день недели за период в рублях.
+// BankAccount - номер счёта, указанный в расходах.
+type ForecastSpend struct {
+	BankAccount string
+	// Weekday - день недели, 0 - воскресенье.
+	Weekday int
+	Amount  float64
+}
+
+// ForecastPoint - прогноз на один день. Суммы в валюте ряда.
+type ForecastPoint struct {
+	Date    string  `json:"date"`
+	Income  float64 `json:"income"`
+	Planned float64 `json:"planned_expense"`
+	// Discretionary - ожидаемые необязательные расходы по средним за день недели.
+	Discretionary float64 `json:"discretionary_expense"`
+	Balance       float64 `json:"balance"`
+}
+
+// ForecastSeries - прогноз остатка счёта или общего остатка по дням.
+type ForecastSeries struct {
+	// AccountID пуст для общего остатка.
+	AccountID    string          `json:"account_id,omitempty"`
+	AccountName  string          `json:"account_name,omitempty"`
+	Currency     string          `json:"currency"`
+	StartBalance float64         `json:"start_balance"`
+	MinBalance   float64         `json:"min_balance"`
+	MinDate      string          `json:"min_date"`
+	Points       []ForecastPoint `json:"points"`
+}
+
+// ForecastWarning - предупреждение о том, что остаток опустится ниже порога.
+type ForecastWarning struct {
+	// AccountID пуст для общего остатка.
+	AccountID   string `json:"account_id,omitempty"`
+	AccountName string `json:"account_name,omitempty"`
+	// Date - первый день, когда остаток ниже порога.
+	Date       string  `json:"date"`
+	Balance    float64 `json:"balance"`
+	MinBalance float64 `json:"min_balance"`
+	MinDate    string  `json:"min_date"`
+}
+
+// Forecast - прогноз остатков на ближайшие дни.
+type Forecast struct {
+	// From - сегодняшний день, остаток которого берётся из счетов.
+	From      string            `json:"from"`
+	Days      int               `json:"days"`
+	Threshold float64           `json:"threshold"`
+	Accounts  []ForecastSeries  `json:"accounts"`
+	Total     ForecastSeries    `json:"total"`
+	Warnings  []ForecastWarning `json:"warnings"`
+}
@@ -0,0 +1,52 @@
+package models
+
+import "encoding/json"
+
+// KindAccount - подключённый счёт как объект журнала изменений.
+const KindAccount = "account"
+
+// Действия, которые попадают в журнал изменений.
+const (
+	ActionCreate  = "create"
+	ActionUpdate  = "update"
+	ActionDelete  = "delete"
+	ActionRestore = "restore"
+	ActionPurge   = "purge"
+)
+
+// Actor - кто сделал изменение. Пустой UserID означает изменение, сделанное сервером.
+type Actor struct {
+	UserID   string
+	DeviceID string
+}
+
+// SystemActor - изменение, сделанное фоновой задачей сервера job. Имя задачи записывается вместо устройства.
+func SystemActor(job string) Actor {
+	return Actor{DeviceID: "system:" + job}
+}
+
+// HistoryEntry - запись журнала изменений: состояние записи до и после изменения.
+// Before пуст для создания, After пуст для окончательного удаления.
+type HistoryEntry struct {
+	ID         int64           `json:"id"`
+	UserID     string          `json:"-"`
+	RecordType string          `json:"record_type"`
+	RecordID   string          `json:"record_id"`
+	Action     string          `json:"action"`
+	Before     json.RawMessage `json:"before,omitempty" swaggertype:"object"`
+	After      json.RawMessage `json:"after,omitempty" swaggertype:"object"`
+	// ActorID пуст, если изменение сделано сервером.
+	ActorID   string `json:"actor_id"`
+	DeviceID  string `json:"device_id"`
+	CreatedAt string `json:"created_at"`
+}
+
+// HistoryFilter - параметры выборки журнала изменений пользователя.
+// RecordType и RecordID необязательны и сужают выборку до типа записей или одной записи.
+type HistoryFilter struct {
+	UserID     string
+	RecordType string
+	RecordID   string
+	Limit      int
+	Offset     int
+}
@@ -0,0 +1,111 @@
+package models
+
+// Соглашения о знаке суммы в выписке.
+const (
+	// SignNegativeExpense - отрицательная сумма это расход, положительная - доход.
+	SignNegativeExpense = "negative_expense"
+	// SignPositiveExpense - положительная сумма это расход, отрицательная - доход.
+	SignPositiveExpense = "positive_expense"
+	// SignSplitColumns - расход и доход лежат в разных колонках (DebitColumn и CreditColumn).
+	SignSplitColumns = "split_columns"
+)
+
+// CSVMapping описывает, как колонки CSV-файла превращаются в доходы и расходы.
+// Колонки задаются именем из заголовка или номером, начиная с 0.
+type CSVMapping struct {
+	Delimiter string `json:"delimiter"`
+	HasHeader bool   `json:"has_header"`
+	SkipRows  int    `json:"skip_rows"`
+	// DateFormat - формат даты вида DD.MM.YYYY (поддерживаются YYYY, YY, MM, M, DD, D, HH, mm, ss).
+	DateFormat         string `json:"date_format"`
+	DateColumn         string `json:"date_column"`
+	AmountColumn       string `json:"amount_column"`
+	DebitColumn        string `json:"debit_column"`
+	CreditColumn       string `json:"credit_column"`
+	AmountSign         string `json:"amount_sign"`
+	DecimalSeparator   string `json:"decimal_separator"`
+	ThousandsSeparator string `json:"thousands_separator"`
+	CurrencyColumn     string `json:"currency_column"`
+	DefaultCurrency    string `json:"default_currency"`
+	PayeeColumn        string `json:"payee_column"`
+	CategoryColumn     string `json:"category_column"`
+	// CategoryMap сопоставляет значение колонки категории с id категории пользователя.
+	// Несопоставленные значения ищутся среди названий категорий пользователя без учёта регистра.
+	CategoryMap            map[string]string `json:"category_map"`
+	DefaultExpenseCategory string            `json:"default_expense_category"`
+	DefaultIncomeCategory  string            `json:"default_income_category"`
+	BankAccount            string            `json:"bank_account"`
+}
+
+// ImportProfile - сохранённый пользователем маппинг колонок.
+type ImportProfile struct {
+	ID      int64      `json:"id"`
+	UserID  string     `json:"user_id"`
+	Name    string     `json:"name"`
+	Mapping CSVMapping `json:"mapping"`
+}
+
+// ImportRow - одна строка выписки, приведённая к доходу или расходу.
+type ImportRow struct {
+	Line         int     `json:"line"`
+	Kind         string  `json:"kind,omitempty"`
+	Amount       float64 `json:"amount,omitempty"`
+	Date         string  `json:"date,omitempty"`
+	Currency     string  `json:"currency,omitempty"`
+	Counterparty string  `json:"counterparty,omitempty"`
+	CategoryID   string  `json:"category_id,omitempty"`
+	BankAccount  string  `json:"bank_account,omitempty"`
+	// ValueDate - дата валютирования, если она есть в выписке.
+	ValueDate string `json:"value_date,omitempty"`
+	// Reference - платёжная референция (EndToEndId, референция клиента).
+	Reference string `json:"reference,omitempty"`
+	// ExternalID - идентификатор операции в банке (FITID).
+	ExternalID string `json:"external_id,omitempty"`
+	// Duplicate - операция уже была импортирована ранее и пропускается.
+	Duplicate bool `json:"duplicate,omitempty"`
+	// PossibleDuplicates - уже сохранённые операции, похожие на эту. Строка всё равно импортируется.
+	PossibleDuplicates []DuplicateMatch `json:"possible_duplicates,omitempty"`
+	// TagIDs и Planned задаются правилами автокатегоризации.
+	TagIDs  []string `json:"tag_ids,omitempty"`
+	Planned bool     `json:"planned,omitempty"`
+	// ID - id созданного дохода или расхода.
+	ID    int64  `json:"id,omitempty"`
+	Error string `json:"error,omitempty"`
+}
+
+// ImportResult - результат разбора или импорта выписки.
+type ImportResult struct {
+	DryRun   bool `json:"dry_run"`
+	Total    int  `json:"total"`
+	Imported int  `json:"imported"`
+	Failed   int  `json:"failed"`
+	Skipped  int  `json:"skipped"`
+	// PossibleDuplicates - число строк, похожих на уже сохранённые операции.
+	PossibleDuplicates int          `json:"possible_duplicates"`
+	Rows               []*ImportRow `json:"rows"`
+	// ClosingBalance - остаток счёта по выписке, который записывается в подключённый счёт, если он не старше
+	// уже записанного.
+	ClosingBalance *AccountBalance `json:"closing_balance,omitempty"`
+}
+
+// AccountBalance - остаток подключённого счёта на дату.
+type AccountBalance struct {
+	AccountID string  `json:"account_id"`
+	State     float64 `json:"state"`
+	Date      string  `json:"date,omitempty"`
+	// Skipped - остаток не записан в счёт, потому что счёт уже обновлён по более новой выписке.
+	Skipped bool `json:"skipped,omitempty"`
+}
+
+// StatementOptions - параметры импорта банковской выписки в подключённый счёт.
+type StatementOptions struct {
+	// Format - формат выписки. Если не задан, определяется по содержимому файла.
+	Format string `json:"format"`
+	// AccountID - id подключённого счёта, к которому привязываются операции.
+	AccountID string `json:"account_id"`
+	// DateFormat - формат даты для QIF (например, DD.MM.YYYY). Если не задан, перебираются распространённые форматы.
+	DateFormat             string            `json:"date_format"`
+	CategoryMap            map[string]string `json:"category_map"`
+	DefaultExpenseCategory string            `json:"default_expense_category"`
+	DefaultIncomeCategory  string            `json:"default_income_category"`
+}
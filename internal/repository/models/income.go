@@ -10,4 +10,9 @@ type Income struct {
 	Sender      string  `json:"sender"`
 	BankAccount string  `json:"bank_account"`
 	Currency    string  `json:"currency"`
+	RecurringID *int64  `json:"recurring_id,omitempty"`
+	// ExternalID - идентификатор операции в банке (FITID), по нему отсекаются повторные импорты.
+	ExternalID string `json:"external_id,omitempty"`
+	// Tags - пользовательские теги дохода.
+	Tags []Tag `json:"tags,omitempty"`
 }
@@ -0,0 +1,41 @@
+package models
+
+// Разрешения истории чистого капитала.
+const (
+	ResolutionDay   = "day"
+	ResolutionWeek  = "week"
+	ResolutionMonth = "month"
+)
+
+// NetWorthSnapshot - чистый капитал пользователя на конец дня: остатки счетов и активы фонда благосостояния
+// за вычетом кредитов. В базе суммы хранятся в рублях, в ответах - в базовой валюте пользователя.
+type NetWorthSnapshot struct {
+	Date     string  `json:"date"`
+	Accounts float64 `json:"accounts"`
+	Liquid   float64 `json:"liquid"`
+	Illiquid float64 `json:"illiquid"`
+	Loans    float64 `json:"loans"`
+	NetWorth float64 `json:"net_worth"`
+}
+
+// NetWorthChange - изменения составляющих чистого капитала за день в рублях. Accounts - операции
+// по подключённым счетам и переводы между ними, остальные поля - записи фонда благосостояния.
+type NetWorthChange struct {
+	Date     string
+	Accounts float64
+	Liquid   float64
+	Illiquid float64
+	Loans    float64
+}
+
+// NetWorthHistory - история чистого капитала. Для недель и месяцев точка - последний снимок периода.
+type NetWorthHistory struct {
+	Currency   string             `json:"currency"`
+	Resolution string             `json:"resolution"`
+	From       string             `json:"from"`
+	To         string             `json:"to"`
+	Points     []NetWorthSnapshot `json:"points"`
+	// Change - изменение чистого капитала от первой до последней точки, ChangePercent пуст, если первая точка нулевая.
+	Change        float64  `json:"change"`
+	ChangePercent *float64 `json:"change_percent"`
+}
@@ -0,0 +1,56 @@
+package models
+
+// Frequency определяет правило повторения серии.
+type Frequency string
+
+const (
+	Daily      Frequency = "daily"
+	Weekly     Frequency = "weekly"
+	Monthly    Frequency = "monthly"
+	NthWeekday Frequency = "nth_weekday"
+)
+
+const (
+	KindExpense = "expense"
+	KindIncome  = "income"
+)
+
+// RecurringSeries описывает шаблон повторяющегося дохода или расхода и правило его повторения.
+type RecurringSeries struct {
+	ID           int64     `json:"id"`
+	UserID       string    `json:"user_id"`
+	Kind         string    `json:"kind"`
+	Amount       float64   `json:"amount"`
+	Planned      bool      `json:"planned"`
+	CategoryID   string    `json:"category_id"`
+	Counterparty string    `json:"counterparty"`
+	BankAccount  string    `json:"bank_account"`
+	Currency     string    `json:"currency"`
+	Frequency    Frequency `json:"frequency"`
+	// Interval - повторять каждые N дней/недель/месяцев.
+	Interval int `json:"interval"`
+	// Weekday - день недели (0 - воскресенье) для nth_weekday. Weekly повторяется в день недели StartDate.
+	Weekday int `json:"weekday"`
+	// WeekOfMonth - номер недели месяца (1-5, -1 - последняя) для nth_weekday.
+	WeekOfMonth int `json:"week_of_month"`
+	// DayOfMonth - число месяца для monthly, 0 - число из StartDate.
+	DayOfMonth int    `json:"day_of_month"`
+	StartDate  string `json:"start_date"`
+	EndDate    string `json:"end_date,omitempty"`
+	// Count - общее количество повторений, 0 - без ограничения.
+	Count int `json:"count"`
+	// Materialized - количество уже наступивших повторений, включая пропущенные.
+	Materialized int    `json:"materialized"`
+	NextDate     string `json:"next_date,omitempty"`
+	Paused       bool   `json:"paused"`
+}
+
+// RecurringOccurrence - предстоящее повторение серии, ещё не ставшее операцией.
+type RecurringOccurrence struct {
+	SeriesID    int64
+	Date        string
+	Kind        string
+	Amount      float64
+	Currency    string
+	BankAccount string
+}
@@ -0,0 +1,78 @@
+package models
+
+// CategorizationRule - пользовательское правило автокатегоризации, например «всё, что отправлено
+// в Пятёрочку, - Продукты». Пустые условия не проверяются; правило без условий не допускается.
+type CategorizationRule struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id,omitempty"`
+	Name   string `json:"name"`
+	// Kind - expense, income или пусто, если правило применяется к обоим видам операций.
+	Kind string `json:"kind"`
+	// Priority - порядок применения: правила с меньшим значением проверяются раньше.
+	Priority int  `json:"priority"`
+	Enabled  bool `json:"enabled"`
+
+	// Payee - подстрока получателя расхода или отправителя дохода без учёта регистра.
+	Payee string `json:"payee,omitempty"`
+	// Pattern - регулярное выражение по контрагенту и назначению платежа без учёта регистра.
+	Pattern     string   `json:"pattern,omitempty"`
+	AmountMin   *float64 `json:"amount_min,omitempty"`
+	AmountMax   *float64 `json:"amount_max,omitempty"`
+	BankAccount string   `json:"bank_account,omitempty"`
+	Currency    string   `json:"currency,omitempty"`
+
+	// CategoryID - категория, которую задаёт правило. Требует указать Kind, так как категории
+	// расходов и доходов различаются.
+	CategoryID string   `json:"category_id,omitempty"`
+	TagIDs     []string `json:"tag_ids,omitempty"`
+	Planned    *bool    `json:"planned,omitempty"`
+}
+
+// RuleTarget - операция, к которой применяются правила.
+type RuleTarget struct {
+	Kind   string
+	ID     string
+	Date   string
+	Amount float64
+	Payee  string
+	// Text - дополнительный текст операции, например назначение платежа из выписки.
+	Text        string
+	BankAccount string
+	Currency    string
+	CategoryID  string
+	Planned     bool
+	TagIDs      []string
+}
+
+// RuleOutcome - результат применения правил к операции. Пустые поля правилами не задаются.
+type RuleOutcome struct {
+	CategoryID string
+	Planned    *bool
+	TagIDs     []string
+	RuleIDs    []string
+}
+
+// RuleChange - изменение прошлой операции при повторном применении правил.
+type RuleChange struct {
+	Kind           string   `json:"kind"`
+	ID             string   `json:"id"`
+	Date           string   `json:"date"`
+	Amount         float64  `json:"amount"`
+	Currency       string   `json:"currency"`
+	Payee          string   `json:"payee"`
+	CategoryBefore string   `json:"category_before"`
+	CategoryAfter  string   `json:"category_after"`
+	PlannedBefore  bool     `json:"planned_before"`
+	PlannedAfter   bool     `json:"planned_after"`
+	AddTagIDs      []string `json:"add_tag_ids,omitempty"`
+	// RuleIDs - правила, которые сработали для операции.
+	RuleIDs []string `json:"rule_ids"`
+}
+
+// RuleApplyFilter - какие прошлые операции пересматриваются при повторном применении правил.
+type RuleApplyFilter struct {
+	UserID   string
+	Kind     string
+	DateFrom string
+	DateTo   string
+}
@@ -0,0 +1,27 @@
+package models
+
+// SearchQuery - параметры полнотекстового поиска по операциям.
+type SearchQuery struct {
+	UserID string
+	Text   string
+	// Amounts - числа из запроса, которые сравниваются с суммой операции.
+	Amounts []float64
+	// Kind ограничивает поиск расходами (KindExpense) или доходами (KindIncome).
+	Kind     string
+	DateFrom string
+	DateTo   string
+	Limit    int
+}
+
+// SearchResult - найденная операция. Snippet содержит фрагмент текста с совпадениями, выделенными тегом <b>.
+type SearchResult struct {
+	Kind     string  `json:"kind"`
+	ID       string  `json:"id"`
+	Amount   float64 `json:"amount"`
+	Date     string  `json:"date"`
+	Currency string  `json:"currency"`
+	Payee    string  `json:"payee"`
+	Category string  `json:"category"`
+	Snippet  string  `json:"snippet"`
+	Rank     float64 `json:"rank"`
+}
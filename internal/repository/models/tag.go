@@ -0,0 +1,49 @@
+package models
+
+// KindWealthFund - запись фонда благосостояния как объект, к которому можно привязать тег.
+const KindWealthFund = "wealth_fund"
+
+// Tag - пользовательская метка, которая объединяет операции из разных категорий, например «отпуск 2026».
+type Tag struct {
+	ID     string `json:"id"`
+	UserID string `json:"user_id,omitempty"`
+	Name   string `json:"name"`
+	// Color - цвет в формате #RRGGBB, необязателен.
+	Color string `json:"color"`
+}
+
+// TagTotal - сырая сумма операций с тегом за день в исходной валюте.
+type TagTotal struct {
+	Kind     string
+	Date     string
+	Month    string
+	Currency string
+	Amount   float64
+}
+
+// TagSummary - итоги по тегу, пересчитанные в валюту Currency. Учитываются только фактические (не плановые) операции.
+type TagSummary struct {
+	TagID      string             `json:"tag_id"`
+	Currency   string             `json:"currency"`
+	Expense    float64            `json:"expense"`
+	Income     float64            `json:"income"`
+	WealthFund float64            `json:"wealth_fund"`
+	ByCurrency []TagCurrencyTotal `json:"by_currency"`
+	ByMonth    []TagMonthTotal    `json:"by_month"`
+}
+
+// TagCurrencyTotal - сумма операций одного вида в исходной валюте и её пересчёт.
+type TagCurrencyTotal struct {
+	Kind      string  `json:"kind"`
+	Currency  string  `json:"currency"`
+	Amount    float64 `json:"amount"`
+	Converted float64 `json:"converted"`
+}
+
+// TagMonthTotal - пересчитанные суммы за месяц в формате YYYY-MM.
+type TagMonthTotal struct {
+	Month      string  `json:"month"`
+	Expense    float64 `json:"expense"`
+	Income     float64 `json:"income"`
+	WealthFund float64 `json:"wealth_fund"`
+}
@@ -0,0 +1,20 @@
+package models
+
+// Transfer - перевод между подключёнными счетами пользователя. Перевод не является ни доходом,
+// ни расходом: он уменьшает остаток счёта-источника на Amount и увеличивает остаток счёта-получателя на ToAmount.
+type Transfer struct {
+	ID            int64  `json:"id"`
+	UserID        string `json:"user_id"`
+	FromAccountID string `json:"from_account_id"`
+	ToAccountID   string `json:"to_account_id"`
+	// Amount - списанная сумма в валюте счёта-источника.
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+	// ToAmount - зачисленная сумма в валюте счёта-получателя.
+	ToAmount   float64 `json:"to_amount"`
+	ToCurrency string  `json:"to_currency"`
+	// Rate - применённый курс: ToAmount = Amount * Rate.
+	Rate float64 `json:"rate"`
+	Date string  `json:"date"`
+	Note string  `json:"note"`
+}
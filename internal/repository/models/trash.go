@@ -0,0 +1,19 @@
+package models
+
+// KindGoal - цель как объект, который можно перенести в корзину и восстановить.
+const KindGoal = "goal"
+
+// TrashItem - запись в корзине: удалённый расход, доход, запись фонда благосостояния или цель.
+// Запись можно восстановить, пока не истёк срок хранения корзины.
+type TrashItem struct {
+	Kind     string  `json:"kind"`
+	ID       string  `json:"id"`
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+	Date     string  `json:"date"`
+	// Title - получатель расхода, отправитель дохода или название цели.
+	Title     string `json:"title"`
+	DeletedAt string `json:"deleted_at"`
+	// ExpiresAt - момент, после которого запись будет удалена окончательно.
+	ExpiresAt string `json:"expires_at,omitempty"`
+}
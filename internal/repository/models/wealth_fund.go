@@ -9,6 +9,8 @@ type WealthFund struct {
 	ConnectedAccount string      `json:"bank_account"`
 	CategoryID       string      `json:"category_id"`
 	UserID           string      `json:"user_id"`
+	// Tags - пользовательские теги записи.
+	Tags []Tag `json:"tags,omitempty"`
 }
 
 type WelfareFund int
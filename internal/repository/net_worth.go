@@ -0,0 +1,224 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+type NetWorthModel struct {
+	DB *mydb.Database
+}
+
+// rubleRate - текущий курс валюты колонки к рублю по таблице курсов; рубль и неизвестные валюты считаются по курсу 1.
+func rubleRate(column string) string {
+	return "COALESCE((SELECT rate_to_ruble FROM exchange_rates WHERE exchange_rates.currency_code = " + column + "), 1)"
+}
+
+// netWorthFund разделяет записи фонда благосостояния на ликвидные и неликвидные активы и кредиты.
+const netWorthFund = `
+	CASE WHEN type IS DISTINCT FROM 'loan' AND COALESCE(is_liquid, false) THEN amount_in_rubles ELSE 0 END,
+	CASE WHEN type IS DISTINCT FROM 'loan' AND NOT COALESCE(is_liquid, false) THEN amount_in_rubles ELSE 0 END,
+	CASE WHEN type = 'loan' THEN amount_in_rubles ELSE 0 END`
+
+func (m *NetWorthModel) BaseCurrency(userID string) (string, error) {
+	var currency string
+	err := m.DB.QueryRow("SELECT base_currency FROM users WHERE id = $1", userID).Scan(&currency)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", fmt.Errorf("%w: no user found with id %s", myerrors.ErrNotFound, userID)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	return currency, nil
+}
+
+func (m *NetWorthModel) SetBaseCurrency(userID, currency string) error {
+	result, err := m.DB.Exec("UPDATE users SET base_currency = $1 WHERE id = $2", currency, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: no user found with id %s", myerrors.ErrNotFound, userID)
+	}
+
+	return nil
+}
+
+// Current возвращает текущие составляющие чистого капитала в рублях по текущим курсам.
+// Запланированные записи фонда и записи в корзине не учитываются.
+func (m *NetWorthModel) Current(userID string) (*models.NetWorthSnapshot, error) {
+	var snapshot models.NetWorthSnapshot
+	err := m.DB.QueryRow(`SELECT COALESCE(SUM(state * `+rubleRate("a.currency")+`), 0)
+		FROM connected_accounts a WHERE a.user_id = $1`, userID).Scan(&snapshot.Accounts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	err = m.DB.QueryRow(`SELECT COALESCE(SUM(liquid), 0), COALESCE(SUM(illiquid), 0), COALESCE(SUM(loans), 0)
+		FROM (
+			SELECT `+netWorthFund+`
+			FROM wealth_fund_in_rubles
+			WHERE user_id = $1 AND NOT COALESCE(planned, false) AND date <= CURRENT_DATE
+		) f (liquid, illiquid, loans)`, userID).Scan(&snapshot.Liquid, &snapshot.Illiquid, &snapshot.Loans)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return &snapshot, nil
+}
+
+// Changes возвращает изменения составляющих чистого капитала по дням до сегодняшнего включительно в рублях
+// по курсам на дату операций. В остатках счетов учитываются фактические доходы и расходы по подключённым счетам
+// и разница курсов переводов.
+func (m *NetWorthModel) Changes(userID string) ([]models.NetWorthChange, error) {
+	rows, err := m.DB.Query(`WITH accounts AS (
+			SELECT account_number FROM connected_accounts WHERE user_id = $1
+		),
+		flows (date, accounts, liquid, illiquid, loans) AS (
+			SELECT date, amount_in_rubles, 0, 0, 0 FROM income_in_rubles
+			WHERE user_id = $1 AND NOT COALESCE(planned, false) AND connected_account IN (SELECT account_number FROM accounts)
+			UNION ALL
+			SELECT date, -amount_in_rubles, 0, 0, 0 FROM expense_in_rubles
+			WHERE user_id = $1 AND NOT COALESCE(planned, false) AND connected_account IN (SELECT account_number FROM accounts)
+			UNION ALL
+			SELECT t.date, t.to_amount * rate_to_ruble_on(t.to_currency_code, t.date) - t.amount * rate_to_ruble_on(t.currency_code, t.date), 0, 0, 0
+			FROM transfers t WHERE t.user_id = $1
+			UNION ALL
+			SELECT date, 0, `+netWorthFund+`
+			FROM wealth_fund_in_rubles
+			WHERE user_id = $1 AND NOT COALESCE(planned, false)
+		)
+		SELECT date, SUM(accounts), SUM(liquid), SUM(illiquid), SUM(loans)
+		FROM flows WHERE date <= CURRENT_DATE
+		GROUP BY date ORDER BY date`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	changes := make([]models.NetWorthChange, 0)
+	for rows.Next() {
+		var change models.NetWorthChange
+		var date time.Time
+		if err := rows.Scan(&date, &change.Accounts, &change.Liquid, &change.Illiquid, &change.Loans); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		change.Date = date.Format("2006-01-02")
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return changes, nil
+}
+
+// SaveSnapshots сохраняет снимки в одной транзакции, заменяя снимки за те же дни.
+func (m *NetWorthModel) SaveSnapshots(userID string, snapshots []models.NetWorthSnapshot) (err error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	stmt, err := tx.Prepare(`INSERT INTO net_worth_snapshots (user_id, date, accounts, liquid, illiquid, loans, net_worth)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (user_id, date) DO UPDATE SET accounts = EXCLUDED.accounts, liquid = EXCLUDED.liquid,
+			illiquid = EXCLUDED.illiquid, loans = EXCLUDED.loans, net_worth = EXCLUDED.net_worth, updated_at = NOW()`)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer stmt.Close()
+
+	for _, s := range snapshots {
+		_, err = stmt.Exec(userID, s.Date, s.Accounts, s.Liquid, s.Illiquid, s.Loans, s.NetWorth)
+		if err != nil {
+			return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+	}
+
+	return nil
+}
+
+// Snapshots возвращает снимки пользователя за [from, to] по возрастанию даты.
+func (m *NetWorthModel) Snapshots(userID string, from, to time.Time) ([]models.NetWorthSnapshot, error) {
+	rows, err := m.DB.Query(`SELECT date, accounts, liquid, illiquid, loans, net_worth
+		FROM net_worth_snapshots WHERE user_id = $1 AND date >= $2 AND date <= $3
+		ORDER BY date`, userID, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	snapshots := make([]models.NetWorthSnapshot, 0)
+	for rows.Next() {
+		var s models.NetWorthSnapshot
+		var date time.Time
+		if err := rows.Scan(&date, &s.Accounts, &s.Liquid, &s.Illiquid, &s.Loans, &s.NetWorth); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		s.Date = date.Format("2006-01-02")
+		snapshots = append(snapshots, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return snapshots, nil
+}
+
+// LastSnapshotDate возвращает дату последнего снимка пользователя или пустую строку, если снимков нет.
+func (m *NetWorthModel) LastSnapshotDate(userID string) (string, error) {
+	var date sql.NullTime
+	err := m.DB.QueryRow("SELECT MAX(date) FROM net_worth_snapshots WHERE user_id = $1", userID).Scan(&date)
+	if err != nil {
+		return "", fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if !date.Valid {
+		return "", nil
+	}
+	return date.Time.Format("2006-01-02"), nil
+}
+
+// UsersWithAssets возвращает пользователей, у которых есть подключённые счета или записи фонда благосостояния.
+func (m *NetWorthModel) UsersWithAssets() ([]string, error) {
+	rows, err := m.DB.Query(`SELECT u.id::text FROM users u
+		WHERE EXISTS (SELECT 1 FROM connected_accounts a WHERE a.user_id = u.id)
+			OR EXISTS (SELECT 1 FROM wealth_fund f WHERE f.user_id = u.id AND f.deleted_at IS NULL)
+		ORDER BY u.id`)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	users := make([]string, 0)
+	for rows.Next() {
+		var userID string
+		if err := rows.Scan(&userID); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		users = append(users, userID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return users, nil
+}
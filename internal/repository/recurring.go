@@ -0,0 +1,247 @@
+// Package repository provides basic financial repository functionality.
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"time"
+)
+
+type RecurringModel struct {
+	DB *mydb.Database
+}
+
+const recurringColumns = `id, user_id, kind, amount, planned, COALESCE(category::text, ''), counterparty, connected_account,
+	currency_code, frequency, repeat_every, weekday, week_of_month, day_of_month, start_date, end_date, occurrence_count,
+	materialized, next_date, paused`
+
+func scanRecurring(row rowScanner) (*models.RecurringSeries, error) {
+	var s models.RecurringSeries
+	var startDate time.Time
+	var endDate, nextDate sql.NullTime
+
+	err := row.Scan(&s.ID, &s.UserID, &s.Kind, &s.Amount, &s.Planned, &s.CategoryID, &s.Counterparty, &s.BankAccount,
+		&s.Currency, &s.Frequency, &s.Interval, &s.Weekday, &s.WeekOfMonth, &s.DayOfMonth, &startDate, &endDate, &s.Count,
+		&s.Materialized, &nextDate, &s.Paused)
+	if err != nil {
+		return nil, err
+	}
+
+	s.StartDate = startDate.Format("2006-01-02")
+	if endDate.Valid {
+		s.EndDate = endDate.Time.Format("2006-01-02")
+	}
+	if nextDate.Valid {
+		s.NextDate = nextDate.Time.Format("2006-01-02")
+	}
+
+	return &s, nil
+}
+
+// nullDate превращает пустую строку в NULL.
+func nullDate(date string) any {
+	if date == "" {
+		return nil
+	}
+	return date
+}
+
+func (m *RecurringModel) Create(series *models.RecurringSeries) (int64, error) {
+	var id int64
+	err := m.DB.QueryRow(`
+		INSERT INTO recurring_series (user_id, kind, amount, planned, category, counterparty, connected_account, currency_code,
+			frequency, repeat_every, weekday, week_of_month, day_of_month, start_date, end_date, occurrence_count,
+			materialized, next_date)
+		VALUES ($1, $2, $3, $4, NULLIF($5, '')::integer, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18)
+		RETURNING id`,
+		series.UserID, series.Kind, series.Amount, series.Planned, series.CategoryID, series.Counterparty, series.BankAccount,
+		series.Currency, series.Frequency, series.Interval, series.Weekday, series.WeekOfMonth, series.DayOfMonth,
+		series.StartDate, nullDate(series.EndDate), series.Count, series.Materialized, nullDate(series.NextDate)).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// Update изменяет шаблон, правило и состояние серии. Уже материализованные операции не затрагиваются.
+// next - дата следующего повторения, прочитанная перед изменением: если воркер успел материализовать серию,
+// изменение не применяется и возвращается ErrConflict.
+func (m *RecurringModel) Update(series *models.RecurringSeries, next string) error {
+	result, err := m.DB.Exec(`
+		UPDATE recurring_series SET
+			amount = $1,
+			planned = $2,
+			category = NULLIF($3, '')::integer,
+			counterparty = $4,
+			connected_account = $5,
+			currency_code = $6,
+			frequency = $7,
+			repeat_every = $8,
+			weekday = $9,
+			week_of_month = $10,
+			day_of_month = $11,
+			start_date = $12,
+			end_date = $13,
+			occurrence_count = $14,
+			materialized = $15,
+			next_date = $16,
+			paused = $17
+		WHERE id = $18 AND user_id = $19 AND next_date IS NOT DISTINCT FROM $20::date`,
+		series.Amount, series.Planned, series.CategoryID, series.Counterparty, series.BankAccount, series.Currency,
+		series.Frequency, series.Interval, series.Weekday, series.WeekOfMonth, series.DayOfMonth, series.StartDate,
+		nullDate(series.EndDate), series.Count, series.Materialized, nullDate(series.NextDate), series.Paused,
+		series.ID, series.UserID, nullDate(next))
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	if rowsAffected == 0 {
+		if _, err := m.Get(series.ID, series.UserID); err != nil {
+			return err
+		}
+		return fmt.Errorf("%w: recurring series %d was materialized concurrently, try again", myerrors.ErrConflict, series.ID)
+	}
+
+	return nil
+}
+
+func (m *RecurringModel) Delete(id int64, userID string) error {
+	result, err := m.DB.Exec("DELETE FROM recurring_series WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: no recurring series found with id %d for user %s", myerrors.ErrNotFound, id, userID)
+	}
+
+	return nil
+}
+
+func (m *RecurringModel) Get(id int64, userID string) (*models.RecurringSeries, error) {
+	row := m.DB.QueryRow("SELECT "+recurringColumns+" FROM recurring_series WHERE id = $1 AND user_id = $2", id, userID)
+	series, err := scanRecurring(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, fmt.Errorf("%w: no recurring series found with id %d for user %s", myerrors.ErrNotFound, id, userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	return series, nil
+}
+
+func (m *RecurringModel) ListByUserID(userID string) ([]models.RecurringSeries, error) {
+	rows, err := m.DB.Query("SELECT "+recurringColumns+" FROM recurring_series WHERE user_id = $1 ORDER BY id", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.RecurringSeries
+	for rows.Next() {
+		series, err := scanRecurring(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *series)
+	}
+
+	return list, rows.Err()
+}
+
+// ListDue возвращает активные серии, у которых следующее повторение наступило к date.
+func (m *RecurringModel) ListDue(date time.Time) ([]models.RecurringSeries, error) {
+	rows, err := m.DB.Query("SELECT "+recurringColumns+" FROM recurring_series WHERE paused = false AND next_date <= $1", date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var list []models.RecurringSeries
+	for rows.Next() {
+		series, err := scanRecurring(rows)
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, *series)
+	}
+
+	return list, rows.Err()
+}
+
+func (m *RecurringModel) AddSkip(id int64, date string) error {
+	_, err := m.DB.Exec("INSERT INTO recurring_skips (series_id, date) VALUES ($1, $2) ON CONFLICT DO NOTHING", id, date)
+	return err
+}
+
+// Skips возвращает множество пропущенных дат серии в формате 2006-01-02.
+func (m *RecurringModel) Skips(id int64) (map[string]bool, error) {
+	rows, err := m.DB.Query("SELECT date FROM recurring_skips WHERE series_id = $1", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	skips := make(map[string]bool)
+	for rows.Next() {
+		var date time.Time
+		if err := rows.Scan(&date); err != nil {
+			return nil, err
+		}
+		skips[date.Format("2006-01-02")] = true
+	}
+
+	return skips, rows.Err()
+}
+
+// Materialize создаёт операции серии expenses и incomes от имени actor и переносит next_date серии в одной
+// транзакции. Серия захватывается, только если её не успел продвинуть другой экземпляр приложения; иначе
+// возвращается false и ничего не создаётся. При любой ошибке транзакция откатывается, и серия остаётся
+// необработанной до следующего запуска.
+func (m *RecurringModel) Materialize(actor models.Actor, series *models.RecurringSeries, next string, materialized int,
+	expenses []models.Expense, incomes []models.Income) (claimed bool, err error) {
+	err = inTx(m.DB, func(tx *sql.Tx) error {
+		result, err := tx.Exec("UPDATE recurring_series SET next_date = $1, materialized = $2 WHERE id = $3 AND next_date = $4",
+			nullDate(next), materialized, series.ID, series.NextDate)
+		if err != nil {
+			return err
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return err
+		}
+		if claimed = rowsAffected == 1; !claimed {
+			return nil
+		}
+
+		for i := range expenses {
+			if _, err := insertExpense(tx, actor, &expenses[i]); err != nil {
+				return fmt.Errorf("occurrence %s: %w", expenses[i].Date, err)
+			}
+		}
+		for i := range incomes {
+			if _, err := insertIncome(tx, actor, &incomes[i]); err != nil {
+				return fmt.Errorf("occurrence %s: %w", incomes[i].Date, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return claimed, nil
+}
@@ -1,10 +1,13 @@
 package repository
 
 import (
+	"database/sql"
+	"fmt"
 	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
 	"time"
 
 	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
 	"github.com/wachrusz/Back-End-API/internal/repository/models"
 )
 
@@ -17,6 +20,22 @@ type Models struct {
 	Incomes           IncomeRepo
 	WealthFunds       WealthFundRepo
 	Subscriptions     SubscriptionRepo
+	Recurring         RecurringRepo
+	Imports           ImportRepo
+	Transfers         TransferRepo
+	Attachments       AttachmentRepo
+	Tags              TagRepo
+	Search            SearchRepo
+	Batch             BatchRepo
+	Trash             TrashRepo
+	History           HistoryRepo
+	Rules             RuleRepo
+	Duplicates        DuplicateRepo
+	Budgets           BudgetRepo
+	Envelopes         EnvelopeRepo
+	Forecast          ForecastRepo
+	Aggregation       AggregationRepo
+	NetWorth          NetWorthRepo
 }
 
 func New(db *mydb.Database) *Models {
@@ -28,51 +47,100 @@ func New(db *mydb.Database) *Models {
 		Incomes:           &IncomeModel{db},
 		WealthFunds:       &WealthFundModel{db},
 		Subscriptions:     &SubscriptionModel{db},
+		Recurring:         &RecurringModel{db},
+		Imports:           &ImportModel{db},
+		Transfers:         &TransferModel{db},
+		Attachments:       &AttachmentModel{db},
+		Tags:              &TagModel{db},
+		Search:            &SearchModel{db},
+		Batch:             &BatchModel{db},
+		Trash:             &TrashModel{db},
+		History:           &HistoryModel{db},
+		Rules:             &RuleModel{db},
+		Duplicates:        &DuplicateModel{db},
+		Budgets:           &BudgetModel{db},
+		Envelopes:         &EnvelopeModel{db},
+		Forecast:          &ForecastModel{db},
+		Aggregation:       &AggregationModel{db},
+		NetWorth:          &NetWorthModel{db},
 	}
 }
 
+// querier позволяет выполнять одни и те же запросы как через *mydb.Database, так и внутри *sql.Tx.
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// inTx выполняет fn в транзакции и откатывает её, если fn вернула ошибку.
+func inTx(db *mydb.Database, fn func(tx *sql.Tx) error) (err error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	return fn(tx)
+}
+
+// rowScanner объединяет *sql.Row и *sql.Rows.
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
 type AccountRepo interface {
-	Create(account *models.ConnectedAccount) (int64, error)
-	Update(account *models.ConnectedAccount) error
-	Delete(id, userID string) error
+	Create(actor models.Actor, account *models.ConnectedAccount) (int64, error)
+	Update(actor models.Actor, account *models.ConnectedAccount) error
+	Delete(actor models.Actor, id, userID string) error
+	Get(id, userID string) (*models.ConnectedAccount, error)
 }
 
 type ExpenseRepo interface {
-	Create(expense *models.Expense) (int64, error)
-	Update(expense *models.Expense) error
-	Delete(id, userID string) error
+	Create(actor models.Actor, expense *models.Expense) (int64, error)
+	Update(actor models.Actor, expense *models.Expense) error
+	Delete(actor models.Actor, id, userID string) error
 	ListByUserID(userID string) ([]models.Expense, error)
+	List(filter *models.TransactionFilter) ([]models.Expense, *jsonresponse.Metadata, error)
+	SetSplits(actor models.Actor, expenseID, userID string, splits []models.ExpenseSplit) error
 	GetForMonth(userID string, month time.Month, year int) (float64, float64, error)
 	GetMonthlyIncrease(userID string) (int, int, error)
 }
 
 type GoalRepo interface {
-	Create(goal *models.Goal) (id int64, err error)
-	Update(goal *models.Goal) error
-	Delete(id int64, userID int64) error
+	Create(actor models.Actor, goal *models.Goal) (id int64, err error)
+	Update(actor models.Actor, goal *models.Goal) error
+	Delete(actor models.Actor, id int64, userID int64) error
 	ListByUserID(userID int64) ([]models.Goal, error)
-	Details(id int64, userID int64) (*models.GoalDetails, error)
+	Details(actor models.Actor, id int64, userID int64) (*models.GoalDetails, error)
 	TrackerInfo(userID int64, limitStr, offsetStr int) ([]*models.GoalTrackerInfo, *jsonresponse.Metadata, error)
 }
 
 type GoalTransactionRepo interface {
-	Create(transaction *models.GoalTransaction, userID int64) (id int64, err error)
+	Create(actor models.Actor, transaction *models.GoalTransaction, userID int64) (id int64, err error)
 }
 
 type IncomeRepo interface {
-	Create(income *models.Income) (int64, error)
-	Update(income *models.Income) error
-	Delete(id, userID string) error
+	Create(actor models.Actor, income *models.Income) (int64, error)
+	Update(actor models.Actor, income *models.Income) error
+	Delete(actor models.Actor, id, userID string) error
 	ListByUserID(userID string) ([]models.Income, error)
+	List(filter *models.TransactionFilter) ([]models.Income, *jsonresponse.Metadata, error)
 	ListByMonth(userID string, month time.Month, year int) (float64, float64, error)
 	GetMonthlyIncomeIncrease(userID string) (int, int, error)
 }
 
 type WealthFundRepo interface {
-	Create(wealthFund *models.WealthFund) (int64, error)
-	Update(wealthFund *models.WealthFund) error
-	Delete(id, userID string) error
+	Create(actor models.Actor, wealthFund *models.WealthFund) (int64, error)
+	Update(actor models.Actor, wealthFund *models.WealthFund) error
+	Delete(actor models.Actor, id, userID string) error
 	ListByUserID(userID string) ([]models.WealthFund, error)
+	List(filter *models.TransactionFilter) ([]models.WealthFund, *jsonresponse.Metadata, error)
 }
 
 type SubscriptionRepo interface {
@@ -80,3 +148,129 @@ type SubscriptionRepo interface {
 	Update(subscription *models.Subscription) error
 	Delete(id, userID string) error
 }
+
+type RecurringRepo interface {
+	Create(series *models.RecurringSeries) (int64, error)
+	Update(series *models.RecurringSeries, next string) error
+	Delete(id int64, userID string) error
+	Get(id int64, userID string) (*models.RecurringSeries, error)
+	ListByUserID(userID string) ([]models.RecurringSeries, error)
+	ListDue(date time.Time) ([]models.RecurringSeries, error)
+	AddSkip(id int64, date string) error
+	Skips(id int64) (map[string]bool, error)
+	Materialize(actor models.Actor, series *models.RecurringSeries, next string, materialized int, expenses []models.Expense, incomes []models.Income) (bool, error)
+}
+
+type ImportRepo interface {
+	CreateProfile(profile *models.ImportProfile) (int64, error)
+	UpdateProfile(profile *models.ImportProfile) error
+	DeleteProfile(id int64, userID string) error
+	GetProfile(id int64, userID string) (*models.ImportProfile, error)
+	ListProfiles(userID string) ([]models.ImportProfile, error)
+	Categories(userID string) (expense map[string]string, income map[string]string, err error)
+	ExternalIDs(userID, bankAccount string) (map[string]bool, error)
+	Commit(actor models.Actor, userID string, rows []*models.ImportRow, balance *models.AccountBalance) error
+}
+
+type TransferRepo interface {
+	Create(actor models.Actor, transfer *models.Transfer) (int64, error)
+	Delete(actor models.Actor, id int64, userID string) error
+	ListByUserID(userID string) ([]models.Transfer, error)
+}
+
+type AttachmentRepo interface {
+	Create(attachment *models.Attachment, data, thumbnail []byte, quota int64) (int64, error)
+	ListByOwner(userID, ownerType, ownerID string) ([]models.Attachment, error)
+	Content(id int64, userID string, thumbnail bool) (*models.Attachment, []byte, error)
+	Delete(id int64, userID string) error
+	Usage(userID string) (int64, error)
+}
+
+type TagRepo interface {
+	Create(tag *models.Tag) (int64, error)
+	Update(tag *models.Tag) error
+	Delete(id, userID string) error
+	ListByUserID(userID string) ([]models.Tag, error)
+	SetTags(kind, recordID, userID string, tagIDs []string) error
+	Totals(tagID, userID, dateFrom, dateTo string) ([]models.TagTotal, error)
+}
+
+type SearchRepo interface {
+	Search(query *models.SearchQuery) ([]models.SearchResult, error)
+}
+
+type BatchRepo interface {
+	Apply(actor models.Actor, userID string, ops []models.BatchOperation, atomic bool) (ids []string, errs []error, committed bool, err error)
+}
+
+type TrashRepo interface {
+	List(userID string) ([]models.TrashItem, error)
+	Restore(actor models.Actor, kind, id, userID string) error
+	Purge(actor models.Actor, kind, id, userID string) error
+	PurgeExpired(before time.Time) (int64, error)
+}
+
+type HistoryRepo interface {
+	List(filter *models.HistoryFilter) ([]models.HistoryEntry, error)
+}
+
+type RuleRepo interface {
+	Create(rule *models.CategorizationRule) (int64, error)
+	Update(rule *models.CategorizationRule) error
+	Delete(id, userID string) error
+	ListByUserID(userID string) ([]models.CategorizationRule, error)
+	Transactions(filter *models.RuleApplyFilter, limit int) ([]models.RuleTarget, error)
+	ApplyChanges(actor models.Actor, userID string, changes []models.RuleChange) error
+}
+
+type DuplicateRepo interface {
+	Records(filter *models.DuplicateFilter, limit int) ([]models.DuplicateRecord, error)
+	Dismissals(userID string) ([]models.DuplicateDismissal, error)
+	Dismiss(userID, kind, firstID, secondID string) error
+	Merge(actor models.Actor, userID, kind, keepID, duplicateID string) error
+}
+
+type BudgetRepo interface {
+	Create(budget *models.Budget) (int64, error)
+	Update(budget *models.Budget) error
+	Delete(id, userID string) error
+	ListByUserID(userID string) ([]models.Budget, error)
+	Spending(userID string, from, to time.Time) ([]models.BudgetSpending, error)
+	UsersWithBudgets() ([]string, error)
+	UserEmail(userID string) (string, error)
+	MarkAlert(budgetID, period string, threshold int) (bool, error)
+	UnmarkAlert(budgetID, period string, threshold int) error
+}
+
+type EnvelopeRepo interface {
+	Create(envelope *models.Envelope) (int64, error)
+	Delete(id, userID string) error
+	ListByUserID(userID string) ([]models.Envelope, error)
+	CreateMovement(movement *models.EnvelopeMovement) (int64, error)
+	Movements(userID string, month time.Time) ([]models.EnvelopeMovement, error)
+	Activity(userID string, month time.Time) (*models.EnvelopeActivity, error)
+	Closing(userID string, month time.Time) (*models.EnvelopeClosing, error)
+	HasClosingBefore(userID string, month time.Time) (bool, error)
+	Close(userID string, closing *models.EnvelopeClosing) error
+}
+
+type ForecastRepo interface {
+	Accounts(userID string) ([]models.ConnectedAccount, error)
+	Planned(userID string, from, to time.Time) ([]models.ForecastFlow, error)
+	WeekdaySpend(userID string, from, to time.Time) ([]models.ForecastSpend, error)
+}
+
+type AggregationRepo interface {
+	Aggregate(filter *models.AggregationFilter) ([]models.AggregationRow, error)
+}
+
+type NetWorthRepo interface {
+	BaseCurrency(userID string) (string, error)
+	SetBaseCurrency(userID, currency string) error
+	Current(userID string) (*models.NetWorthSnapshot, error)
+	Changes(userID string) ([]models.NetWorthChange, error)
+	SaveSnapshots(userID string, snapshots []models.NetWorthSnapshot) error
+	Snapshots(userID string, from, to time.Time) ([]models.NetWorthSnapshot, error)
+	LastSnapshotDate(userID string) (string, error)
+	UsersWithAssets() ([]string, error)
+}
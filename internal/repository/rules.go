@@ -0,0 +1,252 @@
+// Package repository provides basic financial repository functionality.
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+type RuleModel struct {
+	DB *mydb.Database
+}
+
+const ruleColumns = "id, name, kind, priority, enabled, payee, pattern, amount_min, amount_max, bank_account, currency_code, COALESCE(category_id::text, ''), tag_ids, planned"
+
+func scanRule(row rowScanner) (*models.CategorizationRule, error) {
+	var rule models.CategorizationRule
+	var amountMin, amountMax sql.NullFloat64
+	var planned sql.NullBool
+	var tags pq.StringArray
+	err := row.Scan(&rule.ID, &rule.Name, &rule.Kind, &rule.Priority, &rule.Enabled, &rule.Payee, &rule.Pattern,
+		&amountMin, &amountMax, &rule.BankAccount, &rule.Currency, &rule.CategoryID, &tags, &planned)
+	if err != nil {
+		return nil, err
+	}
+	if amountMin.Valid {
+		rule.AmountMin = &amountMin.Float64
+	}
+	if amountMax.Valid {
+		rule.AmountMax = &amountMax.Float64
+	}
+	if planned.Valid {
+		rule.Planned = &planned.Bool
+	}
+	rule.TagIDs = tags
+	return &rule, nil
+}
+
+// ruleTags приводит пустой список тегов к пустому массиву, а не к NULL.
+func ruleTags(tagIDs []string) any {
+	if tagIDs == nil {
+		tagIDs = []string{}
+	}
+	return pq.Array(tagIDs)
+}
+
+func (m *RuleModel) Create(rule *models.CategorizationRule) (int64, error) {
+	var id int64
+	err := m.DB.QueryRow(`INSERT INTO categorization_rules (user_id, name, kind, priority, enabled, payee, pattern, amount_min, amount_max,
+			bank_account, currency_code, category_id, tag_ids, planned)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, NULLIF($12, '')::integer, $13::int[], $14) RETURNING id`,
+		rule.UserID, rule.Name, rule.Kind, rule.Priority, rule.Enabled, rule.Payee, rule.Pattern, rule.AmountMin, rule.AmountMax,
+		rule.BankAccount, rule.Currency, rule.CategoryID, ruleTags(rule.TagIDs), rule.Planned).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	return id, nil
+}
+
+func (m *RuleModel) Update(rule *models.CategorizationRule) error {
+	result, err := m.DB.Exec(`UPDATE categorization_rules SET name = $1, kind = $2, priority = $3, enabled = $4, payee = $5, pattern = $6,
+			amount_min = $7, amount_max = $8, bank_account = $9, currency_code = $10, category_id = NULLIF($11, '')::integer,
+			tag_ids = $12::int[], planned = $13
+		WHERE id = $14 AND user_id = $15`,
+		rule.Name, rule.Kind, rule.Priority, rule.Enabled, rule.Payee, rule.Pattern, rule.AmountMin, rule.AmountMax,
+		rule.BankAccount, rule.Currency, rule.CategoryID, ruleTags(rule.TagIDs), rule.Planned, rule.ID, rule.UserID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: no rule found with id %s for user %s", myerrors.ErrNotFound, rule.ID, rule.UserID)
+	}
+
+	return nil
+}
+
+func (m *RuleModel) Delete(id, userID string) error {
+	result, err := m.DB.Exec("DELETE FROM categorization_rules WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: no rule found with id %s for user %s", myerrors.ErrNotFound, id, userID)
+	}
+
+	return nil
+}
+
+// ListByUserID возвращает правила пользователя в порядке применения.
+func (m *RuleModel) ListByUserID(userID string) ([]models.CategorizationRule, error) {
+	rows, err := m.DB.Query("SELECT "+ruleColumns+" FROM categorization_rules WHERE user_id = $1 ORDER BY priority, id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	rules := make([]models.CategorizationRule, 0)
+	for rows.Next() {
+		rule, err := scanRule(rows)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		rule.UserID = userID
+		rules = append(rules, *rule)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return rules, nil
+}
+
+// Transactions возвращает прошлые расходы и доходы пользователя для повторного применения правил,
+// но не больше limit операций. Разделённые расходы пропускаются: категории заданы у их частей.
+func (m *RuleModel) Transactions(filter *models.RuleApplyFilter, limit int) ([]models.RuleTarget, error) {
+	args := []any{filter.UserID}
+	period := ""
+	if filter.DateFrom != "" {
+		args = append(args, filter.DateFrom)
+		period += fmt.Sprintf(" AND date >= $%d", len(args))
+	}
+	if filter.DateTo != "" {
+		args = append(args, filter.DateTo)
+		period += fmt.Sprintf(" AND date <= $%d", len(args))
+	}
+
+	var parts []string
+	if filter.Kind == "" || filter.Kind == models.KindExpense {
+		parts = append(parts, `SELECT 'expense' AS kind, id, date, amount, COALESCE(sent_to, ''), COALESCE(connected_account, ''), currency_code, category::text, planned
+			FROM expense e WHERE user_id = $1 AND deleted_at IS NULL`+period+`
+			AND NOT EXISTS (SELECT 1 FROM expense_splits s WHERE s.expense_id = e.id)`)
+	}
+	if filter.Kind == "" || filter.Kind == models.KindIncome {
+		parts = append(parts, `SELECT 'income' AS kind, id, date, amount, COALESCE(sender, ''), COALESCE(connected_account, ''), currency_code, category::text, planned
+			FROM income WHERE user_id = $1 AND deleted_at IS NULL`+period)
+	}
+
+	query := ""
+	for i, part := range parts {
+		if i > 0 {
+			query += " UNION ALL "
+		}
+		query += part
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY date DESC, kind, id DESC LIMIT $%d", len(args))
+
+	rows, err := m.DB.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	targets := make([]models.RuleTarget, 0)
+	ids := map[string][]string{}
+	for rows.Next() {
+		var t models.RuleTarget
+		var date time.Time
+		if err := rows.Scan(&t.Kind, &t.ID, &date, &t.Amount, &t.Payee, &t.BankAccount, &t.Currency, &t.CategoryID, &t.Planned); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		t.Date = date.Format("2006-01-02")
+		targets = append(targets, t)
+		ids[t.Kind] = append(ids[t.Kind], t.ID)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	for kind, kindIDs := range ids {
+		tags, err := tagsByRecord(m.DB, kind, kindIDs)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		for i := range targets {
+			if targets[i].Kind == kind {
+				targets[i].TagIDs = tagIDs(tags[targets[i].ID])
+			}
+		}
+	}
+
+	return targets, nil
+}
+
+// ApplyChanges сохраняет изменения операций от повторного применения правил в одной транзакции
+// и записывает их в журнал.
+func (m *RuleModel) ApplyChanges(actor models.Actor, userID string, changes []models.RuleChange) (err error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	for _, change := range changes {
+		var table string
+		switch change.Kind {
+		case models.KindExpense:
+			table = "expense"
+		case models.KindIncome:
+			table = "income"
+		default:
+			return fmt.Errorf("%w: unknown kind %q", myerrors.ErrValidation, change.Kind)
+		}
+
+		before, err := snapshot(tx, change.Kind, change.ID, userID)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("UPDATE "+table+" SET category = $1, planned = $2 WHERE id = $3 AND user_id = $4 AND deleted_at IS NULL",
+			change.CategoryAfter, change.PlannedAfter, change.ID, userID)
+		if err != nil {
+			return fmt.Errorf("%w: %s %s: %v", myerrors.ErrInternal, change.Kind, change.ID, err)
+		}
+		if change.Kind == models.KindExpense && change.CategoryAfter != change.CategoryBefore {
+			// Архив операций хранит категорию расхода, поэтому она меняется вместе с расходом.
+			_, err = tx.Exec("UPDATE operations SET category = $1, operation_type = $1 WHERE expense_id = $2 AND user_id = $3",
+				change.CategoryAfter, change.ID, userID)
+			if err != nil {
+				return fmt.Errorf("%w: %s %s: %v", myerrors.ErrInternal, change.Kind, change.ID, err)
+			}
+		}
+		if err = addTags(tx, change.Kind, change.ID, userID, change.AddTagIDs); err != nil {
+			return fmt.Errorf("%w: %s %s: %v", myerrors.ErrInternal, change.Kind, change.ID, err)
+		}
+		if err = recordChange(tx, actor, change.Kind, change.ID, userID, models.ActionUpdate, before); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,94 @@
+// Package repository provides basic financial repository functionality.
+package repository
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+type SearchModel struct {
+	DB *mydb.Database
+}
+
+// searchQuery ищет расходы и доходы пользователя. Операция находится, если:
+//   - запрос совпадает по полнотекстовому поиску (русский и английский словари) с получателем/отправителем,
+//     описанием дохода, заметками частей расхода или названием категории;
+//   - запрос нечётко (по триграммам) совпадает с частью названия получателя/отправителя;
+//   - одно из чисел запроса равно сумме операции.
+//
+// Ранг складывается из ts_rank, сходства названия и точного совпадения суммы.
+const searchQuery = `
+WITH q AS (
+	SELECT websearch_to_tsquery('russian', $2) || websearch_to_tsquery('english', $2) AS ts
+),
+found AS (
+	SELECT 'expense' AS kind, e.id, e.amount, e.date, e.currency_code,
+		COALESCE(NULLIF(e.sent_to, 'blank'), '') AS payee,
+		COALESCE(c.name, '') AS category,
+		concat_ws(' ', NULLIF(e.sent_to, 'blank'), n.notes, c.name) AS body,
+		e.search_vector ||
+			to_tsvector('russian', concat_ws(' ', n.notes, c.name)) ||
+			to_tsvector('english', concat_ws(' ', n.notes, c.name)) AS doc
+	FROM expense e
+	LEFT JOIN expense_categories c ON c.id = e.category
+	LEFT JOIN LATERAL (
+		SELECT string_agg(note, ' ') AS notes FROM expense_splits WHERE expense_id = e.id
+	) n ON true
+	WHERE e.user_id = $1 AND e.deleted_at IS NULL AND $5 IN ('', 'expense')
+		AND ($6 = '' OR e.date >= $6::date) AND ($7 = '' OR e.date <= $7::date)
+
+	UNION ALL
+
+	SELECT 'income', i.id, i.amount, i.date, i.currency_code,
+		COALESCE(NULLIF(i.sender, 'blank'), ''),
+		COALESCE(c.name, ''),
+		concat_ws(' ', NULLIF(i.sender, 'blank'), NULLIF(i.description, 'blank'), c.name),
+		i.search_vector ||
+			to_tsvector('russian', COALESCE(c.name, '')) ||
+			to_tsvector('english', COALESCE(c.name, ''))
+	FROM income i
+	LEFT JOIN income_categories c ON c.id = i.category
+	WHERE i.user_id = $1 AND i.deleted_at IS NULL AND $5 IN ('', 'income')
+		AND ($6 = '' OR i.date >= $6::date) AND ($7 = '' OR i.date <= $7::date)
+)
+SELECT f.kind, f.id, f.amount, f.date, f.currency_code, f.payee, f.category,
+	ts_headline('russian', f.body, q.ts, 'StartSel=<b>, StopSel=</b>, MaxWords=20, MinWords=5') AS snippet,
+	ts_rank(f.doc, q.ts)
+		+ word_similarity($2, f.payee)
+		+ CASE WHEN round(f.amount, 2) = ANY($3::numeric[]) THEN 1 ELSE 0 END AS rank
+FROM found f, q
+WHERE f.doc @@ q.ts OR $2 <% f.payee OR round(f.amount, 2) = ANY($3::numeric[])
+ORDER BY rank DESC, f.date DESC, f.id DESC
+LIMIT $4`
+
+// Search возвращает операции пользователя, подходящие под запрос, в порядке убывания релевантности.
+func (m *SearchModel) Search(query *models.SearchQuery) ([]models.SearchResult, error) {
+	rows, err := m.DB.Query(searchQuery, query.UserID, query.Text, pq.Array(query.Amounts), query.Limit,
+		query.Kind, query.DateFrom, query.DateTo)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	results := make([]models.SearchResult, 0, query.Limit)
+	for rows.Next() {
+		var result models.SearchResult
+		var date time.Time
+		if err := rows.Scan(&result.Kind, &result.ID, &result.Amount, &date, &result.Currency, &result.Payee,
+			&result.Category, &result.Snippet, &result.Rank); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		result.Date = date.Format("2006-01-02")
+		results = append(results, result)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return results, nil
+}
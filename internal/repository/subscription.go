@@ -63,7 +63,7 @@ func (m *SubscriptionModel) Update(subscription *models.Subscription) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("%w: no subscription found with id %s for user %s", myerrors.ErrNotFound, subscription.ID, subscription.UserID)
+		return fmt.Errorf("%w: no subscription found with id %d for user %s", myerrors.ErrNotFound, subscription.ID, subscription.UserID)
 	}
 
 	return nil
@@ -0,0 +1,250 @@
+// Package repository provides basic financial repository functionality.
+package repository
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/lib/pq"
+	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+type TagModel struct {
+	DB *mydb.Database
+}
+
+// taggable описывает таблицу записей, к которым привязываются теги, и её таблицу связей.
+type taggable struct {
+	table    string
+	tagTable string
+	column   string
+}
+
+var taggables = map[string]taggable{
+	models.KindExpense:    {table: "expense", tagTable: "expense_tags", column: "expense_id"},
+	models.KindIncome:     {table: "income", tagTable: "income_tags", column: "income_id"},
+	models.KindWealthFund: {table: "wealth_fund", tagTable: "wealth_fund_tags", column: "wealth_fund_id"},
+}
+
+func lookupTaggable(kind string) (taggable, error) {
+	t, ok := taggables[kind]
+	if !ok {
+		return taggable{}, fmt.Errorf("%w: unknown kind %q, expected expense, income or wealth_fund", myerrors.ErrValidation, kind)
+	}
+	return t, nil
+}
+
+// tagErr переводит нарушение уникальности имени тега в ошибку валидации.
+func tagErr(err error, name string) error {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) && pqErr.Code == "23505" {
+		return fmt.Errorf("%w: tag %q already exists", myerrors.ErrValidation, name)
+	}
+	return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+}
+
+func (m *TagModel) Create(tag *models.Tag) (int64, error) {
+	var id int64
+	err := m.DB.QueryRow("INSERT INTO tags (user_id, name, color) VALUES ($1, $2, $3) RETURNING id",
+		tag.UserID, tag.Name, tag.Color).Scan(&id)
+	if err != nil {
+		return 0, tagErr(err, tag.Name)
+	}
+	return id, nil
+}
+
+func (m *TagModel) Update(tag *models.Tag) error {
+	result, err := m.DB.Exec("UPDATE tags SET name = $1, color = $2 WHERE id = $3 AND user_id = $4",
+		tag.Name, tag.Color, tag.ID, tag.UserID)
+	if err != nil {
+		return tagErr(err, tag.Name)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: no tag found with id %s for user %s", myerrors.ErrNotFound, tag.ID, tag.UserID)
+	}
+	return nil
+}
+
+// Delete удаляет тег. Связи с операциями удаляются каскадно, сами операции не меняются.
+func (m *TagModel) Delete(id, userID string) error {
+	result, err := m.DB.Exec("DELETE FROM tags WHERE id = $1 AND user_id = $2", id, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: no tag found with id %s for user %s", myerrors.ErrNotFound, id, userID)
+	}
+	return nil
+}
+
+func (m *TagModel) ListByUserID(userID string) ([]models.Tag, error) {
+	rows, err := m.DB.Query("SELECT id, user_id, name, color FROM tags WHERE user_id = $1 ORDER BY lower(name)", userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	var tags []models.Tag
+	for rows.Next() {
+		var tag models.Tag
+		if err := rows.Scan(&tag.ID, &tag.UserID, &tag.Name, &tag.Color); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		tags = append(tags, tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// SetTags заменяет теги записи указанного вида. И запись, и все теги должны принадлежать пользователю.
+func (m *TagModel) SetTags(kind, recordID, userID string, tagIDs []string) (err error) {
+	t, err := lookupTaggable(kind)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	var exists bool
+	err = tx.QueryRow("SELECT EXISTS (SELECT 1 FROM "+t.table+" WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL)", recordID, userID).Scan(&exists)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if !exists {
+		return fmt.Errorf("%w: no %s found with id %s for user %s", myerrors.ErrNotFound, kind, recordID, userID)
+	}
+
+	var owned int
+	err = tx.QueryRow("SELECT COUNT(*) FROM tags WHERE id = ANY($1::int[]) AND user_id = $2", pq.Array(tagIDs), userID).Scan(&owned)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if owned != len(tagIDs) {
+		return fmt.Errorf("%w: some tags were not found for user %s", myerrors.ErrNotFound, userID)
+	}
+
+	if _, err = tx.Exec("DELETE FROM "+t.tagTable+" WHERE "+t.column+" = $1", recordID); err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	_, err = tx.Exec("INSERT INTO "+t.tagTable+" ("+t.column+", tag_id) SELECT $1, unnest($2::int[])", recordID, pq.Array(tagIDs))
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return nil
+}
+
+// Totals возвращает суммы фактических операций с тегом по видам, месяцам и валютам.
+// Пустые границы периода не ограничивают выборку.
+func (m *TagModel) Totals(tagID, userID, dateFrom, dateTo string) ([]models.TagTotal, error) {
+	var exists bool
+	err := m.DB.QueryRow("SELECT EXISTS (SELECT 1 FROM tags WHERE id = $1 AND user_id = $2)", tagID, userID).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if !exists {
+		return nil, fmt.Errorf("%w: no tag found with id %s for user %s", myerrors.ErrNotFound, tagID, userID)
+	}
+
+	var totals []models.TagTotal
+	for _, kind := range []string{models.KindExpense, models.KindIncome, models.KindWealthFund} {
+		t := taggables[kind]
+		rows, err := m.DB.Query(`SELECT to_char(r.date, 'YYYY-MM-DD') AS day, r.currency_code, SUM(r.amount)
+			FROM `+t.table+` r JOIN `+t.tagTable+` rt ON rt.`+t.column+` = r.id
+			WHERE rt.tag_id = $1 AND r.user_id = $2 AND r.planned = false AND r.deleted_at IS NULL
+				AND ($3 = '' OR r.date >= $3::date) AND ($4 = '' OR r.date <= $4::date)
+			GROUP BY day, r.currency_code
+			ORDER BY day, r.currency_code`, tagID, userID, dateFrom, dateTo)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+
+		for rows.Next() {
+			total := models.TagTotal{Kind: kind}
+			if err := rows.Scan(&total.Date, &total.Currency, &total.Amount); err != nil {
+				rows.Close()
+				return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+			}
+			total.Month = total.Date[:7]
+			totals = append(totals, total)
+		}
+		rows.Close()
+		if err := rows.Err(); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+	}
+
+	return totals, nil
+}
+
+// tagsByRecord возвращает теги записей указанного вида, сгруппированные по id записи.
+func tagsByRecord(q *mydb.Database, kind string, ids []string) (map[string][]models.Tag, error) {
+	tags := make(map[string][]models.Tag)
+	if len(ids) == 0 {
+		return tags, nil
+	}
+	t := taggables[kind]
+
+	rows, err := q.Query(`SELECT rt.`+t.column+`, tg.id, tg.name, tg.color
+		FROM `+t.tagTable+` rt JOIN tags tg ON tg.id = rt.tag_id
+		WHERE rt.`+t.column+` = ANY($1::int[])
+		ORDER BY lower(tg.name)`, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var recordID string
+		var tag models.Tag
+		if err := rows.Scan(&recordID, &tag.ID, &tag.Name, &tag.Color); err != nil {
+			return nil, err
+		}
+		tags[recordID] = append(tags[recordID], tag)
+	}
+
+	return tags, rows.Err()
+}
+
+// addTags добавляет теги пользователя к записи, не трогая уже привязанные. Чужие теги пропускаются.
+func addTags(q querier, kind string, recordID any, userID string, tagIDs []string) error {
+	if len(tagIDs) == 0 {
+		return nil
+	}
+	t, err := lookupTaggable(kind)
+	if err != nil {
+		return err
+	}
+	_, err = q.Exec("INSERT INTO "+t.tagTable+" ("+t.column+", tag_id) SELECT $1, id FROM tags WHERE id = ANY($2::int[]) AND user_id = $3 ON CONFLICT DO NOTHING",
+		recordID, pq.Array(tagIDs), userID)
+	return err
+}
+
+// tagIDs возвращает идентификаторы тегов.
+func tagIDs(tags []models.Tag) []string {
+	ids := make([]string, 0, len(tags))
+	for _, tag := range tags {
+		ids = append(ids, tag.ID)
+	}
+	return ids
+}
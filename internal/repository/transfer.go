@@ -0,0 +1,142 @@
+// Package repository provides basic financial repository functionality.
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+type TransferModel struct {
+	DB *mydb.Database
+}
+
+const transferColumns = "id, user_id, from_account, to_account, amount, currency_code, to_amount, to_currency_code, rate, date, note"
+
+func scanTransfer(row rowScanner) (*models.Transfer, error) {
+	var t models.Transfer
+	var date time.Time
+	err := row.Scan(&t.ID, &t.UserID, &t.FromAccountID, &t.ToAccountID, &t.Amount, &t.Currency,
+		&t.ToAmount, &t.ToCurrency, &t.Rate, &date, &t.Note)
+	if err != nil {
+		return nil, err
+	}
+	t.Date = date.Format("2006-01-02")
+	return &t, nil
+}
+
+// Create сохраняет перевод и в той же транзакции меняет остатки обоих счетов.
+func (m *TransferModel) Create(actor models.Actor, transfer *models.Transfer) (id int64, err error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	err = tx.QueryRow(`INSERT INTO transfers (user_id, from_account, to_account, amount, currency_code, to_amount, to_currency_code, rate, date, note)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`,
+		transfer.UserID, transfer.FromAccountID, transfer.ToAccountID, transfer.Amount, transfer.Currency,
+		transfer.ToAmount, transfer.ToCurrency, transfer.Rate, transfer.Date, transfer.Note).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	if err = moveAccountState(tx, actor, transfer, 1); err != nil {
+		return 0, err
+	}
+
+	return id, nil
+}
+
+// Delete удаляет перевод и возвращает остатки счетов.
+func (m *TransferModel) Delete(actor models.Actor, id int64, userID string) (err error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	transfer, err := scanTransfer(tx.QueryRow("SELECT "+transferColumns+" FROM transfers WHERE id = $1 AND user_id = $2 FOR UPDATE", id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("%w: no transfer found with id %d for user %s", myerrors.ErrNotFound, id, userID)
+	}
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	if _, err = tx.Exec("DELETE FROM transfers WHERE id = $1", id); err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return moveAccountState(tx, actor, transfer, -1)
+}
+
+// moveAccountState списывает сумму перевода со счёта-источника и зачисляет на счёт-получатель
+// и записывает изменения обоих счетов в журнал. direction = -1 отменяет перевод.
+func moveAccountState(q querier, actor models.Actor, transfer *models.Transfer, direction float64) error {
+	for _, change := range []struct {
+		account string
+		delta   float64
+	}{
+		{transfer.FromAccountID, -transfer.Amount * direction},
+		{transfer.ToAccountID, transfer.ToAmount * direction},
+	} {
+		before, err := snapshot(q, models.KindAccount, change.account, transfer.UserID)
+		if err != nil {
+			return err
+		}
+		result, err := q.Exec("UPDATE connected_accounts SET state = state + $1, updated_at = NOW() WHERE id = $2 AND user_id = $3",
+			change.delta, change.account, transfer.UserID)
+		if err != nil {
+			return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("%w: no account found with id %s for user %s", myerrors.ErrNotFound, change.account, transfer.UserID)
+		}
+		err = recordChange(q, actor, models.KindAccount, change.account, transfer.UserID, models.ActionUpdate, before)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (m *TransferModel) ListByUserID(userID string) ([]models.Transfer, error) {
+	rows, err := m.DB.Query("SELECT "+transferColumns+" FROM transfers WHERE user_id = $1 ORDER BY date DESC, id DESC", userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var transfers []models.Transfer
+	for rows.Next() {
+		transfer, err := scanTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, *transfer)
+	}
+
+	return transfers, rows.Err()
+}
@@ -0,0 +1,217 @@
+// Package repository provides basic financial repository functionality.
+package repository
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+type TrashModel struct {
+	DB *mydb.Database
+}
+
+// trashable описывает таблицу, записи которой удаляются мягко, и зависимые записи,
+// которые нужно удалить перед окончательным удалением.
+type trashable struct {
+	table string
+	title string
+	date  string
+	// dependents - запросы, удаляющие зависимые записи; вместо %s подставляется подзапрос с id удаляемых записей.
+	dependents []string
+}
+
+var trashables = map[string]trashable{
+	models.KindExpense: {
+		table:      "expense",
+		title:      "sent_to",
+		date:       "date",
+		dependents: []string{"DELETE FROM operations WHERE expense_id IN (%s)"},
+	},
+	models.KindIncome: {
+		table:      "income",
+		title:      "sender",
+		date:       "date",
+		dependents: []string{"DELETE FROM operations WHERE income_id IN (%s)"},
+	},
+	models.KindWealthFund: {table: "wealth_fund", title: "''", date: "date"},
+	models.KindGoal: {
+		table:      "goals",
+		title:      "name",
+		date:       "COALESCE(start_date, deleted_at::date)",
+		dependents: []string{"DELETE FROM goal_transactions WHERE goal_id IN (%s)"},
+	},
+}
+
+// trashKinds задаёт порядок обхода таблиц, чтобы запросы по корзине были детерминированными.
+var trashKinds = []string{models.KindExpense, models.KindIncome, models.KindWealthFund, models.KindGoal}
+
+func lookupTrashable(kind string) (trashable, error) {
+	t, ok := trashables[kind]
+	if !ok {
+		return trashable{}, fmt.Errorf("%w: unknown kind %q, expected expense, income, wealth_fund or goal", myerrors.ErrValidation, kind)
+	}
+	return t, nil
+}
+
+// List возвращает записи из корзины пользователя, сначала удалённые последними.
+func (m *TrashModel) List(userID string) ([]models.TrashItem, error) {
+	query := ""
+	for i, kind := range trashKinds {
+		t := trashables[kind]
+		if i > 0 {
+			query += " UNION ALL "
+		}
+		query += fmt.Sprintf(`SELECT '%s', id::text, amount, COALESCE(currency_code, 'RUB'), %s, COALESCE(%s, ''), deleted_at
+			FROM %s WHERE user_id = $1 AND deleted_at IS NOT NULL`, kind, t.date, t.title, t.table)
+	}
+	query += " ORDER BY 7 DESC, 1, 2"
+
+	rows, err := m.DB.Query(query, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	items := make([]models.TrashItem, 0)
+	for rows.Next() {
+		var item models.TrashItem
+		var date, deletedAt time.Time
+		if err := rows.Scan(&item.Kind, &item.ID, &item.Amount, &item.Currency, &date, &item.Title, &deletedAt); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		item.Date = date.Format("2006-01-02")
+		item.DeletedAt = deletedAt.Format(time.RFC3339)
+		items = append(items, item)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return items, nil
+}
+
+// Restore возвращает запись из корзины и записывает восстановление в журнал.
+func (m *TrashModel) Restore(actor models.Actor, kind, id, userID string) error {
+	t, err := lookupTrashable(kind)
+	if err != nil {
+		return err
+	}
+
+	return inTx(m.DB, func(tx *sql.Tx) error {
+		before, err := snapshot(tx, kind, id, userID)
+		if err != nil {
+			return err
+		}
+
+		result, err := tx.Exec("UPDATE "+t.table+" SET deleted_at = NULL WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL", id, userID)
+		if err != nil {
+			return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		if rowsAffected == 0 {
+			return fmt.Errorf("%w: no %s found in trash with id %s for user %s", myerrors.ErrNotFound, kind, id, userID)
+		}
+
+		return recordChange(tx, actor, kind, id, userID, models.ActionRestore, before)
+	})
+}
+
+// Purge окончательно удаляет запись из корзины вместе с зависимыми записями и записывает удаление в журнал.
+func (m *TrashModel) Purge(actor models.Actor, kind, id, userID string) (err error) {
+	t, err := lookupTrashable(kind)
+	if err != nil {
+		return err
+	}
+
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	before, err := snapshot(tx, kind, id, userID)
+	if err != nil {
+		return err
+	}
+
+	selected := "SELECT id FROM " + t.table + " WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL"
+	for _, dependent := range t.dependents {
+		if _, err = tx.Exec(fmt.Sprintf(dependent, selected), id, userID); err != nil {
+			return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+	}
+
+	result, err := tx.Exec("DELETE FROM "+t.table+" WHERE id = $1 AND user_id = $2 AND deleted_at IS NOT NULL", id, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	if rowsAffected == 0 {
+		return fmt.Errorf("%w: no %s found in trash with id %s for user %s", myerrors.ErrNotFound, kind, id, userID)
+	}
+
+	return recordChange(tx, actor, kind, id, userID, models.ActionPurge, before)
+}
+
+// PurgeExpired окончательно удаляет записи всех пользователей, попавшие в корзину раньше before,
+// и записывает удаление в журнал их владельцев как изменение, сделанное сервером.
+// Возвращает количество удалённых записей.
+func (m *TrashModel) PurgeExpired(before time.Time) (purged int64, err error) {
+	tx, err := m.DB.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		} else {
+			err = tx.Commit()
+		}
+	}()
+
+	for _, kind := range trashKinds {
+		t := trashables[kind]
+		_, err = tx.Exec(`INSERT INTO record_history (user_id, record_type, record_id, action, before)
+			SELECT t.user_id, $2, t.id, $3, `+stateExpr(kind)+` FROM `+t.table+` t WHERE t.deleted_at < $1`,
+			before, kind, models.ActionPurge)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+
+		selected := "SELECT id FROM " + t.table + " WHERE deleted_at < $1"
+		for _, dependent := range t.dependents {
+			if _, err = tx.Exec(fmt.Sprintf(dependent, selected), before); err != nil {
+				return 0, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+			}
+		}
+
+		result, err := tx.Exec("DELETE FROM "+t.table+" WHERE deleted_at < $1", before)
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return 0, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		purged += rowsAffected
+	}
+
+	return purged, nil
+}
@@ -2,10 +2,13 @@
 package repository
 
 import (
+	"database/sql"
 	"fmt"
 	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
 	"github.com/wachrusz/Back-End-API/internal/myerrors"
 	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	jsonresponse "github.com/wachrusz/Back-End-API/pkg/json_response"
+	"strconv"
 	"time"
 )
 
@@ -13,23 +16,49 @@ type WealthFundModel struct {
 	DB *mydb.Database
 }
 
-func (m *WealthFundModel) Create(wealthFund *models.WealthFund) (int64, error) {
+func (m *WealthFundModel) Create(actor models.Actor, wealthFund *models.WealthFund) (id int64, err error) {
+	err = inTx(m.DB, func(tx *sql.Tx) error {
+		id, err = insertWealthFund(tx, actor, wealthFund)
+		return err
+	})
+	return id, err
+}
+
+// insertWealthFund создаёт запись фонда благосостояния в транзакции q и записывает создание в журнал.
+func insertWealthFund(q querier, actor models.Actor, wealthFund *models.WealthFund) (int64, error) {
 	parsedDate, err := time.Parse("2006-01-02", wealthFund.Date)
 	if err != nil {
 		return 0, err
 	}
 
 	var wealthFundID int64
-	err1 := m.DB.QueryRow("INSERT INTO wealth_fund (amount, date, planned, user_id, currency_code, connected_account, category_id) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id",
+	err1 := q.QueryRow("INSERT INTO wealth_fund (amount, date, planned, user_id, currency_code, connected_account, category_id) VALUES ($1, $2, $3, $4, $5, $6, $7) RETURNING id",
 		wealthFund.Amount, parsedDate, wealthFund.PlannedStatus, wealthFund.UserID, wealthFund.Currency, wealthFund.ConnectedAccount, wealthFund.CategoryID).Scan(&wealthFundID)
 	if err1 != nil {
 		return 0, err1
 	}
+
+	err = recordChange(q, actor, models.KindWealthFund, strconv.FormatInt(wealthFundID, 10), wealthFund.UserID, models.ActionCreate, nil)
+	if err != nil {
+		return 0, err
+	}
 	return wealthFundID, nil
 }
 
-func (m *WealthFundModel) Delete(id, userID string) error {
-	result, err := m.DB.Exec("DELETE FROM wealth_fund WHERE id = $1 AND user_id = $2", id, userID)
+func (m *WealthFundModel) Delete(actor models.Actor, id, userID string) error {
+	return inTx(m.DB, func(tx *sql.Tx) error {
+		return deleteWealthFund(tx, actor, id, userID)
+	})
+}
+
+// deleteWealthFund переносит запись фонда благосостояния в корзину в транзакции q и записывает удаление в журнал.
+func deleteWealthFund(q querier, actor models.Actor, id, userID string) error {
+	before, err := snapshot(q, models.KindWealthFund, id, userID)
+	if err != nil {
+		return err
+	}
+
+	result, err := q.Exec("UPDATE wealth_fund SET deleted_at = NOW() WHERE id = $1 AND user_id = $2 AND deleted_at IS NULL", id, userID)
 	if err != nil {
 		// Возвращаем обернутую ошибку, если запрос завершился с ошибкой
 		return fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
@@ -47,11 +76,23 @@ func (m *WealthFundModel) Delete(id, userID string) error {
 		return fmt.Errorf("%w: no wealth fund found with id %s for user %s", myerrors.ErrNotFound, id, userID)
 	}
 
-	return nil
+	return recordChange(q, actor, models.KindWealthFund, id, userID, models.ActionDelete, before)
 }
 
-func (m *WealthFundModel) Update(wealthFund *models.WealthFund) error {
-	q := `
+func (m *WealthFundModel) Update(actor models.Actor, wealthFund *models.WealthFund) error {
+	return inTx(m.DB, func(tx *sql.Tx) error {
+		return updateWealthFund(tx, actor, wealthFund)
+	})
+}
+
+// updateWealthFund обновляет запись фонда благосостояния в транзакции q и записывает изменение в журнал.
+func updateWealthFund(q querier, actor models.Actor, wealthFund *models.WealthFund) error {
+	before, err := snapshot(q, models.KindWealthFund, wealthFund.ID, wealthFund.UserID)
+	if err != nil {
+		return err
+	}
+
+	query := `
 		UPDATE wealth_fund SET 
 		   amount=$1, 
 		   date=$2, 
@@ -59,9 +100,9 @@ func (m *WealthFundModel) Update(wealthFund *models.WealthFund) error {
 		   currency_code=$4,
 		   connected_account=$5, 
            category_id=$6
-	   WHERE id=$7 AND user_id=$8`
+	   WHERE id=$7 AND user_id=$8 AND deleted_at IS NULL`
 
-	result, err := m.DB.Exec(q, wealthFund.Amount, wealthFund.Date, wealthFund.PlannedStatus, wealthFund.Currency,
+	result, err := q.Exec(query, wealthFund.Amount, wealthFund.Date, wealthFund.PlannedStatus, wealthFund.Currency,
 		wealthFund.ConnectedAccount, wealthFund.CategoryID, wealthFund.ID, wealthFund.UserID)
 
 	if err != nil {
@@ -75,14 +116,14 @@ func (m *WealthFundModel) Update(wealthFund *models.WealthFund) error {
 	}
 
 	if rowsAffected == 0 {
-		return fmt.Errorf("%w: no wealth func found with id %s for user %s", myerrors.ErrNotFound)
+		return fmt.Errorf("%w: no wealth fund found with id %s for user %s", myerrors.ErrNotFound, wealthFund.ID, wealthFund.UserID)
 	}
 
-	return nil
+	return recordChange(q, actor, models.KindWealthFund, wealthFund.ID, wealthFund.UserID, models.ActionUpdate, before)
 }
 
 func (m *WealthFundModel) ListByUserID(userID string) ([]models.WealthFund, error) {
-	rows, err := m.DB.Query("SELECT id, amount, date, planned, currency_code, connected_account FROM wealth_fund WHERE user_id = $1", userID)
+	rows, err := m.DB.Query("SELECT id, amount, date, planned, currency_code, connected_account FROM wealth_fund WHERE user_id = $1 AND deleted_at IS NULL", userID)
 	if err != nil {
 		return nil, err
 	}
@@ -100,3 +141,74 @@ func (m *WealthFundModel) ListByUserID(userID string) ([]models.WealthFund, erro
 
 	return wealthFunds, nil
 }
+
+// List возвращает страницу записей фонда благосостояния пользователя по фильтру.
+func (m *WealthFundModel) List(filter *models.TransactionFilter) ([]models.WealthFund, *jsonresponse.Metadata, error) {
+	q := &listQuery{
+		table:          "wealth_fund",
+		kind:           models.KindWealthFund,
+		columns:        "id, amount, date, planned, currency_code, connected_account, category_id",
+		categoryColumn: "category_id",
+	}
+	q.filter(filter)
+
+	var total int
+	if err := m.DB.QueryRow(q.count(), q.args...).Scan(&total); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	query, err := q.page(filter)
+	if err != nil {
+		return nil, nil, err
+	}
+	rows, err := m.DB.Query(query, q.args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	wealthFunds := make([]models.WealthFund, 0, filter.Limit+1)
+	keys := make([]string, 0, filter.Limit+1)
+	for rows.Next() {
+		var wealthFund models.WealthFund
+		var date time.Time
+		var key string
+		var planned bool
+		if err := rows.Scan(&wealthFund.ID, &wealthFund.Amount, &date, &planned, &wealthFund.Currency,
+			&wealthFund.ConnectedAccount, &wealthFund.CategoryID, &key); err != nil {
+			return nil, nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		wealthFund.Date = date.Format("2006-01-02")
+		// Create пишет PlannedStatus в boolean-колонку как 0/1, поэтому true соответствует значению 1.
+		if planned {
+			wealthFund.PlannedStatus = 1
+		}
+		wealthFund.UserID = filter.UserID
+		wealthFunds = append(wealthFunds, wealthFund)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	meta := pageMetadata(filter, total, len(wealthFunds), func(i int) (string, string) {
+		return keys[i], wealthFunds[i].ID
+	})
+	if len(wealthFunds) > filter.Limit {
+		wealthFunds = wealthFunds[:filter.Limit]
+	}
+
+	ids := make([]string, len(wealthFunds))
+	for i := range wealthFunds {
+		ids[i] = wealthFunds[i].ID
+	}
+	tags, err := tagsByRecord(m.DB, models.KindWealthFund, ids)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	for i := range wealthFunds {
+		wealthFunds[i].Tags = tags[wealthFunds[i].ID]
+	}
+
+	return wealthFunds, meta, nil
+}
@@ -0,0 +1,280 @@
+// Package aggregation provides income and expense totals by time buckets and groups.
+package aggregation
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	repo "github.com/wachrusz/Back-End-API/internal/repository"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	// MaxBuckets - наибольшее число интервалов в одном ответе.
+	MaxBuckets = 1000
+	// defaultBuckets - сколько последних интервалов возвращается, если начало периода не указано.
+	defaultBuckets = 12
+)
+
+type Aggregation interface {
+	Aggregate(filter *models.AggregationFilter, currency, timezone string) (*models.Aggregation, error)
+}
+
+type RateSource interface {
+	RateToRuble(code string) (float64, bool)
+}
+
+type Service struct {
+	aggregation repo.AggregationRepo
+	rates       RateSource
+}
+
+func NewService(ar repo.AggregationRepo, rs RateSource) *Service {
+	return &Service{aggregation: ar, rates: rs}
+}
+
+// Aggregate считает итоги доходов или расходов по интервалам и группам в валюте currency (по умолчанию рубли):
+// операции пересчитываются в рубли по курсу на их дату, рубли в currency - по текущему курсу. Операции хранятся
+// с календарной датой, timezone (IANA, по умолчанию UTC) определяет сегодняшний день для периода по умолчанию:
+// последние 12 интервалов или 30 дней без разбивки на интервалы.
+// Пустые интервалы периода возвращаются с нулевыми итогами.
+func (s *Service) Aggregate(filter *models.AggregationFilter, currency, timezone string) (*models.Aggregation, error) {
+	if filter.Kind == "" {
+		filter.Kind = models.KindExpense
+	}
+	if filter.GroupBy == "" {
+		filter.GroupBy = models.GroupByCategory
+	}
+	if filter.Bucket == "" {
+		filter.Bucket = models.BucketMonth
+	}
+	if _, ok := bucketStep[filter.Bucket]; !ok && filter.Bucket != models.BucketNone {
+		return nil, fmt.Errorf("%w: unknown bucket %q", myerrors.ErrValidation, filter.Bucket)
+	}
+
+	currency = strings.ToUpper(currency)
+	if currency == "" {
+		currency = "RUB"
+	}
+	rate, ok := s.rates.RateToRuble(currency)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown currency %q", myerrors.ErrValidation, currency)
+	}
+
+	if timezone == "" {
+		timezone = "UTC"
+	}
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("%w: unknown timezone %q", myerrors.ErrValidation, timezone)
+	}
+
+	from, to, err := period(filter, time.Now().In(location))
+	if err != nil {
+		return nil, err
+	}
+	filter.From, filter.To = from.Format(dateLayout), to.Format(dateLayout)
+
+	starts := bucketStarts(filter.Bucket, from, to)
+	if len(starts) > MaxBuckets {
+		return nil, fmt.Errorf("%w: too many buckets, at most %d are allowed", myerrors.ErrValidation, MaxBuckets)
+	}
+
+	rows, err := s.aggregation.Aggregate(filter)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.Aggregation{
+		Kind:     filter.Kind,
+		GroupBy:  filter.GroupBy,
+		Bucket:   filter.Bucket,
+		From:     filter.From,
+		To:       filter.To,
+		Currency: currency,
+		Timezone: location.String(),
+		Total:    newBucket(from, to),
+		Buckets:  make([]models.AggregationBucket, len(starts)),
+	}
+
+	index := make(map[string]int, len(starts))
+	for i, start := range starts {
+		end := nextStart(filter.Bucket, start).AddDate(0, 0, -1)
+		result.Buckets[i] = newBucket(maxDate(start, from), minDate(end, to))
+		index[start.Format(dateLayout)] = i
+	}
+
+	for _, row := range rows {
+		target := &result.Total
+		if !row.AllBuckets {
+			i, ok := index[row.Bucket]
+			if !ok {
+				continue
+			}
+			target = &result.Buckets[i]
+		}
+
+		total := round(row.Amount / rate)
+		if row.AllGroups {
+			target.Total = total
+			target.Count = row.Count
+			continue
+		}
+		if filter.GroupBy == models.GroupByNone {
+			continue
+		}
+		target.Groups = append(target.Groups, models.AggregationGroup{
+			Key:   row.Key,
+			Name:  row.Name,
+			Total: total,
+			Count: row.Count,
+		})
+	}
+
+	finish(&result.Total)
+	for i := range result.Buckets {
+		finish(&result.Buckets[i])
+	}
+
+	return result, nil
+}
+
+// period возвращает первый и последний день периода агрегации.
+func period(filter *models.AggregationFilter, now time.Time) (time.Time, time.Time, error) {
+	to := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	if filter.To != "" {
+		parsed, err := time.Parse(dateLayout, filter.To)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid to: %v", myerrors.ErrValidation, err)
+		}
+		to = parsed
+	}
+
+	var from time.Time
+	switch {
+	case filter.From != "":
+		parsed, err := time.Parse(dateLayout, filter.From)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("%w: invalid from: %v", myerrors.ErrValidation, err)
+		}
+		from = parsed
+	case filter.Bucket == models.BucketNone:
+		from = to.AddDate(0, 0, -29)
+	default:
+		from = bucketStart(filter.Bucket, to)
+		for i := 1; i < defaultBuckets; i++ {
+			from = bucketStart(filter.Bucket, from.AddDate(0, 0, -1))
+		}
+	}
+
+	if to.Before(from) {
+		return time.Time{}, time.Time{}, fmt.Errorf("%w: to is before from", myerrors.ErrValidation)
+	}
+	return from, to, nil
+}
+
+// bucketStep - шаг между началами интервалов в годах, месяцах и днях.
+var bucketStep = map[string][3]int{
+	models.BucketDay:     {0, 0, 1},
+	models.BucketWeek:    {0, 0, 7},
+	models.BucketMonth:   {0, 1, 0},
+	models.BucketQuarter: {0, 3, 0},
+	models.BucketYear:    {1, 0, 0},
+}
+
+// bucketStart возвращает начало интервала, в который попадает date, так же, как date_trunc в базе.
+func bucketStart(bucket string, date time.Time) time.Time {
+	switch bucket {
+	case models.BucketWeek:
+		return date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+	case models.BucketMonth:
+		return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+	case models.BucketQuarter:
+		return time.Date(date.Year(), (date.Month()-1)/3*3+1, 1, 0, 0, 0, 0, time.UTC)
+	case models.BucketYear:
+		return time.Date(date.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return date
+}
+
+// nextStart возвращает начало следующего интервала. Для агрегации без интервалов следующего интервала нет.
+func nextStart(bucket string, start time.Time) time.Time {
+	step, ok := bucketStep[bucket]
+	if !ok {
+		return time.Date(9999, time.December, 31, 0, 0, 0, 0, time.UTC)
+	}
+	return start.AddDate(step[0], step[1], step[2])
+}
+
+// bucketStarts возвращает начала интервалов, пересекающихся с периодом. Без интервалов
+// весь период - один интервал с началом from, как и в базе.
+func bucketStarts(bucket string, from, to time.Time) []time.Time {
+	if bucket == models.BucketNone {
+		return []time.Time{from}
+	}
+	var starts []time.Time
+	for start := bucketStart(bucket, from); !start.After(to); start = nextStart(bucket, start) {
+		starts = append(starts, start)
+		if len(starts) > MaxBuckets {
+			break
+		}
+	}
+	return starts
+}
+
+func newBucket(start, end time.Time) models.AggregationBucket {
+	return models.AggregationBucket{
+		Start:  start.Format(dateLayout),
+		End:    end.Format(dateLayout),
+		Groups: make([]models.AggregationGroup, 0),
+	}
+}
+
+// finish считает средние и доли групп и сортирует группы по убыванию итога.
+func finish(bucket *models.AggregationBucket) {
+	bucket.Average = average(bucket.Total, bucket.Count)
+	for i := range bucket.Groups {
+		group := &bucket.Groups[i]
+		group.Average = average(group.Total, group.Count)
+		if bucket.Total != 0 {
+			group.Share = round(group.Total / bucket.Total * 100)
+		}
+	}
+	sort.SliceStable(bucket.Groups, func(i, j int) bool {
+		if bucket.Groups[i].Total != bucket.Groups[j].Total {
+			return bucket.Groups[i].Total > bucket.Groups[j].Total
+		}
+		return bucket.Groups[i].Name < bucket.Groups[j].Name
+	})
+}
+
+func average(total float64, count int) float64 {
+	if count == 0 {
+		return 0
+	}
+	return round(total / float64(count))
+}
+
+func maxDate(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minDate(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,160 @@
+package aggregation
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+func date(s string) time.Time {
+	d, err := time.Parse(dateLayout, s)
+	if err != nil {
+		panic(err)
+	}
+	return d
+}
+
+func TestBucketStarts(t *testing.T) {
+	tests := []struct {
+		bucket   string
+		from, to string
+		want     []string
+	}{
+		{bucket: models.BucketNone, from: "2024-01-03", to: "2024-03-01", want: []string{"2024-01-03"}},
+		{bucket: models.BucketDay, from: "2024-02-28", to: "2024-03-01", want: []string{"2024-02-28", "2024-02-29", "2024-03-01"}},
+		{bucket: models.BucketWeek, from: "2024-01-03", to: "2024-01-15", want: []string{"2024-01-01", "2024-01-08", "2024-01-15"}},
+		{bucket: models.BucketWeek, from: "2024-01-07", to: "2024-01-07", want: []string{"2024-01-01"}},
+		{bucket: models.BucketMonth, from: "2024-01-31", to: "2024-03-01", want: []string{"2024-01-01", "2024-02-01", "2024-03-01"}},
+		{bucket: models.BucketQuarter, from: "2024-02-10", to: "2024-07-01", want: []string{"2024-01-01", "2024-04-01", "2024-07-01"}},
+		{bucket: models.BucketYear, from: "2023-06-01", to: "2024-01-01", want: []string{"2023-01-01", "2024-01-01"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.bucket+" "+tt.from, func(t *testing.T) {
+			starts := bucketStarts(tt.bucket, date(tt.from), date(tt.to))
+			got := make([]string, len(starts))
+			for i, s := range starts {
+				got[i] = s.Format(dateLayout)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("bucketStarts = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("bucketStarts = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestBucketStartsLimit(t *testing.T) {
+	starts := bucketStarts(models.BucketDay, date("2000-01-01"), date("2024-01-01"))
+	if len(starts) != MaxBuckets+1 {
+		t.Errorf("got %d starts, want the walk to stop at %d", len(starts), MaxBuckets+1)
+	}
+}
+
+func TestPeriod(t *testing.T) {
+	now := time.Date(2024, time.May, 15, 10, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		filter   models.AggregationFilter
+		from, to string
+		wantErr  bool
+	}{
+		{name: "explicit", filter: models.AggregationFilter{Bucket: models.BucketMonth, From: "2024-01-10", To: "2024-02-20"}, from: "2024-01-10", to: "2024-02-20"},
+		{name: "default months", filter: models.AggregationFilter{Bucket: models.BucketMonth}, from: "2023-06-01", to: "2024-05-15"},
+		{name: "default weeks", filter: models.AggregationFilter{Bucket: models.BucketWeek}, from: "2024-02-26", to: "2024-05-15"},
+		{name: "default quarters from to", filter: models.AggregationFilter{Bucket: models.BucketQuarter, To: "2024-02-10"}, from: "2021-04-01", to: "2024-02-10"},
+		{name: "default without buckets", filter: models.AggregationFilter{Bucket: models.BucketNone}, from: "2024-04-16", to: "2024-05-15"},
+		{name: "invalid from", filter: models.AggregationFilter{Bucket: models.BucketMonth, From: "10.01.2024"}, wantErr: true},
+		{name: "to before from", filter: models.AggregationFilter{Bucket: models.BucketMonth, From: "2024-02-01", To: "2024-01-01"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			from, to, err := period(&tt.filter, now)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("period = %s..%s, want error", from, to)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("period: %v", err)
+			}
+			if got := from.Format(dateLayout) + ".." + to.Format(dateLayout); got != tt.from+".."+tt.to {
+				t.Errorf("period = %s, want %s..%s", got, tt.from, tt.to)
+			}
+		})
+	}
+}
+
+type aggregationRepo struct {
+	rows []models.AggregationRow
+}
+
+func (r *aggregationRepo) Aggregate(*models.AggregationFilter) ([]models.AggregationRow, error) {
+	return r.rows, nil
+}
+
+type rates map[string]float64
+
+func (r rates) RateToRuble(code string) (float64, bool) {
+	rate, ok := r[code]
+	return rate, ok
+}
+
+func TestAggregateSharesByTag(t *testing.T) {
+	// Расход 100 с двумя тегами входит в группы обоих тегов, но в итог интервала - один раз.
+	repo := &aggregationRepo{rows: []models.AggregationRow{
+		{Bucket: "2024-03-01", Key: "1", Name: "first", Amount: 100, Count: 1},
+		{Bucket: "2024-03-01", Key: "2", Name: "second", Amount: 100, Count: 1},
+		{Bucket: "2024-03-01", Key: "", Name: "", Amount: 50, Count: 1},
+		{Bucket: "2024-03-01", AllGroups: true, Amount: 150, Count: 2},
+		{AllBuckets: true, AllGroups: true, Amount: 150, Count: 2},
+	}}
+	s := NewService(repo, rates{"RUB": 1})
+
+	result, err := s.Aggregate(&models.AggregationFilter{
+		Kind:    models.KindExpense,
+		GroupBy: models.GroupByTag,
+		Bucket:  models.BucketMonth,
+		From:    "2024-02-01",
+		To:      "2024-03-31",
+	}, "", "")
+	if err != nil {
+		t.Fatalf("Aggregate: %v", err)
+	}
+
+	if result.Total.Total != 150 || result.Total.Count != 2 || result.Total.Average != 75 {
+		t.Errorf("total = %+v, want 150 over 2 operations", result.Total)
+	}
+	if len(result.Buckets) != 2 {
+		t.Fatalf("got %d buckets, want 2", len(result.Buckets))
+	}
+	if empty := result.Buckets[0]; empty.Start != "2024-02-01" || empty.Total != 0 || len(empty.Groups) != 0 {
+		t.Errorf("empty bucket = %+v", empty)
+	}
+
+	march := result.Buckets[1]
+	if march.Total != 150 {
+		t.Errorf("march total = %v, want 150", march.Total)
+	}
+	want := []models.AggregationGroup{
+		{Key: "1", Name: "first", Total: 100, Count: 1, Average: 100, Share: 66.67},
+		{Key: "2", Name: "second", Total: 100, Count: 1, Average: 100, Share: 66.67},
+		{Key: "", Name: "", Total: 50, Count: 1, Average: 50, Share: 33.33},
+	}
+	if len(march.Groups) != len(want) {
+		t.Fatalf("march groups = %+v, want %+v", march.Groups, want)
+	}
+	for i := range want {
+		if march.Groups[i] != want[i] {
+			t.Errorf("group %d = %+v, want %+v", i, march.Groups[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,160 @@
+// Package attachments provides receipts and documents attached to expenses, incomes and goal transactions.
+package attachments
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	repo "github.com/wachrusz/Back-End-API/internal/repository"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"github.com/wachrusz/Back-End-API/pkg/attachment"
+	"github.com/wachrusz/Back-End-API/secret"
+)
+
+const (
+	// MaxFileSize - максимальный размер одного файла.
+	MaxFileSize = 10 << 20
+	// UserQuota - суммарный объём вложений одного пользователя.
+	UserQuota = 200 << 20
+	// thumbnailSide - длина большей стороны миниатюры в пикселях.
+	thumbnailSide = 256
+	maxFileName   = 255
+)
+
+type Attachments interface {
+	Upload(userID, ownerType, ownerID, fileName string, r io.Reader) (*models.Attachment, error)
+	List(userID, ownerType, ownerID string) ([]models.Attachment, error)
+	Download(id int64, userID string, thumbnail bool) (*models.Attachment, []byte, error)
+	Delete(id int64, userID string) error
+	Usage(userID string) (used, quota int64, err error)
+}
+
+type Service struct {
+	attachments repo.AttachmentRepo
+}
+
+func NewService(ar repo.AttachmentRepo) *Service {
+	return &Service{attachments: ar}
+}
+
+// Upload читает файл, определяет его тип по содержимому, строит миниатюру для изображений и сохраняет вложение.
+// Изображения больше attachment.MaxPixels сохраняются без миниатюры.
+func (s *Service) Upload(userID, ownerType, ownerID, fileName string, r io.Reader) (*models.Attachment, error) {
+	if _, err := strconv.ParseInt(ownerID, 10, 64); err != nil {
+		return nil, fmt.Errorf("%w: invalid owner_id %q", myerrors.ErrValidation, ownerID)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, MaxFileSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to read file: %v", myerrors.ErrInternal, err)
+	}
+	if len(data) == 0 {
+		return nil, fmt.Errorf("%w: file is empty", myerrors.ErrValidation)
+	}
+	if len(data) > MaxFileSize {
+		return nil, fmt.Errorf("%w: maximum size is %d bytes", myerrors.ErrTooLarge, MaxFileSize)
+	}
+
+	contentType, err := attachment.Sniff(data)
+	if errors.Is(err, attachment.ErrUnsupportedType) {
+		return nil, fmt.Errorf("%w: only images and PDF files are accepted", myerrors.ErrValidation)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	var thumbnail []byte
+	if attachment.HasThumbnail(contentType) {
+		thumbnail, err = attachment.Thumbnail(data, thumbnailSide)
+		if errors.Is(err, attachment.ErrTooManyPixels) {
+			// Слишком большое изображение сохраняется без миниатюры.
+			thumbnail, err = nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: broken image: %v", myerrors.ErrValidation, err)
+		}
+	}
+
+	a := &models.Attachment{
+		UserID:      userID,
+		OwnerType:   ownerType,
+		OwnerID:     ownerID,
+		FileName:    cleanFileName(fileName),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+	}
+	if _, err := s.attachments.Create(a, data, thumbnail, UserQuota); err != nil {
+		return nil, err
+	}
+	setURLs(a)
+
+	return a, nil
+}
+
+func (s *Service) List(userID, ownerType, ownerID string) ([]models.Attachment, error) {
+	if _, err := strconv.ParseInt(ownerID, 10, 64); err != nil {
+		return nil, fmt.Errorf("%w: invalid owner_id %q", myerrors.ErrValidation, ownerID)
+	}
+
+	attachments, err := s.attachments.ListByOwner(userID, ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range attachments {
+		setURLs(&attachments[i])
+	}
+	return attachments, nil
+}
+
+// Download возвращает файл или миниатюру. Вложение ищется только среди файлов пользователя.
+func (s *Service) Download(id int64, userID string, thumbnail bool) (*models.Attachment, []byte, error) {
+	a, data, err := s.attachments.Content(id, userID, thumbnail)
+	if err != nil {
+		return nil, nil, err
+	}
+	if thumbnail {
+		a.ContentType = "image/jpeg"
+	}
+	setURLs(a)
+	return a, data, nil
+}
+
+func (s *Service) Delete(id int64, userID string) error {
+	return s.attachments.Delete(id, userID)
+}
+
+func (s *Service) Usage(userID string) (int64, int64, error) {
+	used, err := s.attachments.Usage(userID)
+	if err != nil {
+		return 0, 0, err
+	}
+	return used, UserQuota, nil
+}
+
+// setURLs заполняет ссылки на скачивание. Ссылки требуют авторизации владельца.
+func setURLs(a *models.Attachment) {
+	a.URL = "https://" + secret.Secret.BaseURL + "/v1/analytics/attachment/download?id=" + strconv.FormatInt(a.ID, 10)
+	if a.HasThumbnail {
+		a.ThumbnailURL = a.URL + "&thumbnail=true"
+	}
+}
+
+// cleanFileName оставляет от имени файла только базовое имя и обрезает его до длины колонки.
+func cleanFileName(name string) string {
+	name = strings.ToValidUTF8(name, "")
+	name = strings.TrimSpace(filepath.Base(strings.ReplaceAll(name, "\\", "/")))
+	if name == "." || name == "/" {
+		return ""
+	}
+	for len(name) > maxFileName {
+		_, size := utf8.DecodeLastRuneInString(name)
+		name = name[:len(name)-size]
+	}
+	return name
+}
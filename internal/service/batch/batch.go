@@ -0,0 +1,136 @@
+// Package batch applies mixed lists of expense, income and wealth fund operations in one request.
+package batch
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	repo "github.com/wachrusz/Back-End-API/internal/repository"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+// MaxOperations - максимальное число операций в одном пакете.
+const MaxOperations = 500
+
+type Batch interface {
+	Apply(actor models.Actor, userID string, ops []models.BatchOperation, atomic bool) ([]models.BatchResult, bool, error)
+}
+
+type Service struct {
+	batch repo.BatchRepo
+}
+
+func NewService(br repo.BatchRepo) *Service {
+	return &Service{batch: br}
+}
+
+// Apply проверяет и выполняет операции пакета. Возвращает результат по каждой операции и признак того,
+// что изменения сохранены. В атомарном режиме пакет с хотя бы одной некорректной операцией не выполняется.
+func (s *Service) Apply(actor models.Actor, userID string, ops []models.BatchOperation, atomic bool) ([]models.BatchResult, bool, error) {
+	if len(ops) == 0 {
+		return nil, false, fmt.Errorf("%w: no operations", myerrors.ErrValidation)
+	}
+	if len(ops) > MaxOperations {
+		return nil, false, fmt.Errorf("%w: at most %d operations are allowed", myerrors.ErrValidation, MaxOperations)
+	}
+
+	results := make([]models.BatchResult, len(ops))
+	valid := make([]models.BatchOperation, 0, len(ops))
+	positions := make([]int, 0, len(ops))
+	for i := range ops {
+		results[i] = models.BatchResult{Index: i, ClientID: ops[i].ClientID}
+		if err := validate(&ops[i]); err != nil {
+			fail(&results[i], err)
+			continue
+		}
+		valid = append(valid, ops[i])
+		positions = append(positions, i)
+	}
+
+	if atomic && len(valid) < len(ops) {
+		for _, i := range positions {
+			results[i].Status = models.BatchRolledBack
+		}
+		return results, false, nil
+	}
+	if len(valid) == 0 {
+		return results, false, nil
+	}
+
+	ids, errs, committed, err := s.batch.Apply(actor, userID, valid, atomic)
+	if err != nil {
+		return nil, false, err
+	}
+
+	for j, i := range positions {
+		switch {
+		case errs[j] != nil:
+			fail(&results[i], errs[j])
+		case !committed:
+			results[i].Status = models.BatchRolledBack
+		default:
+			results[i].Status = models.BatchOK
+			results[i].ID = ids[j]
+		}
+	}
+
+	return results, committed, nil
+}
+
+func validate(op *models.BatchOperation) error {
+	switch op.Kind {
+	case models.KindExpense, models.KindIncome, models.KindWealthFund:
+	default:
+		return fmt.Errorf("%w: kind must be expense, income or wealth_fund", myerrors.ErrValidation)
+	}
+
+	switch op.Action {
+	case models.BatchCreate, models.BatchUpdate:
+		id, ok := payloadID(op)
+		if !ok {
+			return fmt.Errorf("%w: %s object is required", myerrors.ErrValidation, op.Kind)
+		}
+		if op.Action == models.BatchUpdate && id == "" {
+			return fmt.Errorf("%w: %s id is required for update", myerrors.ErrValidation, op.Kind)
+		}
+	case models.BatchDelete:
+		if op.ID == "" {
+			return fmt.Errorf("%w: id is required for delete", myerrors.ErrValidation)
+		}
+	default:
+		return fmt.Errorf("%w: action must be create, update or delete", myerrors.ErrValidation)
+	}
+	return nil
+}
+
+// payloadID возвращает id объекта операции и признак того, что объект нужного вида передан.
+func payloadID(op *models.BatchOperation) (string, bool) {
+	switch op.Kind {
+	case models.KindExpense:
+		if op.Expense != nil {
+			return op.Expense.ID, true
+		}
+	case models.KindIncome:
+		if op.Income != nil {
+			return op.Income.ID, true
+		}
+	case models.KindWealthFund:
+		if op.WealthFund != nil {
+			return op.WealthFund.ID, true
+		}
+	}
+	return "", false
+}
+
+func fail(result *models.BatchResult, err error) {
+	code := models.BatchErrInternal
+	switch {
+	case errors.Is(err, myerrors.ErrValidation):
+		code = models.BatchErrValidation
+	case errors.Is(err, myerrors.ErrNotFound):
+		code = models.BatchErrNotFound
+	}
+	result.Status = models.BatchFailed
+	result.Error = &models.BatchError{Code: code, Message: err.Error()}
+}
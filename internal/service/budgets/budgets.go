@@ -0,0 +1,315 @@
+// Package budgets provides monthly expense budgets with rollover and overspend alerts.
+package budgets
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	repo "github.com/wachrusz/Back-End-API/internal/repository"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+const dateLayout = "2006-01-02"
+
+const (
+	// alertInterval - как часто воркер проверяет бюджеты и отправляет уведомления.
+	alertInterval = time.Hour
+	// maxThreshold - максимальный порог уведомления в процентах.
+	maxThreshold = 1000
+)
+
+// DefaultAlertThresholds - пороги уведомлений в процентах, если они не заданы ни в бюджете, ни в конфигурации.
+var DefaultAlertThresholds = []int{80, 100}
+
+const alertSubject = "Cash Advisor App – расходы по бюджету"
+
+// Notifier отправляет письмо пользователю.
+type Notifier interface {
+	SendEmail(to, subject, body string) error
+}
+
+type Budgets interface {
+	Create(budget *models.Budget) (int64, error)
+	Update(budget *models.Budget) error
+	Delete(id, userID string) error
+	ListByUserID(userID string) ([]models.Budget, error)
+	Report(userID string, month time.Time) (*models.BudgetReport, error)
+	CheckAlerts(now time.Time) error
+	ScheduleAlerts()
+}
+
+type Service struct {
+	budgets    repo.BudgetRepo
+	notifier   Notifier
+	thresholds []int
+}
+
+// NewService создаёт сервис бюджетов. thresholds - пороги уведомлений по умолчанию в процентах.
+func NewService(br repo.BudgetRepo, n Notifier, thresholds []int) *Service {
+	if len(thresholds) == 0 {
+		thresholds = DefaultAlertThresholds
+	}
+	return &Service{budgets: br, notifier: n, thresholds: thresholds}
+}
+
+func (s *Service) Create(budget *models.Budget) (int64, error) {
+	if err := validate(budget); err != nil {
+		return 0, err
+	}
+	return s.budgets.Create(budget)
+}
+
+func (s *Service) Update(budget *models.Budget) error {
+	if err := validate(budget); err != nil {
+		return err
+	}
+	return s.budgets.Update(budget)
+}
+
+func (s *Service) Delete(id, userID string) error {
+	return s.budgets.Delete(id, userID)
+}
+
+func (s *Service) ListByUserID(userID string) ([]models.Budget, error) {
+	return s.budgets.ListByUserID(userID)
+}
+
+// Report считает исполнение бюджетов пользователя за календарный месяц, в который попадает month.
+// Для бюджетов с переносом остатка неизрасходованные суммы прошлых месяцев, начиная с месяца создания бюджета,
+// добавляются к бюджету периода; перерасход прошлых месяцев бюджет не уменьшает.
+func (s *Service) Report(userID string, month time.Time) (*models.BudgetReport, error) {
+	start := monthStart(month)
+	end := start.AddDate(0, 1, 0)
+
+	budgets, err := s.budgets.ListByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	from := start
+	for _, budget := range budgets {
+		if created := createdMonth(&budget); budget.Rollover && created.Before(from) {
+			from = created
+		}
+	}
+
+	spending, err := s.budgets.Spending(userID, from, end)
+	if err != nil {
+		return nil, err
+	}
+	// spent[месяц][категория]; пустая категория - расходы за месяц всего.
+	spent := make(map[string]map[string]float64)
+	for _, sp := range spending {
+		if spent[sp.Month] == nil {
+			spent[sp.Month] = make(map[string]float64)
+		}
+		spent[sp.Month][sp.CategoryID] += sp.Amount
+		if sp.CategoryID != "" {
+			spent[sp.Month][""] += sp.Amount
+		}
+	}
+
+	report := &models.BudgetReport{
+		PeriodStart: start.Format(dateLayout),
+		PeriodEnd:   end.AddDate(0, 0, -1).Format(dateLayout),
+		Budgets:     make([]models.BudgetLine, 0, len(budgets)),
+	}
+	for _, budget := range budgets {
+		line := models.BudgetLine{
+			BudgetID:     budget.ID,
+			CategoryID:   budget.CategoryID,
+			CategoryName: budget.CategoryName,
+			Amount:       budget.Amount,
+		}
+
+		if budget.Rollover {
+			for m := createdMonth(&budget); m.Before(start); m = m.AddDate(0, 1, 0) {
+				left := budget.Amount + line.Carryover - spent[m.Format(dateLayout)][budget.CategoryID]
+				line.Carryover = math.Max(0, left)
+			}
+		}
+
+		line.Available = round(budget.Amount + line.Carryover)
+		line.Carryover = round(line.Carryover)
+		line.Spent = round(spent[start.Format(dateLayout)][budget.CategoryID])
+		line.Remaining = round(line.Available - line.Spent)
+		line.Projected = round(project(line.Spent, start, end, time.Now()))
+		line.Percent = round(line.Spent / line.Available * 100)
+		line.Status = s.status(&budget, line.Percent)
+		report.Budgets = append(report.Budgets, line)
+	}
+
+	return report, nil
+}
+
+// project оценивает расходы к концу периода, продолжая текущий темп трат. Для прошедшего периода это
+// фактические расходы.
+func project(spent float64, start, end, now time.Time) float64 {
+	if !now.Before(end) {
+		return spent
+	}
+	if now.Before(start) {
+		return 0
+	}
+	elapsed := math.Floor(now.Sub(start).Hours()/24) + 1
+	total := math.Round(end.Sub(start).Hours() / 24)
+	return spent / elapsed * total
+}
+
+func (s *Service) status(budget *models.Budget, percent float64) string {
+	if percent >= 100 {
+		return models.BudgetExceeded
+	}
+	if thresholds := s.alertThresholds(budget); len(thresholds) > 0 && percent >= float64(thresholds[0]) {
+		return models.BudgetWarning
+	}
+	return models.BudgetOK
+}
+
+// alertThresholds возвращает пороги бюджета по возрастанию.
+func (s *Service) alertThresholds(budget *models.Budget) []int {
+	thresholds := budget.AlertThresholds
+	if len(thresholds) == 0 {
+		thresholds = s.thresholds
+	}
+	sorted := append([]int(nil), thresholds...)
+	sort.Ints(sorted)
+	return sorted
+}
+
+// CheckAlerts отправляет уведомления о бюджетах текущего месяца, расходы по которым достигли порогов.
+// Каждый порог бюджета срабатывает один раз за месяц.
+func (s *Service) CheckAlerts(now time.Time) error {
+	users, err := s.budgets.UsersWithBudgets()
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range users {
+		if err := s.checkUserAlerts(userID, now); err != nil {
+			log.Printf("Error checking budget alerts for user %s: %v", userID, err)
+		}
+	}
+	return nil
+}
+
+func (s *Service) checkUserAlerts(userID string, now time.Time) error {
+	report, err := s.Report(userID, now)
+	if err != nil {
+		return err
+	}
+	budgets, err := s.budgets.ListByUserID(userID)
+	if err != nil {
+		return err
+	}
+	byID := make(map[string]*models.Budget, len(budgets))
+	for i := range budgets {
+		byID[budgets[i].ID] = &budgets[i]
+	}
+
+	var email string
+	for _, line := range report.Budgets {
+		budget, ok := byID[line.BudgetID]
+		if !ok {
+			continue
+		}
+
+		// Уведомление отправляется только о самом высоком достигнутом пороге, более низкие отмечаются молча.
+		thresholds := s.alertThresholds(budget)
+		reached := -1
+		for i, t := range thresholds {
+			if line.Percent >= float64(t) {
+				reached = i
+			}
+		}
+		for i := 0; i <= reached; i++ {
+			threshold := thresholds[i]
+			marked, err := s.budgets.MarkAlert(line.BudgetID, report.PeriodStart, threshold)
+			if err != nil {
+				return err
+			}
+			if !marked || i != reached {
+				continue
+			}
+
+			if email == "" {
+				if email, err = s.budgets.UserEmail(userID); err != nil {
+					return err
+				}
+			}
+			if err := s.notifier.SendEmail(email, alertSubject, alertBody(&line, threshold)); err != nil {
+				if err := s.budgets.UnmarkAlert(line.BudgetID, report.PeriodStart, threshold); err != nil {
+					log.Println("Error unmarking budget alert:", err)
+				}
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func alertBody(line *models.BudgetLine, threshold int) string {
+	name := "Общий бюджет"
+	if line.CategoryName != "" {
+		name = fmt.Sprintf("Бюджет «%s»", line.CategoryName)
+	}
+	if threshold >= 100 {
+		return fmt.Sprintf("%s превышен: потрачено %.2f ₽ из %.2f ₽ (%.0f%%).", name, line.Spent, line.Available, line.Percent)
+	}
+	return fmt.Sprintf("%s израсходован на %.0f%%: потрачено %.2f ₽ из %.2f ₽, осталось %.2f ₽.",
+		name, line.Percent, line.Spent, line.Available, line.Remaining)
+}
+
+// ScheduleAlerts периодически проверяет бюджеты и отправляет уведомления о перерасходе.
+func (s *Service) ScheduleAlerts() {
+	for {
+		if err := s.CheckAlerts(time.Now()); err != nil {
+			log.Println("Error checking budget alerts:", err)
+		}
+		time.Sleep(alertInterval)
+	}
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func createdMonth(budget *models.Budget) time.Time {
+	created, err := time.Parse(time.RFC3339, budget.CreatedAt)
+	if err != nil {
+		return monthStart(time.Now())
+	}
+	return monthStart(created)
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+func validate(budget *models.Budget) error {
+	if budget.Amount <= 0 {
+		return fmt.Errorf("%w: amount must be positive", myerrors.ErrValidation)
+	}
+	if budget.CategoryID != "" {
+		if _, err := strconv.ParseInt(budget.CategoryID, 10, 64); err != nil {
+			return fmt.Errorf("%w: invalid category id %q", myerrors.ErrValidation, budget.CategoryID)
+		}
+	}
+	seen := make(map[int]bool, len(budget.AlertThresholds))
+	for _, t := range budget.AlertThresholds {
+		if t <= 0 || t > maxThreshold {
+			return fmt.Errorf("%w: alert thresholds must be between 1 and %d percent", myerrors.ErrValidation, maxThreshold)
+		}
+		if seen[t] {
+			return fmt.Errorf("%w: duplicate alert threshold %d", myerrors.ErrValidation, t)
+		}
+		seen[t] = true
+	}
+	sort.Ints(budget.AlertThresholds)
+	return nil
+}
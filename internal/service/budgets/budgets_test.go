@@ -0,0 +1,103 @@
+package budgets
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+// budgetRepo - хранилище бюджетов в памяти для расчёта отчёта.
+type budgetRepo struct {
+	budgets  []models.Budget
+	spending []models.BudgetSpending
+	from     time.Time
+}
+
+func (r *budgetRepo) Create(*models.Budget) (int64, error) { return 0, nil }
+func (r *budgetRepo) Update(*models.Budget) error          { return nil }
+func (r *budgetRepo) Delete(string, string) error          { return nil }
+func (r *budgetRepo) ListByUserID(string) ([]models.Budget, error) {
+	return r.budgets, nil
+}
+func (r *budgetRepo) Spending(_ string, from, _ time.Time) ([]models.BudgetSpending, error) {
+	r.from = from
+	return r.spending, nil
+}
+func (r *budgetRepo) UsersWithBudgets() ([]string, error)         { return nil, nil }
+func (r *budgetRepo) UserEmail(string) (string, error)            { return "", nil }
+func (r *budgetRepo) MarkAlert(string, string, int) (bool, error) { return false, nil }
+func (r *budgetRepo) UnmarkAlert(string, string, int) error       { return nil }
+
+func TestReport(t *testing.T) {
+	repo := &budgetRepo{
+		budgets: []models.Budget{
+			{ID: "rollover", CategoryID: "1", Amount: 1000, Rollover: true, CreatedAt: "2024-01-10T12:00:00Z"},
+			{ID: "plain", CategoryID: "2", Amount: 500, CreatedAt: "2024-01-01T00:00:00Z"},
+			{ID: "total", Amount: 2000, CreatedAt: "2024-01-01T00:00:00Z"},
+			{ID: "new", CategoryID: "1", Amount: 100, Rollover: true, CreatedAt: "2024-04-02T00:00:00Z"},
+		},
+		spending: []models.BudgetSpending{
+			{Month: "2024-01-01", CategoryID: "1", Amount: 600},
+			{Month: "2024-02-01", CategoryID: "1", Amount: 1500},
+			{Month: "2024-03-01", CategoryID: "1", Amount: 700},
+			{Month: "2024-04-01", CategoryID: "1", Amount: 650},
+			{Month: "2024-04-01", CategoryID: "2", Amount: 450},
+			{Month: "2024-04-01", CategoryID: "3", Amount: 1000},
+		},
+	}
+	s := NewService(repo, nil, nil)
+
+	report, err := s.Report("1", time.Date(2024, time.April, 15, 0, 0, 0, 0, time.UTC))
+	if err != nil {
+		t.Fatalf("Report: %v", err)
+	}
+
+	if report.PeriodStart != "2024-04-01" || report.PeriodEnd != "2024-04-30" {
+		t.Errorf("period = %s..%s, want 2024-04-01..2024-04-30", report.PeriodStart, report.PeriodEnd)
+	}
+	// Остатки считаются с месяца создания самого старого бюджета с переносом.
+	if got := repo.from.Format(dateLayout); got != "2024-01-01" {
+		t.Errorf("spending loaded from %s, want 2024-01-01", got)
+	}
+
+	want := map[string]models.BudgetLine{
+		// Январь оставил 400, февральский перерасход обнулил остаток, март оставил 300.
+		"rollover": {Carryover: 300, Available: 1300, Spent: 650, Remaining: 650, Projected: 650, Percent: 50, Status: models.BudgetOK},
+		"plain":    {Available: 500, Spent: 450, Remaining: 50, Projected: 450, Percent: 90, Status: models.BudgetWarning},
+		"total":    {Available: 2000, Spent: 2100, Remaining: -100, Projected: 2100, Percent: 105, Status: models.BudgetExceeded},
+		"new":      {Available: 100, Spent: 650, Remaining: -550, Projected: 650, Percent: 650, Status: models.BudgetExceeded},
+	}
+	if len(report.Budgets) != len(want) {
+		t.Fatalf("got %d budget lines, want %d", len(report.Budgets), len(want))
+	}
+	for _, line := range report.Budgets {
+		w := want[line.BudgetID]
+		w.BudgetID, w.CategoryID, w.Amount = line.BudgetID, line.CategoryID, line.Amount
+		if line != w {
+			t.Errorf("budget %s = %+v, want %+v", line.BudgetID, line, w)
+		}
+	}
+}
+
+func TestProject(t *testing.T) {
+	start := time.Date(2024, time.April, 1, 0, 0, 0, 0, time.UTC)
+	end := start.AddDate(0, 1, 0)
+
+	tests := []struct {
+		name string
+		now  time.Time
+		want float64
+	}{
+		{name: "before period", now: start.AddDate(0, 0, -1), want: 0},
+		{name: "first day", now: start.Add(12 * time.Hour), want: 3000},
+		{name: "tenth day", now: start.AddDate(0, 0, 9).Add(12 * time.Hour), want: 300},
+		{name: "after period", now: end, want: 100},
+	}
+
+	for _, tt := range tests {
+		if got := project(100, start, end, tt.now); got != tt.want {
+			t.Errorf("%s: project = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
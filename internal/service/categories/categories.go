@@ -22,18 +22,16 @@ import (
 )
 
 type Service struct {
-	repo          *mydb.Database
-	curr          *currency.Service
-	exchangeRates map[string]currency.Valute
-	goals         repository.GoalRepo
+	repo  *mydb.Database
+	curr  *currency.Service
+	goals repository.GoalRepo
 }
 
 func NewService(db *mydb.Database, currencyService *currency.Service, goals repository.GoalRepo) *Service {
 	return &Service{
-		repo:          db,
-		curr:          currencyService,
-		exchangeRates: make(map[string]currency.Valute),
-		goals:         goals,
+		repo:  db,
+		curr:  currencyService,
+		goals: goals,
 	}
 }
 
@@ -55,7 +53,9 @@ func round(num float64, precision int) float64 {
 	return math.Round(num*output) / output
 }
 
-func (s *Service) ConvertCurrency(amount float64, fromCurrencyCode string, toCurrencyCode string) float64 {
+// ConvertCurrency пересчитывает сумму из одной валюты в другую по курсам, действовавшим на дату date.
+// Если дату разобрать не удалось, используются текущие курсы.
+func (s *Service) ConvertCurrency(amount float64, fromCurrencyCode string, toCurrencyCode string, date string) float64 {
 	if fromCurrencyCode == "" || toCurrencyCode == "" {
 		return round(amount, 2)
 	}
@@ -64,45 +64,26 @@ func (s *Service) ConvertCurrency(amount float64, fromCurrencyCode string, toCur
 		return round(amount, 2)
 	}
 
-	if len(s.exchangeRates) == 0 {
-		s.exchangeRates = s.curr.CurrentCurrencyData.Valute
-	}
-
-	switch {
-	case toCurrencyCode == "RUB":
-		// To RUB
-		rate, ok := s.exchangeRates[fromCurrencyCode]
-		if !ok {
-			log.Printf("Couldn't find exchange rate for %v", fromCurrencyCode)
-			return amount
-		}
-		return round(amount*(rate.Value/float64(rate.Nominal)), 2)
-
-	case fromCurrencyCode == "RUB":
-		// From RUB
-		rate, ok := s.exchangeRates[toCurrencyCode]
-		if !ok {
-			log.Printf("Couldn't find exchange rate for %v", toCurrencyCode)
-			return amount
-		}
-		return round(amount/(rate.Value/float64(rate.Nominal)), 2)
-
-	default:
-		// Convert non RUB valutes
-		rubleRateFrom, ok := s.exchangeRates[fromCurrencyCode]
-		if !ok {
-			log.Printf("Couldn't find exchange rate for %v", fromCurrencyCode)
-			return amount
+	rate := s.curr.RateToRuble
+	if len(date) >= 10 {
+		if on, err := time.Parse("2006-01-02", date[:10]); err == nil {
+			rate = func(code string) (float64, bool) { return s.curr.RateToRubleOn(code, on) }
 		}
+	}
 
-		rubleRateTo, ok := s.exchangeRates[toCurrencyCode]
-		if !ok {
-			log.Printf("Couldn't find exchange rate for %v", toCurrencyCode)
-			return amount
-		}
+	rubleRateFrom, ok := rate(fromCurrencyCode)
+	if !ok {
+		log.Printf("Couldn't find exchange rate for %v", fromCurrencyCode)
+		return amount
+	}
 
-		return round((amount*(rubleRateFrom.Value/float64(rubleRateFrom.Nominal)))/(rubleRateTo.Value/float64(rubleRateTo.Nominal)), 2)
+	rubleRateTo, ok := rate(toCurrencyCode)
+	if !ok {
+		log.Printf("Couldn't find exchange rate for %v", toCurrencyCode)
+		return amount
 	}
+
+	return round(amount*rubleRateFrom/rubleRateTo, 2)
 }
 
 func (s *Service) GetAnalyticsFromDB(userID, currencyCode, limitStr, offsetStr, startDateStr, endDateStr string) (*Analytics, error) {
@@ -114,7 +95,7 @@ func (s *Service) GetAnalyticsFromDB(userID, currencyCode, limitStr, offsetStr,
 		endDateStr = time.Now().Format("2006-01-02")
 	}
 
-	queryIncome := "SELECT id, amount, date, planned, category, sender, connected_account, currency_code FROM income WHERE user_id = $1 AND date >= $2 AND date <= $3 ORDER BY date DESC LIMIT $4 OFFSET $5;"
+	queryIncome := "SELECT id, amount, date, planned, category, sender, connected_account, currency_code FROM income WHERE user_id = $1 AND deleted_at IS NULL AND date >= $2 AND date <= $3 ORDER BY date DESC LIMIT $4 OFFSET $5;"
 	rowsIncome, err := s.repo.Query(queryIncome, userID, startDateStr, endDateStr, limitStr, offsetStr)
 	if err != nil {
 		return nil, fmt.Errorf("error getting income: %v", err)
@@ -129,12 +110,15 @@ func (s *Service) GetAnalyticsFromDB(userID, currencyCode, limitStr, offsetStr,
 		}
 		income.UserID = userID
 		if income.Currency != currencyCode && currencyCode != "" {
-			income.Amount = s.ConvertCurrency(income.Amount, income.Currency, currencyCode)
+			income.Amount = s.ConvertCurrency(income.Amount, income.Currency, currencyCode, income.Date)
 		}
 		incomeList = append(incomeList, income)
 	}
 
-	queryExpense := "SELECT id, amount, date, planned, category, sent_to, connected_account, currency_code FROM expense WHERE user_id = $1 AND date >= $2 AND date <= $3 ORDER BY date DESC LIMIT $4 OFFSET $5;"
+	// Разделённые расходы раскрываются в свои части, у каждой своя категория и сумма.
+	queryExpense := `SELECT e.id, COALESCE(s.amount, e.amount), e.date, e.planned, COALESCE(s.category, e.category), e.sent_to, e.connected_account, e.currency_code, COALESCE(s.id, 0)
+		FROM expense e LEFT JOIN expense_splits s ON s.expense_id = e.id
+		WHERE e.user_id = $1 AND e.deleted_at IS NULL AND e.date >= $2 AND e.date <= $3 ORDER BY e.date DESC, e.id, s.id LIMIT $4 OFFSET $5;`
 	rowsExpense, err := s.repo.Query(queryExpense, userID, startDateStr, endDateStr, limitStr, offsetStr)
 	if err != nil {
 		return nil, fmt.Errorf("error getting expense: %v", err)
@@ -144,17 +128,17 @@ func (s *Service) GetAnalyticsFromDB(userID, currencyCode, limitStr, offsetStr,
 	var expenseList []models.Expense
 	for rowsExpense.Next() {
 		var expense models.Expense
-		if err := rowsExpense.Scan(&expense.ID, &expense.Amount, &expense.Date, &expense.Planned, &expense.CategoryID, &expense.SentTo, &expense.BankAccount, &expense.Currency); err != nil {
+		if err := rowsExpense.Scan(&expense.ID, &expense.Amount, &expense.Date, &expense.Planned, &expense.CategoryID, &expense.SentTo, &expense.BankAccount, &expense.Currency, &expense.SplitID); err != nil {
 			return nil, fmt.Errorf("error scanning expense: %v", err)
 		}
 		expense.UserID = userID
 		if expense.Currency != currencyCode && currencyCode != "" {
-			expense.Amount = s.ConvertCurrency(expense.Amount, expense.Currency, currencyCode)
+			expense.Amount = s.ConvertCurrency(expense.Amount, expense.Currency, currencyCode, expense.Date)
 		}
 		expenseList = append(expenseList, expense)
 	}
 
-	queryWealthFund := "SELECT id, amount, date, planned, currency_code, connected_account, user_id, category_id FROM wealth_fund WHERE user_id = $1 AND date >= $2 AND date <= $3 ORDER BY date DESC LIMIT $4 OFFSET $5;"
+	queryWealthFund := "SELECT id, amount, date, planned, currency_code, connected_account, user_id, category_id FROM wealth_fund WHERE user_id = $1 AND deleted_at IS NULL AND date >= $2 AND date <= $3 ORDER BY date DESC LIMIT $4 OFFSET $5;"
 	rowsWealthFund, err := s.repo.Query(queryWealthFund, userID, startDateStr, endDateStr, limitStr, offsetStr)
 	if err != nil {
 		return nil, fmt.Errorf("error getting wealth funds: %v", err)
@@ -168,7 +152,7 @@ func (s *Service) GetAnalyticsFromDB(userID, currencyCode, limitStr, offsetStr,
 			return nil, fmt.Errorf("error scanning wealth funds: %v", err)
 		}
 		if wealthFund.Currency != currencyCode && currencyCode != "" {
-			wealthFund.Amount = s.ConvertCurrency(wealthFund.Amount, wealthFund.Currency, currencyCode)
+			wealthFund.Amount = s.ConvertCurrency(wealthFund.Amount, wealthFund.Currency, currencyCode, wealthFund.Date)
 		}
 		wealthFundList = append(wealthFundList, wealthFund)
 	}
@@ -374,6 +358,8 @@ func (s *Service) GetOperationArchiveFromDB(userID, limit, offset string) ([]rep
 		SELECT id, description, amount, date, category, operation_type
 		FROM operations
 		WHERE user_id = $1
+		AND NOT EXISTS (SELECT 1 FROM expense e WHERE e.id = operations.expense_id AND e.deleted_at IS NOT NULL)
+		AND NOT EXISTS (SELECT 1 FROM income i WHERE i.id = operations.income_id AND i.deleted_at IS NOT NULL)
 		ORDER BY date DESC
 		LIMIT $2 OFFSET $3;
 	`
@@ -0,0 +1,257 @@
+// Package comparison provides period-over-period comparison of incomes and expenses by category.
+package comparison
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	repo "github.com/wachrusz/Back-End-API/internal/repository"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	DefaultTop = 5
+	MaxTop     = 50
+)
+
+type Comparison interface {
+	Compare(filter *models.ComparisonFilter) (*models.Comparison, error)
+}
+
+type RateSource interface {
+	RateToRuble(code string) (float64, bool)
+}
+
+type Service struct {
+	aggregation repo.AggregationRepo
+	rates       RateSource
+}
+
+func NewService(ar repo.AggregationRepo, rs RateSource) *Service {
+	return &Service{aggregation: ar, rates: rs}
+}
+
+// averages - число периодов для баз сравнения по среднему.
+var averages = map[string]int{
+	models.BaselineAvg3:  3,
+	models.BaselineAvg6:  6,
+	models.BaselineAvg12: 12,
+}
+
+// Compare сравнивает итоги по категориям за период с базой сравнения: предыдущим периодом, тем же периодом
+// год назад или средним за 3, 6 или 12 предыдущих периодов. Период - календарный месяц (по умолчанию текущий)
+// или произвольные даты; для месяца предыдущие периоды - предыдущие календарные месяцы, для дат - отрезки той же длины.
+func (s *Service) Compare(filter *models.ComparisonFilter) (*models.Comparison, error) {
+	if filter.Kind == "" {
+		filter.Kind = models.KindExpense
+	}
+	if filter.Kind != models.KindExpense && filter.Kind != models.KindIncome {
+		return nil, fmt.Errorf("%w: kind must be %q or %q", myerrors.ErrValidation, models.KindExpense, models.KindIncome)
+	}
+	if filter.Baseline == "" {
+		filter.Baseline = models.BaselinePrevious
+	}
+	if filter.Top == 0 {
+		filter.Top = DefaultTop
+	}
+	if filter.Top < 0 || filter.Top > MaxTop {
+		return nil, fmt.Errorf("%w: top must be between 1 and %d", myerrors.ErrValidation, MaxTop)
+	}
+
+	currency := strings.ToUpper(filter.Currency)
+	if currency == "" {
+		currency = "RUB"
+	}
+	rate, ok := s.rates.RateToRuble(currency)
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown currency %q", myerrors.ErrValidation, currency)
+	}
+
+	from, to, monthly, err := period(filter)
+	if err != nil {
+		return nil, err
+	}
+	baseFrom, baseTo, periods, err := baseline(filter.Baseline, from, to, monthly)
+	if err != nil {
+		return nil, err
+	}
+
+	current, currentNames, err := s.totals(filter, from, to)
+	if err != nil {
+		return nil, err
+	}
+	base, baseNames, err := s.totals(filter, baseFrom, baseTo)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.Comparison{
+		Kind:           filter.Kind,
+		Baseline:       filter.Baseline,
+		Currency:       currency,
+		Period:         models.ComparisonPeriod{From: from.Format(dateLayout), To: to.Format(dateLayout), Periods: 1},
+		BaselinePeriod: models.ComparisonPeriod{From: baseFrom.Format(dateLayout), To: baseTo.Format(dateLayout), Periods: periods},
+		Categories:     make([]models.ComparisonLine, 0),
+	}
+
+	lines := make(map[string]*models.ComparisonLine)
+	line := func(key string) *models.ComparisonLine {
+		if lines[key] == nil {
+			lines[key] = &models.ComparisonLine{CategoryID: key}
+		}
+		return lines[key]
+	}
+	for key, amount := range current {
+		line(key).Current = amount / rate
+		line(key).CategoryName = currentNames[key]
+	}
+	for key, amount := range base {
+		line(key).Baseline = amount / rate / float64(periods)
+		if line(key).CategoryName == "" {
+			line(key).CategoryName = baseNames[key]
+		}
+	}
+
+	for _, l := range lines {
+		result.Current += l.Current
+		result.BaselineTotal += l.Baseline
+	}
+	result.Current = round(result.Current)
+	result.BaselineTotal = round(result.BaselineTotal)
+	result.Delta = round(result.Current - result.BaselineTotal)
+	result.DeltaPercent = percent(result.Delta, result.BaselineTotal)
+
+	for _, l := range lines {
+		l.Current = round(l.Current)
+		l.Baseline = round(l.Baseline)
+		l.Delta = round(l.Current - l.Baseline)
+		l.DeltaPercent = percent(l.Delta, l.Baseline)
+		if result.Delta != 0 {
+			l.Contribution = round(l.Delta / result.Delta * 100)
+		}
+		result.Categories = append(result.Categories, *l)
+	}
+	sort.Slice(result.Categories, func(i, j int) bool {
+		a, b := math.Abs(result.Categories[i].Delta), math.Abs(result.Categories[j].Delta)
+		if a != b {
+			return a > b
+		}
+		return result.Categories[i].CategoryName < result.Categories[j].CategoryName
+	})
+
+	result.TopIncreases = make([]models.ComparisonLine, 0, filter.Top)
+	result.TopDecreases = make([]models.ComparisonLine, 0, filter.Top)
+	for _, l := range result.Categories {
+		if l.Delta > 0 && len(result.TopIncreases) < filter.Top {
+			result.TopIncreases = append(result.TopIncreases, l)
+		}
+		if l.Delta < 0 && len(result.TopDecreases) < filter.Top {
+			result.TopDecreases = append(result.TopDecreases, l)
+		}
+	}
+
+	return result, nil
+}
+
+// totals возвращает итоги категорий за период в рублях и их названия.
+func (s *Service) totals(filter *models.ComparisonFilter, from, to time.Time) (map[string]float64, map[string]string, error) {
+	rows, err := s.aggregation.Aggregate(&models.AggregationFilter{
+		UserID:  filter.UserID,
+		Kind:    filter.Kind,
+		GroupBy: models.GroupByCategory,
+		Bucket:  models.BucketNone,
+		From:    from.Format(dateLayout),
+		To:      to.Format(dateLayout),
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	totals := make(map[string]float64)
+	names := make(map[string]string)
+	for _, row := range rows {
+		if !row.AllBuckets || row.AllGroups {
+			continue
+		}
+		totals[row.Key] += row.Amount
+		names[row.Key] = row.Name
+	}
+	return totals, names, nil
+}
+
+// period возвращает первый и последний день сравниваемого периода и признак календарного месяца.
+func period(filter *models.ComparisonFilter) (time.Time, time.Time, bool, error) {
+	if filter.From != "" || filter.To != "" {
+		if filter.Month != "" {
+			return time.Time{}, time.Time{}, false, fmt.Errorf("%w: month can't be used with from and to", myerrors.ErrValidation)
+		}
+		from, err := time.Parse(dateLayout, filter.From)
+		if err != nil {
+			return time.Time{}, time.Time{}, false, fmt.Errorf("%w: invalid from: %v", myerrors.ErrValidation, err)
+		}
+		to, err := time.Parse(dateLayout, filter.To)
+		if err != nil {
+			return time.Time{}, time.Time{}, false, fmt.Errorf("%w: invalid to: %v", myerrors.ErrValidation, err)
+		}
+		if to.Before(from) {
+			return time.Time{}, time.Time{}, false, fmt.Errorf("%w: to is before from", myerrors.ErrValidation)
+		}
+		return from, to, false, nil
+	}
+
+	month := time.Now()
+	if filter.Month != "" {
+		parsed, err := time.Parse("2006-01", filter.Month)
+		if err != nil {
+			return time.Time{}, time.Time{}, false, fmt.Errorf("%w: invalid month %q, expected YYYY-MM", myerrors.ErrValidation, filter.Month)
+		}
+		month = parsed
+	}
+	from := time.Date(month.Year(), month.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return from, from.AddDate(0, 1, -1), true, nil
+}
+
+// baseline возвращает отрезок базы сравнения и число периодов в нём.
+func baseline(kind string, from, to time.Time, monthly bool) (time.Time, time.Time, int, error) {
+	periods := 1
+	switch kind {
+	case models.BaselinePrevious:
+	case models.BaselineLastYear:
+		if monthly {
+			baseFrom := from.AddDate(-1, 0, 0)
+			return baseFrom, baseFrom.AddDate(0, 1, -1), 1, nil
+		}
+		return from.AddDate(-1, 0, 0), to.AddDate(-1, 0, 0), 1, nil
+	default:
+		n, ok := averages[kind]
+		if !ok {
+			return time.Time{}, time.Time{}, 0, fmt.Errorf("%w: unknown baseline %q", myerrors.ErrValidation, kind)
+		}
+		periods = n
+	}
+
+	if monthly {
+		return from.AddDate(0, -periods, 0), from.AddDate(0, 0, -1), periods, nil
+	}
+	days := int(to.Sub(from).Hours()/24) + 1
+	return from.AddDate(0, 0, -days*periods), from.AddDate(0, 0, -1), periods, nil
+}
+
+// percent возвращает изменение в процентах от base или nil, если base нулевая.
+func percent(delta, base float64) *float64 {
+	if base == 0 {
+		return nil
+	}
+	value := round(delta / math.Abs(base) * 100)
+	return &value
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
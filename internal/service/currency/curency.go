@@ -1,29 +1,62 @@
 package currency
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"github.com/wachrusz/Back-End-API/internal/config"
 	mydb "github.com/wachrusz/Back-End-API/internal/mydatabase"
-	"github.com/wachrusz/Back-End-API/secret"
-	"io"
-	"net/http"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"log"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+const (
+	// historyDays - на сколько дней назад загружается история курсов из архива ЦБ.
+	historyDays = 5 * 365
+	// archiveDelay - пауза между запросами к архиву ЦБ.
+	archiveDelay = 200 * time.Millisecond
+	// archiveReloadEvery - через сколько загруженных из архива дней история обновляется в памяти.
+	archiveReloadEvery = 30
+)
+
 type Service struct {
-	repo                *mydb.Database
-	CurrentCurrencyData *CurrencyData
+	repo *mydb.Database
+	// providers - провайдеры курсов в порядке приоритета.
+	providers []Provider
+	// events - рассылка версий курсов другим экземплярам сервиса, может быть nil.
+	events Broadcaster
+
+	snapshot atomic.Pointer[Snapshot]
+	// updateMu не даёт обновлять курсы одновременно, reloadMu - подменять снимок более старым.
+	updateMu sync.Mutex
+	reloadMu sync.Mutex
 }
 
-func NewService(db *mydb.Database) (*Service, error) {
+func NewService(db *mydb.Database, providers []config.CurrencyProvider, events Broadcaster) (*Service, error) {
 	s := &Service{
-		repo:                db,
-		CurrentCurrencyData: new(CurrencyData),
+		repo:   db,
+		events: events,
 	}
-	err := s.initCurrentCurrencyData()
+	s.snapshot.Store(&Snapshot{Valute: make(map[string]Valute), history: make(map[string][]datedRate)})
+
+	p, err := NewProviders(providers, s.knownRateOn)
 	if err != nil {
 		return nil, err
 	}
+	s.providers = p
+
+	if _, err := s.reload(); err != nil {
+		return nil, err
+	}
+	if err := s.updateRates(); err != nil {
+		fmt.Println("Error in updating database:", err)
+	}
 	return s, nil
 }
 
@@ -43,65 +76,234 @@ type CurrencyData struct {
 	PreviousURL  string `json:"PreviousURL"`
 	Timestamp    string `json:"Timestamp"`
 	Valute       map[string]Valute
+	// Provider - провайдер, от которого получены курсы, FetchedAt - время получения.
+	Provider  string    `json:"-"`
+	FetchedAt time.Time `json:"-"`
 }
 
-func (s *Service) initCurrentCurrencyData() error {
-	err := s.parseJSONAndUpdateDB(secret.Secret.CurrencyURL)
+// fetch загружает курсы ЦБ в JSON по url.
+func fetch(url string) (*CurrencyData, error) {
+	bodyBytes, err := get(url)
 	if err != nil {
-		fmt.Println("Error in updating database:", err)
+		return nil, err
 	}
-	s.CurrentCurrencyData.Valute = make(map[string]Valute)
-	rows, err := s.repo.Query("SELECT id, num_code, currency_code, nominal, name, value, previous FROM currency")
+
+	var data CurrencyData
+	err = json.Unmarshal(bodyBytes, &data)
 	if err != nil {
-		return err
+		return nil, fmt.Errorf("Ошибка при разборе JSON: %w", err)
 	}
 
-	for rows.Next() {
-		var item Valute
-		err := rows.Scan(&item.ID, &item.NumCode, &item.CharCode, &item.Nominal, &item.Name, &item.Value, &item.Previous)
+	return &data, nil
+}
+
+// fetchRates запрашивает курсы у провайдеров по порядку приоритета и возвращает курсы первого ответившего.
+func (s *Service) fetchRates() (*CurrencyData, error) {
+	var failures []string
+	for _, p := range s.providers {
+		data, err := p.Fetch()
+		if err == nil && len(data.Valute) == 0 {
+			err = fmt.Errorf("no rates")
+		}
 		if err != nil {
-			return err
+			log.Printf("Rate provider %s failed: %v", p.Name(), err)
+			failures = append(failures, p.Name()+": "+err.Error())
+			continue
 		}
+		data.Provider = p.Name()
+		data.FetchedAt = time.Now()
+		return data, nil
+	}
+	return nil, fmt.Errorf("all rate providers failed: %s", strings.Join(failures, "; "))
+}
 
-		s.CurrentCurrencyData.Valute[item.CharCode] = item
+// merge дополняет курсы провайдера данными о валютах, которые он не передаёт. Курсы валют, которых
+// у провайдера нет, остаются прежними.
+func (s *Service) merge(data *CurrencyData) {
+	current := s.Snapshot()
+	for code, item := range data.Valute {
+		if known, ok := current.Valute[code]; ok {
+			if item.ID == "" {
+				item.ID = known.ID
+			}
+			if item.NumCode == "" {
+				item.NumCode = known.NumCode
+			}
+			if item.Name == "" {
+				item.Name = known.Name
+			}
+		}
+		if item.Name == "" {
+			item.Name = code
+		}
+		data.Valute[code] = item
+	}
+}
+
+// updateRates загружает курсы от провайдеров, сохраняет их в базу и историю, подменяет снимок
+// и сообщает о новой версии другим экземплярам сервиса.
+func (s *Service) updateRates() error {
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
+	data, err := s.fetchRates()
+	if err != nil {
+		return err
+	}
+
+	s.merge(data)
+
+	err = s.updateCurrencyRatesAndDataInDB(data)
+	if err != nil {
+		return fmt.Errorf("Ошибка при обновлении курсов валют в базе данных: %w", err)
+	}
+
+	if _, err := s.saveHistory(data); err != nil {
+		return fmt.Errorf("Ошибка при сохранении истории курсов: %w", err)
 	}
 
+	snapshot, err := s.reload()
+	if err != nil {
+		return fmt.Errorf("Ошибка при обновлении снимка курсов: %w", err)
+	}
+	s.publish(snapshot.Version)
+
 	return nil
 }
 
-func (s *Service) parseJSONAndUpdateDB(url string) error {
-	response, err := http.Get(url)
+// effectiveDate возвращает дату, с которой действуют курсы.
+func effectiveDate(data *CurrencyData) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, data.Date)
 	if err != nil {
-		return fmt.Errorf("Error retrieving data: %w", err)
+		return time.Time{}, fmt.Errorf("invalid date %q: %w", data.Date, err)
 	}
-	defer response.Body.Close()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC), nil
+}
 
-	bodyBytes, err := io.ReadAll(response.Body)
+// saveHistory сохраняет курсы в историю. Возвращает false, если курсы на эту дату уже были сохранены.
+func (s *Service) saveHistory(data *CurrencyData) (bool, error) {
+	date, err := effectiveDate(data)
 	if err != nil {
-		return fmt.Errorf("Ошибка при чтении тела ответа: %w", err)
+		return false, err
 	}
 
-	var data CurrencyData
-	err = json.Unmarshal(bodyBytes, &data)
+	var exists bool
+	err = s.repo.QueryRow("SELECT EXISTS (SELECT 1 FROM exchange_rate_history WHERE date = $1 AND previous_url IS NOT NULL)", date).Scan(&exists)
 	if err != nil {
-		return fmt.Errorf("Ошибка при разборе JSON: %w", err)
+		return false, err
 	}
 
-	s.CurrentCurrencyData = &data
+	for _, item := range data.Valute {
+		if item.Nominal == 0 || item.Value <= 0 {
+			continue
+		}
+		rate := item.Value / float64(item.Nominal)
+		_, err := s.repo.Exec(`INSERT INTO exchange_rate_history (currency_code, date, rate_to_ruble, previous_url, provider, fetched_at)
+			VALUES ($1, $2, $3, NULLIF($4, ''), $5, $6)
+			ON CONFLICT (currency_code, date) DO UPDATE SET rate_to_ruble = EXCLUDED.rate_to_ruble,
+				previous_url = COALESCE(EXCLUDED.previous_url, exchange_rate_history.previous_url),
+				provider = EXCLUDED.provider, fetched_at = EXCLUDED.fetched_at`,
+			item.CharCode, date, rate, data.PreviousURL, data.Provider, data.FetchedAt)
+		if err != nil {
+			return false, err
+		}
+	}
 
-	err = s.updateCurrencyRatesAndDataInDB(data.Valute)
+	return !exists, nil
+}
+
+// oldestArchive возвращает самую раннюю загруженную из архива дату и ссылку на архив за предыдущий день торгов.
+func (s *Service) oldestArchive() (time.Time, string, error) {
+	var date time.Time
+	var url string
+	err := s.repo.QueryRow(`SELECT date, previous_url FROM exchange_rate_history
+		WHERE previous_url IS NOT NULL ORDER BY date LIMIT 1`).Scan(&date, &url)
 	if err != nil {
-		return fmt.Errorf("Ошибка при обновлении курсов валют в базе данных: %w", err)
+		return time.Time{}, "", err
 	}
+	return date, url, nil
+}
 
-	return nil
+// BackfillHistory загружает историю курсов из архива ЦБ за последние historyDays дней, переходя по PreviousURL.
+// Уже загруженные дни пропускаются: дойдя до сохранённого дня, загрузка продолжается с самого раннего
+// загруженного дня, поэтому прерванная загрузка при следующем запуске продолжается с места остановки.
+// Загруженные курсы попадают в снимок каждые archiveReloadEvery дней и по окончании загрузки.
+func (s *Service) BackfillHistory() {
+	limit := time.Now().AddDate(0, 0, -historyDays)
+	url := s.Snapshot().PreviousURL
+	loaded := 0
+	defer func() {
+		if loaded == 0 {
+			return
+		}
+		snapshot, err := s.reload()
+		if err != nil {
+			log.Println("Error reloading exchange rates:", err)
+			return
+		}
+		s.publish(snapshot.Version)
+	}()
+
+	for url != "" {
+		data, err := fetch(url)
+		if err != nil {
+			log.Println("Error loading exchange rate archive:", err)
+			return
+		}
+		data.Provider = ProviderCBRJSON
+		data.FetchedAt = time.Now()
+		date, err := effectiveDate(data)
+		if err != nil {
+			log.Println("Error loading exchange rate archive:", err)
+			return
+		}
+		if date.Before(limit) {
+			break
+		}
+
+		added, err := s.saveHistory(data)
+		if err != nil {
+			log.Println("Error saving exchange rate history:", err)
+			return
+		}
+		url = data.PreviousURL
+
+		if !added {
+			// День уже загружен: продолжаем с самого раннего загруженного дня.
+			oldest, oldestURL, err := s.oldestArchive()
+			if errors.Is(err, sql.ErrNoRows) {
+				break
+			}
+			if err != nil {
+				log.Println("Error loading exchange rate archive:", err)
+				return
+			}
+			if oldest.Before(limit) {
+				break
+			}
+			url = oldestURL
+		} else {
+			loaded++
+			if loaded%archiveReloadEvery == 0 {
+				if snapshot, err := s.reload(); err != nil {
+					log.Println("Error reloading exchange rates:", err)
+				} else {
+					s.publish(snapshot.Version)
+				}
+			}
+		}
+
+		time.Sleep(archiveDelay)
+	}
+
+	log.Printf("Exchange rate history loaded: %d days", loaded)
 }
 
-func (s *Service) updateCurrencyRatesAndDataInDB(rates map[string]Valute) error {
+func (s *Service) updateCurrencyRatesAndDataInDB(data *CurrencyData) error {
 	query1 := `
-    INSERT INTO exchange_rates (currency_code, rate_to_ruble)
-    VALUES ($1, $2)
-    ON CONFLICT (currency_code) DO UPDATE SET rate_to_ruble = EXCLUDED.rate_to_ruble;
+    INSERT INTO exchange_rates (currency_code, rate_to_ruble, provider, fetched_at)
+    VALUES ($1, $2, $3, $4)
+    ON CONFLICT (currency_code) DO UPDATE SET rate_to_ruble = EXCLUDED.rate_to_ruble, provider = EXCLUDED.provider, fetched_at = EXCLUDED.fetched_at;
     `
 
 	query2 := `
@@ -110,8 +312,8 @@ func (s *Service) updateCurrencyRatesAndDataInDB(rates map[string]Valute) error
     ON CONFLICT (currency_code) DO UPDATE SET num_code = EXCLUDED.num_code, currency_code = EXCLUDED.currency_code, nominal = EXCLUDED.nominal, name = EXCLUDED.name, value = EXCLUDED.value, previous = EXCLUDED.previous;
     `
 
-	for _, item := range rates {
-		_, err := mydb.GlobalDB.Exec(query1, item.CharCode, item.Value/float64(item.Nominal))
+	for _, item := range data.Valute {
+		_, err := mydb.GlobalDB.Exec(query1, item.CharCode, item.Value/float64(item.Nominal), data.Provider, data.FetchedAt)
 		if err != nil {
 			return err
 		}
@@ -143,13 +345,63 @@ func (s *Service) ScheduleCurrencyUpdates() {
 
 		time.Sleep(nextUpdate.Sub(now))
 
-		err := s.parseJSONAndUpdateDB(secret.Secret.CurrencyURL)
+		err := s.updateRates()
 		if err != nil {
 			fmt.Println("Error in updating database:", err)
 		}
 	}
 }
 
+// RateToRuble возвращает курс валюты к рублю за одну единицу валюты.
+func (s *Service) RateToRuble(code string) (float64, bool) {
+	if code == "RUB" {
+		return 1, true
+	}
+	rate, ok := s.Snapshot().Valute[code]
+	if !ok || rate.Nominal == 0 {
+		return 0, false
+	}
+	return rate.Value / float64(rate.Nominal), true
+}
+
+// RateToRubleOn возвращает курс валюты к рублю, действовавший на дату date: последний курс не позже даты,
+// для дат до начала истории - самый ранний известный. Без истории используется текущий курс.
+func (s *Service) RateToRubleOn(code string, date time.Time) (float64, bool) {
+	if code == "RUB" {
+		return 1, true
+	}
+
+	rates := s.Snapshot().history[code]
+	if len(rates) == 0 {
+		return s.RateToRuble(code)
+	}
+
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	i := sort.Search(len(rates), func(i int) bool { return rates[i].date.After(date) })
+	if i == 0 {
+		return rates[0].rate, true
+	}
+	return rates[i-1].rate, true
+}
+
+// knownRateOn возвращает курс валюты к рублю, действовавший на дату date, только если история курсов
+// валюты дошла до этой даты, то есть курс на дату известен, а не взят из более старых данных.
+func (s *Service) knownRateOn(code string, date time.Time) (float64, bool) {
+	rates := s.Snapshot().history[code]
+	date = time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, time.UTC)
+	if len(rates) == 0 || rates[len(rates)-1].date.Before(date) {
+		return 0, false
+	}
+	return s.RateToRubleOn(code, date)
+}
+
 type CurrencyService interface {
 	ScheduleCurrencyUpdates()
+	BackfillHistory()
+	ListenInvalidations()
+	Refresh() (int64, error)
+	Version() int64
+	Currencies() ([]models.Currency, error)
+	Convert(amount float64, from, to, date string) (*models.Conversion, error)
+	RateHistory(from, to, dateFrom, dateTo string) (*models.RateHistory, error)
 }
@@ -0,0 +1,228 @@
+package currency
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/config"
+	"github.com/wachrusz/Back-End-API/pkg/rates"
+	"github.com/wachrusz/Back-End-API/secret"
+	"gopkg.in/yaml.v3"
+)
+
+// Типы провайдеров курсов.
+const (
+	ProviderCBRJSON = "cbr_json"
+	ProviderCBRXML  = "cbr_xml"
+	ProviderECB     = "ecb"
+	ProviderStatic  = "static"
+)
+
+// Адреса провайдеров по умолчанию.
+const (
+	defaultCBRXMLURL = "https://www.cbr.ru/scripts/XML_daily.asp"
+	defaultECBURL    = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+)
+
+// Provider загружает текущие курсы валют к рублю.
+type Provider interface {
+	Name() string
+	Fetch() (*CurrencyData, error)
+}
+
+// NewProviders создаёт провайдеры по конфигу. Без конфига используется только JSON ЦБ по CURRENCY_URL.
+// rateOn - курс валюты к рублю на дату, через него ECB пересчитывает курсы к евро в рубли.
+func NewProviders(cfgs []config.CurrencyProvider, rateOn func(code string, date time.Time) (float64, bool)) ([]Provider, error) {
+	if len(cfgs) == 0 {
+		cfgs = []config.CurrencyProvider{{Type: ProviderCBRJSON}}
+	}
+
+	providers := make([]Provider, 0, len(cfgs))
+	for _, cfg := range cfgs {
+		switch cfg.Type {
+		case ProviderCBRJSON:
+			url := cfg.URL
+			if url == "" {
+				url = secret.Secret.CurrencyURL
+			}
+			providers = append(providers, &cbrJSON{url: url})
+		case ProviderCBRXML:
+			providers = append(providers, &cbrXML{url: orDefault(cfg.URL, defaultCBRXMLURL)})
+		case ProviderECB:
+			providers = append(providers, &ecb{url: orDefault(cfg.URL, defaultECBURL), rateOn: rateOn})
+		case ProviderStatic:
+			if cfg.Path == "" {
+				return nil, fmt.Errorf("static rate provider requires path")
+			}
+			providers = append(providers, &staticFile{path: cfg.Path})
+		default:
+			return nil, fmt.Errorf("unknown rate provider %q", cfg.Type)
+		}
+	}
+
+	return providers, nil
+}
+
+func orDefault(value, def string) string {
+	if value == "" {
+		return def
+	}
+	return value
+}
+
+// get загружает тело ответа по url.
+func get(url string) ([]byte, error) {
+	response, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("Error retrieving data: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("Error retrieving data: status %s", response.Status)
+	}
+
+	body, err := io.ReadAll(response.Body)
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка при чтении тела ответа: %w", err)
+	}
+	return body, nil
+}
+
+// dayStart возвращает полночь UTC даты в формате RFC3339, как в JSON ЦБ.
+func dayStart(layout, value string) (string, error) {
+	date, err := time.Parse(layout, value)
+	if err != nil {
+		return "", fmt.Errorf("invalid date %q: %w", value, err)
+	}
+	return date.Format(time.RFC3339), nil
+}
+
+// cbrJSON - ежедневные курсы ЦБ в JSON (www.cbr-xml-daily.ru), со ссылками на архив за предыдущие дни.
+type cbrJSON struct {
+	url string
+}
+
+func (p *cbrJSON) Name() string { return ProviderCBRJSON }
+
+func (p *cbrJSON) Fetch() (*CurrencyData, error) {
+	return fetch(p.url)
+}
+
+// cbrXML - ежедневные курсы ЦБ в XML (XML_daily.asp) в кодировке windows-1251 с десятичной запятой.
+type cbrXML struct {
+	url string
+}
+
+func (p *cbrXML) Name() string { return ProviderCBRXML }
+
+func (p *cbrXML) Fetch() (*CurrencyData, error) {
+	body, err := get(p.url)
+	if err != nil {
+		return nil, err
+	}
+
+	daily, err := rates.ParseCBR(body)
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка при разборе XML: %w", err)
+	}
+
+	data := &CurrencyData{Date: daily.Date.Format(time.RFC3339), Valute: make(map[string]Valute, len(daily.Rates))}
+	for _, r := range daily.Rates {
+		data.Valute[r.Code] = Valute{
+			ID:       r.ID,
+			NumCode:  r.NumCode,
+			CharCode: r.Code,
+			Nominal:  r.Nominal,
+			Name:     r.Name,
+			Value:    r.Value,
+		}
+	}
+
+	return data, nil
+}
+
+// ecb - ежедневные курсы ЕЦБ к евро (eurofxref). ЕЦБ не публикует курс рубля, поэтому курсы
+// пересчитываются в рубли через курс евро на дату курсов ЕЦБ, полученный от других провайдеров.
+type ecb struct {
+	url    string
+	rateOn func(code string, date time.Time) (float64, bool)
+}
+
+func (p *ecb) Name() string { return ProviderECB }
+
+func (p *ecb) Fetch() (*CurrencyData, error) {
+	body, err := get(p.url)
+	if err != nil {
+		return nil, err
+	}
+
+	daily, err := rates.ParseECB(body)
+	if err != nil {
+		return nil, fmt.Errorf("Ошибка при разборе XML: %w", err)
+	}
+
+	// Курс евро старше даты курсов ЕЦБ дал бы устаревшие курсы под новой датой.
+	euro, ok := p.rateOn("EUR", daily.Date)
+	if !ok || euro <= 0 {
+		return nil, fmt.Errorf("no euro to ruble rate on %s to convert ECB rates", daily.Date.Format("2006-01-02"))
+	}
+
+	data := &CurrencyData{Date: daily.Date.Format(time.RFC3339), Valute: make(map[string]Valute, len(daily.Rates)+1)}
+	data.Valute["EUR"] = Valute{CharCode: "EUR", Nominal: 1, Value: euro}
+	for _, r := range daily.Rates {
+		if r.Value <= 0 || r.Code == "RUB" {
+			continue
+		}
+		data.Valute[r.Code] = Valute{CharCode: r.Code, Nominal: 1, Value: euro / r.Value}
+	}
+
+	return data, nil
+}
+
+// staticFile - курсы из YAML-файла: дата и курсы к рублю за единицу валюты.
+//
+//	date: "2024-03-01"
+//	rates:
+//	  USD: 90.5
+//	  EUR: 98.2
+type staticFile struct {
+	path string
+}
+
+type staticRates struct {
+	Date  string             `yaml:"date"`
+	Rates map[string]float64 `yaml:"rates"`
+}
+
+func (p *staticFile) Name() string { return ProviderStatic }
+
+func (p *staticFile) Fetch() (*CurrencyData, error) {
+	body, err := os.ReadFile(p.path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rates file %s: %w", p.path, err)
+	}
+
+	var file staticRates
+	if err := yaml.Unmarshal(body, &file); err != nil {
+		return nil, fmt.Errorf("failed to decode YAML from rates file %s: %w", p.path, err)
+	}
+
+	date, err := dayStart("2006-01-02", file.Date)
+	if err != nil {
+		return nil, err
+	}
+
+	data := &CurrencyData{Date: date, Valute: make(map[string]Valute, len(file.Rates))}
+	for code, rate := range file.Rates {
+		if rate <= 0 {
+			return nil, fmt.Errorf("invalid rate %v of %s in %s", rate, code, p.path)
+		}
+		data.Valute[code] = Valute{CharCode: code, Nominal: 1, Value: rate}
+	}
+
+	return data, nil
+}
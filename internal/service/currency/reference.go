@@ -0,0 +1,156 @@
+package currency
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+const (
+	dateLayout = "2006-01-02"
+	// DefaultHistoryDays - период истории курсов по умолчанию.
+	DefaultHistoryDays = 30
+)
+
+// rubleCurrency - рубль, которого нет в таблице валют ЦБ.
+var rubleCurrency = models.Currency{Code: "RUB", NumCode: "643", Name: "Российский рубль", Nominal: 1, Value: 1}
+
+// Currencies возвращает рубль и валюты из таблицы currency с текущими курсами.
+func (s *Service) Currencies() ([]models.Currency, error) {
+	rows, err := s.repo.Query(`SELECT c.currency_code, c.num_code, c.name, c.nominal, c.value,
+			COALESCE(er.provider, ''), er.fetched_at
+		FROM currency c LEFT JOIN exchange_rates er ON er.currency_code = c.currency_code
+		WHERE c.currency_code IS NOT NULL AND c.currency_code <> 'RUB'
+		ORDER BY c.currency_code`)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+	defer rows.Close()
+
+	currencies := []models.Currency{rubleCurrency}
+	for rows.Next() {
+		var c models.Currency
+		var fetchedAt sql.NullTime
+		if err := rows.Scan(&c.Code, &c.NumCode, &c.Name, &c.Nominal, &c.Value, &c.Provider, &fetchedAt); err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+		}
+		if fetchedAt.Valid {
+			c.UpdatedAt = fetchedAt.Time.Format(time.RFC3339)
+		}
+		currencies = append(currencies, c)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrInternal, err)
+	}
+
+	return currencies, nil
+}
+
+// code приводит код валюты к верхнему регистру и проверяет, что курс валюты известен.
+func (s *Service) code(name, value string) (string, error) {
+	code := strings.ToUpper(strings.TrimSpace(value))
+	if code == "" {
+		return "", fmt.Errorf("%w: %s is required", myerrors.ErrValidation, name)
+	}
+	if _, ok := s.RateToRuble(code); !ok {
+		return "", fmt.Errorf("%w: unknown currency %q", myerrors.ErrValidation, value)
+	}
+	return code, nil
+}
+
+// parseDate разбирает дату в формате YYYY-MM-DD, пустая дата заменяется на def.
+func parseDate(name, value string, def time.Time) (time.Time, error) {
+	if value == "" {
+		return def, nil
+	}
+	date, err := time.Parse(dateLayout, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: invalid %s %q, expected YYYY-MM-DD", myerrors.ErrValidation, name, value)
+	}
+	return date, nil
+}
+
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+// pairRate возвращает, сколько единиц to стоит одна единица from на дату date.
+func (s *Service) pairRate(from, to string, date time.Time) float64 {
+	fromRate, _ := s.RateToRubleOn(from, date)
+	toRate, _ := s.RateToRubleOn(to, date)
+	return fromRate / toRate
+}
+
+// Convert пересчитывает amount из валюты from в валюту to по курсам на дату date (по умолчанию сегодня).
+// Сумма округляется до копеек.
+func (s *Service) Convert(amount float64, from, to, date string) (*models.Conversion, error) {
+	if math.IsNaN(amount) || math.IsInf(amount, 0) {
+		return nil, fmt.Errorf("%w: invalid amount", myerrors.ErrValidation)
+	}
+	from, err := s.code("from", from)
+	if err != nil {
+		return nil, err
+	}
+	to, err = s.code("to", to)
+	if err != nil {
+		return nil, err
+	}
+	on, err := parseDate("date", date, today())
+	if err != nil {
+		return nil, err
+	}
+
+	rate := s.pairRate(from, to, on)
+	return &models.Conversion{
+		From:      from,
+		To:        to,
+		Date:      on.Format(dateLayout),
+		Amount:    amount,
+		Rate:      rate,
+		Converted: math.Round(amount*rate*100) / 100,
+	}, nil
+}
+
+// RateHistory возвращает курс пары валют на каждый день периода [dateFrom, dateTo], по умолчанию за последние
+// DefaultHistoryDays дней. Период не длиннее истории курсов. В выходные и праздники действует курс последнего
+// рабочего дня.
+func (s *Service) RateHistory(from, to, dateFrom, dateTo string) (*models.RateHistory, error) {
+	from, err := s.code("from", from)
+	if err != nil {
+		return nil, err
+	}
+	to, err = s.code("to", to)
+	if err != nil {
+		return nil, err
+	}
+	end, err := parseDate("date_to", dateTo, today())
+	if err != nil {
+		return nil, err
+	}
+	start, err := parseDate("date_from", dateFrom, end.AddDate(0, 0, -DefaultHistoryDays+1))
+	if err != nil {
+		return nil, err
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("%w: date_to is before date_from", myerrors.ErrValidation)
+	}
+	if end.Sub(start) >= historyDays*24*time.Hour {
+		return nil, fmt.Errorf("%w: period must be shorter than %d days", myerrors.ErrValidation, historyDays)
+	}
+
+	history := &models.RateHistory{From: from, To: to, Rates: make([]models.RatePoint, 0)}
+	for day := start; !day.After(end); day = day.AddDate(0, 0, 1) {
+		history.Rates = append(history.Rates, models.RatePoint{
+			Date: day.Format(dateLayout),
+			Rate: s.pairRate(from, to, day),
+		})
+	}
+
+	return history, nil
+}
@@ -0,0 +1,161 @@
+package currency
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strconv"
+	"time"
+)
+
+// Snapshot - неизменяемый снимок курсов. Обновление строит новый снимок и атомарно подменяет текущий,
+// поэтому чтение курсов не блокируется и не видит частично обновлённых данных.
+type Snapshot struct {
+	// Version - время получения последнего сохранённого курса в наносекундах Unix. Одинаковые данные
+	// в базе дают одинаковую версию на всех экземплярах сервиса.
+	Version int64
+	// PreviousURL - архив ЦБ за день торгов перед последним загруженным днём.
+	PreviousURL string
+	Valute      map[string]Valute
+	// history - курсы к рублю по валютам, отсортированные по дате начала действия.
+	history map[string][]datedRate
+}
+
+// datedRate - курс к рублю за единицу валюты, действующий с даты date.
+type datedRate struct {
+	date time.Time
+	rate float64
+}
+
+// Broadcaster рассылает сообщения всем экземплярам сервиса.
+type Broadcaster interface {
+	Publish(message string) error
+	// Subscribe вызывает handle для каждого сообщения, пока подписка не закроется.
+	Subscribe(handle func(message string))
+}
+
+// Snapshot возвращает текущий снимок курсов.
+func (s *Service) Snapshot() *Snapshot {
+	return s.snapshot.Load()
+}
+
+// Version возвращает версию текущего снимка курсов.
+func (s *Service) Version() int64 {
+	return s.Snapshot().Version
+}
+
+// load читает курсы и их историю из базы в новый снимок.
+func (s *Service) load() (*Snapshot, error) {
+	snapshot := &Snapshot{
+		Valute:  make(map[string]Valute),
+		history: make(map[string][]datedRate),
+	}
+
+	var version sql.NullTime
+	err := s.repo.QueryRow(`SELECT GREATEST((SELECT MAX(fetched_at) FROM exchange_rates),
+		(SELECT MAX(fetched_at) FROM exchange_rate_history))`).Scan(&version)
+	if err != nil {
+		return nil, err
+	}
+	if version.Valid {
+		snapshot.Version = version.Time.UnixNano()
+	}
+
+	err = s.repo.QueryRow(`SELECT COALESCE((SELECT previous_url FROM exchange_rate_history
+		WHERE previous_url IS NOT NULL ORDER BY date DESC LIMIT 1), '')`).Scan(&snapshot.PreviousURL)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.repo.Query("SELECT id, num_code, currency_code, nominal, name, value, previous FROM currency")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var item Valute
+		err := rows.Scan(&item.ID, &item.NumCode, &item.CharCode, &item.Nominal, &item.Name, &item.Value, &item.Previous)
+		if err != nil {
+			return nil, err
+		}
+
+		snapshot.Valute[item.CharCode] = item
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	history, err := s.repo.Query("SELECT currency_code, date, rate_to_ruble FROM exchange_rate_history ORDER BY currency_code, date")
+	if err != nil {
+		return nil, err
+	}
+	defer history.Close()
+
+	for history.Next() {
+		var code string
+		var r datedRate
+		if err := history.Scan(&code, &r.date, &r.rate); err != nil {
+			return nil, err
+		}
+		r.date = time.Date(r.date.Year(), r.date.Month(), r.date.Day(), 0, 0, 0, 0, time.UTC)
+		snapshot.history[code] = append(snapshot.history[code], r)
+	}
+	if err := history.Err(); err != nil {
+		return nil, err
+	}
+
+	return snapshot, nil
+}
+
+// reload перечитывает курсы из базы и подменяет текущий снимок.
+func (s *Service) reload() (*Snapshot, error) {
+	s.reloadMu.Lock()
+	defer s.reloadMu.Unlock()
+
+	snapshot, err := s.load()
+	if err != nil {
+		return nil, err
+	}
+	s.snapshot.Store(snapshot)
+	return snapshot, nil
+}
+
+// publish сообщает другим экземплярам сервиса о новой версии курсов.
+func (s *Service) publish(version int64) {
+	if s.events == nil {
+		return
+	}
+	if err := s.events.Publish(strconv.FormatInt(version, 10)); err != nil {
+		log.Println("Error publishing exchange rate update:", err)
+	}
+}
+
+// Refresh загружает курсы от провайдеров вне расписания и возвращает версию нового снимка.
+func (s *Service) Refresh() (int64, error) {
+	if err := s.updateRates(); err != nil {
+		return 0, fmt.Errorf("error refreshing exchange rates: %w", err)
+	}
+	return s.Version(), nil
+}
+
+// ListenInvalidations перечитывает курсы из базы, когда другой экземпляр сервиса сообщает о более новой версии.
+func (s *Service) ListenInvalidations() {
+	if s.events == nil {
+		return
+	}
+
+	s.events.Subscribe(func(message string) {
+		version, err := strconv.ParseInt(message, 10, 64)
+		if err != nil {
+			log.Printf("Invalid exchange rate version %q: %v", message, err)
+			return
+		}
+		if version <= s.Version() {
+			return
+		}
+		if _, err := s.reload(); err != nil {
+			log.Println("Error reloading exchange rates:", err)
+		}
+	})
+}
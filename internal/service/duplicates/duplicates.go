@@ -0,0 +1,211 @@
+// Package duplicates provides detection and merging of duplicate incomes and expenses.
+package duplicates
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	repo "github.com/wachrusz/Back-End-API/internal/repository"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+const dateLayout = "2006-01-02"
+
+const (
+	// DefaultPeriodDays - за сколько последних дней ищутся дубликаты, если период не задан.
+	DefaultPeriodDays = 90
+	// MaxRecords - максимальное число операций, среди которых ищутся дубликаты за один запрос.
+	MaxRecords = 10000
+)
+
+type Duplicates interface {
+	Check(userID string, records []models.DuplicateRecord) ([][]models.DuplicateMatch, error)
+	List(filter *models.DuplicateFilter) ([]models.DuplicatePair, error)
+	Merge(actor models.Actor, userID, kind, keepID, duplicateID string) error
+	Dismiss(userID, kind, firstID, secondID string) error
+}
+
+type Service struct {
+	duplicates repo.DuplicateRepo
+}
+
+func NewService(dr repo.DuplicateRepo) *Service {
+	return &Service{duplicates: dr}
+}
+
+// Check ищет среди сохранённых операций пользователя вероятные дубликаты каждой из records,
+// например новых операций перед созданием или строк выписки перед импортом.
+// Результат i соответствует records[i].
+func (s *Service) Check(userID string, records []models.DuplicateRecord) ([][]models.DuplicateMatch, error) {
+	matches := make([][]models.DuplicateMatch, len(records))
+
+	var from, to time.Time
+	for _, record := range records {
+		date, err := time.Parse(dateLayout, record.Date)
+		if err != nil {
+			continue
+		}
+		if from.IsZero() || date.Before(from) {
+			from = date
+		}
+		if to.IsZero() || date.After(to) {
+			to = date
+		}
+	}
+	if from.IsZero() {
+		return matches, nil
+	}
+
+	stored, err := s.duplicates.Records(&models.DuplicateFilter{
+		UserID:   userID,
+		DateFrom: from.AddDate(0, 0, -MaxDateDiff).Format(dateLayout),
+		DateTo:   to.AddDate(0, 0, MaxDateDiff).Format(dateLayout),
+	}, MaxRecords)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range records {
+		record := &records[i]
+		for j := range stored {
+			candidate := &stored[j]
+			if record.ID != "" && record.Kind == candidate.Kind && record.ID == candidate.ID {
+				continue
+			}
+			if score := Score(record, candidate); score >= LikelyScore {
+				matches[i] = append(matches[i], models.DuplicateMatch{DuplicateRecord: *candidate, Score: score})
+			}
+		}
+		sort.SliceStable(matches[i], func(a, b int) bool { return matches[i][a].Score > matches[i][b].Score })
+	}
+
+	return matches, nil
+}
+
+// List возвращает вероятные дубликаты среди операций пользователя, сначала самые похожие.
+// Пары, отмеченные как не дубликаты, пропускаются.
+func (s *Service) List(filter *models.DuplicateFilter) ([]models.DuplicatePair, error) {
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+
+	records, err := s.duplicates.Records(filter, MaxRecords+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(records) > MaxRecords {
+		return nil, fmt.Errorf("%w: more than %d transactions match, narrow the period", myerrors.ErrValidation, MaxRecords)
+	}
+
+	dismissals, err := s.duplicates.Dismissals(filter.UserID)
+	if err != nil {
+		return nil, err
+	}
+	dismissed := make(map[string]bool, len(dismissals))
+	for _, d := range dismissals {
+		dismissed[pairKey(d.Kind, d.FirstID, d.SecondID)] = true
+	}
+
+	// Операции упорядочены по дате, поэтому каждую достаточно сравнить со следующими в пределах MaxDateDiff.
+	pairs := make([]models.DuplicatePair, 0)
+	for i := range records {
+		a := &records[i]
+		for j := i + 1; j < len(records); j++ {
+			b := &records[j]
+			if days, ok := dateDiff(a.Date, b.Date); !ok || days > MaxDateDiff {
+				break
+			}
+			score := Score(a, b)
+			if score < LikelyScore || dismissed[pairKey(a.Kind, a.ID, b.ID)] {
+				continue
+			}
+			first, second := *a, *b
+			if idLess(second.ID, first.ID) {
+				first, second = second, first
+			}
+			pairs = append(pairs, models.DuplicatePair{Kind: a.Kind, First: first, Second: second, Score: score})
+		}
+	}
+
+	sort.SliceStable(pairs, func(i, j int) bool {
+		if pairs[i].Score != pairs[j].Score {
+			return pairs[i].Score > pairs[j].Score
+		}
+		return pairs[i].Second.Date > pairs[j].Second.Date
+	})
+	return pairs, nil
+}
+
+// Merge оставляет операцию keepID и переносит дубликат duplicateID в корзину.
+func (s *Service) Merge(actor models.Actor, userID, kind, keepID, duplicateID string) error {
+	if err := validatePair(kind, keepID, duplicateID); err != nil {
+		return err
+	}
+	return s.duplicates.Merge(actor, userID, kind, keepID, duplicateID)
+}
+
+// Dismiss отмечает пару как не дубликаты, чтобы она больше не предлагалась.
+func (s *Service) Dismiss(userID, kind, firstID, secondID string) error {
+	if err := validatePair(kind, firstID, secondID); err != nil {
+		return err
+	}
+	return s.duplicates.Dismiss(userID, kind, firstID, secondID)
+}
+
+// pairKey не зависит от порядка id в паре.
+func pairKey(kind, a, b string) string {
+	if idLess(b, a) {
+		a, b = b, a
+	}
+	return kind + ":" + a + ":" + b
+}
+
+func idLess(a, b string) bool {
+	na, errA := strconv.ParseInt(a, 10, 64)
+	nb, errB := strconv.ParseInt(b, 10, 64)
+	if errA != nil || errB != nil {
+		return a < b
+	}
+	return na < nb
+}
+
+func validatePair(kind, firstID, secondID string) error {
+	if kind != models.KindExpense && kind != models.KindIncome {
+		return fmt.Errorf("%w: kind must be expense or income", myerrors.ErrValidation)
+	}
+	for _, id := range []string{firstID, secondID} {
+		if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+			return fmt.Errorf("%w: invalid id %q", myerrors.ErrValidation, id)
+		}
+	}
+	if firstID == secondID {
+		return fmt.Errorf("%w: a transaction is not a duplicate of itself", myerrors.ErrValidation)
+	}
+	return nil
+}
+
+func validateFilter(filter *models.DuplicateFilter) error {
+	switch filter.Kind {
+	case "", models.KindExpense, models.KindIncome:
+	default:
+		return fmt.Errorf("%w: kind must be expense, income or empty", myerrors.ErrValidation)
+	}
+	for _, date := range []string{filter.DateFrom, filter.DateTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(dateLayout, date); err != nil {
+			return fmt.Errorf("%w: invalid date %q, expected YYYY-MM-DD", myerrors.ErrValidation, date)
+		}
+	}
+	if filter.DateFrom == "" && filter.DateTo == "" {
+		filter.DateFrom = time.Now().AddDate(0, 0, -DefaultPeriodDays).Format(dateLayout)
+	}
+	if filter.DateFrom != "" && filter.DateTo != "" && filter.DateFrom > filter.DateTo {
+		return fmt.Errorf("%w: date_from is after date_to", myerrors.ErrValidation)
+	}
+	return nil
+}
@@ -0,0 +1,134 @@
+package duplicates
+
+import (
+	"math"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+const (
+	// MaxDateDiff - на сколько дней могут различаться даты дубликатов: банк проводит операцию
+	// на день-два позже, чем её вносят вручную.
+	MaxDateDiff = 3
+	// AmountTolerance - допустимое относительное расхождение сумм, например из-за округления курса.
+	AmountTolerance = 0.01
+	// LikelyScore - оценка, начиная с которой пара считается вероятными дубликатами.
+	LikelyScore = 0.75
+)
+
+// Веса признаков в оценке. Сумма весов равна 1.
+const (
+	weightAmount  = 0.4
+	weightDate    = 0.25
+	weightPayee   = 0.25
+	weightAccount = 0.1
+)
+
+// Score оценивает от 0 до 1, насколько вероятно, что a и b - одна и та же операция.
+// Операции разного вида, в разной валюте, с датами дальше MaxDateDiff или суммами
+// дальше AmountTolerance получают 0.
+func Score(a, b *models.DuplicateRecord) float64 {
+	if a.Kind != b.Kind || !strings.EqualFold(a.Currency, b.Currency) {
+		return 0
+	}
+
+	days, ok := dateDiff(a.Date, b.Date)
+	if !ok || days > MaxDateDiff {
+		return 0
+	}
+
+	diff := math.Abs(a.Amount - b.Amount)
+	limit := AmountTolerance * math.Max(math.Abs(a.Amount), math.Abs(b.Amount))
+	if diff > limit && diff >= 0.005 {
+		return 0
+	}
+	amount := 1.0
+	if diff >= 0.005 {
+		amount = 1 - diff/limit
+	}
+
+	date := 1 - float64(days)/float64(MaxDateDiff+1)
+
+	score := weightAmount*amount + weightDate*date + weightPayee*payeeSimilarity(a.Payee, b.Payee) + weightAccount*accountSimilarity(a.BankAccount, b.BankAccount)
+	return math.Round(score*100) / 100
+}
+
+func dateDiff(a, b string) (int, bool) {
+	da, err := time.Parse(dateLayout, a)
+	if err != nil {
+		return 0, false
+	}
+	db, err := time.Parse(dateLayout, b)
+	if err != nil {
+		return 0, false
+	}
+	days := int(math.Abs(da.Sub(db).Hours()) / 24)
+	return days, true
+}
+
+// payeeSimilarity сравнивает контрагентов по совпадению пар соседних символов (коэффициент Сёренсена).
+// Если контрагент неизвестен хотя бы у одной операции, сравнение ничего не говорит и даёт 0.5.
+func payeeSimilarity(a, b string) float64 {
+	na, nb := normalize(a), normalize(b)
+	if na == "" || nb == "" {
+		return 0.5
+	}
+	if na == nb || strings.Contains(na, nb) || strings.Contains(nb, na) {
+		return 1
+	}
+
+	ba, bb := bigrams(na), bigrams(nb)
+	if len(ba) == 0 || len(bb) == 0 {
+		return 0
+	}
+	common := 0
+	for bigram, n := range ba {
+		common += min(n, bb[bigram])
+	}
+	total := 0
+	for _, n := range ba {
+		total += n
+	}
+	for _, n := range bb {
+		total += n
+	}
+	return 2 * float64(common) / float64(total)
+}
+
+// accountSimilarity даёт 1 для одного счёта, 0 для разных и 0.5, если счёт известен не у обеих операций.
+func accountSimilarity(a, b string) float64 {
+	switch {
+	case a == "" || b == "":
+		return 0.5
+	case a == b:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// normalize оставляет только буквы и цифры в нижнем регистре, ё заменяется на е.
+func normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(s) {
+		switch {
+		case r == 'ё':
+			b.WriteRune('е')
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+func bigrams(s string) map[string]int {
+	runes := []rune(s)
+	result := make(map[string]int, len(runes))
+	for i := 0; i+1 < len(runes); i++ {
+		result[string(runes[i:i+2])]++
+	}
+	return result
+}
@@ -0,0 +1,99 @@
+package duplicates
+
+import (
+	"math"
+	"testing"
+
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+func TestScore(t *testing.T) {
+	base := models.DuplicateRecord{
+		Kind:        models.KindExpense,
+		Date:        "2024-03-10",
+		Amount:      1000,
+		Currency:    "RUB",
+		Payee:       "Магнит",
+		BankAccount: "40817810000000000001",
+	}
+
+	tests := []struct {
+		name   string
+		change func(r *models.DuplicateRecord)
+		want   float64
+	}{
+		{name: "same operation", change: func(r *models.DuplicateRecord) {}, want: 1},
+		{name: "other kind", change: func(r *models.DuplicateRecord) { r.Kind = models.KindIncome }, want: 0},
+		{name: "other currency", change: func(r *models.DuplicateRecord) { r.Currency = "USD" }, want: 0},
+		{name: "currency case", change: func(r *models.DuplicateRecord) { r.Currency = "rub" }, want: 1},
+		{name: "invalid date", change: func(r *models.DuplicateRecord) { r.Date = "10.03.2024" }, want: 0},
+		// 0.4 + 0.25*(1-3/4) + 0.25 + 0.1
+		{name: "three days apart", change: func(r *models.DuplicateRecord) { r.Date = "2024-03-13" }, want: 0.81},
+		{name: "four days apart", change: func(r *models.DuplicateRecord) { r.Date = "2024-03-06" }, want: 0},
+		// 0.4*(1-5/10.05) + 0.25 + 0.25 + 0.1
+		{name: "amount within tolerance", change: func(r *models.DuplicateRecord) { r.Amount = 1005 }, want: 0.8},
+		{name: "amount beyond tolerance", change: func(r *models.DuplicateRecord) { r.Amount = 1011 }, want: 0},
+		// 0.4 + 0.25*(1-1/4) + 0.25*0.5 + 0.1
+		{name: "unknown payee", change: func(r *models.DuplicateRecord) { r.Date, r.Payee = "2024-03-11", "" }, want: 0.81},
+		// 0.4 + 0.25 + 0.25 + 0.1*0
+		{name: "other account", change: func(r *models.DuplicateRecord) { r.BankAccount = "40817810000000000002" }, want: 0.9},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := base
+			tt.change(&b)
+			if got := Score(&base, &b); got != tt.want {
+				t.Errorf("Score = %v, want %v", got, tt.want)
+			}
+			if got := Score(&b, &base); got != tt.want {
+				t.Errorf("Score reversed = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestScoreAmountRounding проверяет, что расхождение меньше 0.005 считается округлением даже для сумм,
+// у которых допуск AmountTolerance меньше копейки. Счёт не указан, поэтому совпадение даёт 0.95.
+func TestScoreAmountRounding(t *testing.T) {
+	tests := []struct {
+		a, b float64
+		want float64
+	}{
+		{a: 0.10, b: 0.104, want: 0.95},
+		{a: 0.10, b: 0.106, want: 0},
+		{a: 0.30, b: 0.1 + 0.2, want: 0.95},
+	}
+
+	for _, tt := range tests {
+		a := models.DuplicateRecord{Kind: models.KindExpense, Date: "2024-03-10", Amount: tt.a, Currency: "RUB", Payee: "Кафе"}
+		b := a
+		b.Amount = tt.b
+		if got := Score(&a, &b); got != tt.want {
+			t.Errorf("Score(%v, %v) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestPayeeSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{a: "", b: "Магнит", want: 0.5},
+		{a: "Магнит", b: "", want: 0.5},
+		{a: "***", b: "Магнит", want: 0.5},
+		{a: "Ёлка", b: "елка", want: 1},
+		{a: "ООО «Магнит»", b: "магнит", want: 1},
+		{a: "abcd", b: "abce", want: 2.0 / 3},
+		{a: "a", b: "b", want: 0},
+		{a: "Пятёрочка", b: "Перекрёсток", want: 0.11},
+	}
+
+	for _, tt := range tests {
+		got := payeeSimilarity(tt.a, tt.b)
+		if math.Abs(got-tt.want) > 0.01 {
+			t.Errorf("payeeSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
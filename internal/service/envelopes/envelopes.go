@@ -0,0 +1,211 @@
+// Package envelopes provides the envelope (zero-based) budgeting mode.
+package envelopes
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	repo "github.com/wachrusz/Back-End-API/internal/repository"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+const dateLayout = "2006-01-02"
+
+type Envelopes interface {
+	Create(envelope *models.Envelope) (int64, error)
+	Delete(id, userID string) error
+	ListByUserID(userID string) ([]models.Envelope, error)
+	Move(movement *models.EnvelopeMovement) (int64, error)
+	Movements(userID string, month time.Time) ([]models.EnvelopeMovement, error)
+	Report(userID string, month time.Time) (*models.EnvelopeReport, error)
+	Close(userID string, month time.Time) (*models.EnvelopeReport, error)
+}
+
+type Service struct {
+	envelopes repo.EnvelopeRepo
+}
+
+func NewService(er repo.EnvelopeRepo) *Service {
+	return &Service{envelopes: er}
+}
+
+func (s *Service) Create(envelope *models.Envelope) (int64, error) {
+	if _, err := strconv.ParseInt(envelope.CategoryID, 10, 64); err != nil {
+		return 0, fmt.Errorf("%w: invalid category id %q", myerrors.ErrValidation, envelope.CategoryID)
+	}
+	return s.envelopes.Create(envelope)
+}
+
+func (s *Service) Delete(id, userID string) error {
+	return s.envelopes.Delete(id, userID)
+}
+
+func (s *Service) ListByUserID(userID string) ([]models.Envelope, error) {
+	return s.envelopes.ListByUserID(userID)
+}
+
+// Move перемещает деньги между конвертами или между конвертом и суммой к распределению
+// в месяце movement.Month (YYYY-MM или YYYY-MM-DD; по умолчанию текущий месяц).
+func (s *Service) Move(movement *models.EnvelopeMovement) (int64, error) {
+	month := monthStart(time.Now())
+	if movement.Month != "" {
+		parsed, err := parseMonth(movement.Month)
+		if err != nil {
+			return 0, err
+		}
+		month = parsed
+	}
+	movement.Month = month.Format(dateLayout)
+
+	if movement.Amount <= 0 {
+		return 0, fmt.Errorf("%w: amount must be positive", myerrors.ErrValidation)
+	}
+	movement.Amount = round(movement.Amount)
+	if movement.FromEnvelopeID == "" && movement.ToEnvelopeID == "" {
+		return 0, fmt.Errorf("%w: from_envelope_id or to_envelope_id is required", myerrors.ErrValidation)
+	}
+	if movement.FromEnvelopeID == movement.ToEnvelopeID {
+		return 0, fmt.Errorf("%w: cannot move money to the same envelope", myerrors.ErrValidation)
+	}
+	movement.Note = strings.TrimSpace(movement.Note)
+	if len(movement.Note) > 255 {
+		return 0, fmt.Errorf("%w: note is too long", myerrors.ErrValidation)
+	}
+
+	return s.envelopes.CreateMovement(movement)
+}
+
+func (s *Service) Movements(userID string, month time.Time) ([]models.EnvelopeMovement, error) {
+	return s.envelopes.Movements(userID, monthStart(month))
+}
+
+// Report считает конвертный бюджет за месяц. Остатки конвертов и нераспределённая сумма переносятся
+// из предыдущего месяца, только если он закрыт.
+func (s *Service) Report(userID string, month time.Time) (*models.EnvelopeReport, error) {
+	month = monthStart(month)
+
+	envelopes, err := s.envelopes.ListByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	activity, err := s.envelopes.Activity(userID, month)
+	if err != nil {
+		return nil, err
+	}
+	previous, err := s.envelopes.Closing(userID, month.AddDate(0, -1, 0))
+	if err != nil {
+		return nil, err
+	}
+	current, err := s.envelopes.Closing(userID, month)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &models.EnvelopeReport{
+		Month:          month.Format(dateLayout),
+		Closed:         current != nil,
+		PreviousClosed: previous != nil,
+		Income:         round(activity.Income),
+		Assigned:       round(activity.FromPool),
+		Envelopes:      make([]models.EnvelopeLine, 0, len(envelopes)),
+	}
+	if previous != nil {
+		report.CarriedToAssign = previous.ToAssign
+	}
+
+	budgeted := make(map[string]bool, len(envelopes))
+	for _, envelope := range envelopes {
+		budgeted[envelope.CategoryID] = true
+		line := models.EnvelopeLine{
+			EnvelopeID:   envelope.ID,
+			CategoryID:   envelope.CategoryID,
+			CategoryName: envelope.CategoryName,
+			Assigned:     round(activity.Assigned[envelope.ID]),
+			Spent:        round(activity.Spent[envelope.CategoryID]),
+		}
+		if previous != nil {
+			line.Carried = previous.Balances[envelope.ID]
+		}
+		line.Balance = round(line.Carried + line.Assigned - line.Spent)
+		report.Envelopes = append(report.Envelopes, line)
+	}
+
+	// Расходы вне конвертов оплачиваются из нераспределённых денег.
+	for category, spent := range activity.Spent {
+		if !budgeted[category] {
+			report.UnbudgetedSpent += spent
+		}
+	}
+	report.UnbudgetedSpent = round(report.UnbudgetedSpent)
+	report.AvailableToAssign = round(report.CarriedToAssign + report.Income - report.Assigned - report.UnbudgetedSpent)
+
+	return report, nil
+}
+
+// Close закрывает месяц: положительные остатки конвертов переносятся на следующий месяц,
+// а перерасход конвертов и нераспределённая сумма - в сумму к распределению следующего месяца.
+// Месяцы закрываются по порядку, будущие месяцы закрыть нельзя.
+func (s *Service) Close(userID string, month time.Time) (*models.EnvelopeReport, error) {
+	month = monthStart(month)
+	if month.After(monthStart(time.Now())) {
+		return nil, fmt.Errorf("%w: cannot close a future month", myerrors.ErrValidation)
+	}
+
+	report, err := s.Report(userID, month)
+	if err != nil {
+		return nil, err
+	}
+	if report.Closed {
+		return nil, fmt.Errorf("%w: month %s is already closed", myerrors.ErrValidation, month.Format("2006-01"))
+	}
+	if !report.PreviousClosed {
+		closedBefore, err := s.envelopes.HasClosingBefore(userID, month)
+		if err != nil {
+			return nil, err
+		}
+		if closedBefore {
+			return nil, fmt.Errorf("%w: close %s first", myerrors.ErrValidation, month.AddDate(0, -1, 0).Format("2006-01"))
+		}
+	}
+
+	closing := &models.EnvelopeClosing{
+		Month:    report.Month,
+		ToAssign: report.AvailableToAssign,
+		Balances: make(map[string]float64, len(report.Envelopes)),
+	}
+	for _, line := range report.Envelopes {
+		if line.Balance < 0 {
+			closing.ToAssign += line.Balance
+			continue
+		}
+		closing.Balances[line.EnvelopeID] = line.Balance
+	}
+	closing.ToAssign = round(closing.ToAssign)
+
+	if err := s.envelopes.Close(userID, closing); err != nil {
+		return nil, err
+	}
+	report.Closed = true
+	return report, nil
+}
+
+func parseMonth(value string) (time.Time, error) {
+	for _, layout := range []string{"2006-01", dateLayout} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return monthStart(t), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("%w: invalid month %q, expected YYYY-MM", myerrors.ErrValidation, value)
+}
+
+func monthStart(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,256 @@
+// Package forecast provides the cash-flow forecast of account balances.
+package forecast
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	repo "github.com/wachrusz/Back-End-API/internal/repository"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	DefaultDays = 30
+	MaxDays     = 365
+
+	// spendWeeks - за сколько последних недель считаются средние необязательные расходы.
+	spendWeeks = 12
+)
+
+type Forecast interface {
+	Forecast(userID string, days int, threshold float64) (*models.Forecast, error)
+}
+
+// UpcomingSource возвращает предстоящие повторения серий; его реализует сервис recurring.
+type UpcomingSource interface {
+	Upcoming(userID string, from, to time.Time) ([]models.RecurringOccurrence, error)
+}
+
+type RateSource interface {
+	RateToRuble(code string) (float64, bool)
+}
+
+type Service struct {
+	forecast  repo.ForecastRepo
+	recurring UpcomingSource
+	rates     RateSource
+}
+
+func NewService(fr repo.ForecastRepo, us UpcomingSource, rs RateSource) *Service {
+	return &Service{forecast: fr, recurring: us, rates: rs}
+}
+
+// series - прогноз одного ряда в рублях по дням.
+type series struct {
+	income        []float64
+	planned       []float64
+	discretionary []float64
+}
+
+func newSeries(days int) *series {
+	return &series{
+		income:        make([]float64, days),
+		planned:       make([]float64, days),
+		discretionary: make([]float64, days),
+	}
+}
+
+// Forecast прогнозирует остатки каждого подключённого счёта и общий остаток на days дней, начиная с завтрашнего.
+// Остаток на сегодня берётся из счетов. Каждый день к нему добавляются запланированные доходы и расходы,
+// предстоящие повторения серий и средние необязательные расходы за этот день недели за последние недели.
+// Операции со счётом, которого нет среди подключённых, учитываются только в общем остатке.
+// Счета прогнозируются в своей валюте, общий остаток - в рублях.
+func (s *Service) Forecast(userID string, days int, threshold float64) (*models.Forecast, error) {
+	if days == 0 {
+		days = DefaultDays
+	}
+	if days < 0 || days > MaxDays {
+		return nil, fmt.Errorf("%w: days must be between 1 and %d", myerrors.ErrValidation, MaxDays)
+	}
+
+	now := time.Now()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	from, to := today.AddDate(0, 0, 1), today.AddDate(0, 0, days+1)
+
+	accounts, err := s.forecast.Accounts(userID)
+	if err != nil {
+		return nil, err
+	}
+	planned, err := s.forecast.Planned(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	upcoming, err := s.recurring.Upcoming(userID, from, to)
+	if err != nil {
+		return nil, err
+	}
+	spend, err := s.forecast.WeekdaySpend(userID, today.AddDate(0, 0, -7*spendWeeks), today)
+	if err != nil {
+		return nil, err
+	}
+
+	byNumber := make(map[string]*series, len(accounts))
+	perAccount := make([]*series, len(accounts))
+	for i, account := range accounts {
+		perAccount[i] = newSeries(days)
+		if _, ok := byNumber[account.AccountNumber]; !ok {
+			byNumber[account.AccountNumber] = perAccount[i]
+		}
+	}
+	total := newSeries(days)
+
+	flows := planned
+	for _, occ := range upcoming {
+		flows = append(flows, models.ForecastFlow{
+			Date:        occ.Date,
+			Kind:        occ.Kind,
+			Amount:      occ.Amount,
+			Currency:    occ.Currency,
+			BankAccount: occ.BankAccount,
+		})
+	}
+	for _, flow := range flows {
+		date, err := time.Parse(dateLayout, flow.Date)
+		if err != nil {
+			continue
+		}
+		day := int(date.Sub(from).Hours() / 24)
+		if day < 0 || day >= days {
+			continue
+		}
+		amount := s.toRubles(flow.Amount, flow.Currency)
+		for _, target := range []*series{total, byNumber[flow.BankAccount]} {
+			if target == nil {
+				continue
+			}
+			if flow.Kind == models.KindIncome {
+				target.income[day] += amount
+			} else {
+				target.planned[day] += amount
+			}
+		}
+	}
+
+	for _, weekday := range spend {
+		average := weekday.Amount / spendWeeks
+		for day := 0; day < days; day++ {
+			if int(from.AddDate(0, 0, day).Weekday()) != weekday.Weekday {
+				continue
+			}
+			total.discretionary[day] += average
+			if target := byNumber[weekday.BankAccount]; target != nil {
+				target.discretionary[day] += average
+			}
+		}
+	}
+
+	forecast := &models.Forecast{
+		From:      today.Format(dateLayout),
+		Days:      days,
+		Threshold: threshold,
+		Accounts:  make([]models.ForecastSeries, 0, len(accounts)),
+		Warnings:  make([]models.ForecastWarning, 0),
+	}
+
+	var totalStart float64
+	for i, account := range accounts {
+		currency := account.AccountCurrency
+		if currency == "" {
+			currency = "RUB"
+		}
+		totalStart += s.toRubles(account.AccountState, currency)
+
+		line := build(perAccount[i], from, account.AccountState, s.toRubles(1, currency))
+		line.AccountID = account.ID
+		line.AccountName = account.AccountName
+		line.Currency = currency
+		forecast.Accounts = append(forecast.Accounts, line)
+	}
+	forecast.Total = build(total, from, totalStart, 1)
+	forecast.Total.Currency = "RUB"
+
+	lines := append([]models.ForecastSeries{}, forecast.Accounts...)
+	lines = append(lines, forecast.Total)
+	for i := range lines {
+		if warning := lowBalance(&lines[i], today, threshold); warning != nil {
+			forecast.Warnings = append(forecast.Warnings, *warning)
+		}
+	}
+
+	return forecast, nil
+}
+
+// build считает остатки по дням. rate - курс валюты ряда к рублю.
+func build(daily *series, from time.Time, start, rate float64) models.ForecastSeries {
+	line := models.ForecastSeries{
+		StartBalance: round(start),
+		MinBalance:   round(start),
+		MinDate:      from.AddDate(0, 0, -1).Format(dateLayout),
+		Points:       make([]models.ForecastPoint, len(daily.income)),
+	}
+
+	balance := start
+	for day := range daily.income {
+		point := models.ForecastPoint{
+			Date:          from.AddDate(0, 0, day).Format(dateLayout),
+			Income:        round(daily.income[day] / rate),
+			Planned:       round(daily.planned[day] / rate),
+			Discretionary: round(daily.discretionary[day] / rate),
+		}
+		balance += (daily.income[day] - daily.planned[day] - daily.discretionary[day]) / rate
+		point.Balance = round(balance)
+		if point.Balance < line.MinBalance {
+			line.MinBalance = point.Balance
+			line.MinDate = point.Date
+		}
+		line.Points[day] = point
+	}
+
+	return line
+}
+
+// lowBalance возвращает предупреждение, если остаток ряда опускается ниже порога.
+func lowBalance(line *models.ForecastSeries, today time.Time, threshold float64) *models.ForecastWarning {
+	if line.MinBalance >= threshold {
+		return nil
+	}
+
+	warning := &models.ForecastWarning{
+		AccountID:   line.AccountID,
+		AccountName: line.AccountName,
+		Date:        today.Format(dateLayout),
+		Balance:     line.StartBalance,
+		MinBalance:  line.MinBalance,
+		MinDate:     line.MinDate,
+	}
+	if line.StartBalance >= threshold {
+		for _, point := range line.Points {
+			if point.Balance < threshold {
+				warning.Date = point.Date
+				warning.Balance = point.Balance
+				break
+			}
+		}
+	}
+	return warning
+}
+
+// toRubles переводит сумму в рубли. Как и представления в рублях, неизвестную валюту считает по курсу 1.
+func (s *Service) toRubles(amount float64, currency string) float64 {
+	if currency == "" {
+		return amount
+	}
+	rate, ok := s.rates.RateToRuble(currency)
+	if !ok {
+		return amount
+	}
+	return amount * rate
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
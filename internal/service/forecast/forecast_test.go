@@ -0,0 +1,153 @@
+package forecast
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+// forecastRepo отдаёт операции относительно первого дня прогноза, который передаёт сервис.
+type forecastRepo struct {
+	accounts []models.ConnectedAccount
+	planned  func(from time.Time) []models.ForecastFlow
+	spend    func(from time.Time) []models.ForecastSpend
+}
+
+func (r *forecastRepo) Accounts(string) ([]models.ConnectedAccount, error) {
+	return r.accounts, nil
+}
+func (r *forecastRepo) Planned(_ string, from, _ time.Time) ([]models.ForecastFlow, error) {
+	return r.planned(from), nil
+}
+func (r *forecastRepo) WeekdaySpend(_ string, _, today time.Time) ([]models.ForecastSpend, error) {
+	return r.spend(today.AddDate(0, 0, 1)), nil
+}
+
+type upcoming func(from time.Time) []models.RecurringOccurrence
+
+func (u upcoming) Upcoming(_ string, from, _ time.Time) ([]models.RecurringOccurrence, error) {
+	return u(from), nil
+}
+
+type rates map[string]float64
+
+func (r rates) RateToRuble(code string) (float64, bool) {
+	rate, ok := r[code]
+	return rate, ok
+}
+
+func day(from time.Time, n int) string {
+	return from.AddDate(0, 0, n).Format(dateLayout)
+}
+
+func TestForecast(t *testing.T) {
+	var from time.Time
+	repo := &forecastRepo{
+		accounts: []models.ConnectedAccount{
+			{ID: "1", AccountName: "card", AccountNumber: "A1", AccountState: 1000},
+			{ID: "2", AccountName: "dollars", AccountNumber: "U1", AccountState: 10, AccountCurrency: "USD"},
+		},
+		planned: func(f time.Time) []models.ForecastFlow {
+			from = f
+			return []models.ForecastFlow{
+				{Date: day(f, 0), Kind: models.KindExpense, Amount: 5, Currency: "USD", BankAccount: "U1"},
+				{Date: day(f, 1), Kind: models.KindExpense, Amount: 300, Currency: "RUB", BankAccount: "A1"},
+				// Счёт не подключён: операция входит только в общий остаток.
+				{Date: day(f, 2), Kind: models.KindIncome, Amount: 500, Currency: "RUB", BankAccount: "X"},
+				// Вне прогноза и с неверной датой.
+				{Date: day(f, -1), Kind: models.KindExpense, Amount: 999, Currency: "RUB", BankAccount: "A1"},
+				{Date: day(f, 3), Kind: models.KindExpense, Amount: 999, Currency: "RUB", BankAccount: "A1"},
+				{Date: "soon", Kind: models.KindExpense, Amount: 999, Currency: "RUB", BankAccount: "A1"},
+			}
+		},
+		spend: func(f time.Time) []models.ForecastSpend {
+			// 120 за 12 недель - в среднем 10 в день недели первого дня прогноза.
+			return []models.ForecastSpend{{BankAccount: "A1", Weekday: int(f.Weekday()), Amount: 120}}
+		},
+	}
+	recurring := upcoming(func(f time.Time) []models.RecurringOccurrence {
+		return []models.RecurringOccurrence{
+			{SeriesID: 1, Date: day(f, 2), Kind: models.KindExpense, Amount: 200, Currency: "RUB", BankAccount: "A1"},
+		}
+	})
+	s := NewService(repo, recurring, rates{"USD": 90})
+
+	forecast, err := s.Forecast("1", 3, 500)
+	if err != nil {
+		t.Fatalf("Forecast: %v", err)
+	}
+
+	today := day(from, -1)
+	if forecast.From != today || forecast.Days != 3 {
+		t.Errorf("from = %s, days = %d, want %s, 3", forecast.From, forecast.Days, today)
+	}
+
+	card := models.ForecastSeries{
+		AccountID: "1", AccountName: "card", Currency: "RUB",
+		StartBalance: 1000, MinBalance: 490, MinDate: day(from, 2),
+		Points: []models.ForecastPoint{
+			{Date: day(from, 0), Discretionary: 10, Balance: 990},
+			{Date: day(from, 1), Planned: 300, Balance: 690},
+			{Date: day(from, 2), Planned: 200, Balance: 490},
+		},
+	}
+	dollars := models.ForecastSeries{
+		AccountID: "2", AccountName: "dollars", Currency: "USD",
+		StartBalance: 10, MinBalance: 5, MinDate: day(from, 0),
+		Points: []models.ForecastPoint{
+			{Date: day(from, 0), Planned: 5, Balance: 5},
+			{Date: day(from, 1), Balance: 5},
+			{Date: day(from, 2), Balance: 5},
+		},
+	}
+	total := models.ForecastSeries{
+		Currency:     "RUB",
+		StartBalance: 1900, MinBalance: 1140, MinDate: day(from, 1),
+		Points: []models.ForecastPoint{
+			{Date: day(from, 0), Planned: 450, Discretionary: 10, Balance: 1440},
+			{Date: day(from, 1), Planned: 300, Balance: 1140},
+			{Date: day(from, 2), Income: 500, Planned: 200, Balance: 1440},
+		},
+	}
+	if !reflect.DeepEqual(forecast.Accounts, []models.ForecastSeries{card, dollars}) {
+		t.Errorf("accounts =\n%+v\nwant\n%+v", forecast.Accounts, []models.ForecastSeries{card, dollars})
+	}
+	if !reflect.DeepEqual(forecast.Total, total) {
+		t.Errorf("total =\n%+v\nwant\n%+v", forecast.Total, total)
+	}
+
+	// Карта опускается ниже порога в прогнозе, долларовый счёт ниже порога уже сегодня.
+	warnings := []models.ForecastWarning{
+		{AccountID: "1", AccountName: "card", Date: day(from, 2), Balance: 490, MinBalance: 490, MinDate: day(from, 2)},
+		{AccountID: "2", AccountName: "dollars", Date: today, Balance: 10, MinBalance: 5, MinDate: day(from, 0)},
+	}
+	if !reflect.DeepEqual(forecast.Warnings, warnings) {
+		t.Errorf("warnings =\n%+v\nwant\n%+v", forecast.Warnings, warnings)
+	}
+}
+
+func TestForecastDays(t *testing.T) {
+	repo := &forecastRepo{
+		planned: func(time.Time) []models.ForecastFlow { return nil },
+		spend:   func(time.Time) []models.ForecastSpend { return nil },
+	}
+	s := NewService(repo, upcoming(func(time.Time) []models.RecurringOccurrence { return nil }), rates{})
+
+	forecast, err := s.Forecast("1", 0, 0)
+	if err != nil {
+		t.Fatalf("Forecast: %v", err)
+	}
+	if forecast.Days != DefaultDays || len(forecast.Total.Points) != DefaultDays {
+		t.Errorf("days = %d with %d points, want %d", forecast.Days, len(forecast.Total.Points), DefaultDays)
+	}
+
+	for _, days := range []int{-1, MaxDays + 1} {
+		if _, err := s.Forecast("1", days, 0); !errors.Is(err, myerrors.ErrValidation) {
+			t.Errorf("Forecast(%d) = %v, want validation error", days, err)
+		}
+	}
+}
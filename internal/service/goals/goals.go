@@ -10,12 +10,12 @@ import (
 )
 
 type Goals interface {
-	Create(goal *models.Goal) (int64, error)
-	Update(goal *models.Goal) error
-	Delete(id int64, userID int64) error
+	Create(actor models.Actor, goal *models.Goal) (int64, error)
+	Update(actor models.Actor, goal *models.Goal) error
+	Delete(actor models.Actor, id int64, userID int64) error
 	ListByUserID(userID int64) ([]models.Goal, error)
-	Details(id int64, userID int64) (*models.GoalDetails, error)
-	NewTransaction(transaction *models.GoalTransaction, userID int64) (*models.GoalDetails, error)
+	Details(actor models.Actor, id int64, userID int64) (*models.GoalDetails, error)
+	NewTransaction(actor models.Actor, transaction *models.GoalTransaction, userID int64) (*models.GoalDetails, error)
 }
 
 type Service struct {
@@ -27,17 +27,17 @@ func NewService(gr repo.GoalRepo, tr repo.GoalTransactionRepo) *Service {
 	return &Service{goalRepo: gr, transactionRepo: tr}
 }
 
-func (s *Service) NewTransaction(transaction *models.GoalTransaction, userID int64) (*models.GoalDetails, error) {
-	_, err := s.transactionRepo.Create(transaction, userID)
+func (s *Service) NewTransaction(actor models.Actor, transaction *models.GoalTransaction, userID int64) (*models.GoalDetails, error) {
+	_, err := s.transactionRepo.Create(actor, transaction, userID)
 	if err != nil {
 		return nil, err
 	}
 
-	return s.Details(transaction.GoalID, userID)
+	return s.Details(actor, transaction.GoalID, userID)
 }
 
-func (s *Service) Details(goalID, userID int64) (*models.GoalDetails, error) {
-	details, err := s.goalRepo.Details(goalID, userID)
+func (s *Service) Details(actor models.Actor, goalID, userID int64) (*models.GoalDetails, error) {
+	details, err := s.goalRepo.Details(actor, goalID, userID)
 	if err != nil && errors.Is(err, sql.ErrNoRows) {
 		return nil, fmt.Errorf("no goal (id %d) found: %w", goalID, myerrors.ErrNotFound)
 	}
@@ -48,16 +48,16 @@ func (s *Service) Details(goalID, userID int64) (*models.GoalDetails, error) {
 	return details, nil
 }
 
-func (s *Service) Create(goal *models.Goal) (int64, error) {
-	return s.goalRepo.Create(goal)
+func (s *Service) Create(actor models.Actor, goal *models.Goal) (int64, error) {
+	return s.goalRepo.Create(actor, goal)
 }
 
-func (s *Service) Update(goal *models.Goal) error {
-	return s.goalRepo.Update(goal)
+func (s *Service) Update(actor models.Actor, goal *models.Goal) error {
+	return s.goalRepo.Update(actor, goal)
 }
 
-func (s *Service) Delete(id int64, userID int64) error {
-	return s.goalRepo.Delete(id, userID)
+func (s *Service) Delete(actor models.Actor, id int64, userID int64) error {
+	return s.goalRepo.Delete(actor, id, userID)
 }
 
 func (s *Service) ListByUserID(userID int64) ([]models.Goal, error) {
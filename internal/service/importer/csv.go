@@ -0,0 +1,321 @@
+package importer
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+var dateTokens = strings.NewReplacer(
+	"YYYY", "2006",
+	"YY", "06",
+	"MM", "01",
+	"DD", "02",
+	"HH", "15",
+	"mm", "04",
+	"ss", "05",
+	"M", "1",
+	"D", "2",
+)
+
+// goLayout переводит формат вида DD.MM.YYYY в layout пакета time.
+// Форматы, уже записанные как layout (содержащие 2006), возвращаются как есть.
+func goLayout(format string) string {
+	if format == "" {
+		return dateLayout
+	}
+	if strings.Contains(format, "2006") {
+		return format
+	}
+	return dateTokens.Replace(format)
+}
+
+// parseAmount разбирает сумму с учётом разделителей. Скобки и знак минус означают отрицательную сумму.
+// Без десятичного разделителя десятичной считается точка, а запятая, оставшаяся после удаления разделителя
+// тысяч, - ошибкой: «1,234» может быть и 1234, и 1.234, и угадывать нельзя.
+func parseAmount(raw, decimalSep, thousandsSep string) (float64, error) {
+	s := strings.TrimSpace(raw)
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative = true
+		s = s[1 : len(s)-1]
+	}
+
+	s = strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsSpace(r):
+			return -1
+		case r == '−':
+			return '-'
+		}
+		return r
+	}, s)
+
+	if thousandsSep != "" && !isSpaceSeparator(thousandsSep) {
+		s = strings.ReplaceAll(s, thousandsSep, "")
+	}
+	if decimalSep == "" && strings.Contains(s, ",") {
+		return 0, fmt.Errorf("ambiguous amount %q: set decimal_separator or thousands_separator", raw)
+	}
+	if decimalSep != "" && decimalSep != "." {
+		s = strings.ReplaceAll(s, decimalSep, ".")
+	}
+
+	// Отбрасываем символы валют и прочий мусор вокруг числа.
+	s = strings.TrimFunc(s, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '-' && r != '+' && r != '.'
+	})
+	if s == "" {
+		return 0, errors.New("amount is empty")
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+	if negative {
+		value = -value
+	}
+	return value, nil
+}
+
+func isSpaceSeparator(sep string) bool {
+	r, _ := utf8.DecodeRuneInString(sep)
+	return unicode.IsSpace(r)
+}
+
+func validateMapping(m *models.CSVMapping) error {
+	if m.DateColumn == "" {
+		return fmt.Errorf("%w: date_column is required", myerrors.ErrValidation)
+	}
+
+	switch m.AmountSign {
+	case "", models.SignNegativeExpense, models.SignPositiveExpense:
+		if m.AmountColumn == "" {
+			return fmt.Errorf("%w: amount_column is required", myerrors.ErrValidation)
+		}
+	case models.SignSplitColumns:
+		if m.DebitColumn == "" || m.CreditColumn == "" {
+			return fmt.Errorf("%w: debit_column and credit_column are required for %s", myerrors.ErrValidation, models.SignSplitColumns)
+		}
+	default:
+		return fmt.Errorf("%w: unknown amount_sign %q", myerrors.ErrValidation, m.AmountSign)
+	}
+
+	if utf8.RuneCountInString(m.Delimiter) > 1 && m.Delimiter != `\t` {
+		return fmt.Errorf("%w: delimiter must be a single character", myerrors.ErrValidation)
+	}
+	if m.DecimalSeparator != "" && m.DecimalSeparator == m.ThousandsSeparator {
+		return fmt.Errorf("%w: decimal and thousands separators must differ", myerrors.ErrValidation)
+	}
+
+	return nil
+}
+
+// columns находит номера колонок по имени из заголовка или по номеру.
+type columns map[string]int
+
+func (c columns) index(ref string) (int, error) {
+	if ref == "" {
+		return -1, nil
+	}
+	if i, ok := c[strings.ToLower(strings.TrimSpace(ref))]; ok {
+		return i, nil
+	}
+	if i, err := strconv.Atoi(ref); err == nil && i >= 0 {
+		return i, nil
+	}
+	return -1, fmt.Errorf("%w: column %q not found", myerrors.ErrValidation, ref)
+}
+
+func field(record []string, i int) string {
+	if i < 0 || i >= len(record) {
+		return ""
+	}
+	return strings.TrimSpace(record[i])
+}
+
+// ImportCSV разбирает CSV по маппингу и, если это не пробный запуск, сохраняет корректные строки.
+func (s *Service) ImportCSV(actor models.Actor, userID string, file io.Reader, mapping *models.CSVMapping, dryRun bool) (*models.ImportResult, error) {
+	if err := validateMapping(mapping); err != nil {
+		return nil, err
+	}
+
+	delimiter := ','
+	switch mapping.Delimiter {
+	case "":
+	case `\t`:
+		delimiter = '\t'
+	default:
+		delimiter, _ = utf8.DecodeRuneInString(mapping.Delimiter)
+	}
+
+	br := bufio.NewReader(file)
+	if bom, err := br.Peek(3); err == nil && bytes.Equal(bom, []byte{0xEF, 0xBB, 0xBF}) {
+		_, _ = br.Discard(3)
+	}
+
+	reader := csv.NewReader(br)
+	reader.Comma = delimiter
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	for i := 0; i < mapping.SkipRows; i++ {
+		if _, err := reader.Read(); err != nil {
+			return nil, fmt.Errorf("%w: can't skip rows: %v", myerrors.ErrValidation, err)
+		}
+	}
+
+	cols := make(columns)
+	if mapping.HasHeader {
+		header, err := reader.Read()
+		if err != nil {
+			return nil, fmt.Errorf("%w: can't read header: %v", myerrors.ErrValidation, err)
+		}
+		for i, name := range header {
+			cols[strings.ToLower(strings.TrimSpace(name))] = i
+		}
+	}
+
+	var idx struct{ date, amount, debit, credit, currency, payee, category int }
+	for _, c := range []struct {
+		ref string
+		dst *int
+	}{
+		{mapping.DateColumn, &idx.date},
+		{mapping.AmountColumn, &idx.amount},
+		{mapping.DebitColumn, &idx.debit},
+		{mapping.CreditColumn, &idx.credit},
+		{mapping.CurrencyColumn, &idx.currency},
+		{mapping.PayeeColumn, &idx.payee},
+		{mapping.CategoryColumn, &idx.category},
+	} {
+		i, err := cols.index(c.ref)
+		if err != nil {
+			return nil, err
+		}
+		*c.dst = i
+	}
+
+	categories, err := s.newCategoryResolver(userID, mapping.CategoryMap, mapping.DefaultExpenseCategory, mapping.DefaultIncomeCategory)
+	if err != nil {
+		return nil, err
+	}
+
+	defaultCurrency := strings.ToUpper(mapping.DefaultCurrency)
+	if defaultCurrency == "" {
+		defaultCurrency = "RUB"
+	}
+	layout := goLayout(mapping.DateFormat)
+
+	var rows []*models.ImportRow
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		var parseErr *csv.ParseError
+		if errors.As(err, &parseErr) {
+			rows = append(rows, &models.ImportRow{Line: parseErr.Line, Error: parseErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: can't read file: %v", myerrors.ErrValidation, err)
+		}
+		line, _ := reader.FieldPos(0)
+		if strings.TrimSpace(strings.Join(record, "")) == "" {
+			continue
+		}
+
+		row := &models.ImportRow{
+			Line:         line,
+			Counterparty: field(record, idx.payee),
+			BankAccount:  mapping.BankAccount,
+		}
+		rows = append(rows, row)
+
+		date, err := time.Parse(layout, field(record, idx.date))
+		if err != nil {
+			row.Error = fmt.Sprintf("invalid date %q", field(record, idx.date))
+			continue
+		}
+		row.Date = date.Format(dateLayout)
+
+		amount, kind, err := csvAmount(record, mapping, idx.amount, idx.debit, idx.credit)
+		if err != nil {
+			row.Error = err.Error()
+			continue
+		}
+		row.Amount = amount
+		row.Kind = kind
+
+		row.Currency = strings.ToUpper(field(record, idx.currency))
+		if row.Currency == "" {
+			row.Currency = defaultCurrency
+		}
+		if len(row.Currency) != 3 {
+			row.Error = fmt.Sprintf("invalid currency %q", row.Currency)
+			continue
+		}
+
+		if err := categories.resolve(row, field(record, idx.category)); err != nil {
+			row.Error = err.Error()
+		}
+	}
+
+	return s.finish(actor, userID, rows, nil, dryRun)
+}
+
+// csvAmount возвращает модуль суммы и тип операции по соглашению о знаке.
+func csvAmount(record []string, m *models.CSVMapping, amountIdx, debitIdx, creditIdx int) (float64, string, error) {
+	if m.AmountSign == models.SignSplitColumns {
+		if raw := field(record, debitIdx); raw != "" {
+			amount, err := parseAmount(raw, m.DecimalSeparator, m.ThousandsSeparator)
+			if err != nil {
+				return 0, "", err
+			}
+			if amount != 0 {
+				return math.Abs(amount), models.KindExpense, nil
+			}
+		}
+		if raw := field(record, creditIdx); raw != "" {
+			amount, err := parseAmount(raw, m.DecimalSeparator, m.ThousandsSeparator)
+			if err != nil {
+				return 0, "", err
+			}
+			if amount != 0 {
+				return math.Abs(amount), models.KindIncome, nil
+			}
+		}
+		return 0, "", errors.New("both debit and credit are empty")
+	}
+
+	amount, err := parseAmount(field(record, amountIdx), m.DecimalSeparator, m.ThousandsSeparator)
+	if err != nil {
+		return 0, "", err
+	}
+	if amount == 0 {
+		return 0, "", errors.New("amount is zero")
+	}
+
+	expense := amount < 0
+	if m.AmountSign == models.SignPositiveExpense {
+		expense = amount > 0
+	}
+	if expense {
+		return math.Abs(amount), models.KindExpense, nil
+	}
+	return math.Abs(amount), models.KindIncome, nil
+}
@@ -0,0 +1,93 @@
+package importer
+
+import (
+	"testing"
+
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		name                   string
+		raw, decimal, thousand string
+		want                   float64
+		wantErr                bool
+	}{
+		{name: "plain", raw: "1234.5", want: 1234.5},
+		{name: "negative", raw: "-12.30", want: -12.3},
+		{name: "parentheses", raw: "(12.30)", want: -12.3},
+		{name: "unicode minus", raw: "−5", want: -5},
+		{name: "currency symbol", raw: "₽ 1 500.00", want: 1500},
+		{name: "decimal comma", raw: "1234,56", decimal: ",", want: 1234.56},
+		{name: "decimal comma with space thousands", raw: "1 234,56", decimal: ",", thousand: " ", want: 1234.56},
+		{name: "non-breaking space thousands", raw: "1 234,56", decimal: ",", thousand: " ", want: 1234.56},
+		{name: "dot thousands", raw: "1.234.567,8", decimal: ",", thousand: ".", want: 1234567.8},
+		{name: "comma thousands", raw: "1,234", thousand: ",", want: 1234},
+		{name: "comma thousands with dot decimal", raw: "1,234.50", decimal: ".", thousand: ",", want: 1234.5},
+		{name: "comma without separators", raw: "1,234", wantErr: true},
+		{name: "comma with dot decimal only", raw: "1,234.50", decimal: ".", wantErr: true},
+		{name: "leading comma without separators", raw: ",50", wantErr: true},
+		{name: "trailing comma without separators", raw: "1234,", wantErr: true},
+		{name: "decimal comma with three digits", raw: "1,234", decimal: ",", want: 1.234},
+		{name: "empty", raw: "  ", wantErr: true},
+		{name: "garbage", raw: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAmount(tt.raw, tt.decimal, tt.thousand)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("parseAmount(%q) = %v, want error", tt.raw, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("parseAmount(%q): %v", tt.raw, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseAmount(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCSVAmount(t *testing.T) {
+	tests := []struct {
+		name     string
+		record   []string
+		sign     string
+		want     float64
+		wantKind string
+		wantErr  bool
+	}{
+		{name: "negative is expense", record: []string{"-10"}, want: 10, wantKind: models.KindExpense},
+		{name: "positive is income", record: []string{"10"}, want: 10, wantKind: models.KindIncome},
+		{name: "positive expense", record: []string{"10"}, sign: models.SignPositiveExpense, want: 10, wantKind: models.KindExpense},
+		{name: "positive expense refund", record: []string{"-10"}, sign: models.SignPositiveExpense, want: 10, wantKind: models.KindIncome},
+		{name: "zero", record: []string{"0"}, wantErr: true},
+		{name: "debit column", record: []string{"", "25", ""}, sign: models.SignSplitColumns, want: 25, wantKind: models.KindExpense},
+		{name: "credit column", record: []string{"", "", "30"}, sign: models.SignSplitColumns, want: 30, wantKind: models.KindIncome},
+		{name: "zero debit falls back to credit", record: []string{"", "0", "30"}, sign: models.SignSplitColumns, want: 30, wantKind: models.KindIncome},
+		{name: "empty debit and credit", record: []string{"", "", ""}, sign: models.SignSplitColumns, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &models.CSVMapping{AmountSign: tt.sign}
+			got, kind, err := csvAmount(tt.record, m, 0, 1, 2)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("csvAmount(%q) = %v %s, want error", tt.record, got, kind)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("csvAmount(%q): %v", tt.record, err)
+			}
+			if got != tt.want || kind != tt.wantKind {
+				t.Errorf("csvAmount(%q) = %v %s, want %v %s", tt.record, got, kind, tt.want, tt.wantKind)
+			}
+		})
+	}
+}
@@ -0,0 +1,235 @@
+// Package importer provides import of incomes and expenses from bank statements and spreadsheets.
+package importer
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"strings"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	repo "github.com/wachrusz/Back-End-API/internal/repository"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"github.com/wachrusz/Back-End-API/internal/service/rules"
+)
+
+const dateLayout = "2006-01-02"
+
+type Importer interface {
+	ImportCSV(actor models.Actor, userID string, file io.Reader, mapping *models.CSVMapping, dryRun bool) (*models.ImportResult, error)
+	ImportStatement(actor models.Actor, userID string, file io.Reader, opts *models.StatementOptions, dryRun bool) (*models.ImportResult, error)
+	CreateProfile(profile *models.ImportProfile) (int64, error)
+	UpdateProfile(profile *models.ImportProfile) error
+	DeleteProfile(id int64, userID string) error
+	GetProfile(id int64, userID string) (*models.ImportProfile, error)
+	ListProfiles(userID string) ([]models.ImportProfile, error)
+}
+
+// RuleSource возвращает правила автокатегоризации пользователя.
+type RuleSource interface {
+	Matcher(userID string) (*rules.Matcher, error)
+}
+
+// DuplicateSource ищет вероятные дубликаты операций среди уже сохранённых.
+type DuplicateSource interface {
+	Check(userID string, records []models.DuplicateRecord) ([][]models.DuplicateMatch, error)
+}
+
+type Service struct {
+	imports    repo.ImportRepo
+	accounts   repo.AccountRepo
+	rules      RuleSource
+	duplicates DuplicateSource
+}
+
+func NewService(ir repo.ImportRepo, ar repo.AccountRepo, rs RuleSource, ds DuplicateSource) *Service {
+	return &Service{imports: ir, accounts: ar, rules: rs, duplicates: ds}
+}
+
+func (s *Service) CreateProfile(profile *models.ImportProfile) (int64, error) {
+	if err := validateProfile(profile); err != nil {
+		return 0, err
+	}
+	return s.imports.CreateProfile(profile)
+}
+
+func (s *Service) UpdateProfile(profile *models.ImportProfile) error {
+	if err := validateProfile(profile); err != nil {
+		return err
+	}
+	return s.imports.UpdateProfile(profile)
+}
+
+func (s *Service) DeleteProfile(id int64, userID string) error {
+	return s.imports.DeleteProfile(id, userID)
+}
+
+func (s *Service) GetProfile(id int64, userID string) (*models.ImportProfile, error) {
+	return s.imports.GetProfile(id, userID)
+}
+
+func (s *Service) ListProfiles(userID string) ([]models.ImportProfile, error) {
+	return s.imports.ListProfiles(userID)
+}
+
+func validateProfile(profile *models.ImportProfile) error {
+	if strings.TrimSpace(profile.Name) == "" {
+		return fmt.Errorf("%w: profile name is empty", myerrors.ErrValidation)
+	}
+	return validateMapping(&profile.Mapping)
+}
+
+// categoryResolver сопоставляет категории из выписки с категориями пользователя.
+type categoryResolver struct {
+	explicit       map[string]string
+	expense        map[string]string
+	income         map[string]string
+	defaultExpense string
+	defaultIncome  string
+	rules          *rules.Matcher
+}
+
+func (s *Service) newCategoryResolver(userID string, explicit map[string]string, defaultExpense, defaultIncome string) (*categoryResolver, error) {
+	expense, income, err := s.imports.Categories(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to load categories: %v", myerrors.ErrInternal, err)
+	}
+	matcher, err := s.rules.Matcher(userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to load categorization rules: %v", myerrors.ErrInternal, err)
+	}
+
+	lowered := make(map[string]string, len(explicit))
+	for name, id := range explicit {
+		lowered[strings.ToLower(strings.TrimSpace(name))] = id
+	}
+
+	return &categoryResolver{
+		explicit:       lowered,
+		expense:        expense,
+		income:         income,
+		defaultExpense: defaultExpense,
+		defaultIncome:  defaultIncome,
+		rules:          matcher,
+	}, nil
+}
+
+// resolve задаёт категорию строки: явное сопоставление, затем категория с тем же названием,
+// затем правила автокатегоризации и категория по умолчанию. Правила также задают теги и признак
+// запланированной операции. raw - категория из выписки.
+func (c *categoryResolver) resolve(row *models.ImportRow, raw string) error {
+	outcome := c.rules.Match(&models.RuleTarget{
+		Kind:        row.Kind,
+		Amount:      row.Amount,
+		Payee:       row.Counterparty,
+		Text:        strings.TrimSpace(raw + " " + row.Reference),
+		BankAccount: row.BankAccount,
+		Currency:    row.Currency,
+	})
+	row.TagIDs = outcome.TagIDs
+	row.Planned = outcome.Planned != nil && *outcome.Planned
+
+	name := strings.ToLower(strings.TrimSpace(raw))
+	if name != "" {
+		if id, ok := c.explicit[name]; ok {
+			row.CategoryID = id
+			return nil
+		}
+
+		byName := c.expense
+		if row.Kind == models.KindIncome {
+			byName = c.income
+		}
+		if id, ok := byName[name]; ok {
+			row.CategoryID = id
+			return nil
+		}
+	}
+
+	if outcome.CategoryID != "" {
+		row.CategoryID = outcome.CategoryID
+		return nil
+	}
+	if row.Kind == models.KindIncome && c.defaultIncome != "" {
+		row.CategoryID = c.defaultIncome
+		return nil
+	}
+	if row.Kind == models.KindExpense && c.defaultExpense != "" {
+		row.CategoryID = c.defaultExpense
+		return nil
+	}
+
+	return fmt.Errorf("category %q is not matched and no default %s category is set", raw, row.Kind)
+}
+
+// finish сохраняет строки без ошибок и остаток счёта, если это не пробный запуск, и подводит итоги.
+// При ошибке базы данных ничего не сохраняется, а результат содержит строку с ошибкой.
+func (s *Service) finish(actor models.Actor, userID string, rows []*models.ImportRow, balance *models.AccountBalance, dryRun bool) (*models.ImportResult, error) {
+	result := &models.ImportResult{
+		DryRun:         dryRun,
+		Total:          len(rows),
+		Rows:           rows,
+		ClosingBalance: balance,
+	}
+
+	s.flagDuplicates(userID, rows)
+
+	var err error
+	if !dryRun {
+		err = s.imports.Commit(actor, userID, rows, balance)
+	}
+
+	for _, row := range rows {
+		if row.Error != "" {
+			result.Failed++
+		}
+		if row.Duplicate {
+			result.Skipped++
+		}
+		if row.ID != 0 {
+			result.Imported++
+		}
+		if len(row.PossibleDuplicates) > 0 {
+			result.PossibleDuplicates++
+		}
+	}
+
+	if err != nil {
+		return result, fmt.Errorf("%w: import rolled back: %v", myerrors.ErrInternal, err)
+	}
+
+	return result, nil
+}
+
+// flagDuplicates отмечает строки, похожие на уже сохранённые операции, например внесённые вручную.
+// Поиск дубликатов не должен мешать импорту, поэтому его ошибка только записывается в лог.
+func (s *Service) flagDuplicates(userID string, rows []*models.ImportRow) {
+	var checked []*models.ImportRow
+	var records []models.DuplicateRecord
+	for _, row := range rows {
+		if row.Error != "" || row.Duplicate {
+			continue
+		}
+		checked = append(checked, row)
+		records = append(records, models.DuplicateRecord{
+			Kind:        row.Kind,
+			Date:        row.Date,
+			Amount:      row.Amount,
+			Currency:    row.Currency,
+			Payee:       row.Counterparty,
+			BankAccount: row.BankAccount,
+		})
+	}
+	if len(records) == 0 {
+		return
+	}
+
+	matches, err := s.duplicates.Check(userID, records)
+	if err != nil {
+		log.Println("Error checking imported rows for duplicates:", err)
+		return
+	}
+	for i, row := range checked {
+		row.PossibleDuplicates = matches[i]
+	}
+}
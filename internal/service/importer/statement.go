@@ -0,0 +1,145 @@
+package importer
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+	"github.com/wachrusz/Back-End-API/pkg/statement"
+)
+
+// ImportStatement разбирает банковскую выписку и привязывает операции к подключённому счёту.
+// Операции, чей банковский идентификатор уже встречался на этом счёте, пропускаются.
+func (s *Service) ImportStatement(actor models.Actor, userID string, file io.Reader, opts *models.StatementOptions, dryRun bool) (*models.ImportResult, error) {
+	if opts.AccountID == "" {
+		return nil, fmt.Errorf("%w: account_id is required", myerrors.ErrValidation)
+	}
+	account, err := s.accounts.Get(opts.AccountID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, fmt.Errorf("%w: can't read file: %v", myerrors.ErrValidation, err)
+	}
+
+	format := strings.ToLower(opts.Format)
+	if format == "" {
+		format, err = statement.Detect(data)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", myerrors.ErrValidation, err)
+		}
+	}
+
+	var layouts []string
+	if opts.DateFormat != "" {
+		layouts = []string{goLayout(opts.DateFormat)}
+	}
+
+	st, err := statement.Parse(format, bytes.NewReader(data), layouts)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", myerrors.ErrValidation, err)
+	}
+
+	categories, err := s.newCategoryResolver(userID, opts.CategoryMap, opts.DefaultExpenseCategory, opts.DefaultIncomeCategory)
+	if err != nil {
+		return nil, err
+	}
+
+	imported, err := s.imports.ExternalIDs(userID, account.AccountNumber)
+	if err != nil {
+		return nil, fmt.Errorf("%w: failed to load imported transactions: %v", myerrors.ErrInternal, err)
+	}
+
+	rows := statementRows(st, account, categories, imported)
+	return s.finish(actor, userID, rows, closingBalance(st, account), dryRun)
+}
+
+// closingBalance возвращает остаток счёта на конец выписки. Остаток в другой валюте, чем счёт, не используется.
+func closingBalance(st *statement.Statement, account *models.ConnectedAccount) *models.AccountBalance {
+	b := st.ClosingBalance
+	if b == nil {
+		return nil
+	}
+
+	currency := b.Currency
+	if currency == "" {
+		currency = st.Currency
+	}
+	if currency != "" && account.AccountCurrency != "" && !strings.EqualFold(currency, account.AccountCurrency) {
+		return nil
+	}
+
+	balance := &models.AccountBalance{
+		AccountID: account.ID,
+		State:     b.Amount,
+	}
+	if !b.Date.IsZero() {
+		balance.Date = b.Date.Format(dateLayout)
+	}
+	return balance
+}
+
+// statementRows переводит операции выписки в строки импорта.
+func statementRows(st *statement.Statement, account *models.ConnectedAccount, categories *categoryResolver, imported map[string]bool) []*models.ImportRow {
+	defaultCurrency := st.Currency
+	if defaultCurrency == "" {
+		defaultCurrency = strings.ToUpper(account.AccountCurrency)
+	}
+
+	rows := make([]*models.ImportRow, 0, len(st.Transactions))
+	for _, t := range st.Transactions {
+		row := &models.ImportRow{
+			Line:         t.Line,
+			Counterparty: t.Payee,
+			BankAccount:  account.AccountNumber,
+			ExternalID:   t.ID,
+			Reference:    t.Reference,
+			Error:        t.Error,
+		}
+		rows = append(rows, row)
+		if row.Counterparty == "" {
+			row.Counterparty = t.Memo
+		}
+		if row.Error != "" {
+			continue
+		}
+
+		row.Date = t.Date.Format(dateLayout)
+		if !t.ValueDate.IsZero() {
+			row.ValueDate = t.ValueDate.Format(dateLayout)
+		}
+		row.Currency = t.Currency
+		if row.Currency == "" {
+			row.Currency = defaultCurrency
+		}
+
+		switch {
+		case t.Amount < 0:
+			row.Kind = models.KindExpense
+			row.Amount = -t.Amount
+		case t.Amount > 0:
+			row.Kind = models.KindIncome
+			row.Amount = t.Amount
+		default:
+			row.Error = "amount is zero"
+			continue
+		}
+
+		if imported[t.ID] {
+			row.Duplicate = true
+			continue
+		}
+		imported[t.ID] = true
+
+		if err := categories.resolve(row, t.Category); err != nil {
+			row.Error = err.Error()
+		}
+	}
+
+	return rows
+}
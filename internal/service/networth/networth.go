@@ -0,0 +1,300 @@
+// Package networth provides daily net worth snapshots and their history.
+package networth
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	repo "github.com/wachrusz/Back-End-API/internal/repository"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+const (
+	dateLayout = "2006-01-02"
+
+	// MaxBackfillDays - на сколько дней назад восстанавливаются снимки.
+	MaxBackfillDays = 3650
+
+	// snapshotInterval - как часто воркер обновляет снимки.
+	snapshotInterval = time.Hour
+)
+
+type NetWorth interface {
+	History(userID, resolution, from, to string) (*models.NetWorthHistory, error)
+	SetBaseCurrency(userID, currency string) error
+	Backfill(userID string) (int, error)
+	ScheduleSnapshots()
+}
+
+type RateSource interface {
+	RateToRuble(code string) (float64, bool)
+}
+
+type Service struct {
+	netWorth repo.NetWorthRepo
+	rates    RateSource
+}
+
+func NewService(nr repo.NetWorthRepo, rs RateSource) *Service {
+	return &Service{netWorth: nr, rates: rs}
+}
+
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func (s *Service) SetBaseCurrency(userID, currency string) error {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if _, ok := s.rates.RateToRuble(currency); !ok {
+		return fmt.Errorf("%w: unknown currency %q", myerrors.ErrValidation, currency)
+	}
+	return s.netWorth.SetBaseCurrency(userID, currency)
+}
+
+// Backfill пересчитывает все снимки пользователя по истории операций и возвращает число сохранённых дней.
+func (s *Service) Backfill(userID string) (int, error) {
+	return s.backfill(userID, time.Time{})
+}
+
+// backfill восстанавливает снимки по дням и сохраняет снимки с from по сегодняшний день.
+// Остаток счетов на день - текущий остаток за вычетом доходов и с добавлением расходов по счетам после этого дня,
+// поэтому история верна, если текущие остатки счетов учитывают эти операции. Активы и кредиты фонда
+// благосостояния - сумма его записей по этот день. Операции пересчитываются в рубли по курсам на их дату,
+// текущие остатки счетов - по текущим курсам.
+func (s *Service) backfill(userID string, from time.Time) (int, error) {
+	current, err := s.netWorth.Current(userID)
+	if err != nil {
+		return 0, err
+	}
+	changes, err := s.netWorth.Changes(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	end := today()
+	first := end
+	if len(changes) > 0 {
+		if date, err := time.Parse(dateLayout, changes[0].Date); err == nil && date.Before(first) {
+			first = date
+		}
+	}
+	if limit := end.AddDate(0, 0, -MaxBackfillDays); first.Before(limit) {
+		first = limit
+	}
+	if from.After(first) {
+		first = from
+	}
+
+	// Начальные значения - итог всех изменений до first, для счетов - текущий остаток без изменений после.
+	state := models.NetWorthSnapshot{Accounts: current.Accounts}
+	byDate := make(map[string]models.NetWorthChange, len(changes))
+	for _, change := range changes {
+		date, err := time.Parse(dateLayout, change.Date)
+		if err != nil {
+			continue
+		}
+		if date.Before(first) {
+			state.Liquid += change.Liquid
+			state.Illiquid += change.Illiquid
+			state.Loans += change.Loans
+			continue
+		}
+		state.Accounts -= change.Accounts
+		byDate[change.Date] = change
+	}
+
+	snapshots := make([]models.NetWorthSnapshot, 0, int(end.Sub(first).Hours()/24)+1)
+	for day := first; !day.After(end); day = day.AddDate(0, 0, 1) {
+		date := day.Format(dateLayout)
+		change := byDate[date]
+		state.Accounts += change.Accounts
+		state.Liquid += change.Liquid
+		state.Illiquid += change.Illiquid
+		state.Loans += change.Loans
+
+		snapshot := models.NetWorthSnapshot{
+			Date:     date,
+			Accounts: round(state.Accounts),
+			Liquid:   round(state.Liquid),
+			Illiquid: round(state.Illiquid),
+			Loans:    round(state.Loans),
+		}
+		snapshot.NetWorth = round(snapshot.Accounts + snapshot.Liquid + snapshot.Illiquid - snapshot.Loans)
+		snapshots = append(snapshots, snapshot)
+	}
+
+	if err := s.netWorth.SaveSnapshots(userID, snapshots); err != nil {
+		return 0, err
+	}
+	return len(snapshots), nil
+}
+
+// History возвращает чистый капитал по дням, неделям (с понедельника) или месяцам в базовой валюте пользователя.
+// По умолчанию - последние 30 дней, 12 недель или 12 месяцев. Если снимков ещё нет, они восстанавливаются.
+func (s *Service) History(userID, resolution, from, to string) (*models.NetWorthHistory, error) {
+	if resolution == "" {
+		resolution = models.ResolutionDay
+	}
+
+	end := today()
+	if to != "" {
+		parsed, err := time.Parse(dateLayout, to)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid to: %v", myerrors.ErrValidation, err)
+		}
+		end = parsed
+	}
+
+	var start time.Time
+	switch resolution {
+	case models.ResolutionDay:
+		start = end.AddDate(0, 0, -29)
+	case models.ResolutionWeek:
+		start = weekStart(end).AddDate(0, 0, -7*11)
+	case models.ResolutionMonth:
+		start = monthStart(end).AddDate(0, -11, 0)
+	default:
+		return nil, fmt.Errorf("%w: unknown resolution %q", myerrors.ErrValidation, resolution)
+	}
+	if from != "" {
+		parsed, err := time.Parse(dateLayout, from)
+		if err != nil {
+			return nil, fmt.Errorf("%w: invalid from: %v", myerrors.ErrValidation, err)
+		}
+		start = parsed
+	}
+	if end.Before(start) {
+		return nil, fmt.Errorf("%w: to is before from", myerrors.ErrValidation)
+	}
+
+	currency, err := s.netWorth.BaseCurrency(userID)
+	if err != nil {
+		return nil, err
+	}
+	rate, ok := s.rates.RateToRuble(currency)
+	if !ok {
+		return nil, fmt.Errorf("%w: no exchange rate for %s", myerrors.ErrInternal, currency)
+	}
+
+	last, err := s.netWorth.LastSnapshotDate(userID)
+	if err != nil {
+		return nil, err
+	}
+	if last == "" {
+		if _, err := s.backfill(userID, time.Time{}); err != nil {
+			return nil, err
+		}
+	}
+
+	snapshots, err := s.netWorth.Snapshots(userID, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	history := &models.NetWorthHistory{
+		Currency:   currency,
+		Resolution: resolution,
+		From:       start.Format(dateLayout),
+		To:         end.Format(dateLayout),
+		Points:     make([]models.NetWorthSnapshot, 0),
+	}
+
+	// Снимки идут по возрастанию даты, поэтому в периоде остаётся последний.
+	lastKey := ""
+	for _, snapshot := range snapshots {
+		date, err := time.Parse(dateLayout, snapshot.Date)
+		if err != nil {
+			continue
+		}
+		key := snapshot.Date
+		switch resolution {
+		case models.ResolutionWeek:
+			key = weekStart(date).Format(dateLayout)
+		case models.ResolutionMonth:
+			key = monthStart(date).Format(dateLayout)
+		}
+
+		point := convert(snapshot, rate)
+		if key == lastKey {
+			history.Points[len(history.Points)-1] = point
+			continue
+		}
+		history.Points = append(history.Points, point)
+		lastKey = key
+	}
+
+	if n := len(history.Points); n > 0 {
+		first, last := history.Points[0].NetWorth, history.Points[n-1].NetWorth
+		history.Change = round(last - first)
+		if first != 0 {
+			percent := round(history.Change / math.Abs(first) * 100)
+			history.ChangePercent = &percent
+		}
+	}
+
+	return history, nil
+}
+
+// ScheduleSnapshots периодически обновляет снимки за сегодня и восстанавливает пропущенные дни.
+// Для пользователей без снимков восстанавливается вся история.
+func (s *Service) ScheduleSnapshots() {
+	for {
+		if err := s.snapshotAll(); err != nil {
+			log.Println("Error updating net worth snapshots:", err)
+		}
+		time.Sleep(snapshotInterval)
+	}
+}
+
+func (s *Service) snapshotAll() error {
+	users, err := s.netWorth.UsersWithAssets()
+	if err != nil {
+		return err
+	}
+
+	for _, userID := range users {
+		last, err := s.netWorth.LastSnapshotDate(userID)
+		if err != nil {
+			log.Printf("Error getting net worth snapshots of user %s: %v", userID, err)
+			continue
+		}
+		var from time.Time
+		if last != "" {
+			from, _ = time.Parse(dateLayout, last)
+		}
+		if _, err := s.backfill(userID, from); err != nil {
+			log.Printf("Error updating net worth snapshots of user %s: %v", userID, err)
+		}
+	}
+
+	return nil
+}
+
+// convert переводит снимок из рублей в валюту с курсом rate.
+func convert(snapshot models.NetWorthSnapshot, rate float64) models.NetWorthSnapshot {
+	return models.NetWorthSnapshot{
+		Date:     snapshot.Date,
+		Accounts: round(snapshot.Accounts / rate),
+		Liquid:   round(snapshot.Liquid / rate),
+		Illiquid: round(snapshot.Illiquid / rate),
+		Loans:    round(snapshot.Loans / rate),
+		NetWorth: round(snapshot.NetWorth / rate),
+	}
+}
+
+func weekStart(date time.Time) time.Time {
+	return date.AddDate(0, 0, -((int(date.Weekday()) + 6) % 7))
+}
+
+func monthStart(date time.Time) time.Time {
+	return time.Date(date.Year(), date.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
@@ -0,0 +1,345 @@
+// Package recurring provides recurring incomes and expenses and their materialization.
+package recurring
+
+import (
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	repo "github.com/wachrusz/Back-End-API/internal/repository"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+// materializeInterval - как часто воркер проверяет наступившие повторения.
+const materializeInterval = time.Hour
+
+type Recurring interface {
+	Create(series *models.RecurringSeries) (int64, error)
+	Update(series *models.RecurringSeries) error
+	Delete(id int64, userID string) error
+	ListByUserID(userID string) ([]models.RecurringSeries, error)
+	Pause(id int64, userID string) error
+	Resume(id int64, userID string) error
+	Skip(id int64, userID, date string) error
+	Upcoming(userID string, from, to time.Time) ([]models.RecurringOccurrence, error)
+	ScheduleMaterialization()
+}
+
+// RuleSource применяет правила автокатегоризации пользователя к новым операциям.
+type RuleSource interface {
+	CategorizeExpense(expense *models.Expense) error
+	CategorizeIncome(income *models.Income) error
+}
+
+type Service struct {
+	series repo.RecurringRepo
+	rules  RuleSource
+}
+
+func NewService(sr repo.RecurringRepo, rs RuleSource) *Service {
+	return &Service{series: sr, rules: rs}
+}
+
+func today() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+func validate(s *models.RecurringSeries) error {
+	if s.Kind != models.KindExpense && s.Kind != models.KindIncome {
+		return fmt.Errorf("%w: kind must be %q or %q", myerrors.ErrValidation, models.KindExpense, models.KindIncome)
+	}
+	switch s.Frequency {
+	case models.Daily, models.Weekly, models.Monthly, models.NthWeekday:
+	default:
+		return fmt.Errorf("%w: unknown frequency %q", myerrors.ErrValidation, s.Frequency)
+	}
+	if s.Interval == 0 {
+		s.Interval = 1
+	}
+	if s.Interval < 0 {
+		return fmt.Errorf("%w: interval must be positive", myerrors.ErrValidation)
+	}
+	if s.Amount <= 0 {
+		return fmt.Errorf("%w: amount must be positive", myerrors.ErrValidation)
+	}
+	if s.Weekday < 0 || s.Weekday > 6 {
+		return fmt.Errorf("%w: weekday must be between 0 and 6", myerrors.ErrValidation)
+	}
+	if s.WeekOfMonth == 0 {
+		s.WeekOfMonth = 1
+	}
+	if s.WeekOfMonth < -1 || s.WeekOfMonth > 5 {
+		return fmt.Errorf("%w: week_of_month must be between 1 and 5 or -1", myerrors.ErrValidation)
+	}
+	if s.DayOfMonth < 0 || s.DayOfMonth > 31 {
+		return fmt.Errorf("%w: day_of_month must be between 0 and 31", myerrors.ErrValidation)
+	}
+	if s.Count < 0 {
+		return fmt.Errorf("%w: count must not be negative", myerrors.ErrValidation)
+	}
+
+	start, err := time.Parse(dateLayout, s.StartDate)
+	if err != nil {
+		return fmt.Errorf("%w: invalid start_date: %v", myerrors.ErrValidation, err)
+	}
+	if s.EndDate != "" {
+		end, err := time.Parse(dateLayout, s.EndDate)
+		if err != nil {
+			return fmt.Errorf("%w: invalid end_date: %v", myerrors.ErrValidation, err)
+		}
+		if end.Before(start) {
+			return fmt.Errorf("%w: end_date is before start_date", myerrors.ErrValidation)
+		}
+	}
+
+	return nil
+}
+
+// reschedule выставляет следующее повторение серии не раньше from.
+// Прошедшие повторения считаются наступившими и не материализуются.
+func reschedule(s *models.RecurringSeries, from time.Time) {
+	k, date, ok := firstFrom(s, from)
+	s.Materialized = k
+	s.NextDate = ""
+	if ok {
+		s.NextDate = date.Format(dateLayout)
+	}
+}
+
+// Create создаёт серию. Повторения, выпавшие на даты до сегодняшнего дня, не материализуются.
+func (s *Service) Create(series *models.RecurringSeries) (int64, error) {
+	if err := validate(series); err != nil {
+		return 0, err
+	}
+	series.Paused = false
+	reschedule(series, today())
+
+	return s.series.Create(series)
+}
+
+// Update меняет шаблон и правило для будущих повторений серии.
+func (s *Service) Update(series *models.RecurringSeries) error {
+	current, err := s.series.Get(series.ID, series.UserID)
+	if err != nil {
+		return err
+	}
+
+	series.Kind = current.Kind
+	series.Paused = current.Paused
+	if err := validate(series); err != nil {
+		return err
+	}
+
+	from := today()
+	if current.NextDate != "" {
+		if next, err := time.Parse(dateLayout, current.NextDate); err == nil && next.After(from) {
+			from = next
+		}
+	}
+	reschedule(series, from)
+
+	return s.series.Update(series, current.NextDate)
+}
+
+func (s *Service) Delete(id int64, userID string) error {
+	return s.series.Delete(id, userID)
+}
+
+func (s *Service) ListByUserID(userID string) ([]models.RecurringSeries, error) {
+	return s.series.ListByUserID(userID)
+}
+
+func (s *Service) Pause(id int64, userID string) error {
+	series, err := s.series.Get(id, userID)
+	if err != nil {
+		return err
+	}
+	series.Paused = true
+	return s.series.Update(series, series.NextDate)
+}
+
+// Resume возобновляет серию. Повторения, пропущенные во время паузы, не материализуются.
+func (s *Service) Resume(id int64, userID string) error {
+	series, err := s.series.Get(id, userID)
+	if err != nil {
+		return err
+	}
+	next := series.NextDate
+	series.Paused = false
+	reschedule(series, today())
+	return s.series.Update(series, next)
+}
+
+// Skip отменяет одно будущее повторение серии.
+func (s *Service) Skip(id int64, userID, date string) error {
+	series, err := s.series.Get(id, userID)
+	if err != nil {
+		return err
+	}
+
+	skipDate, err := time.Parse(dateLayout, date)
+	if err != nil {
+		return fmt.Errorf("%w: invalid date: %v", myerrors.ErrValidation, err)
+	}
+	if skipDate.Before(today()) {
+		return fmt.Errorf("%w: can't skip an occurrence in the past", myerrors.ErrValidation)
+	}
+
+	_, occ, ok := firstFrom(series, skipDate)
+	if !ok || !occ.Equal(skipDate) {
+		return fmt.Errorf("%w: series has no occurrence on %s", myerrors.ErrValidation, date)
+	}
+
+	return s.series.AddSkip(id, date)
+}
+
+// Upcoming возвращает ещё не материализованные повторения активных серий пользователя с датами в [from, to).
+// Пропущенные даты не возвращаются.
+func (s *Service) Upcoming(userID string, from, to time.Time) ([]models.RecurringOccurrence, error) {
+	list, err := s.series.ListByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences := make([]models.RecurringOccurrence, 0)
+	for i := range list {
+		series := &list[i]
+		if series.Paused || series.NextDate == "" {
+			continue
+		}
+		skips, err := s.series.Skips(series.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		for k := series.Materialized; ; k++ {
+			occ, ok := occurrence(series, k)
+			if !ok || !occ.Before(to) {
+				break
+			}
+			if occ.Before(from) || skips[occ.Format(dateLayout)] {
+				continue
+			}
+			occurrences = append(occurrences, models.RecurringOccurrence{
+				SeriesID:    series.ID,
+				Date:        occ.Format(dateLayout),
+				Kind:        series.Kind,
+				Amount:      series.Amount,
+				Currency:    series.Currency,
+				BankAccount: series.BankAccount,
+			})
+		}
+	}
+
+	return occurrences, nil
+}
+
+// Materialize создаёт операции для всех повторений, наступивших к date.
+func (s *Service) Materialize(date time.Time) error {
+	due, err := s.series.ListDue(date)
+	if err != nil {
+		return err
+	}
+
+	for i := range due {
+		if err := s.materializeSeries(&due[i], date); err != nil {
+			log.Printf("Error materializing recurring series %d: %v", due[i].ID, err)
+		}
+	}
+
+	return nil
+}
+
+func (s *Service) materializeSeries(series *models.RecurringSeries, date time.Time) error {
+	skips, err := s.series.Skips(series.ID)
+	if err != nil {
+		return err
+	}
+
+	var dates []string
+	k := series.Materialized
+	next := ""
+	for {
+		occ, ok := occurrence(series, k)
+		if !ok {
+			break
+		}
+		if occ.After(date) {
+			next = occ.Format(dateLayout)
+			break
+		}
+		if !skips[occ.Format(dateLayout)] {
+			dates = append(dates, occ.Format(dateLayout))
+		}
+		k++
+	}
+
+	// Операции серии проходят правила автокатегоризации, как созданные вручную или импортированные.
+	// Все повторения серии одинаковы, поэтому правила применяются один раз.
+	var expenses []models.Expense
+	var incomes []models.Income
+	id := series.ID
+	switch series.Kind {
+	case models.KindExpense:
+		template := models.Expense{
+			Amount:      series.Amount,
+			Planned:     series.Planned,
+			UserID:      series.UserID,
+			CategoryID:  series.CategoryID,
+			SentTo:      series.Counterparty,
+			BankAccount: series.BankAccount,
+			Currency:    series.Currency,
+			RecurringID: &id,
+		}
+		if len(dates) > 0 {
+			if err := s.rules.CategorizeExpense(&template); err != nil {
+				return err
+			}
+		}
+		for _, date := range dates {
+			expense := template
+			expense.Date = date
+			expenses = append(expenses, expense)
+		}
+	case models.KindIncome:
+		template := models.Income{
+			Amount:      series.Amount,
+			Planned:     series.Planned,
+			UserID:      series.UserID,
+			CategoryID:  series.CategoryID,
+			Sender:      series.Counterparty,
+			BankAccount: series.BankAccount,
+			Currency:    series.Currency,
+			RecurringID: &id,
+		}
+		if len(dates) > 0 {
+			if err := s.rules.CategorizeIncome(&template); err != nil {
+				return err
+			}
+		}
+		for _, date := range dates {
+			income := template
+			income.Date = date
+			incomes = append(incomes, income)
+		}
+	default:
+		return fmt.Errorf("%w: unknown kind %q", myerrors.ErrValidation, series.Kind)
+	}
+
+	// Серия захватывается в той же транзакции, в которой создаются операции, поэтому несколько экземпляров
+	// не создадут дубликаты, а ошибка не оставит серию продвинутой без операций.
+	_, err = s.series.Materialize(models.SystemActor("recurring"), series, next, k, expenses, incomes)
+	return err
+}
+
+// ScheduleMaterialization периодически материализует наступившие повторения всех серий.
+func (s *Service) ScheduleMaterialization() {
+	for {
+		if err := s.Materialize(today()); err != nil {
+			log.Println("Error materializing recurring transactions:", err)
+		}
+		time.Sleep(materializeInterval)
+	}
+}
@@ -0,0 +1,103 @@
+package recurring
+
+import (
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+const dateLayout = "2006-01-02"
+
+// occurrence возвращает дату k-го (с нуля) повторения серии.
+// Второе значение false, если серия к этому повторению уже закончилась.
+func occurrence(s *models.RecurringSeries, k int) (time.Time, bool) {
+	if s.Count > 0 && k >= s.Count {
+		return time.Time{}, false
+	}
+
+	start, err := time.Parse(dateLayout, s.StartDate)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	var date time.Time
+	switch s.Frequency {
+	case models.Daily:
+		date = start.AddDate(0, 0, k*s.Interval)
+	case models.Weekly:
+		date = start.AddDate(0, 0, 7*k*s.Interval)
+	case models.Monthly:
+		day := s.DayOfMonth
+		if day == 0 {
+			day = start.Day()
+		}
+		offset := 0
+		if dayInMonth(start.Year(), start.Month(), day).Before(start) {
+			offset = 1
+		}
+		year, month := addMonths(start.Year(), start.Month(), offset+k*s.Interval)
+		date = dayInMonth(year, month, day)
+	case models.NthWeekday:
+		weekday := time.Weekday(s.Weekday)
+		offset := 0
+		if nthWeekday(start.Year(), start.Month(), weekday, s.WeekOfMonth).Before(start) {
+			offset = 1
+		}
+		year, month := addMonths(start.Year(), start.Month(), offset+k*s.Interval)
+		date = nthWeekday(year, month, weekday, s.WeekOfMonth)
+	default:
+		return time.Time{}, false
+	}
+
+	if s.EndDate != "" {
+		end, err := time.Parse(dateLayout, s.EndDate)
+		if err == nil && date.After(end) {
+			return time.Time{}, false
+		}
+	}
+
+	return date, true
+}
+
+// firstFrom возвращает индекс и дату первого повторения не раньше from.
+func firstFrom(s *models.RecurringSeries, from time.Time) (int, time.Time, bool) {
+	for k := 0; ; k++ {
+		date, ok := occurrence(s, k)
+		if !ok {
+			return k, time.Time{}, false
+		}
+		if !date.Before(from) {
+			return k, date, true
+		}
+	}
+}
+
+func addMonths(year int, month time.Month, n int) (int, time.Month) {
+	m := int(month) - 1 + n
+	return year + m/12, time.Month(m%12 + 1)
+}
+
+// dayInMonth возвращает day-е число месяца, либо последний день, если месяц короче.
+func dayInMonth(year int, month time.Month, day int) time.Time {
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, time.UTC).Day()
+	return time.Date(year, month, min(day, last), 0, 0, 0, 0, time.UTC)
+}
+
+// nthWeekday возвращает n-й день недели weekday в месяце. n = -1 или несуществующая
+// пятая неделя означают последний такой день месяца.
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int) time.Time {
+	first := time.Date(year, month, 1, 0, 0, 0, 0, time.UTC)
+	date := first.AddDate(0, 0, (int(weekday)-int(first.Weekday())+7)%7)
+
+	if n > 0 {
+		candidate := date.AddDate(0, 0, 7*(n-1))
+		if candidate.Month() == month {
+			return candidate
+		}
+	}
+
+	for date.AddDate(0, 0, 7).Month() == month {
+		date = date.AddDate(0, 0, 7)
+	}
+	return date
+}
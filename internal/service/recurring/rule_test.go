@@ -0,0 +1,152 @@
+package recurring
+
+import (
+	"testing"
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+func TestOccurrence(t *testing.T) {
+	tests := []struct {
+		name   string
+		series models.RecurringSeries
+		k      int
+		// want - дата повторения, пустая строка - серия закончилась.
+		want string
+	}{
+		{
+			name:   "daily every two days across months",
+			series: models.RecurringSeries{Frequency: models.Daily, Interval: 2, StartDate: "2024-01-30"},
+			k:      1,
+			want:   "2024-02-01",
+		},
+		{
+			name:   "weekly",
+			series: models.RecurringSeries{Frequency: models.Weekly, Interval: 1, StartDate: "2024-01-01"},
+			k:      3,
+			want:   "2024-01-22",
+		},
+		{
+			name:   "monthly on 31st clamps to leap February",
+			series: models.RecurringSeries{Frequency: models.Monthly, Interval: 1, StartDate: "2024-01-31"},
+			k:      1,
+			want:   "2024-02-29",
+		},
+		{
+			name:   "monthly on 31st returns to 31st",
+			series: models.RecurringSeries{Frequency: models.Monthly, Interval: 1, StartDate: "2024-01-31"},
+			k:      2,
+			want:   "2024-03-31",
+		},
+		{
+			name:   "monthly on 31st clamps to 30-day month",
+			series: models.RecurringSeries{Frequency: models.Monthly, Interval: 1, StartDate: "2024-01-31"},
+			k:      3,
+			want:   "2024-04-30",
+		},
+		{
+			name:   "monthly day before start moves to next month",
+			series: models.RecurringSeries{Frequency: models.Monthly, Interval: 1, DayOfMonth: 15, StartDate: "2024-01-20"},
+			k:      0,
+			want:   "2024-02-15",
+		},
+		{
+			name:   "monthly across year",
+			series: models.RecurringSeries{Frequency: models.Monthly, Interval: 1, StartDate: "2024-11-10"},
+			k:      2,
+			want:   "2025-01-10",
+		},
+		{
+			name:   "yearly as every 12 months",
+			series: models.RecurringSeries{Frequency: models.Monthly, Interval: 12, StartDate: "2023-12-05"},
+			k:      1,
+			want:   "2024-12-05",
+		},
+		{
+			name:   "second Monday",
+			series: models.RecurringSeries{Frequency: models.NthWeekday, Interval: 1, Weekday: 1, WeekOfMonth: 2, StartDate: "2024-01-01"},
+			k:      1,
+			want:   "2024-02-12",
+		},
+		{
+			name:   "first Monday before start moves to next month",
+			series: models.RecurringSeries{Frequency: models.NthWeekday, Interval: 1, Weekday: 1, WeekOfMonth: 1, StartDate: "2024-01-10"},
+			k:      0,
+			want:   "2024-02-05",
+		},
+		{
+			name:   "last Friday",
+			series: models.RecurringSeries{Frequency: models.NthWeekday, Interval: 1, Weekday: 5, WeekOfMonth: -1, StartDate: "2024-03-01"},
+			k:      1,
+			want:   "2024-04-26",
+		},
+		{
+			name:   "missing fifth Monday falls back to last",
+			series: models.RecurringSeries{Frequency: models.NthWeekday, Interval: 1, Weekday: 1, WeekOfMonth: 5, StartDate: "2024-02-01"},
+			k:      0,
+			want:   "2024-02-26",
+		},
+		{
+			name:   "count reached",
+			series: models.RecurringSeries{Frequency: models.Daily, Interval: 1, Count: 2, StartDate: "2024-01-01"},
+			k:      2,
+		},
+		{
+			name:   "on end date",
+			series: models.RecurringSeries{Frequency: models.Daily, Interval: 1, StartDate: "2024-01-01", EndDate: "2024-01-02"},
+			k:      1,
+			want:   "2024-01-02",
+		},
+		{
+			name:   "after end date",
+			series: models.RecurringSeries{Frequency: models.Daily, Interval: 1, StartDate: "2024-01-01", EndDate: "2024-01-02"},
+			k:      2,
+		},
+		{
+			name:   "unknown frequency",
+			series: models.RecurringSeries{Frequency: "yearly", Interval: 1, StartDate: "2024-01-01"},
+			k:      0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			date, ok := occurrence(&tt.series, tt.k)
+			got := ""
+			if ok {
+				got = date.Format(dateLayout)
+			}
+			if got != tt.want {
+				t.Errorf("occurrence(%d) = %q, want %q", tt.k, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFirstFrom(t *testing.T) {
+	series := models.RecurringSeries{Frequency: models.Weekly, Interval: 1, Count: 3, StartDate: "2024-01-01"}
+
+	tests := []struct {
+		from  string
+		wantK int
+		want  string
+	}{
+		{from: "2023-12-01", wantK: 0, want: "2024-01-01"},
+		{from: "2024-01-01", wantK: 0, want: "2024-01-01"},
+		{from: "2024-01-10", wantK: 2, want: "2024-01-15"},
+		{from: "2024-01-16", wantK: 3},
+	}
+
+	for _, tt := range tests {
+		from, _ := time.Parse(dateLayout, tt.from)
+		k, date, ok := firstFrom(&series, from)
+		got := ""
+		if ok {
+			got = date.Format(dateLayout)
+		}
+		if k != tt.wantK || got != tt.want {
+			t.Errorf("firstFrom(%s) = %d %q, want %d %q", tt.from, k, got, tt.wantK, tt.want)
+		}
+	}
+}
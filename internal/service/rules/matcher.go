@@ -0,0 +1,104 @@
+package rules
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+// Matcher применяет включённые правила пользователя к операциям. Нулевой Matcher ничего не меняет.
+type Matcher struct {
+	rules []compiled
+}
+
+type compiled struct {
+	rule    models.CategorizationRule
+	payee   string
+	pattern *regexp.Regexp
+}
+
+// Compile готовит правила к применению. Правила должны быть упорядочены по приоритету;
+// выключенные правила пропускаются.
+func Compile(rules []models.CategorizationRule) (*Matcher, error) {
+	m := &Matcher{}
+	for _, rule := range rules {
+		if !rule.Enabled {
+			continue
+		}
+		c := compiled{rule: rule, payee: normalize(rule.Payee)}
+		if rule.Pattern != "" {
+			pattern, err := regexp.Compile("(?i)" + rule.Pattern)
+			if err != nil {
+				return nil, fmt.Errorf("%w: rule %q: invalid pattern: %v", myerrors.ErrValidation, rule.Name, err)
+			}
+			c.pattern = pattern
+		}
+		m.rules = append(m.rules, c)
+	}
+	return m, nil
+}
+
+// Match применяет правила к операции. Категорию и признак запланированной операции задаёт первое
+// сработавшее правило, в котором они указаны; теги всех сработавших правил объединяются.
+func (m *Matcher) Match(t *models.RuleTarget) *models.RuleOutcome {
+	outcome := &models.RuleOutcome{}
+	if m == nil {
+		return outcome
+	}
+
+	seen := map[string]bool{}
+	for i := range m.rules {
+		c := &m.rules[i]
+		if !c.matches(t) {
+			continue
+		}
+		outcome.RuleIDs = append(outcome.RuleIDs, c.rule.ID)
+		if outcome.CategoryID == "" {
+			outcome.CategoryID = c.rule.CategoryID
+		}
+		if outcome.Planned == nil {
+			outcome.Planned = c.rule.Planned
+		}
+		for _, tag := range c.rule.TagIDs {
+			if !seen[tag] {
+				seen[tag] = true
+				outcome.TagIDs = append(outcome.TagIDs, tag)
+			}
+		}
+	}
+	return outcome
+}
+
+func (c *compiled) matches(t *models.RuleTarget) bool {
+	r := &c.rule
+	if r.Kind != "" && r.Kind != t.Kind {
+		return false
+	}
+	if c.payee != "" && !strings.Contains(normalize(t.Payee), c.payee) {
+		return false
+	}
+	if c.pattern != nil && !c.pattern.MatchString(t.Payee) && !c.pattern.MatchString(t.Text) {
+		return false
+	}
+	if r.AmountMin != nil && t.Amount < *r.AmountMin {
+		return false
+	}
+	if r.AmountMax != nil && t.Amount > *r.AmountMax {
+		return false
+	}
+	if r.BankAccount != "" && r.BankAccount != t.BankAccount {
+		return false
+	}
+	if r.Currency != "" && !strings.EqualFold(r.Currency, t.Currency) {
+		return false
+	}
+	return true
+}
+
+// normalize приводит строку к виду для сравнения без учёта регистра, пробелов по краям и различия «ё» и «е».
+func normalize(s string) string {
+	return strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), "ё", "е")
+}
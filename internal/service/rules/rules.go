@@ -0,0 +1,261 @@
+// Package rules provides rule-based auto-categorization of incomes and expenses.
+package rules
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	repo "github.com/wachrusz/Back-End-API/internal/repository"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+const dateLayout = "2006-01-02"
+
+// MaxReapply - максимальное число прошлых операций, к которым правила применяются за один запрос.
+const MaxReapply = 10000
+
+type Rules interface {
+	Create(rule *models.CategorizationRule) (int64, error)
+	Update(rule *models.CategorizationRule) error
+	Delete(id, userID string) error
+	ListByUserID(userID string) ([]models.CategorizationRule, error)
+	Matcher(userID string) (*Matcher, error)
+	CategorizeExpense(expense *models.Expense) error
+	CategorizeIncome(income *models.Income) error
+	Preview(filter *models.RuleApplyFilter) ([]models.RuleChange, error)
+	Apply(actor models.Actor, userID string, changes []models.RuleChange) error
+}
+
+type Service struct {
+	rules repo.RuleRepo
+}
+
+func NewService(rr repo.RuleRepo) *Service {
+	return &Service{rules: rr}
+}
+
+func (s *Service) Create(rule *models.CategorizationRule) (int64, error) {
+	if err := validate(rule); err != nil {
+		return 0, err
+	}
+	return s.rules.Create(rule)
+}
+
+func (s *Service) Update(rule *models.CategorizationRule) error {
+	if err := validate(rule); err != nil {
+		return err
+	}
+	return s.rules.Update(rule)
+}
+
+func (s *Service) Delete(id, userID string) error {
+	return s.rules.Delete(id, userID)
+}
+
+func (s *Service) ListByUserID(userID string) ([]models.CategorizationRule, error) {
+	return s.rules.ListByUserID(userID)
+}
+
+// Matcher загружает включённые правила пользователя.
+func (s *Service) Matcher(userID string) (*Matcher, error) {
+	rules, err := s.rules.ListByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return Compile(rules)
+}
+
+// CategorizeExpense применяет правила к новому расходу. Правила не меняют то, что пользователь указал сам:
+// категория задаётся, только если она не выбрана, признак запланированной операции только включается,
+// а теги добавляются к указанным.
+func (s *Service) CategorizeExpense(expense *models.Expense) error {
+	m, err := s.Matcher(expense.UserID)
+	if err != nil {
+		return err
+	}
+	outcome := m.Match(&models.RuleTarget{
+		Kind:        models.KindExpense,
+		Amount:      expense.Amount,
+		Payee:       expense.SentTo,
+		BankAccount: expense.BankAccount,
+		Currency:    expense.Currency,
+	})
+	fill(&expense.CategoryID, &expense.Planned, &expense.Tags, outcome)
+	return nil
+}
+
+// CategorizeIncome применяет правила к новому доходу так же, как CategorizeExpense.
+func (s *Service) CategorizeIncome(income *models.Income) error {
+	m, err := s.Matcher(income.UserID)
+	if err != nil {
+		return err
+	}
+	outcome := m.Match(&models.RuleTarget{
+		Kind:        models.KindIncome,
+		Amount:      income.Amount,
+		Payee:       income.Sender,
+		BankAccount: income.BankAccount,
+		Currency:    income.Currency,
+	})
+	fill(&income.CategoryID, &income.Planned, &income.Tags, outcome)
+	return nil
+}
+
+func fill(categoryID *string, planned *bool, tags *[]models.Tag, outcome *models.RuleOutcome) {
+	if *categoryID == "" {
+		*categoryID = outcome.CategoryID
+	}
+	if outcome.Planned != nil && *outcome.Planned {
+		*planned = true
+	}
+	for _, id := range outcome.TagIDs {
+		if !hasTag(*tags, id) {
+			*tags = append(*tags, models.Tag{ID: id})
+		}
+	}
+}
+
+func hasTag(tags []models.Tag, id string) bool {
+	for _, tag := range tags {
+		if tag.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// Preview применяет правила к прошлым операциям без сохранения и возвращает операции, которые изменятся.
+// В отличие от создания операции, категория и признак запланированной операции перезаписываются.
+func (s *Service) Preview(filter *models.RuleApplyFilter) ([]models.RuleChange, error) {
+	if err := validateFilter(filter); err != nil {
+		return nil, err
+	}
+
+	m, err := s.Matcher(filter.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	targets, err := s.rules.Transactions(filter, MaxReapply+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(targets) > MaxReapply {
+		return nil, fmt.Errorf("%w: more than %d transactions match, narrow the period", myerrors.ErrValidation, MaxReapply)
+	}
+
+	changes := make([]models.RuleChange, 0)
+	for i := range targets {
+		t := &targets[i]
+		outcome := m.Match(t)
+		if len(outcome.RuleIDs) == 0 {
+			continue
+		}
+
+		change := models.RuleChange{
+			Kind:           t.Kind,
+			ID:             t.ID,
+			Date:           t.Date,
+			Amount:         t.Amount,
+			Currency:       t.Currency,
+			Payee:          t.Payee,
+			CategoryBefore: t.CategoryID,
+			CategoryAfter:  t.CategoryID,
+			PlannedBefore:  t.Planned,
+			PlannedAfter:   t.Planned,
+			RuleIDs:        outcome.RuleIDs,
+		}
+		if outcome.CategoryID != "" {
+			change.CategoryAfter = outcome.CategoryID
+		}
+		if outcome.Planned != nil {
+			change.PlannedAfter = *outcome.Planned
+		}
+		for _, id := range outcome.TagIDs {
+			if !contains(t.TagIDs, id) {
+				change.AddTagIDs = append(change.AddTagIDs, id)
+			}
+		}
+
+		if change.CategoryAfter != change.CategoryBefore || change.PlannedAfter != change.PlannedBefore || len(change.AddTagIDs) > 0 {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+// Apply сохраняет изменения, полученные из Preview.
+func (s *Service) Apply(actor models.Actor, userID string, changes []models.RuleChange) error {
+	if len(changes) == 0 {
+		return nil
+	}
+	return s.rules.ApplyChanges(actor, userID, changes)
+}
+
+func contains(ids []string, id string) bool {
+	for _, v := range ids {
+		if v == id {
+			return true
+		}
+	}
+	return false
+}
+
+func validate(rule *models.CategorizationRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" {
+		return fmt.Errorf("%w: rule name is empty", myerrors.ErrValidation)
+	}
+	switch rule.Kind {
+	case "", models.KindExpense, models.KindIncome:
+	default:
+		return fmt.Errorf("%w: kind must be expense, income or empty", myerrors.ErrValidation)
+	}
+
+	rule.Payee = strings.TrimSpace(rule.Payee)
+	rule.Currency = strings.ToUpper(strings.TrimSpace(rule.Currency))
+	if rule.Currency != "" && len(rule.Currency) != 3 {
+		return fmt.Errorf("%w: invalid currency %q", myerrors.ErrValidation, rule.Currency)
+	}
+	if rule.AmountMin != nil && rule.AmountMax != nil && *rule.AmountMin > *rule.AmountMax {
+		return fmt.Errorf("%w: amount_min is greater than amount_max", myerrors.ErrValidation)
+	}
+	if rule.Payee == "" && rule.Pattern == "" && rule.AmountMin == nil && rule.AmountMax == nil &&
+		rule.BankAccount == "" && rule.Currency == "" {
+		return fmt.Errorf("%w: rule has no conditions", myerrors.ErrValidation)
+	}
+	if _, err := Compile([]models.CategorizationRule{{Name: rule.Name, Pattern: rule.Pattern, Enabled: true}}); err != nil {
+		return err
+	}
+
+	if rule.CategoryID == "" && len(rule.TagIDs) == 0 && rule.Planned == nil {
+		return fmt.Errorf("%w: rule has no actions", myerrors.ErrValidation)
+	}
+	if rule.CategoryID != "" && rule.Kind == "" {
+		return fmt.Errorf("%w: kind is required to set a category", myerrors.ErrValidation)
+	}
+	return nil
+}
+
+func validateFilter(filter *models.RuleApplyFilter) error {
+	switch filter.Kind {
+	case "", models.KindExpense, models.KindIncome:
+	default:
+		return fmt.Errorf("%w: kind must be expense, income or empty", myerrors.ErrValidation)
+	}
+	for _, date := range []string{filter.DateFrom, filter.DateTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(dateLayout, date); err != nil {
+			return fmt.Errorf("%w: invalid date %q, expected YYYY-MM-DD", myerrors.ErrValidation, date)
+		}
+	}
+	if filter.DateFrom != "" && filter.DateTo != "" && filter.DateFrom > filter.DateTo {
+		return fmt.Errorf("%w: date_from is after date_to", myerrors.ErrValidation)
+	}
+	return nil
+}
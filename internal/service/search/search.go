@@ -0,0 +1,130 @@
+// Package search provides full-text search over incomes and expenses.
+package search
+
+import (
+	"fmt"
+	"html"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	repo "github.com/wachrusz/Back-End-API/internal/repository"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+const (
+	defaultLimit   = 20
+	maxLimit       = 100
+	maxQueryLength = 200
+	dateLayout     = "2006-01-02"
+)
+
+type Search interface {
+	Search(query *models.SearchQuery) ([]models.SearchResult, error)
+}
+
+type Service struct {
+	search repo.SearchRepo
+}
+
+func NewService(sr repo.SearchRepo) *Service {
+	return &Service{search: sr}
+}
+
+// Search проверяет запрос, выделяет из него суммы и ищет операции.
+func (s *Service) Search(query *models.SearchQuery) ([]models.SearchResult, error) {
+	query.Text = strings.TrimSpace(query.Text)
+	if query.Text == "" {
+		return nil, fmt.Errorf("%w: query is required", myerrors.ErrValidation)
+	}
+	if utf8.RuneCountInString(query.Text) > maxQueryLength {
+		return nil, fmt.Errorf("%w: query must be at most %d characters", myerrors.ErrValidation, maxQueryLength)
+	}
+
+	switch query.Kind {
+	case "", models.KindExpense, models.KindIncome:
+	default:
+		return nil, fmt.Errorf("%w: kind must be %q or %q", myerrors.ErrValidation, models.KindExpense, models.KindIncome)
+	}
+	for _, date := range []string{query.DateFrom, query.DateTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(dateLayout, date); err != nil {
+			return nil, fmt.Errorf("%w: invalid date %q, expected YYYY-MM-DD", myerrors.ErrValidation, date)
+		}
+	}
+
+	switch {
+	case query.Limit == 0:
+		query.Limit = defaultLimit
+	case query.Limit < 0:
+		return nil, fmt.Errorf("%w: limit must be positive", myerrors.ErrValidation)
+	case query.Limit > maxLimit:
+		query.Limit = maxLimit
+	}
+
+	query.Amounts = amounts(query.Text)
+
+	results, err := s.search.Search(query)
+	if err != nil {
+		return nil, err
+	}
+	for i := range results {
+		results[i].Snippet = highlight.Replace(html.EscapeString(results[i].Snippet))
+	}
+	return results, nil
+}
+
+// highlight возвращает теги выделения, экранированные вместе с остальным текстом фрагмента.
+var highlight = strings.NewReplacer("&lt;b&gt;", "<b>", "&lt;/b&gt;", "</b>")
+
+// amounts возвращает числа из запроса: «1500», «1500.50», «1500,50», «1 500» (разряды через пробел)
+// и «1500₽» считаются суммами.
+func amounts(text string) []float64 {
+	var result []float64
+	var digits strings.Builder
+
+	flush := func() {
+		if digits.Len() == 0 {
+			return
+		}
+		if v, err := strconv.ParseFloat(strings.Trim(digits.String(), "."), 64); err == nil {
+			result = append(result, v)
+		}
+		digits.Reset()
+	}
+
+	runes := []rune(text)
+	for i, r := range runes {
+		switch {
+		case unicode.IsDigit(r):
+			digits.WriteRune(r)
+		case (r == '.' || r == ',') && digits.Len() > 0:
+			digits.WriteRune('.')
+		case unicode.IsSpace(r) && digits.Len() > 0 && isThousands(runes[i+1:]):
+			// пробел между разрядами: «1 500»
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return result
+}
+
+// isThousands сообщает, начинается ли rest с группы из ровно трёх цифр.
+func isThousands(rest []rune) bool {
+	if len(rest) < 3 {
+		return false
+	}
+	for _, r := range rest[:3] {
+		if !unicode.IsDigit(r) {
+			return false
+		}
+	}
+	return len(rest) == 3 || !unicode.IsDigit(rest[3])
+}
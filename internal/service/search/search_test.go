@@ -0,0 +1,34 @@
+package search
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAmounts(t *testing.T) {
+	tests := []struct {
+		text string
+		want []float64
+	}{
+		{text: "такси", want: nil},
+		{text: "1500", want: []float64{1500}},
+		{text: "1500.50", want: []float64{1500.5}},
+		{text: "1500,50", want: []float64{1500.5}},
+		{text: "1 500", want: []float64{1500}},
+		{text: "1 500 000,25", want: []float64{1500000.25}},
+		{text: "1 500 руб", want: []float64{1500}},
+		{text: "1500₽", want: []float64{1500}},
+		{text: "кофе 250.", want: []float64{250}},
+		{text: "2 кофе", want: []float64{2}},
+		{text: "1 50", want: []float64{1, 50}},
+		{text: "12 500 2024", want: []float64{12500, 2024}},
+		{text: "такси 350 и 1 200,5", want: []float64{350, 1200.5}},
+		{text: "10.03.2024", want: nil},
+	}
+
+	for _, tt := range tests {
+		if got := amounts(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("amounts(%q) = %v, want %v", tt.text, got, tt.want)
+		}
+	}
+}
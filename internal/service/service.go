@@ -1,26 +1,61 @@
 package service
 
 import (
+	"github.com/wachrusz/Back-End-API/internal/config"
+	"github.com/wachrusz/Back-End-API/internal/history"
 	"github.com/wachrusz/Back-End-API/internal/mydatabase"
 	"github.com/wachrusz/Back-End-API/internal/repository"
+	"github.com/wachrusz/Back-End-API/internal/service/aggregation"
+	"github.com/wachrusz/Back-End-API/internal/service/attachments"
+	"github.com/wachrusz/Back-End-API/internal/service/batch"
+	"github.com/wachrusz/Back-End-API/internal/service/budgets"
 	"github.com/wachrusz/Back-End-API/internal/service/categories"
+	"github.com/wachrusz/Back-End-API/internal/service/comparison"
 	"github.com/wachrusz/Back-End-API/internal/service/currency"
+	"github.com/wachrusz/Back-End-API/internal/service/duplicates"
 	"github.com/wachrusz/Back-End-API/internal/service/email"
+	"github.com/wachrusz/Back-End-API/internal/service/envelopes"
 	"github.com/wachrusz/Back-End-API/internal/service/fin_health"
+	"github.com/wachrusz/Back-End-API/internal/service/forecast"
 	"github.com/wachrusz/Back-End-API/internal/service/goals"
+	"github.com/wachrusz/Back-End-API/internal/service/importer"
+	"github.com/wachrusz/Back-End-API/internal/service/networth"
+	"github.com/wachrusz/Back-End-API/internal/service/recurring"
+	"github.com/wachrusz/Back-End-API/internal/service/rules"
+	"github.com/wachrusz/Back-End-API/internal/service/search"
+	"github.com/wachrusz/Back-End-API/internal/service/tags"
 	"github.com/wachrusz/Back-End-API/internal/service/token"
+	"github.com/wachrusz/Back-End-API/internal/service/transfers"
+	"github.com/wachrusz/Back-End-API/internal/service/trash"
 	"github.com/wachrusz/Back-End-API/internal/service/user"
 	"github.com/wachrusz/Back-End-API/pkg/rabbit"
 )
 
 type Services struct {
-	Users      user.Users
-	Categories categories.Categories
-	Emails     email.Emails
-	Currency   currency.CurrencyService
-	Tokens     token.Tokens
-	FinHealth  fin_health.Health
-	Goals      goals.Goals
+	Users       user.Users
+	Categories  categories.Categories
+	Emails      email.Emails
+	Currency    currency.CurrencyService
+	Tokens      token.Tokens
+	FinHealth   fin_health.Health
+	Goals       goals.Goals
+	Recurring   recurring.Recurring
+	Importer    importer.Importer
+	Transfers   transfers.Transfers
+	Attachments attachments.Attachments
+	Tags        tags.Tags
+	Search      search.Search
+	Batch       batch.Batch
+	Trash       trash.Trash
+	History     history.History
+	Rules       rules.Rules
+	Duplicates  duplicates.Duplicates
+	Budgets     budgets.Budgets
+	Envelopes   envelopes.Envelopes
+	Forecast    forecast.Forecast
+	Aggregation aggregation.Aggregation
+	Comparison  comparison.Comparison
+	NetWorth    networth.NetWorth
 }
 
 type Dependencies struct {
@@ -28,10 +63,15 @@ type Dependencies struct {
 	Mailer                rabbit.Mailer
 	Models                *repository.Models
 	AccessTokenDurMinutes int
+	TrashRetentionDays    int
+	BudgetAlertThresholds []int
+	CurrencyProviders     []config.CurrencyProvider
+	// RateEvents - рассылка обновлений курсов между экземплярами сервиса.
+	RateEvents currency.Broadcaster
 }
 
 func NewServices(deps Dependencies) (*Services, error) {
-	cur, err := currency.NewService(deps.Repo)
+	cur, err := currency.NewService(deps.Repo, deps.CurrencyProviders, deps.RateEvents)
 	if err != nil {
 		return nil, err
 	}
@@ -41,13 +81,47 @@ func NewServices(deps Dependencies) (*Services, error) {
 	h := fin_health.NewService(deps.Repo)
 	t := token.NewService(deps.Repo, e, u, deps.AccessTokenDurMinutes)
 	g := goals.NewService(deps.Models.Goals, deps.Models.GoalsTransactions)
+	hist := history.NewService(deps.Models.History)
+	rl := rules.NewService(deps.Models.Rules)
+	rec := recurring.NewService(deps.Models.Recurring, rl)
+	dup := duplicates.NewService(deps.Models.Duplicates)
+	imp := importer.NewService(deps.Models.Imports, deps.Models.Accounts, rl, dup)
+	tr := transfers.NewService(deps.Models.Transfers, deps.Models.Accounts, cur)
+	att := attachments.NewService(deps.Models.Attachments)
+	tg := tags.NewService(deps.Models.Tags, cat)
+	srch := search.NewService(deps.Models.Search)
+	b := batch.NewService(deps.Models.Batch)
+	tsh := trash.NewService(deps.Models.Trash, deps.TrashRetentionDays)
+	bud := budgets.NewService(deps.Models.Budgets, e, deps.BudgetAlertThresholds)
+	env := envelopes.NewService(deps.Models.Envelopes)
+	fc := forecast.NewService(deps.Models.Forecast, rec, cur)
+	agg := aggregation.NewService(deps.Models.Aggregation, cur)
+	cmp := comparison.NewService(deps.Models.Aggregation, cur)
+	nw := networth.NewService(deps.Models.NetWorth, cur)
 	return &Services{
-		Users:      u,
-		Categories: cat,
-		Emails:     e,
-		Currency:   cur,
-		Tokens:     t,
-		FinHealth:  h,
-		Goals:      g,
+		Users:       u,
+		Categories:  cat,
+		Emails:      e,
+		Currency:    cur,
+		Tokens:      t,
+		FinHealth:   h,
+		Goals:       g,
+		Recurring:   rec,
+		Importer:    imp,
+		Transfers:   tr,
+		Attachments: att,
+		Tags:        tg,
+		Search:      srch,
+		Batch:       b,
+		Trash:       tsh,
+		History:     hist,
+		Rules:       rl,
+		Duplicates:  dup,
+		Budgets:     bud,
+		Envelopes:   env,
+		Forecast:    fc,
+		Aggregation: agg,
+		Comparison:  cmp,
+		NetWorth:    nw,
 	}, nil
 }
@@ -0,0 +1,197 @@
+// Package tags provides user-defined tags on incomes, expenses and wealth fund records and tag analytics.
+package tags
+
+import (
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	repo "github.com/wachrusz/Back-End-API/internal/repository"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+const (
+	maxNameLength = 64
+	dateLayout    = "2006-01-02"
+)
+
+var colorRe = regexp.MustCompile(`^#[0-9a-fA-F]{6}$`)
+
+type Tags interface {
+	Create(tag *models.Tag) (int64, error)
+	Update(tag *models.Tag) error
+	Delete(id, userID string) error
+	ListByUserID(userID string) ([]models.Tag, error)
+	Assign(kind, recordID, userID string, tagIDs []string) error
+	Summary(tagID, userID, currency, dateFrom, dateTo string) (*models.TagSummary, error)
+}
+
+// Converter пересчитывает сумму из одной валюты в другую по курсам на дату date.
+type Converter interface {
+	ConvertCurrency(amount float64, fromCurrencyCode string, toCurrencyCode string, date string) float64
+}
+
+type Service struct {
+	tags      repo.TagRepo
+	converter Converter
+}
+
+func NewService(tr repo.TagRepo, c Converter) *Service {
+	return &Service{tags: tr, converter: c}
+}
+
+func validate(tag *models.Tag) error {
+	tag.Name = strings.TrimSpace(tag.Name)
+	if tag.Name == "" {
+		return fmt.Errorf("%w: name is required", myerrors.ErrValidation)
+	}
+	if utf8.RuneCountInString(tag.Name) > maxNameLength {
+		return fmt.Errorf("%w: name must be at most %d characters", myerrors.ErrValidation, maxNameLength)
+	}
+	if tag.Color != "" && !colorRe.MatchString(tag.Color) {
+		return fmt.Errorf("%w: color must be in #RRGGBB format", myerrors.ErrValidation)
+	}
+	return nil
+}
+
+func (s *Service) Create(tag *models.Tag) (int64, error) {
+	if err := validate(tag); err != nil {
+		return 0, err
+	}
+	return s.tags.Create(tag)
+}
+
+func (s *Service) Update(tag *models.Tag) error {
+	if err := validate(tag); err != nil {
+		return err
+	}
+	return s.tags.Update(tag)
+}
+
+func (s *Service) Delete(id, userID string) error {
+	return s.tags.Delete(id, userID)
+}
+
+func (s *Service) ListByUserID(userID string) ([]models.Tag, error) {
+	return s.tags.ListByUserID(userID)
+}
+
+// Assign заменяет теги записи. Пустой список снимает все теги.
+func (s *Service) Assign(kind, recordID, userID string, tagIDs []string) error {
+	if _, err := strconv.ParseInt(recordID, 10, 64); err != nil {
+		return fmt.Errorf("%w: invalid record id %q", myerrors.ErrValidation, recordID)
+	}
+
+	unique := make([]string, 0, len(tagIDs))
+	seen := make(map[string]bool, len(tagIDs))
+	for _, id := range tagIDs {
+		if _, err := strconv.ParseInt(id, 10, 64); err != nil {
+			return fmt.Errorf("%w: invalid tag id %q", myerrors.ErrValidation, id)
+		}
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+
+	return s.tags.SetTags(kind, recordID, userID, unique)
+}
+
+// Summary считает итоги по тегу: суммы по видам операций, по исходным валютам и по месяцам,
+// пересчитанные в currency через ConvertCurrency по курсам на дату операций.
+func (s *Service) Summary(tagID, userID, currency, dateFrom, dateTo string) (*models.TagSummary, error) {
+	if _, err := strconv.ParseInt(tagID, 10, 64); err != nil {
+		return nil, fmt.Errorf("%w: invalid tag_id %q", myerrors.ErrValidation, tagID)
+	}
+	for _, date := range []string{dateFrom, dateTo} {
+		if date == "" {
+			continue
+		}
+		if _, err := time.Parse(dateLayout, date); err != nil {
+			return nil, fmt.Errorf("%w: invalid date %q, expected YYYY-MM-DD", myerrors.ErrValidation, date)
+		}
+	}
+	currency = strings.ToUpper(currency)
+	if currency == "" {
+		currency = "RUB"
+	}
+
+	totals, err := s.tags.Totals(tagID, userID, dateFrom, dateTo)
+	if err != nil {
+		return nil, err
+	}
+
+	summary := &models.TagSummary{
+		TagID:      tagID,
+		Currency:   currency,
+		ByCurrency: []models.TagCurrencyTotal{},
+		ByMonth:    []models.TagMonthTotal{},
+	}
+	byCurrency := make(map[[2]string]*models.TagCurrencyTotal)
+	byMonth := make(map[string]*models.TagMonthTotal)
+
+	for _, total := range totals {
+		converted := s.converter.ConvertCurrency(total.Amount, total.Currency, currency, total.Date)
+
+		key := [2]string{total.Kind, total.Currency}
+		ct, ok := byCurrency[key]
+		if !ok {
+			ct = &models.TagCurrencyTotal{Kind: total.Kind, Currency: total.Currency}
+			byCurrency[key] = ct
+		}
+		ct.Amount += total.Amount
+		ct.Converted += converted
+
+		mt, ok := byMonth[total.Month]
+		if !ok {
+			mt = &models.TagMonthTotal{Month: total.Month}
+			byMonth[total.Month] = mt
+		}
+
+		switch total.Kind {
+		case models.KindExpense:
+			summary.Expense += converted
+			mt.Expense += converted
+		case models.KindIncome:
+			summary.Income += converted
+			mt.Income += converted
+		case models.KindWealthFund:
+			summary.WealthFund += converted
+			mt.WealthFund += converted
+		}
+	}
+
+	for _, ct := range byCurrency {
+		ct.Amount, ct.Converted = round(ct.Amount), round(ct.Converted)
+		summary.ByCurrency = append(summary.ByCurrency, *ct)
+	}
+	sort.Slice(summary.ByCurrency, func(i, j int) bool {
+		a, b := summary.ByCurrency[i], summary.ByCurrency[j]
+		if a.Kind != b.Kind {
+			return a.Kind < b.Kind
+		}
+		return a.Currency < b.Currency
+	})
+
+	for _, mt := range byMonth {
+		mt.Expense, mt.Income, mt.WealthFund = round(mt.Expense), round(mt.Income), round(mt.WealthFund)
+		summary.ByMonth = append(summary.ByMonth, *mt)
+	}
+	sort.Slice(summary.ByMonth, func(i, j int) bool {
+		return summary.ByMonth[i].Month < summary.ByMonth[j].Month
+	})
+
+	summary.Expense, summary.Income, summary.WealthFund = round(summary.Expense), round(summary.Income), round(summary.WealthFund)
+
+	return summary, nil
+}
+
+func round(v float64) float64 {
+	return math.Round(v*100) / 100
+}
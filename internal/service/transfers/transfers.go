@@ -0,0 +1,111 @@
+// Package transfers provides transfers between connected accounts of the user.
+package transfers
+
+import (
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	repo "github.com/wachrusz/Back-End-API/internal/repository"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+const dateLayout = "2006-01-02"
+
+type Transfers interface {
+	Create(actor models.Actor, transfer *models.Transfer) (int64, error)
+	Delete(actor models.Actor, id int64, userID string) error
+	ListByUserID(userID string) ([]models.Transfer, error)
+}
+
+// RateSource отдаёт курс валюты к рублю.
+type RateSource interface {
+	RateToRuble(code string) (float64, bool)
+}
+
+type Service struct {
+	transfers repo.TransferRepo
+	accounts  repo.AccountRepo
+	rates     RateSource
+}
+
+func NewService(tr repo.TransferRepo, ar repo.AccountRepo, rates RateSource) *Service {
+	return &Service{transfers: tr, accounts: ar, rates: rates}
+}
+
+// Create проверяет перевод, определяет валюты по счетам и применённый курс, затем сохраняет перевод.
+// Для разных валют клиент может передать to_amount или rate; если не передано ничего, курс считается через рубль.
+func (s *Service) Create(actor models.Actor, transfer *models.Transfer) (int64, error) {
+	if transfer.Amount <= 0 {
+		return 0, fmt.Errorf("%w: amount must be positive", myerrors.ErrValidation)
+	}
+	if transfer.FromAccountID == "" || transfer.ToAccountID == "" {
+		return 0, fmt.Errorf("%w: from_account_id and to_account_id are required", myerrors.ErrValidation)
+	}
+	if transfer.FromAccountID == transfer.ToAccountID {
+		return 0, fmt.Errorf("%w: cannot transfer to the same account", myerrors.ErrValidation)
+	}
+	if transfer.Date == "" {
+		transfer.Date = time.Now().Format(dateLayout)
+	} else if _, err := time.Parse(dateLayout, transfer.Date); err != nil {
+		return 0, fmt.Errorf("%w: invalid date: %v", myerrors.ErrValidation, err)
+	}
+
+	from, err := s.accounts.Get(transfer.FromAccountID, transfer.UserID)
+	if err != nil {
+		return 0, err
+	}
+	to, err := s.accounts.Get(transfer.ToAccountID, transfer.UserID)
+	if err != nil {
+		return 0, err
+	}
+	transfer.Currency = from.AccountCurrency
+	transfer.ToCurrency = to.AccountCurrency
+
+	if err := s.applyRate(transfer); err != nil {
+		return 0, err
+	}
+
+	return s.transfers.Create(actor, transfer)
+}
+
+func (s *Service) applyRate(transfer *models.Transfer) error {
+	switch {
+	case transfer.Currency == transfer.ToCurrency:
+		transfer.Rate = 1
+		transfer.ToAmount = transfer.Amount
+		return nil
+	case transfer.ToAmount > 0:
+		transfer.Rate = transfer.ToAmount / transfer.Amount
+	case transfer.Rate > 0:
+		transfer.ToAmount = transfer.Amount * transfer.Rate
+	case transfer.ToAmount < 0 || transfer.Rate < 0:
+		return fmt.Errorf("%w: to_amount and rate must be positive", myerrors.ErrValidation)
+	default:
+		fromRub, ok := s.rates.RateToRuble(transfer.Currency)
+		if !ok {
+			return fmt.Errorf("%w: unknown rate for currency %s, pass to_amount or rate", myerrors.ErrValidation, transfer.Currency)
+		}
+		toRub, ok := s.rates.RateToRuble(transfer.ToCurrency)
+		if !ok || toRub == 0 {
+			return fmt.Errorf("%w: unknown rate for currency %s, pass to_amount or rate", myerrors.ErrValidation, transfer.ToCurrency)
+		}
+		transfer.Rate = fromRub / toRub
+		transfer.ToAmount = transfer.Amount * transfer.Rate
+	}
+
+	transfer.ToAmount = math.Round(transfer.ToAmount*100) / 100
+	if transfer.ToAmount <= 0 {
+		return fmt.Errorf("%w: to_amount must be positive", myerrors.ErrValidation)
+	}
+	return nil
+}
+
+func (s *Service) Delete(actor models.Actor, id int64, userID string) error {
+	return s.transfers.Delete(actor, id, userID)
+}
+
+func (s *Service) ListByUserID(userID string) ([]models.Transfer, error) {
+	return s.transfers.ListByUserID(userID)
+}
@@ -0,0 +1,95 @@
+// Package trash provides the trash bin for soft-deleted records: listing, restore and purge.
+package trash
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	repo "github.com/wachrusz/Back-End-API/internal/repository"
+	"github.com/wachrusz/Back-End-API/internal/repository/models"
+)
+
+const (
+	// DefaultRetentionDays - срок хранения записей в корзине, если он не задан в конфигурации.
+	DefaultRetentionDays = 30
+	// purgeInterval - как часто воркер удаляет записи с истёкшим сроком хранения.
+	purgeInterval = time.Hour
+)
+
+type Trash interface {
+	List(userID string) ([]models.TrashItem, error)
+	Restore(actor models.Actor, kind, id, userID string) error
+	Purge(actor models.Actor, kind, id, userID string) error
+	SchedulePurge()
+}
+
+type Service struct {
+	trash     repo.TrashRepo
+	retention time.Duration
+}
+
+func NewService(tr repo.TrashRepo, retentionDays int) *Service {
+	if retentionDays <= 0 {
+		retentionDays = DefaultRetentionDays
+	}
+	return &Service{trash: tr, retention: time.Duration(retentionDays) * 24 * time.Hour}
+}
+
+// List возвращает содержимое корзины с моментом окончательного удаления каждой записи.
+func (s *Service) List(userID string) ([]models.TrashItem, error) {
+	items, err := s.trash.List(userID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range items {
+		deletedAt, err := time.Parse(time.RFC3339, items[i].DeletedAt)
+		if err != nil {
+			continue
+		}
+		items[i].ExpiresAt = deletedAt.Add(s.retention).Format(time.RFC3339)
+	}
+	return items, nil
+}
+
+func (s *Service) Restore(actor models.Actor, kind, id, userID string) error {
+	kind, err := validate(kind, id)
+	if err != nil {
+		return err
+	}
+	return s.trash.Restore(actor, kind, id, userID)
+}
+
+func (s *Service) Purge(actor models.Actor, kind, id, userID string) error {
+	kind, err := validate(kind, id)
+	if err != nil {
+		return err
+	}
+	return s.trash.Purge(actor, kind, id, userID)
+}
+
+// SchedulePurge периодически окончательно удаляет записи, срок хранения которых в корзине истёк.
+func (s *Service) SchedulePurge() {
+	for {
+		purged, err := s.trash.PurgeExpired(time.Now().Add(-s.retention))
+		if err != nil {
+			log.Println("Error purging trash:", err)
+		} else if purged > 0 {
+			log.Printf("Purged %d expired records from trash", purged)
+		}
+		time.Sleep(purgeInterval)
+	}
+}
+
+func validate(kind, id string) (string, error) {
+	kind = strings.TrimSpace(kind)
+	if kind == "" {
+		return "", fmt.Errorf("%w: kind is required", myerrors.ErrValidation)
+	}
+	if strings.TrimSpace(id) == "" {
+		return "", fmt.Errorf("%w: id is required", myerrors.ErrValidation)
+	}
+	return kind, nil
+}
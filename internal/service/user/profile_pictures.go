@@ -1,10 +1,13 @@
 package user
 
 import (
+	"errors"
 	"fmt"
 	"github.com/wachrusz/Back-End-API/internal/myerrors"
+	"github.com/wachrusz/Back-End-API/pkg/attachment"
 	"github.com/wachrusz/Back-End-API/pkg/encryption"
 	"github.com/wachrusz/Back-End-API/secret"
+	"io"
 	"math/rand"
 	"mime/multipart"
 	"strconv"
@@ -19,11 +22,36 @@ type Icon struct {
 	serviceID string `json:"service_id"`
 }
 
+const (
+	// maxAvatarSize - максимальный размер загружаемой аватарки.
+	maxAvatarSize = 10 << 20
+	// avatarSide - длина большей стороны сохраняемой аватарки в пикселях.
+	avatarSide = 512
+)
+
+// UploadAvatar проверяет тип файла по содержимому и сохраняет аватарку в JPEG не больше avatarSide.
+// Аватарка хранится отдельно от вложений: она одна на пользователя, отдаётся по ссылке без авторизации
+// и не входит в квоту вложений.
 func (s *Service) UploadAvatar(userID string, f multipart.File) (string, error) {
-	fileBytes, err := ioutil.ReadAll(f)
+	data, err := io.ReadAll(io.LimitReader(f, maxAvatarSize+1))
 	if err != nil {
 		return "", fmt.Errorf("%w: failed to read avatar: %v", myerrors.ErrInternal, err)
 	}
+	if len(data) > maxAvatarSize {
+		return "", fmt.Errorf("%w: maximum size is %d bytes", myerrors.ErrTooLarge, maxAvatarSize)
+	}
+
+	contentType, err := attachment.Sniff(data)
+	if err != nil || !attachment.HasThumbnail(contentType) {
+		return "", fmt.Errorf("%w: only JPEG, PNG and GIF images are accepted", myerrors.ErrValidation)
+	}
+	fileBytes, err := attachment.Thumbnail(data, avatarSide)
+	if errors.Is(err, attachment.ErrTooManyPixels) {
+		return "", fmt.Errorf("%w: image dimensions are too large", myerrors.ErrValidation)
+	}
+	if err != nil {
+		return "", fmt.Errorf("%w: broken image: %v", myerrors.ErrValidation, err)
+	}
 
 	encryptedID, err := encryption.EncryptID(userID)
 	if err != nil {
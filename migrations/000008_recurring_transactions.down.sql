@@ -0,0 +1,7 @@
+ALTER TABLE public.expense DROP COLUMN IF EXISTS recurring_id;
+ALTER TABLE public.income DROP COLUMN IF EXISTS recurring_id;
+
+DROP TABLE IF EXISTS public.recurring_skips;
+DROP TABLE IF EXISTS public.recurring_series;
+
+DROP TYPE IF EXISTS public.recurrence_frequency;
@@ -0,0 +1,53 @@
+CREATE TYPE public.recurrence_frequency AS ENUM (
+    'daily',
+    'weekly',
+    'monthly',
+    'nth_weekday'
+);
+
+ALTER TYPE public.recurrence_frequency OWNER TO postgres;
+
+CREATE TABLE public.recurring_series (
+    id serial primary key,
+    user_id integer NOT NULL references public.users on delete cascade,
+    kind varchar(10) NOT NULL CHECK (kind IN ('expense', 'income')),
+    amount numeric NOT NULL,
+    planned boolean default false NOT NULL,
+    category integer,
+    counterparty varchar(300) default 'blank'::character varying,
+    connected_account varchar(20) default '00000000000000000000'::character varying,
+    currency_code varchar(3) default 'RUB'::character varying,
+    frequency public.recurrence_frequency NOT NULL,
+    repeat_every smallint default 1 NOT NULL CHECK (repeat_every > 0),
+    weekday smallint default 0 NOT NULL CHECK (weekday BETWEEN 0 AND 6),
+    week_of_month smallint default 1 NOT NULL CHECK (week_of_month BETWEEN -1 AND 5),
+    day_of_month smallint default 0 NOT NULL CHECK (day_of_month BETWEEN 0 AND 31),
+    start_date date NOT NULL,
+    end_date date,
+    occurrence_count integer default 0 NOT NULL,
+    materialized integer default 0 NOT NULL,
+    next_date date,
+    paused boolean default false NOT NULL,
+    created_at timestamp with time zone default CURRENT_TIMESTAMP NOT NULL
+);
+
+ALTER TABLE public.recurring_series OWNER TO postgres;
+
+CREATE INDEX recurring_series_due_idx
+ON public.recurring_series (next_date)
+WHERE paused = false AND next_date IS NOT NULL;
+
+CREATE INDEX recurring_series_user_idx
+ON public.recurring_series (user_id);
+
+CREATE TABLE public.recurring_skips (
+    series_id integer NOT NULL references public.recurring_series on delete cascade,
+    date date NOT NULL,
+    primary key (series_id, date)
+);
+
+ALTER TABLE public.recurring_skips OWNER TO postgres;
+
+-- Связь материализованных операций с их серией
+ALTER TABLE public.expense ADD COLUMN recurring_id integer references public.recurring_series on delete set null;
+ALTER TABLE public.income ADD COLUMN recurring_id integer references public.recurring_series on delete set null;
@@ -0,0 +1,10 @@
+CREATE TABLE public.import_profiles (
+    id serial primary key,
+    user_id integer NOT NULL references public.users on delete cascade,
+    name varchar(255) NOT NULL,
+    mapping jsonb NOT NULL,
+    created_at timestamp with time zone default CURRENT_TIMESTAMP NOT NULL,
+    UNIQUE (user_id, name)
+);
+
+ALTER TABLE public.import_profiles OWNER TO postgres;
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS public.expense_external_id_idx;
+DROP INDEX IF EXISTS public.income_external_id_idx;
+
+ALTER TABLE public.expense DROP COLUMN IF EXISTS external_id;
+ALTER TABLE public.income DROP COLUMN IF EXISTS external_id;
@@ -0,0 +1,7 @@
+ALTER TABLE public.expense ADD COLUMN external_id varchar(255);
+ALTER TABLE public.income ADD COLUMN external_id varchar(255);
+
+CREATE UNIQUE INDEX expense_external_id_idx ON public.expense (user_id, connected_account, external_id)
+    WHERE external_id IS NOT NULL;
+CREATE UNIQUE INDEX income_external_id_idx ON public.income (user_id, connected_account, external_id)
+    WHERE external_id IS NOT NULL;
@@ -0,0 +1,3 @@
+DROP INDEX IF EXISTS public.expense_user_date_idx;
+DROP INDEX IF EXISTS public.income_user_date_idx;
+DROP INDEX IF EXISTS public.wealth_fund_user_date_idx;
@@ -0,0 +1,3 @@
+CREATE INDEX IF NOT EXISTS expense_user_date_idx ON public.expense (user_id, date, id);
+CREATE INDEX IF NOT EXISTS income_user_date_idx ON public.income (user_id, date, id);
+CREATE INDEX IF NOT EXISTS wealth_fund_user_date_idx ON public.wealth_fund (user_id, date, id);
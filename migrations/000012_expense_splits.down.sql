@@ -0,0 +1,18 @@
+DROP VIEW IF EXISTS public.expense_in_rubles;
+CREATE VIEW public.expense_in_rubles AS
+SELECT
+    *,
+    CASE
+        WHEN currency_code = 'RUB' THEN amount
+        ELSE amount * COALESCE(
+            (SELECT rate_to_ruble
+             FROM exchange_rates
+             WHERE exchange_rates.currency_code = expense.currency_code),
+            1)
+    END AS amount_in_rubles
+FROM expense;
+
+DROP INDEX IF EXISTS public.operations_expense_id_idx;
+ALTER TABLE public.operations DROP COLUMN IF EXISTS expense_id;
+
+DROP TABLE IF EXISTS public.expense_splits;
@@ -0,0 +1,42 @@
+CREATE TABLE public.expense_splits (
+    id serial primary key,
+    expense_id integer NOT NULL references public.expense on delete cascade,
+    category integer NOT NULL,
+    amount numeric NOT NULL CHECK (amount > 0),
+    note varchar(255) DEFAULT '' NOT NULL
+);
+
+ALTER TABLE public.expense_splits OWNER TO postgres;
+
+CREATE INDEX expense_splits_expense_id_idx ON public.expense_splits (expense_id);
+
+-- Связь записи архива операций с расходом, чтобы заменять её частями разделённого расхода.
+ALTER TABLE public.operations ADD COLUMN expense_id integer;
+CREATE INDEX operations_expense_id_idx ON public.operations (expense_id);
+
+-- Разделённый расход раскрывается в свои части: у каждой части своя категория и сумма.
+DROP VIEW IF EXISTS public.expense_in_rubles;
+CREATE VIEW public.expense_in_rubles AS
+SELECT
+    e.id,
+    COALESCE(s.amount, e.amount) AS amount,
+    e.date,
+    e.planned,
+    e.user_id,
+    COALESCE(s.category, e.category) AS category,
+    e.transaction_type,
+    e.currency_code,
+    e.connected_account,
+    e.sent_to,
+    e.type,
+    s.id AS split_id,
+    CASE
+        WHEN e.currency_code = 'RUB' THEN COALESCE(s.amount, e.amount)
+        ELSE COALESCE(s.amount, e.amount) * COALESCE(
+            (SELECT rate_to_ruble
+             FROM exchange_rates
+             WHERE exchange_rates.currency_code = e.currency_code),
+            1)
+    END AS amount_in_rubles
+FROM expense e
+LEFT JOIN expense_splits s ON s.expense_id = e.id;
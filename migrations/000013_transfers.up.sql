@@ -0,0 +1,19 @@
+CREATE TABLE public.transfers (
+    id serial primary key,
+    user_id integer NOT NULL references public.users on delete cascade,
+    from_account integer NOT NULL references public.connected_accounts on delete cascade,
+    to_account integer NOT NULL references public.connected_accounts on delete cascade,
+    amount numeric NOT NULL CHECK (amount > 0),
+    currency_code varchar(3) NOT NULL,
+    to_amount numeric NOT NULL CHECK (to_amount > 0),
+    to_currency_code varchar(3) NOT NULL,
+    rate numeric NOT NULL,
+    date date NOT NULL,
+    note varchar(255) DEFAULT '' NOT NULL,
+    created_at timestamp with time zone default CURRENT_TIMESTAMP NOT NULL,
+    CHECK (from_account <> to_account)
+);
+
+ALTER TABLE public.transfers OWNER TO postgres;
+
+CREATE INDEX transfers_user_date_idx ON public.transfers (user_id, date);
@@ -0,0 +1,23 @@
+-- Вложения (чеки, гарантийные талоны, счета) к расходам, доходам и транзакциям целей.
+-- Ровно одна из ссылок на владельца заполнена; вложение удаляется вместе с владельцем.
+CREATE TABLE public.attachments (
+    id serial primary key,
+    user_id integer NOT NULL references public.users on delete cascade,
+    expense_id integer references public.expense on delete cascade,
+    income_id integer references public.income on delete cascade,
+    goal_transaction_id integer references public.goal_transactions on delete cascade,
+    file_name varchar(255) DEFAULT '' NOT NULL,
+    content_type varchar(100) NOT NULL,
+    size bigint NOT NULL,
+    data bytea NOT NULL,
+    thumbnail bytea,
+    created_at timestamp with time zone default CURRENT_TIMESTAMP NOT NULL,
+    CHECK (num_nonnulls(expense_id, income_id, goal_transaction_id) = 1)
+);
+
+ALTER TABLE public.attachments OWNER TO postgres;
+
+CREATE INDEX attachments_user_id_idx ON public.attachments (user_id);
+CREATE INDEX attachments_expense_id_idx ON public.attachments (expense_id);
+CREATE INDEX attachments_income_id_idx ON public.attachments (income_id);
+CREATE INDEX attachments_goal_transaction_id_idx ON public.attachments (goal_transaction_id);
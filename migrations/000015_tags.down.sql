@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS public.wealth_fund_tags;
+DROP TABLE IF EXISTS public.income_tags;
+DROP TABLE IF EXISTS public.expense_tags;
+DROP TABLE IF EXISTS public.tags;
@@ -0,0 +1,38 @@
+-- Пользовательские теги, которые можно навесить на расходы, доходы и фонд благосостояния поверх категорий.
+CREATE TABLE public.tags (
+    id serial primary key,
+    user_id integer NOT NULL references public.users on delete cascade,
+    name varchar(64) NOT NULL,
+    color varchar(7) DEFAULT '' NOT NULL,
+    created_at timestamp with time zone default CURRENT_TIMESTAMP NOT NULL
+);
+
+ALTER TABLE public.tags OWNER TO postgres;
+
+CREATE UNIQUE INDEX tags_user_name_idx ON public.tags (user_id, lower(name));
+
+CREATE TABLE public.expense_tags (
+    expense_id integer NOT NULL references public.expense on delete cascade,
+    tag_id integer NOT NULL references public.tags on delete cascade,
+    primary key (expense_id, tag_id)
+);
+
+CREATE TABLE public.income_tags (
+    income_id integer NOT NULL references public.income on delete cascade,
+    tag_id integer NOT NULL references public.tags on delete cascade,
+    primary key (income_id, tag_id)
+);
+
+CREATE TABLE public.wealth_fund_tags (
+    wealth_fund_id integer NOT NULL references public.wealth_fund on delete cascade,
+    tag_id integer NOT NULL references public.tags on delete cascade,
+    primary key (wealth_fund_id, tag_id)
+);
+
+ALTER TABLE public.expense_tags OWNER TO postgres;
+ALTER TABLE public.income_tags OWNER TO postgres;
+ALTER TABLE public.wealth_fund_tags OWNER TO postgres;
+
+CREATE INDEX expense_tags_tag_id_idx ON public.expense_tags (tag_id);
+CREATE INDEX income_tags_tag_id_idx ON public.income_tags (tag_id);
+CREATE INDEX wealth_fund_tags_tag_id_idx ON public.wealth_fund_tags (tag_id);
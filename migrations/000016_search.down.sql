@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS public.income_sender_trgm_idx;
+DROP INDEX IF EXISTS public.expense_sent_to_trgm_idx;
+ALTER TABLE public.income DROP COLUMN IF EXISTS search_vector;
+ALTER TABLE public.expense DROP COLUMN IF EXISTS search_vector;
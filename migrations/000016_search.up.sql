@@ -0,0 +1,20 @@
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- Поисковые документы по получателю/отправителю и описанию на русском и английском.
+-- Значение 'blank' - заглушка по умолчанию, в поиск не попадает.
+ALTER TABLE public.expense ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    to_tsvector('russian', COALESCE(NULLIF(sent_to, 'blank'), '')) ||
+    to_tsvector('english', COALESCE(NULLIF(sent_to, 'blank'), ''))
+) STORED;
+
+ALTER TABLE public.income ADD COLUMN search_vector tsvector GENERATED ALWAYS AS (
+    to_tsvector('russian', concat_ws(' ', NULLIF(sender, 'blank'), NULLIF(description, 'blank'))) ||
+    to_tsvector('english', concat_ws(' ', NULLIF(sender, 'blank'), NULLIF(description, 'blank')))
+) STORED;
+
+CREATE INDEX expense_search_vector_idx ON public.expense USING gin (search_vector);
+CREATE INDEX income_search_vector_idx ON public.income USING gin (search_vector);
+
+-- Нечёткий поиск по названию получателя и отправителя.
+CREATE INDEX expense_sent_to_trgm_idx ON public.expense USING gin (sent_to gin_trgm_ops);
+CREATE INDEX income_sender_trgm_idx ON public.income USING gin (sender gin_trgm_ops);
@@ -0,0 +1,72 @@
+DROP VIEW IF EXISTS public.expense_in_rubles;
+DROP VIEW IF EXISTS public.income_in_rubles;
+DROP VIEW IF EXISTS public.wealth_fund_in_rubles;
+
+DROP INDEX IF EXISTS public.goals_deleted_at_idx;
+DROP INDEX IF EXISTS public.wealth_fund_deleted_at_idx;
+DROP INDEX IF EXISTS public.income_deleted_at_idx;
+DROP INDEX IF EXISTS public.expense_deleted_at_idx;
+
+-- Записи из корзины при откате удаляются окончательно, иначе они снова попадут в расчёты.
+DELETE FROM public.operations WHERE expense_id IN (SELECT id FROM public.expense WHERE deleted_at IS NOT NULL);
+DELETE FROM public.goal_transactions WHERE goal_id IN (SELECT id FROM public.goals WHERE deleted_at IS NOT NULL);
+DELETE FROM public.expense WHERE deleted_at IS NOT NULL;
+DELETE FROM public.income WHERE deleted_at IS NOT NULL;
+DELETE FROM public.wealth_fund WHERE deleted_at IS NOT NULL;
+DELETE FROM public.goals WHERE deleted_at IS NOT NULL;
+
+ALTER TABLE public.expense DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE public.income DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE public.wealth_fund DROP COLUMN IF EXISTS deleted_at;
+ALTER TABLE public.goals DROP COLUMN IF EXISTS deleted_at;
+
+CREATE VIEW public.expense_in_rubles AS
+SELECT
+    e.id,
+    COALESCE(s.amount, e.amount) AS amount,
+    e.date,
+    e.planned,
+    e.user_id,
+    COALESCE(s.category, e.category) AS category,
+    e.transaction_type,
+    e.currency_code,
+    e.connected_account,
+    e.sent_to,
+    e.type,
+    s.id AS split_id,
+    CASE
+        WHEN e.currency_code = 'RUB' THEN COALESCE(s.amount, e.amount)
+        ELSE COALESCE(s.amount, e.amount) * COALESCE(
+            (SELECT rate_to_ruble
+             FROM exchange_rates
+             WHERE exchange_rates.currency_code = e.currency_code),
+            1)
+    END AS amount_in_rubles
+FROM expense e
+LEFT JOIN expense_splits s ON s.expense_id = e.id;
+
+CREATE VIEW public.income_in_rubles AS
+SELECT
+    *,
+    CASE
+        WHEN currency_code = 'RUB' THEN amount
+        ELSE amount * COALESCE(
+            (SELECT rate_to_ruble
+             FROM exchange_rates
+             WHERE exchange_rates.currency_code = income.currency_code),
+            1)
+    END AS amount_in_rubles
+FROM income;
+
+CREATE VIEW public.wealth_fund_in_rubles AS
+SELECT
+    *,
+    CASE
+        WHEN currency_code = 'RUB' THEN amount
+        ELSE amount * COALESCE(
+            (SELECT rate_to_ruble
+             FROM exchange_rates
+             WHERE exchange_rates.currency_code = wealth_fund.currency_code),
+            1)
+    END AS amount_in_rubles
+FROM wealth_fund;
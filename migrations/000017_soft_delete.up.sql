@@ -0,0 +1,68 @@
+-- Мягкое удаление: удалённые записи попадают в корзину и окончательно удаляются по истечении срока хранения.
+ALTER TABLE public.expense ADD COLUMN deleted_at timestamp with time zone;
+ALTER TABLE public.income ADD COLUMN deleted_at timestamp with time zone;
+ALTER TABLE public.wealth_fund ADD COLUMN deleted_at timestamp with time zone;
+ALTER TABLE public.goals ADD COLUMN deleted_at timestamp with time zone;
+
+CREATE INDEX expense_deleted_at_idx ON public.expense (user_id, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX income_deleted_at_idx ON public.income (user_id, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX wealth_fund_deleted_at_idx ON public.wealth_fund (user_id, deleted_at) WHERE deleted_at IS NOT NULL;
+CREATE INDEX goals_deleted_at_idx ON public.goals (user_id, deleted_at) WHERE deleted_at IS NOT NULL;
+
+-- Представления в рублях, на которых построены показатели финансового здоровья, не видят записей из корзины.
+DROP VIEW IF EXISTS public.expense_in_rubles;
+CREATE VIEW public.expense_in_rubles AS
+SELECT
+    e.id,
+    COALESCE(s.amount, e.amount) AS amount,
+    e.date,
+    e.planned,
+    e.user_id,
+    COALESCE(s.category, e.category) AS category,
+    e.transaction_type,
+    e.currency_code,
+    e.connected_account,
+    e.sent_to,
+    e.type,
+    s.id AS split_id,
+    CASE
+        WHEN e.currency_code = 'RUB' THEN COALESCE(s.amount, e.amount)
+        ELSE COALESCE(s.amount, e.amount) * COALESCE(
+            (SELECT rate_to_ruble
+             FROM exchange_rates
+             WHERE exchange_rates.currency_code = e.currency_code),
+            1)
+    END AS amount_in_rubles
+FROM expense e
+LEFT JOIN expense_splits s ON s.expense_id = e.id
+WHERE e.deleted_at IS NULL;
+
+DROP VIEW IF EXISTS public.income_in_rubles;
+CREATE VIEW public.income_in_rubles AS
+SELECT
+    *,
+    CASE
+        WHEN currency_code = 'RUB' THEN amount
+        ELSE amount * COALESCE(
+            (SELECT rate_to_ruble
+             FROM exchange_rates
+             WHERE exchange_rates.currency_code = income.currency_code),
+            1)
+    END AS amount_in_rubles
+FROM income
+WHERE deleted_at IS NULL;
+
+DROP VIEW IF EXISTS public.wealth_fund_in_rubles;
+CREATE VIEW public.wealth_fund_in_rubles AS
+SELECT
+    *,
+    CASE
+        WHEN currency_code = 'RUB' THEN amount
+        ELSE amount * COALESCE(
+            (SELECT rate_to_ruble
+             FROM exchange_rates
+             WHERE exchange_rates.currency_code = wealth_fund.currency_code),
+            1)
+    END AS amount_in_rubles
+FROM wealth_fund
+WHERE deleted_at IS NULL;
@@ -0,0 +1,19 @@
+-- Журнал изменений финансовых записей: состояние до и после каждого создания, изменения и удаления.
+CREATE TABLE public.record_history (
+    id bigserial primary key,
+    user_id integer NOT NULL references public.users on delete cascade,
+    record_type varchar(32) NOT NULL,
+    record_id integer NOT NULL,
+    action varchar(16) NOT NULL CHECK (action IN ('create', 'update', 'delete', 'restore', 'purge')),
+    before jsonb,
+    after jsonb,
+    -- NULL - изменение сделано сервером (например, материализация повторяющихся операций).
+    actor_id integer,
+    device_id varchar(255) DEFAULT '' NOT NULL,
+    created_at timestamp with time zone default CURRENT_TIMESTAMP NOT NULL
+);
+
+ALTER TABLE public.record_history OWNER TO postgres;
+
+CREATE INDEX record_history_user_idx ON public.record_history (user_id, created_at DESC, id DESC);
+CREATE INDEX record_history_record_idx ON public.record_history (record_type, record_id, created_at DESC, id DESC);
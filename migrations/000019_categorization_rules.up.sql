@@ -0,0 +1,26 @@
+-- Правила автокатегоризации: условия на контрагента, сумму, счёт, валюту и текст операции
+-- и действия, которые задают категорию, теги и признак запланированной операции.
+CREATE TABLE public.categorization_rules (
+    id serial primary key,
+    user_id integer NOT NULL references public.users on delete cascade,
+    name varchar(255) NOT NULL,
+    -- Пустой вид означает, что правило применяется и к расходам, и к доходам.
+    kind varchar(16) DEFAULT '' NOT NULL CHECK (kind IN ('', 'expense', 'income')),
+    priority integer DEFAULT 0 NOT NULL,
+    enabled boolean DEFAULT true NOT NULL,
+    payee varchar(255) DEFAULT '' NOT NULL,
+    pattern varchar(255) DEFAULT '' NOT NULL,
+    amount_min numeric,
+    amount_max numeric,
+    bank_account varchar(255) DEFAULT '' NOT NULL,
+    currency_code varchar(3) DEFAULT '' NOT NULL,
+    category_id integer,
+    tag_ids integer[] DEFAULT '{}' NOT NULL,
+    planned boolean,
+    created_at timestamp with time zone default CURRENT_TIMESTAMP NOT NULL,
+    CHECK (amount_min IS NULL OR amount_max IS NULL OR amount_min <= amount_max)
+);
+
+ALTER TABLE public.categorization_rules OWNER TO postgres;
+
+CREATE INDEX categorization_rules_user_idx ON public.categorization_rules (user_id, priority, id);
@@ -0,0 +1,13 @@
+-- Пары операций, которые пользователь отметил как не дубликаты. Такие пары больше не предлагаются.
+-- first_id всегда меньше second_id.
+CREATE TABLE public.duplicate_dismissals (
+    user_id integer NOT NULL references public.users on delete cascade,
+    kind varchar(16) NOT NULL CHECK (kind IN ('expense', 'income')),
+    first_id integer NOT NULL,
+    second_id integer NOT NULL,
+    created_at timestamp with time zone default CURRENT_TIMESTAMP NOT NULL,
+    primary key (user_id, kind, first_id, second_id),
+    CHECK (first_id < second_id)
+);
+
+ALTER TABLE public.duplicate_dismissals OWNER TO postgres;
@@ -0,0 +1,28 @@
+-- Месячные бюджеты расходов: по категории или общий (category_id IS NULL). Суммы в рублях.
+CREATE TABLE public.budgets (
+    id serial primary key,
+    user_id integer NOT NULL references public.users on delete cascade,
+    category_id integer,
+    amount numeric(18,2) NOT NULL CHECK (amount > 0),
+    -- rollover переносит неизрасходованный остаток месяца на следующий месяц.
+    rollover boolean DEFAULT false NOT NULL,
+    -- Пороги уведомлений в процентах от бюджета; пустой список - пороги из конфигурации.
+    alert_thresholds integer[] DEFAULT '{}' NOT NULL,
+    created_at timestamp with time zone default CURRENT_TIMESTAMP NOT NULL
+);
+
+ALTER TABLE public.budgets OWNER TO postgres;
+
+CREATE UNIQUE INDEX budgets_user_category_idx ON public.budgets (user_id, category_id) WHERE category_id IS NOT NULL;
+CREATE UNIQUE INDEX budgets_user_overall_idx ON public.budgets (user_id) WHERE category_id IS NULL;
+
+-- Отправленные уведомления о расходе бюджета: каждый порог срабатывает один раз за период.
+CREATE TABLE public.budget_alerts (
+    budget_id integer NOT NULL references public.budgets on delete cascade,
+    period date NOT NULL,
+    threshold integer NOT NULL,
+    sent_at timestamp with time zone default CURRENT_TIMESTAMP NOT NULL,
+    primary key (budget_id, period, threshold)
+);
+
+ALTER TABLE public.budget_alerts OWNER TO postgres;
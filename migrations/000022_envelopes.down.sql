@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS public.envelope_balances;
+DROP TABLE IF EXISTS public.envelope_closings;
+DROP TABLE IF EXISTS public.envelope_movements;
+DROP TABLE IF EXISTS public.envelopes;
@@ -0,0 +1,49 @@
+-- Конвертный бюджет: каждый доход распределяется по конвертам, расходы категории конверта уменьшают его остаток.
+CREATE TABLE public.envelopes (
+    id serial primary key,
+    user_id integer NOT NULL references public.users on delete cascade,
+    category_id integer NOT NULL,
+    created_at timestamp with time zone default CURRENT_TIMESTAMP NOT NULL,
+    unique (user_id, category_id)
+);
+
+ALTER TABLE public.envelopes OWNER TO postgres;
+
+-- Перемещения денег за месяц. Пустой from_envelope_id - деньги из суммы к распределению,
+-- пустой to_envelope_id - возврат в сумму к распределению.
+CREATE TABLE public.envelope_movements (
+    id serial primary key,
+    user_id integer NOT NULL references public.users on delete cascade,
+    month date NOT NULL,
+    from_envelope_id integer references public.envelopes on delete cascade,
+    to_envelope_id integer references public.envelopes on delete cascade,
+    amount numeric(18,2) NOT NULL CHECK (amount > 0),
+    note varchar(255) DEFAULT '' NOT NULL,
+    created_at timestamp with time zone default CURRENT_TIMESTAMP NOT NULL,
+    CHECK (num_nonnulls(from_envelope_id, to_envelope_id) > 0),
+    CHECK (from_envelope_id IS DISTINCT FROM to_envelope_id)
+);
+
+ALTER TABLE public.envelope_movements OWNER TO postgres;
+
+CREATE INDEX envelope_movements_user_month_idx ON public.envelope_movements (user_id, month);
+
+-- Закрытые месяцы: сумма к распределению и остатки конвертов, перенесённые на следующий месяц.
+CREATE TABLE public.envelope_closings (
+    user_id integer NOT NULL references public.users on delete cascade,
+    month date NOT NULL,
+    to_assign numeric(18,2) NOT NULL,
+    closed_at timestamp with time zone default CURRENT_TIMESTAMP NOT NULL,
+    primary key (user_id, month)
+);
+
+ALTER TABLE public.envelope_closings OWNER TO postgres;
+
+CREATE TABLE public.envelope_balances (
+    envelope_id integer NOT NULL references public.envelopes on delete cascade,
+    month date NOT NULL,
+    balance numeric(18,2) NOT NULL,
+    primary key (envelope_id, month)
+);
+
+ALTER TABLE public.envelope_balances OWNER TO postgres;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS public.net_worth_snapshots;
+ALTER TABLE public.users DROP COLUMN IF EXISTS base_currency;
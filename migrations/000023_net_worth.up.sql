@@ -0,0 +1,20 @@
+-- Базовая валюта пользователя, в которой показывается чистый капитал.
+ALTER TABLE public.users ADD COLUMN base_currency varchar(3) DEFAULT 'RUB' NOT NULL;
+
+-- Ежедневные снимки чистого капитала в рублях по курсам на момент расчёта.
+CREATE TABLE public.net_worth_snapshots (
+    user_id integer NOT NULL references public.users on delete cascade,
+    date date NOT NULL,
+    -- Остатки подключённых счетов.
+    accounts numeric(18,2) NOT NULL,
+    -- Ликвидные и неликвидные активы фонда благосостояния.
+    liquid numeric(18,2) NOT NULL,
+    illiquid numeric(18,2) NOT NULL,
+    -- Кредиты фонда благосостояния.
+    loans numeric(18,2) NOT NULL,
+    net_worth numeric(18,2) NOT NULL,
+    updated_at timestamp with time zone default CURRENT_TIMESTAMP NOT NULL,
+    primary key (user_id, date)
+);
+
+ALTER TABLE public.net_worth_snapshots OWNER TO postgres;
@@ -0,0 +1,73 @@
+-- История курсов ЦБ: курс валюты к рублю за единицу, действующий с даты date.
+CREATE TABLE public.exchange_rate_history (
+    currency_code varchar(10) NOT NULL,
+    date date NOT NULL,
+    rate_to_ruble numeric(18,8) NOT NULL CHECK (rate_to_ruble > 0),
+    -- previous_url - архив ЦБ за предыдущий день торгов, с него продолжается загрузка истории.
+    previous_url varchar(255),
+    primary key (currency_code, date)
+);
+
+ALTER TABLE public.exchange_rate_history OWNER TO postgres;
+
+INSERT INTO public.exchange_rate_history (currency_code, date, rate_to_ruble)
+SELECT currency_code, CURRENT_DATE, rate_to_ruble FROM public.exchange_rates WHERE rate_to_ruble > 0;
+
+-- Курс на дату: последний курс не позже даты, для дат до начала истории - самый ранний известный,
+-- для валют без истории - текущий, для рубля и неизвестных валют - 1.
+CREATE FUNCTION public.rate_to_ruble_on(code varchar, on_date date) RETURNS numeric
+LANGUAGE sql STABLE AS $$
+    SELECT COALESCE(
+        (SELECT rate_to_ruble FROM public.exchange_rate_history
+         WHERE currency_code = code AND date <= on_date ORDER BY date DESC LIMIT 1),
+        (SELECT rate_to_ruble FROM public.exchange_rate_history
+         WHERE currency_code = code ORDER BY date LIMIT 1),
+        (SELECT rate_to_ruble FROM public.exchange_rates WHERE currency_code = code),
+        1)
+$$;
+
+-- Представления в рублях пересчитывают операции по курсу на дату операции.
+DROP VIEW IF EXISTS public.expense_in_rubles;
+CREATE VIEW public.expense_in_rubles AS
+SELECT
+    e.id,
+    COALESCE(s.amount, e.amount) AS amount,
+    e.date,
+    e.planned,
+    e.user_id,
+    COALESCE(s.category, e.category) AS category,
+    e.transaction_type,
+    e.currency_code,
+    e.connected_account,
+    e.sent_to,
+    e.type,
+    s.id AS split_id,
+    CASE
+        WHEN e.currency_code = 'RUB' THEN COALESCE(s.amount, e.amount)
+        ELSE COALESCE(s.amount, e.amount) * public.rate_to_ruble_on(e.currency_code, e.date)
+    END AS amount_in_rubles
+FROM expense e
+LEFT JOIN expense_splits s ON s.expense_id = e.id
+WHERE e.deleted_at IS NULL;
+
+DROP VIEW IF EXISTS public.income_in_rubles;
+CREATE VIEW public.income_in_rubles AS
+SELECT
+    *,
+    CASE
+        WHEN currency_code = 'RUB' THEN amount
+        ELSE amount * public.rate_to_ruble_on(income.currency_code, income.date)
+    END AS amount_in_rubles
+FROM income
+WHERE deleted_at IS NULL;
+
+DROP VIEW IF EXISTS public.wealth_fund_in_rubles;
+CREATE VIEW public.wealth_fund_in_rubles AS
+SELECT
+    *,
+    CASE
+        WHEN currency_code = 'RUB' THEN amount
+        ELSE amount * public.rate_to_ruble_on(wealth_fund.currency_code, wealth_fund.date)
+    END AS amount_in_rubles
+FROM wealth_fund
+WHERE deleted_at IS NULL;
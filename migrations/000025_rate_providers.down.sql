@@ -0,0 +1,7 @@
+ALTER TABLE public.exchange_rate_history
+    DROP COLUMN IF EXISTS fetched_at,
+    DROP COLUMN IF EXISTS provider;
+
+ALTER TABLE public.exchange_rates
+    DROP COLUMN IF EXISTS fetched_at,
+    DROP COLUMN IF EXISTS provider;
@@ -0,0 +1,8 @@
+-- Источник курса: провайдер, от которого он получен, и время получения.
+ALTER TABLE public.exchange_rates
+    ADD COLUMN provider varchar(32),
+    ADD COLUMN fetched_at timestamp with time zone;
+
+ALTER TABLE public.exchange_rate_history
+    ADD COLUMN provider varchar(32),
+    ADD COLUMN fetched_at timestamp with time zone;
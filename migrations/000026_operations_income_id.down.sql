@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS public.operations_income_id_idx;
+ALTER TABLE public.operations DROP COLUMN IF EXISTS income_id;
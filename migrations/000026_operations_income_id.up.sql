@@ -0,0 +1,3 @@
+-- Связь записи архива операций с доходом, чтобы скрывать её вместе с доходом в корзине и удалять вместе с ним.
+ALTER TABLE public.operations ADD COLUMN income_id integer;
+CREATE INDEX operations_income_id_idx ON public.operations (income_id);
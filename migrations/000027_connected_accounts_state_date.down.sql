@@ -0,0 +1 @@
+ALTER TABLE public.connected_accounts DROP COLUMN IF EXISTS state_date;
@@ -0,0 +1,2 @@
+-- Дата остатка счёта из последней применённой выписки. Остаток из более старой выписки не перезаписывает её.
+ALTER TABLE public.connected_accounts ADD COLUMN state_date date;
@@ -0,0 +1,120 @@
+// Package attachment contains helpers for storing user files: content type sniffing
+// and thumbnail generation for images.
+package attachment
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"net/http"
+	"strings"
+
+	_ "image/gif"
+	_ "image/png"
+)
+
+// MaxPixels is the largest image, in pixels, Thumbnail decodes. A small compressed file can declare
+// huge dimensions, and decoding it would allocate gigabytes.
+const MaxPixels = 50_000_000
+
+var (
+	// ErrUnsupportedType is returned when the sniffed content type is not in the allowed list.
+	ErrUnsupportedType = errors.New("unsupported content type")
+	// ErrTooManyPixels is returned by Thumbnail for images larger than MaxPixels.
+	ErrTooManyPixels = errors.New("image dimensions are too large")
+)
+
+// allowed maps sniffed content types to whether a thumbnail can be generated for them.
+var allowed = map[string]bool{
+	"image/jpeg":      true,
+	"image/png":       true,
+	"image/gif":       true,
+	"image/webp":      false,
+	"application/pdf": false,
+}
+
+// Sniff detects the content type of data by its first bytes and checks it against the allowed list.
+// The type declared by the client is ignored on purpose.
+func Sniff(data []byte) (string, error) {
+	contentType := http.DetectContentType(data)
+	if i := strings.IndexByte(contentType, ';'); i >= 0 {
+		contentType = contentType[:i]
+	}
+	if _, ok := allowed[contentType]; !ok {
+		return "", ErrUnsupportedType
+	}
+	return contentType, nil
+}
+
+// HasThumbnail reports whether Thumbnail supports the content type.
+func HasThumbnail(contentType string) bool {
+	return allowed[contentType]
+}
+
+// Thumbnail decodes an image and returns a JPEG scaled down so that its longest side is at most maxSide.
+// Images that are already small enough keep their size. The dimensions are checked against MaxPixels
+// before the image is decoded.
+func Thumbnail(data []byte, maxSide int) ([]byte, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return nil, ErrTooManyPixels
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, scale(src, maxSide), &jpeg.Options{Quality: 80}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// scale downsizes src by averaging the source pixels covered by each destination pixel.
+// Transparent pixels are blended onto white since JPEG has no alpha channel.
+func scale(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		maxSide = max(w, h)
+	}
+
+	dw, dh := maxSide, maxSide
+	if w > h {
+		dh = max(1, h*maxSide/w)
+	} else {
+		dw = max(1, w*maxSide/h)
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for y := 0; y < dh; y++ {
+		y0, y1 := b.Min.Y+y*h/dh, b.Min.Y+max((y+1)*h/dh, y*h/dh+1)
+		for x := 0; x < dw; x++ {
+			x0, x1 := b.Min.X+x*w/dw, b.Min.X+max((x+1)*w/dw, x*w/dw+1)
+
+			var r, g, bl, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(sx, sy).RGBA()
+					white := uint64(0xffff - ca)
+					r, g, bl = r+uint64(cr)+white, g+uint64(cg)+white, bl+uint64(cb)+white
+					n++
+				}
+			}
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(bl / n >> 8),
+				A: 0xff,
+			})
+		}
+	}
+	return dst
+}
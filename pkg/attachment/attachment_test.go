@@ -0,0 +1,43 @@
+package attachment
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestThumbnail(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 40, 10))
+	for i := range src.Pix {
+		src.Pix[i] = 0xff
+	}
+	src.Set(0, 0, color.Black)
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, src); err != nil {
+		t.Fatal(err)
+	}
+
+	thumb, err := Thumbnail(buf.Bytes(), 20)
+	if err != nil {
+		t.Fatalf("Thumbnail: %v", err)
+	}
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(thumb))
+	if err != nil {
+		t.Fatalf("decode thumbnail: %v", err)
+	}
+	if format != "jpeg" || cfg.Width != 20 || cfg.Height != 5 {
+		t.Errorf("thumbnail = %s %dx%d, want jpeg 20x5", format, cfg.Width, cfg.Height)
+	}
+}
+
+func TestThumbnailTooManyPixels(t *testing.T) {
+	// GIF header with a 65535x65535 logical screen and no image data.
+	data := []byte("GIF89a\xff\xff\xff\xff\x00\x00\x00")
+
+	if _, err := Thumbnail(data, 256); !errors.Is(err, ErrTooManyPixels) {
+		t.Errorf("Thumbnail = %v, want ErrTooManyPixels", err)
+	}
+}
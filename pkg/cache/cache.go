@@ -34,3 +34,33 @@ func New(c RedisCfg) (*redis.Client, error) {
 	// Если пинг успешен, возвращаем клиент
 	return rdb, nil
 }
+
+// Channel - канал Redis pub/sub для рассылки сообщений всем экземплярам сервиса.
+type Channel struct {
+	rdb  *redis.Client
+	name string
+}
+
+// NewChannel создаёт канал name поверх клиента rdb
+func NewChannel(rdb *redis.Client, name string) *Channel {
+	return &Channel{rdb: rdb, name: name}
+}
+
+// Publish отправляет сообщение в канал
+func (c *Channel) Publish(message string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	return c.rdb.Publish(ctx, c.name, message).Err()
+}
+
+// Subscribe вызывает handle для каждого сообщения канала. Клиент сам переподключается при обрыве связи,
+// поэтому функция возвращается только при закрытии клиента.
+func (c *Channel) Subscribe(handle func(message string)) {
+	sub := c.rdb.Subscribe(context.Background(), c.name)
+	defer sub.Close()
+
+	for msg := range sub.Channel() {
+		handle(msg.Payload)
+	}
+}
@@ -37,6 +37,8 @@ type Metadata struct {
 	CurrentPage  int `json:"current_page,omitempty"`
 	PageSize     int `json:"page_size,omitempty"`
 	TotalRecords int `json:"total_records,omitempty"`
+	// NextCursor is used for keyset pagination: pass it to get the next page. Empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 type IdResponse struct {
@@ -0,0 +1,116 @@
+// Package rates parses the daily exchange rate feeds of the Bank of Russia (XML_daily.asp)
+// and the European Central Bank (eurofxref).
+package rates
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Rate is the price of Nominal units of the currency in the base currency of the feed.
+type Rate struct {
+	ID      string
+	NumCode string
+	Code    string
+	Name    string
+	Nominal int
+	Value   float64
+}
+
+// Daily is a set of rates published for Date (midnight UTC).
+type Daily struct {
+	Date  time.Time
+	Rates []Rate
+}
+
+type cbrDaily struct {
+	Date   string `xml:"Date,attr"`
+	Valute []struct {
+		ID       string `xml:"ID,attr"`
+		NumCode  string `xml:"NumCode"`
+		CharCode string `xml:"CharCode"`
+		Nominal  int    `xml:"Nominal"`
+		Name     string `xml:"Name"`
+		Value    string `xml:"Value"`
+	} `xml:"Valute"`
+}
+
+// ParseCBR parses the Bank of Russia daily feed: windows-1251 XML with rates in rubles
+// and a decimal comma.
+func ParseCBR(data []byte) (*Daily, error) {
+	var feed cbrDaily
+	decoder := xml.NewDecoder(bytes.NewReader(data))
+	decoder.CharsetReader = func(label string, input io.Reader) (io.Reader, error) {
+		if strings.EqualFold(label, "windows-1251") {
+			return charmap.Windows1251.NewDecoder().Reader(input), nil
+		}
+		return nil, fmt.Errorf("unsupported charset %q", label)
+	}
+	if err := decoder.Decode(&feed); err != nil {
+		return nil, fmt.Errorf("invalid XML: %w", err)
+	}
+
+	date, err := time.Parse("02.01.2006", feed.Date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: %w", feed.Date, err)
+	}
+
+	daily := &Daily{Date: date, Rates: make([]Rate, 0, len(feed.Valute))}
+	for _, v := range feed.Valute {
+		value, err := strconv.ParseFloat(strings.Replace(v.Value, ",", ".", 1), 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid rate %q of %s: %w", v.Value, v.CharCode, err)
+		}
+		daily.Rates = append(daily.Rates, Rate{
+			ID:      v.ID,
+			NumCode: v.NumCode,
+			Code:    v.CharCode,
+			Name:    v.Name,
+			Nominal: v.Nominal,
+			Value:   value,
+		})
+	}
+
+	return daily, nil
+}
+
+type ecbEnvelope struct {
+	Cube struct {
+		Cube struct {
+			Time  string `xml:"time,attr"`
+			Rates []struct {
+				Currency string  `xml:"currency,attr"`
+				Rate     float64 `xml:"rate,attr"`
+			} `xml:"Cube"`
+		} `xml:"Cube"`
+	} `xml:"Cube"`
+}
+
+// ParseECB parses the ECB euro reference rates. The value of a rate is the number of currency units
+// per euro, so unlike the Bank of Russia feed it is the inverse of a price.
+func ParseECB(data []byte) (*Daily, error) {
+	var envelope ecbEnvelope
+	if err := xml.Unmarshal(data, &envelope); err != nil {
+		return nil, fmt.Errorf("invalid XML: %w", err)
+	}
+
+	cube := envelope.Cube.Cube
+	date, err := time.Parse("2006-01-02", cube.Time)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date %q: %w", cube.Time, err)
+	}
+
+	daily := &Daily{Date: date, Rates: make([]Rate, 0, len(cube.Rates))}
+	for _, r := range cube.Rates {
+		daily.Rates = append(daily.Rates, Rate{Code: r.Currency, Nominal: 1, Value: r.Rate})
+	}
+
+	return daily, nil
+}
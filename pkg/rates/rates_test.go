@@ -0,0 +1,101 @@
+package rates
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+func TestParseCBR(t *testing.T) {
+	data, err := charmap.Windows1251.NewEncoder().String(`<?xml version="1.0" encoding="windows-1251"?>
+<ValCurs Date="01.03.2024" name="Foreign Currency Market">
+	<Valute ID="R01235"><NumCode>840</NumCode><CharCode>USD</CharCode><Nominal>1</Nominal><Name>Доллар США</Name><Value>91,3336</Value></Valute>
+	<Valute ID="R01375"><NumCode>156</NumCode><CharCode>CNY</CharCode><Nominal>10</Nominal><Name>Китайский юань</Name><Value>126,5434</Value></Valute>
+</ValCurs>`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	daily, err := ParseCBR([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseCBR: %v", err)
+	}
+
+	want := &Daily{
+		Date: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		Rates: []Rate{
+			{ID: "R01235", NumCode: "840", Code: "USD", Name: "Доллар США", Nominal: 1, Value: 91.3336},
+			{ID: "R01375", NumCode: "156", Code: "CNY", Name: "Китайский юань", Nominal: 10, Value: 126.5434},
+		},
+	}
+	if !reflect.DeepEqual(daily, want) {
+		t.Errorf("ParseCBR =\n%+v\nwant\n%+v", daily, want)
+	}
+}
+
+func TestParseCBRErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "invalid rate", data: `<ValCurs Date="01.03.2024"><Valute><CharCode>USD</CharCode><Value>n/a</Value></Valute></ValCurs>`},
+		{name: "invalid date", data: `<ValCurs Date="2024-03-01"></ValCurs>`},
+		{name: "unknown charset", data: `<?xml version="1.0" encoding="koi8-r"?><ValCurs Date="01.03.2024"></ValCurs>`},
+		{name: "not XML", data: `{"Date": "2024-03-01"}`},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseCBR([]byte(tt.data)); err == nil {
+			t.Errorf("%s: ParseCBR succeeded", tt.name)
+		}
+	}
+}
+
+func TestParseECB(t *testing.T) {
+	data := `<?xml version="1.0" encoding="UTF-8"?>
+<gesmes:Envelope xmlns:gesmes="http://www.gesmes.org/xml/2002-08-01" xmlns="http://www.ecb.int/vocabulary/2002-08-01/eurofxref">
+	<gesmes:subject>Reference rates</gesmes:subject>
+	<gesmes:Sender><gesmes:name>European Central Bank</gesmes:name></gesmes:Sender>
+	<Cube>
+		<Cube time="2024-03-01">
+			<Cube currency="USD" rate="1.0830"/>
+			<Cube currency="JPY" rate="162.50"/>
+		</Cube>
+	</Cube>
+</gesmes:Envelope>`
+
+	daily, err := ParseECB([]byte(data))
+	if err != nil {
+		t.Fatalf("ParseECB: %v", err)
+	}
+
+	want := &Daily{
+		Date: time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC),
+		Rates: []Rate{
+			{Code: "USD", Nominal: 1, Value: 1.083},
+			{Code: "JPY", Nominal: 1, Value: 162.5},
+		},
+	}
+	if !reflect.DeepEqual(daily, want) {
+		t.Errorf("ParseECB =\n%+v\nwant\n%+v", daily, want)
+	}
+}
+
+func TestParseECBErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "no date", data: `<Envelope><Cube><Cube><Cube currency="USD" rate="1.08"/></Cube></Cube></Envelope>`},
+		{name: "invalid rate", data: `<Envelope><Cube><Cube time="2024-03-01"><Cube currency="USD" rate="n/a"/></Cube></Cube></Envelope>`},
+		{name: "not XML", data: `USD 1.08`},
+	}
+
+	for _, tt := range tests {
+		if _, err := ParseECB([]byte(tt.data)); err == nil {
+			t.Errorf("%s: ParseECB succeeded", tt.name)
+		}
+	}
+}
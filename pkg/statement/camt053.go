@@ -0,0 +1,272 @@
+package statement
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+type camtAmount struct {
+	Value    string `xml:",chardata"`
+	Currency string `xml:"Ccy,attr"`
+}
+
+type camtDate struct {
+	Date     string `xml:"Dt"`
+	DateTime string `xml:"DtTm"`
+}
+
+type camtAccount struct {
+	IBAN     string `xml:"Id>IBAN"`
+	Other    string `xml:"Id>Othr>Id"`
+	Currency string `xml:"Ccy"`
+}
+
+type camtBalance struct {
+	Code      string     `xml:"Tp>CdOrPrtry>Cd"`
+	Amount    camtAmount `xml:"Amt"`
+	Indicator string     `xml:"CdtDbtInd"`
+	Date      camtDate   `xml:"Dt"`
+}
+
+type camtParty struct {
+	Name      string `xml:"Nm"`
+	PartyName string `xml:"Pty>Nm"`
+}
+
+func (p camtParty) name() string {
+	if p.Name != "" {
+		return p.Name
+	}
+	return p.PartyName
+}
+
+type camtTransaction struct {
+	AccountServicerRef string     `xml:"Refs>AcctSvcrRef"`
+	EndToEndID         string     `xml:"Refs>EndToEndId"`
+	Amount             camtAmount `xml:"Amt"`
+	TxAmount           camtAmount `xml:"AmtDtls>TxAmt>Amt"`
+	Indicator          string     `xml:"CdtDbtInd"`
+	Debtor             camtParty  `xml:"RltdPties>Dbtr"`
+	Creditor           camtParty  `xml:"RltdPties>Cdtr"`
+	Unstructured       []string   `xml:"RmtInf>Ustrd"`
+}
+
+func (t camtTransaction) amount() camtAmount {
+	if t.Amount.Value != "" {
+		return t.Amount
+	}
+	return t.TxAmount
+}
+
+type camtEntry struct {
+	Ref                string            `xml:"NtryRef"`
+	Amount             camtAmount        `xml:"Amt"`
+	Indicator          string            `xml:"CdtDbtInd"`
+	Status             camtStatus        `xml:"Sts"`
+	BookingDate        camtDate          `xml:"BookgDt"`
+	ValueDate          camtDate          `xml:"ValDt"`
+	AccountServicerRef string            `xml:"AcctSvcrRef"`
+	Details            []camtTransaction `xml:"NtryDtls>TxDtls"`
+}
+
+// camtStatus is a plain code up to camt.053.001.07 and a Cd element since camt.053.001.08.
+type camtStatus struct {
+	Value string `xml:",chardata"`
+	Code  string `xml:"Cd"`
+}
+
+func (s camtStatus) code() string {
+	if c := strings.TrimSpace(s.Code); c != "" {
+		return c
+	}
+	return strings.TrimSpace(s.Value)
+}
+
+// ParseCAMT053 parses ISO 20022 camt.053 (Bank to Customer Statement) files of any version.
+// Only booked entries are returned. Entries with several transactions of their own amounts (batch bookings)
+// are split into those transactions.
+func ParseCAMT053(r io.Reader) (*Statement, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	d := xml.NewDecoder(strings.NewReader(text))
+	// The file is already decoded to UTF-8, so the declared encoding is ignored.
+	d.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+
+	st := &Statement{}
+	found := false
+	for {
+		tok, err := d.Token()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("camt.053: %v", err)
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		line, _ := d.InputPos()
+
+		switch start.Name.Local {
+		case "Stmt":
+			found = true
+		case "Acct":
+			var acct camtAccount
+			if err := d.DecodeElement(&acct, &start); err != nil {
+				return nil, fmt.Errorf("camt.053: line %d: %v", line, err)
+			}
+			if st.Account == "" {
+				st.Account = firstNonEmpty(acct.IBAN, acct.Other)
+				st.Currency = strings.ToUpper(acct.Currency)
+			}
+		case "Bal":
+			var bal camtBalance
+			if err := d.DecodeElement(&bal, &start); err != nil {
+				return nil, fmt.Errorf("camt.053: line %d: %v", line, err)
+			}
+			if balance := bal.closing(); balance != nil {
+				st.ClosingBalance = balance
+			}
+		case "Ntry":
+			var entry camtEntry
+			if err := d.DecodeElement(&entry, &start); err != nil {
+				return nil, fmt.Errorf("camt.053: line %d: %v", line, err)
+			}
+			if status := entry.Status.code(); status != "" && status != "BOOK" {
+				continue
+			}
+			st.Transactions = append(st.Transactions, entry.transactions(line)...)
+		}
+	}
+
+	if !found {
+		return nil, errors.New("camt.053: no statement found")
+	}
+
+	st.fillMissingIDs(FormatCAMT053)
+	return st, nil
+}
+
+// closing returns the closing booked balance (CLBD), nil for the other balance types.
+func (b camtBalance) closing() *Balance {
+	if b.Code != "CLBD" {
+		return nil
+	}
+	amount, err := parseAmount(b.Amount.Value)
+	if err != nil {
+		return nil
+	}
+	if b.Indicator == "DBIT" {
+		amount = -amount
+	}
+	date, _ := parseCAMTDate(b.Date)
+	return &Balance{Amount: amount, Currency: strings.ToUpper(b.Amount.Currency), Date: date}
+}
+
+func (e camtEntry) transactions(line int) []Transaction {
+	base := Transaction{Line: line, ID: e.AccountServicerRef, Reference: e.Ref}
+
+	var err error
+	if base.Date, err = parseCAMTDate(e.BookingDate); err != nil {
+		base.Error = err.Error()
+	}
+	base.ValueDate, _ = parseCAMTDate(e.ValueDate)
+	if base.Date.IsZero() && base.Error == "" {
+		base.Date = base.ValueDate
+	}
+
+	split := len(e.Details) > 1
+	for _, tx := range e.Details {
+		split = split && tx.amount().Value != ""
+	}
+	if !split {
+		t := base
+		var tx camtTransaction
+		if len(e.Details) == 1 {
+			tx = e.Details[0]
+		}
+		t.fillCAMT(e.Amount, e.Indicator, tx)
+		return []Transaction{t}
+	}
+
+	transactions := make([]Transaction, 0, len(e.Details))
+	for i, tx := range e.Details {
+		t := base
+		if t.ID != "" {
+			t.ID = fmt.Sprintf("%s/%d", t.ID, i+1)
+		}
+		indicator := tx.Indicator
+		if indicator == "" {
+			indicator = e.Indicator
+		}
+		t.fillCAMT(tx.amount(), indicator, tx)
+		transactions = append(transactions, t)
+	}
+	return transactions
+}
+
+func (t *Transaction) fillCAMT(amount camtAmount, indicator string, tx camtTransaction) {
+	if tx.AccountServicerRef != "" {
+		t.ID = tx.AccountServicerRef
+	}
+	if ref := tx.EndToEndID; ref != "" && ref != "NOTPROVIDED" {
+		t.Reference = ref
+	}
+	t.Memo = strings.Join(tx.Unstructured, " ")
+	t.Currency = strings.ToUpper(amount.Currency)
+
+	value, err := parseAmount(amount.Value)
+	if err != nil && t.Error == "" {
+		t.Error = err.Error()
+	}
+
+	switch indicator {
+	case "DBIT":
+		t.Amount = -value
+		t.Payee = tx.Creditor.name()
+	case "CRDT":
+		t.Amount = value
+		t.Payee = tx.Debtor.name()
+	default:
+		if t.Error == "" {
+			t.Error = fmt.Sprintf("invalid credit/debit indicator %q", indicator)
+		}
+	}
+}
+
+func parseCAMTDate(d camtDate) (time.Time, error) {
+	value := firstNonEmpty(d.Date, d.DateTime)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if len(value) > 10 {
+		value = value[:10]
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return date, nil
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			return v
+		}
+	}
+	return ""
+}
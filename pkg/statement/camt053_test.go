@@ -0,0 +1,106 @@
+package statement
+
+import (
+	"strings"
+	"testing"
+)
+
+const camt053Data = `<?xml version="1.0" encoding="UTF-8"?>
+<Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.08">
+  <BkToCstmrStmt>
+    <Stmt>
+      <Acct><Id><IBAN>DE89370400440532013000</IBAN></Id><Ccy>eur</Ccy></Acct>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>OPBD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="EUR">100.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2024-01-01</Dt></Dt>
+      </Bal>
+      <Bal>
+        <Tp><CdOrPrtry><Cd>CLBD</Cd></CdOrPrtry></Tp>
+        <Amt Ccy="EUR">80.00</Amt><CdtDbtInd>CRDT</CdtDbtInd><Dt><Dt>2024-01-31</Dt></Dt>
+      </Bal>
+      <Ntry>
+        <NtryRef>E1</NtryRef>
+        <Amt Ccy="EUR">50.00</Amt>
+        <CdtDbtInd>DBIT</CdtDbtInd>
+        <Sts><Cd>BOOK</Cd></Sts>
+        <BookgDt><Dt>2024-01-05</Dt></BookgDt>
+        <ValDt><Dt>2024-01-06</Dt></ValDt>
+        <AcctSvcrRef>SVC1</AcctSvcrRef>
+        <NtryDtls><TxDtls>
+          <Refs><EndToEndId>E2E1</EndToEndId></Refs>
+          <RltdPties><Cdtr><Nm>Shop</Nm></Cdtr></RltdPties>
+          <RmtInf><Ustrd>Order</Ustrd><Ustrd>1</Ustrd></RmtInf>
+        </TxDtls></NtryDtls>
+      </Ntry>
+      <Ntry>
+        <Amt Ccy="EUR">999.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts><Cd>PDNG</Cd></Sts>
+        <BookgDt><Dt>2024-01-07</Dt></BookgDt>
+      </Ntry>
+      <Ntry>
+        <NtryRef>E3</NtryRef>
+        <Amt Ccy="EUR">30.00</Amt>
+        <CdtDbtInd>CRDT</CdtDbtInd>
+        <Sts>BOOK</Sts>
+        <BookgDt><DtTm>2024-01-10T10:00:00</DtTm></BookgDt>
+        <AcctSvcrRef>B1</AcctSvcrRef>
+        <NtryDtls>
+          <TxDtls>
+            <Amt Ccy="EUR">10.00</Amt>
+            <RltdPties><Dbtr><Pty><Nm>Alice</Nm></Pty></Dbtr></RltdPties>
+          </TxDtls>
+          <TxDtls>
+            <AmtDtls><TxAmt><Amt Ccy="EUR">20.00</Amt></TxAmt></AmtDtls>
+            <RltdPties><Dbtr><Nm>Bob</Nm></Dbtr></RltdPties>
+          </TxDtls>
+        </NtryDtls>
+      </Ntry>
+    </Stmt>
+  </BkToCstmrStmt>
+</Document>`
+
+func TestParseCAMT053(t *testing.T) {
+	st, err := ParseCAMT053(strings.NewReader(camt053Data))
+	if err != nil {
+		t.Fatalf("ParseCAMT053: %v", err)
+	}
+
+	if st.Account != "DE89370400440532013000" || st.Currency != "EUR" {
+		t.Errorf("account = %q %q, want the IBAN and EUR", st.Account, st.Currency)
+	}
+	if st.ClosingBalance == nil || st.ClosingBalance.Amount != 80 || st.ClosingBalance.Date.Format("2006-01-02") != "2024-01-31" {
+		t.Errorf("closing balance = %+v, want 80 on 2024-01-31", st.ClosingBalance)
+	}
+
+	want := []struct {
+		id, reference, date, payee, memo string
+		amount                           float64
+	}{
+		{id: "SVC1", reference: "E2E1", date: "2024-01-05", payee: "Shop", memo: "Order 1", amount: -50},
+		{id: "B1/1", reference: "E3", date: "2024-01-10", payee: "Alice", amount: 10},
+		{id: "B1/2", reference: "E3", date: "2024-01-10", payee: "Bob", amount: 20},
+	}
+	if len(st.Transactions) != len(want) {
+		t.Fatalf("got %d transactions, want %d: %+v", len(st.Transactions), len(want), st.Transactions)
+	}
+	for i, w := range want {
+		got := st.Transactions[i]
+		if got.Error != "" || got.Currency != "EUR" {
+			t.Errorf("transaction %d: error %q, currency %q", i, got.Error, got.Currency)
+		}
+		if got.ID != w.id || got.Reference != w.reference || got.Date.Format("2006-01-02") != w.date ||
+			got.Payee != w.payee || got.Memo != w.memo || got.Amount != w.amount {
+			t.Errorf("transaction %d = %+v, want %+v", i, got, w)
+		}
+	}
+	if got := st.Transactions[0].ValueDate.Format("2006-01-02"); got != "2024-01-06" {
+		t.Errorf("value date = %s, want 2024-01-06", got)
+	}
+}
+
+func TestParseCAMT053WithoutStatement(t *testing.T) {
+	if _, err := ParseCAMT053(strings.NewReader(`<Document><BkToCstmrStmt/></Document>`)); err == nil {
+		t.Error("ParseCAMT053 without Stmt succeeded")
+	}
+}
@@ -0,0 +1,248 @@
+package statement
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// mt940Tag matches the start of a field, e.g. :61: or :60F:.
+var mt940Tag = regexp.MustCompile(`^:(\d{2}[A-Z]?):`)
+
+// mt940Line is the statement line (field 61): value date, optional entry date, debit/credit mark,
+// optional funds code, amount, transaction type, customer reference, optional bank reference
+// and optional supplementary details on the next line.
+var mt940Line = regexp.MustCompile(`^(\d{6})(\d{4})?(RD|RC|D|C)([A-Z])?([\d,]+)([NFS][A-Z0-9]{3})([^\n]*?)(?://([^\n]*))?(?:\n([\s\S]*))?$`)
+
+// mt940Balance is the balance field (60F, 60M, 62F, 62M, 64): debit/credit mark, date, currency and amount.
+var mt940Balance = regexp.MustCompile(`^([DC])(\d{6})([A-Z]{3})([\d,]+)`)
+
+type mt940Field struct {
+	tag   string
+	value string
+	line  int
+}
+
+// ParseMT940 parses SWIFT MT940 customer statements. Files may contain several statements,
+// with or without the SWIFT envelope ({1:...}{4: ... -}).
+func ParseMT940(r io.Reader) (*Statement, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := mt940Fields(text)
+	if err != nil {
+		return nil, err
+	}
+
+	st := &Statement{}
+	var (
+		trn      *Transaction
+		currency string
+		closing  *Balance
+	)
+	finish := func() {
+		if trn != nil {
+			st.Transactions = append(st.Transactions, *trn)
+			trn = nil
+		}
+	}
+
+	for _, f := range fields {
+		switch f.tag {
+		case "25":
+			finish()
+			if st.Account == "" {
+				st.Account = mt940Account(f.value)
+			}
+		case "60F", "60M":
+			finish()
+			if b, err := parseMT940Balance(f.value); err == nil {
+				currency = b.Currency
+				if st.Currency == "" {
+					st.Currency = b.Currency
+				}
+			}
+		case "61":
+			finish()
+			trn = parseMT940Line(f.value, f.line)
+			trn.Currency = currency
+		case "86":
+			if trn != nil {
+				trn.Payee, trn.Memo = parseMT940Details(f.value)
+			}
+			finish()
+		case "62F", "62M":
+			finish()
+			b, err := parseMT940Balance(f.value)
+			if err != nil {
+				return nil, fmt.Errorf("mt940: line %d: %v", f.line, err)
+			}
+			// The final balance (62F) wins over the intermediate ones (62M).
+			if f.tag == "62F" || closing == nil || closing.Date.Before(b.Date) {
+				closing = b
+			}
+		}
+	}
+	finish()
+
+	if st.Account == "" && len(st.Transactions) == 0 {
+		return nil, errors.New("mt940: no statement found")
+	}
+	st.ClosingBalance = closing
+
+	st.fillMissingIDs(FormatMT940)
+	return st, nil
+}
+
+// mt940Fields splits the text into fields. Lines without a tag continue the previous field.
+func mt940Fields(text string) ([]mt940Field, error) {
+	var fields []mt940Field
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for line := 1; scanner.Scan(); line++ {
+		s := strings.TrimRight(scanner.Text(), " \t\r")
+
+		// SWIFT envelope: {1:...}{2:...}{4: opens the text block, -} closes it.
+		if i := strings.Index(s, "{4:"); i >= 0 {
+			s = s[i+3:]
+		}
+		if s == "-" || s == "-}" || strings.HasPrefix(s, "-}{") || strings.HasPrefix(s, "{") || s == "" {
+			continue
+		}
+
+		if m := mt940Tag.FindStringSubmatch(s); m != nil {
+			fields = append(fields, mt940Field{tag: m[1], value: s[len(m[0]):], line: line})
+			continue
+		}
+		if len(fields) == 0 {
+			continue
+		}
+		fields[len(fields)-1].value += "\n" + s
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(fields) == 0 {
+		return nil, errors.New("mt940: no fields found")
+	}
+	return fields, nil
+}
+
+// mt940Account returns the account number from field 25, which may be prefixed with the bank code: BIC/ACCOUNT.
+func mt940Account(value string) string {
+	value = strings.TrimSpace(value)
+	if i := strings.LastIndexByte(value, '/'); i >= 0 && i < len(value)-1 {
+		return value[i+1:]
+	}
+	return value
+}
+
+func parseMT940Line(value string, line int) *Transaction {
+	t := &Transaction{Line: line}
+
+	m := mt940Line.FindStringSubmatch(value)
+	if m == nil {
+		t.Error = fmt.Sprintf("invalid statement line %q", value)
+		return t
+	}
+
+	valueDate, err := time.Parse("060102", m[1])
+	if err != nil {
+		t.Error = fmt.Sprintf("invalid value date %q", m[1])
+		return t
+	}
+	t.ValueDate = valueDate
+	t.Date = valueDate
+	if m[2] != "" {
+		if entry, err := time.Parse("0102", m[2]); err == nil {
+			t.Date = entryDate(valueDate, entry)
+		}
+	}
+
+	amount, err := parseAmount(m[5])
+	if err != nil {
+		t.Error = err.Error()
+		return t
+	}
+	// RD is a reversal of a debit, so it is a credit, and RC is a reversal of a credit.
+	switch m[3] {
+	case "D", "RC":
+		t.Amount = -amount
+	case "C", "RD":
+		t.Amount = amount
+	}
+
+	if ref := strings.TrimSpace(m[7]); ref != "" && ref != "NONREF" {
+		t.Reference = ref
+	}
+	if ref := strings.TrimSpace(m[8]); ref != "" && ref != "NONREF" {
+		t.ID = ref
+	}
+	return t
+}
+
+// entryDate puts the entry date (MMDD) into the year of the value date, moving it across the new year if needed.
+func entryDate(valueDate, entry time.Time) time.Time {
+	date := time.Date(valueDate.Year(), entry.Month(), entry.Day(), 0, 0, 0, 0, time.UTC)
+	switch {
+	case date.Sub(valueDate) > 180*24*time.Hour:
+		date = date.AddDate(-1, 0, 0)
+	case valueDate.Sub(date) > 180*24*time.Hour:
+		date = date.AddDate(1, 0, 0)
+	}
+	return date
+}
+
+func parseMT940Balance(value string) (*Balance, error) {
+	m := mt940Balance.FindStringSubmatch(strings.TrimSpace(value))
+	if m == nil {
+		return nil, fmt.Errorf("invalid balance %q", value)
+	}
+	date, err := time.Parse("060102", m[2])
+	if err != nil {
+		return nil, fmt.Errorf("invalid balance date %q", m[2])
+	}
+	amount, err := parseAmount(m[4])
+	if err != nil {
+		return nil, err
+	}
+	if m[1] == "D" {
+		amount = -amount
+	}
+	return &Balance{Amount: amount, Currency: m[3], Date: date}, nil
+}
+
+// parseMT940Details returns the counterparty name and the purpose from field 86. Structured details
+// (e.g. 166?00...?20...?32...) keep the purpose in subfields 20-29 and the name in 32-33,
+// unstructured details are returned as the purpose.
+func parseMT940Details(value string) (payee, memo string) {
+	if len(value) < 4 || value[3] != '?' {
+		return "", strings.Join(strings.Fields(value), " ")
+	}
+	// Structured subfields are wrapped at the line length, not at word boundaries.
+	value = strings.ReplaceAll(value, "\n", "")
+
+	var purpose, name []string
+	for _, sub := range strings.Split(value[4:], "?") {
+		if len(sub) < 2 {
+			continue
+		}
+		code, text := sub[:2], sub[2:]
+		switch {
+		case code >= "20" && code <= "29", code >= "60" && code <= "63":
+			purpose = append(purpose, text)
+		case code == "32" || code == "33":
+			name = append(name, text)
+		}
+	}
+	return strings.TrimSpace(strings.Join(name, "")), strings.TrimSpace(strings.Join(purpose, ""))
+}
@@ -0,0 +1,107 @@
+package statement
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+const mt940Data = `{1:F01BANKBEBBAXXX0000000000}{2:I940BANKBEBBXXXXN}{4:
+:20:STMT1
+:25:BANKBEBB/123456789
+:28C:1/1
+:60F:C240101EUR1000,00
+:61:2401020102D150,25NTRFREF1//BREF1
+:86:166?00SEPA?20Payment for?21 invoice 42?32ACME GmbH
+:61:2401030104CR200,NMSCNONREF
+:86:Salary
+ January
+:62M:C240103EUR849,75
+:62F:C240104EUR1049,75
+-}`
+
+func TestParseMT940(t *testing.T) {
+	st, err := ParseMT940(strings.NewReader(mt940Data))
+	if err != nil {
+		t.Fatalf("ParseMT940: %v", err)
+	}
+
+	if st.Account != "123456789" || st.Currency != "EUR" {
+		t.Errorf("account = %q %q, want 123456789 EUR", st.Account, st.Currency)
+	}
+	// The final balance wins over the intermediate one.
+	if st.ClosingBalance == nil || st.ClosingBalance.Amount != 1049.75 || st.ClosingBalance.Date.Format("2006-01-02") != "2024-01-04" {
+		t.Errorf("closing balance = %+v, want 1049.75 on 2024-01-04", st.ClosingBalance)
+	}
+	if len(st.Transactions) != 2 {
+		t.Fatalf("got %d transactions, want 2", len(st.Transactions))
+	}
+
+	debit := st.Transactions[0]
+	if debit.Error != "" || debit.ID != "BREF1" || debit.Reference != "REF1" || debit.Amount != -150.25 ||
+		debit.Currency != "EUR" || debit.Date.Format("2006-01-02") != "2024-01-02" {
+		t.Errorf("debit = %+v", debit)
+	}
+	if debit.Payee != "ACME GmbH" || debit.Memo != "Payment for invoice 42" {
+		t.Errorf("debit details = %q %q, want ACME GmbH and the purpose", debit.Payee, debit.Memo)
+	}
+
+	credit := st.Transactions[1]
+	if credit.Error != "" || credit.Amount != 200 || credit.Reference != "" || credit.Memo != "Salary January" {
+		t.Errorf("credit = %+v", credit)
+	}
+	if credit.ValueDate.Format("2006-01-02") != "2024-01-03" || credit.Date.Format("2006-01-02") != "2024-01-04" {
+		t.Errorf("credit dates = %s %s, want value 2024-01-03 and entry 2024-01-04", credit.ValueDate, credit.Date)
+	}
+	if !strings.HasPrefix(credit.ID, FormatMT940+":") {
+		t.Errorf("derived id = %q, want prefix %q", credit.ID, FormatMT940+":")
+	}
+}
+
+func TestParseMT940Line(t *testing.T) {
+	tests := []struct {
+		name   string
+		value  string
+		amount float64
+		bad    bool
+	}{
+		{name: "debit", value: "240102D10,NTRFNONREF", amount: -10},
+		{name: "credit", value: "240102C10,5NTRFNONREF", amount: 10.5},
+		{name: "reversal of debit", value: "240102RD10,NTRFNONREF", amount: 10},
+		{name: "reversal of credit", value: "240102RC10,NTRFNONREF", amount: -10},
+		{name: "invalid", value: "garbage", bad: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseMT940Line(tt.value, 1)
+			if tt.bad {
+				if got.Error == "" {
+					t.Errorf("parseMT940Line(%q) has no error", tt.value)
+				}
+				return
+			}
+			if got.Error != "" || got.Amount != tt.amount {
+				t.Errorf("parseMT940Line(%q) = %v %q, want %v", tt.value, got.Amount, got.Error, tt.amount)
+			}
+		})
+	}
+}
+
+func TestEntryDate(t *testing.T) {
+	tests := []struct {
+		value, entry, want string
+	}{
+		{value: "2024-03-10", entry: "0311", want: "2024-03-11"},
+		{value: "2023-12-31", entry: "0102", want: "2024-01-02"},
+		{value: "2024-01-02", entry: "1231", want: "2023-12-31"},
+	}
+
+	for _, tt := range tests {
+		value, _ := time.Parse("2006-01-02", tt.value)
+		entry, _ := time.Parse("0102", tt.entry)
+		if got := entryDate(value, entry).Format("2006-01-02"); got != tt.want {
+			t.Errorf("entryDate(%s, %s) = %s, want %s", tt.value, tt.entry, got, tt.want)
+		}
+	}
+}
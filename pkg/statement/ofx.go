@@ -0,0 +1,212 @@
+package statement
+
+import (
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+	"time"
+)
+
+// ParseOFX parses OFX 1.x (SGML) and 2.x (XML) statements. Bank and credit card statements are supported;
+// transactions of all the statements in the file are returned together.
+func ParseOFX(r io.Reader) (*Statement, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	start := strings.Index(strings.ToUpper(text), "<OFX>")
+	if start < 0 {
+		return nil, errors.New("ofx: <OFX> element not found")
+	}
+
+	p := ofxParser{st: &Statement{}, line: 1 + strings.Count(text[:start], "\n")}
+	if err := p.parse(text[start:]); err != nil {
+		return nil, err
+	}
+
+	p.st.fillMissingIDs(FormatOFX)
+	return p.st, nil
+}
+
+type ofxParser struct {
+	st    *Statement
+	line  int
+	stack []string
+	trn   *Transaction
+
+	trnCurrency string
+	balance     string
+	balanceDate string
+}
+
+// parse walks through the elements. In SGML leaf elements are not closed, so an element followed by
+// a value is a leaf and only elements followed by another tag are aggregates.
+func (p *ofxParser) parse(text string) error {
+	for len(text) > 0 {
+		open := strings.IndexByte(text, '<')
+		if open < 0 {
+			break
+		}
+		p.line += strings.Count(text[:open], "\n")
+		text = text[open:]
+
+		end := strings.IndexByte(text, '>')
+		if end < 0 {
+			return fmt.Errorf("ofx: line %d: unterminated tag", p.line)
+		}
+		tag := strings.ToUpper(strings.TrimSpace(text[1:end]))
+		text = text[end+1:]
+
+		switch {
+		case tag == "" || strings.HasPrefix(tag, "?") || strings.HasPrefix(tag, "!"):
+			continue
+		case strings.HasPrefix(tag, "/"):
+			p.close(tag[1:])
+			continue
+		}
+		if i := strings.IndexAny(tag, " \t\r\n"); i >= 0 {
+			tag = tag[:i]
+		}
+
+		next := strings.IndexByte(text, '<')
+		if next < 0 {
+			next = len(text)
+		}
+		value := strings.TrimSpace(text[:next])
+		if value == "" {
+			p.openAggregate(tag)
+			continue
+		}
+		p.leaf(tag, html.UnescapeString(value))
+	}
+
+	if p.trn != nil {
+		p.finishTransaction()
+	}
+	if len(p.st.Transactions) == 0 && p.st.Account == "" {
+		return errors.New("ofx: no statement found")
+	}
+	return nil
+}
+
+func (p *ofxParser) openAggregate(tag string) {
+	p.stack = append(p.stack, tag)
+	if tag == "STMTTRN" {
+		if p.trn != nil {
+			p.finishTransaction()
+		}
+		p.trn = &Transaction{Line: p.line}
+		p.trnCurrency = ""
+	}
+}
+
+// close pops the aggregates up to the closed one. Closing tags of leaves (XML) are not on the stack and are ignored.
+func (p *ofxParser) close(tag string) {
+	for i := len(p.stack) - 1; i >= 0; i-- {
+		if p.stack[i] != tag {
+			continue
+		}
+		for _, closed := range p.stack[i:] {
+			switch closed {
+			case "STMTTRN":
+				if p.trn != nil {
+					p.finishTransaction()
+				}
+			case "LEDGERBAL":
+				p.finishBalance()
+			}
+		}
+		p.stack = p.stack[:i]
+		return
+	}
+}
+
+func (p *ofxParser) parent() string {
+	if len(p.stack) == 0 {
+		return ""
+	}
+	return p.stack[len(p.stack)-1]
+}
+
+func (p *ofxParser) leaf(tag, value string) {
+	parent := p.parent()
+
+	if p.trn != nil {
+		switch {
+		case tag == "FITID":
+			p.trn.ID = value
+		case tag == "DTPOSTED":
+			date, err := parseOFXDate(value)
+			if err != nil {
+				p.trn.Error = err.Error()
+			}
+			p.trn.Date = date
+		case tag == "TRNAMT":
+			amount, err := parseAmount(value)
+			if err != nil {
+				p.trn.Error = err.Error()
+			}
+			p.trn.Amount = amount
+		case tag == "NAME" && (parent == "STMTTRN" || parent == "PAYEE"):
+			p.trn.Payee = value
+		case tag == "MEMO":
+			p.trn.Memo = value
+		case tag == "CURSYM" && (parent == "CURRENCY" || parent == "ORIGCURRENCY"):
+			p.trnCurrency = value
+		}
+		return
+	}
+
+	switch {
+	case tag == "CURDEF":
+		p.st.Currency = strings.ToUpper(value)
+	case tag == "ACCTID" && (parent == "BANKACCTFROM" || parent == "CCACCTFROM"):
+		p.st.Account = value
+	case tag == "BALAMT" && parent == "LEDGERBAL":
+		p.balance = value
+	case tag == "DTASOF" && parent == "LEDGERBAL":
+		p.balanceDate = value
+	}
+}
+
+func (p *ofxParser) finishTransaction() {
+	t := p.trn
+	p.trn = nil
+
+	t.Currency = strings.ToUpper(p.trnCurrency)
+	if t.Error == "" && t.Date.IsZero() {
+		t.Error = "transaction date is missing"
+	}
+	p.st.Transactions = append(p.st.Transactions, *t)
+}
+
+func (p *ofxParser) finishBalance() {
+	if p.balance == "" {
+		return
+	}
+	amount, err := parseAmount(p.balance)
+	if err != nil {
+		return
+	}
+	date, _ := parseOFXDate(p.balanceDate)
+	p.st.ClosingBalance = &Balance{Amount: amount, Date: date}
+}
+
+// parseOFXDate parses dates like 20240131, 20240131120000 or 20240131120000.000[+3:MSK]. Only the date part is used.
+func parseOFXDate(value string) (time.Time, error) {
+	if len(value) < 8 {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	date, err := time.Parse("20060102", value[:8])
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q", value)
+	}
+	return date, nil
+}
@@ -0,0 +1,139 @@
+package statement
+
+import (
+	"strings"
+	"testing"
+)
+
+const ofxSGML = `OFXHEADER:100
+DATA:OFXSGML
+VERSION:102
+ENCODING:UTF-8
+
+<OFX>
+<BANKMSGSRSV1>
+<STMTTRNRS>
+<STMTRS>
+<CURDEF>RUB
+<BANKACCTFROM>
+<BANKID>044525225
+<ACCTID>40817810000000000001
+<ACCTTYPE>CHECKING
+</BANKACCTFROM>
+<BANKTRANLIST>
+<DTSTART>20240101
+<DTEND>20240131
+<STMTTRN>
+<TRNTYPE>DEBIT
+<DTPOSTED>20240105120000.000[+3:MSK]
+<TRNAMT>-1500.50
+<FITID>A1
+<NAME>Пятёрочка
+<MEMO>Покупка
+</STMTTRN>
+<STMTTRN>
+<TRNTYPE>CREDIT
+<DTPOSTED>20240110
+<TRNAMT>50000.00
+<FITID>A2
+<NAME>ООО Ромашка &amp; Ко
+</STMTTRN>
+</BANKTRANLIST>
+<LEDGERBAL>
+<BALAMT>48499.50
+<DTASOF>20240131
+</LEDGERBAL>
+</STMTRS>
+</STMTTRNRS>
+</BANKMSGSRSV1>
+</OFX>
+`
+
+func TestParseOFXSGML(t *testing.T) {
+	st, err := ParseOFX(strings.NewReader(ofxSGML))
+	if err != nil {
+		t.Fatalf("ParseOFX: %v", err)
+	}
+
+	if st.Account != "40817810000000000001" || st.Currency != "RUB" {
+		t.Errorf("account = %q %q, want 40817810000000000001 RUB", st.Account, st.Currency)
+	}
+	if st.ClosingBalance == nil || st.ClosingBalance.Amount != 48499.50 || st.ClosingBalance.Date.Format("2006-01-02") != "2024-01-31" {
+		t.Errorf("closing balance = %+v, want 48499.50 on 2024-01-31", st.ClosingBalance)
+	}
+
+	want := []struct {
+		id, date, payee, memo string
+		amount                float64
+	}{
+		{id: "A1", date: "2024-01-05", payee: "Пятёрочка", memo: "Покупка", amount: -1500.50},
+		{id: "A2", date: "2024-01-10", payee: "ООО Ромашка & Ко", amount: 50000},
+	}
+	if len(st.Transactions) != len(want) {
+		t.Fatalf("got %d transactions, want %d", len(st.Transactions), len(want))
+	}
+	for i, w := range want {
+		got := st.Transactions[i]
+		if got.Error != "" {
+			t.Errorf("transaction %d: unexpected error %q", i, got.Error)
+		}
+		if got.ID != w.id || got.Date.Format("2006-01-02") != w.date || got.Payee != w.payee || got.Memo != w.memo || got.Amount != w.amount {
+			t.Errorf("transaction %d = %+v, want %+v", i, got, w)
+		}
+	}
+}
+
+func TestParseOFXXML(t *testing.T) {
+	const data = `<?xml version="1.0" encoding="UTF-8"?>
+<?OFX OFXHEADER="200" VERSION="220"?>
+<OFX>
+  <CREDITCARDMSGSRSV1>
+    <CCSTMTTRNRS>
+      <CCSTMTRS>
+        <CURDEF>USD</CURDEF>
+        <CCACCTFROM><ACCTID>4111</ACCTID></CCACCTFROM>
+        <BANKTRANLIST>
+          <STMTTRN>
+            <DTPOSTED>20240201</DTPOSTED>
+            <TRNAMT>-12.34</TRNAMT>
+            <NAME>Coffee</NAME>
+            <CURRENCY><CURRATE>1</CURRATE><CURSYM>eur</CURSYM></CURRENCY>
+          </STMTTRN>
+          <STMTTRN>
+            <TRNAMT>-1.00</TRNAMT>
+          </STMTTRN>
+        </BANKTRANLIST>
+      </CCSTMTRS>
+    </CCSTMTTRNRS>
+  </CREDITCARDMSGSRSV1>
+</OFX>`
+
+	st, err := ParseOFX(strings.NewReader(data))
+	if err != nil {
+		t.Fatalf("ParseOFX: %v", err)
+	}
+	if st.Account != "4111" || st.Currency != "USD" {
+		t.Errorf("account = %q %q, want 4111 USD", st.Account, st.Currency)
+	}
+	if len(st.Transactions) != 2 {
+		t.Fatalf("got %d transactions, want 2", len(st.Transactions))
+	}
+
+	coffee := st.Transactions[0]
+	if coffee.Amount != -12.34 || coffee.Payee != "Coffee" || coffee.Currency != "EUR" || coffee.Date.Format("2006-01-02") != "2024-02-01" {
+		t.Errorf("transaction = %+v", coffee)
+	}
+	// Without FITID the id is derived from the transaction contents.
+	if !strings.HasPrefix(coffee.ID, FormatOFX+":") {
+		t.Errorf("derived id = %q, want prefix %q", coffee.ID, FormatOFX+":")
+	}
+	if st.Transactions[1].Error == "" {
+		t.Error("transaction without a date has no error")
+	}
+}
+
+func TestParseOFXWithoutStatement(t *testing.T) {
+	if _, err := ParseOFX(strings.NewReader("OFXHEADER:100\n")); err == nil {
+		t.Error("ParseOFX without <OFX> succeeded")
+	}
+}
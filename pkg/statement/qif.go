@@ -0,0 +1,154 @@
+package statement
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// defaultQIFLayouts are tried in order when no date layout is given. QIF has no fixed date format:
+// Quicken writes M/D'YY for years after 1999, other programs write MM/DD/YYYY or DD.MM.YYYY.
+var defaultQIFLayouts = []string{
+	"1/2'06",
+	"01/02/2006",
+	"1/2/2006",
+	"01/02/06",
+	"1/2/06",
+	"02.01.2006",
+	"02.01.06",
+	"2006-01-02",
+}
+
+// qifTransactionTypes are the QIF sections with transactions of an account.
+var qifTransactionTypes = map[string]bool{
+	"BANK":  true,
+	"CASH":  true,
+	"CCARD": true,
+	"OTH A": true,
+	"OTH L": true,
+}
+
+// ParseQIF parses a QIF file. Only bank, cash, credit card and other asset/liability sections are read;
+// investment, category and class lists are skipped. QIF has no transaction ids, so they are derived
+// from the transaction contents.
+func ParseQIF(r io.Reader, dateLayouts []string) (*Statement, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	text, err := decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if len(dateLayouts) == 0 {
+		dateLayouts = defaultQIFLayouts
+	}
+
+	st := &Statement{}
+	var (
+		section string
+		trn     *Transaction
+		found   bool
+	)
+
+	finish := func() {
+		if trn == nil {
+			return
+		}
+		if trn.Error == "" && trn.Date.IsZero() {
+			trn.Error = "transaction date is missing"
+		}
+		st.Transactions = append(st.Transactions, *trn)
+		trn = nil
+	}
+
+	scanner := bufio.NewScanner(strings.NewReader(text))
+	for line := 1; scanner.Scan(); line++ {
+		s := strings.TrimRight(scanner.Text(), " \t\r")
+		if s == "" {
+			continue
+		}
+
+		if s[0] == '!' {
+			finish()
+			header := strings.ToUpper(s)
+			switch {
+			case strings.HasPrefix(header, "!TYPE:"):
+				section = strings.TrimSpace(header[len("!TYPE:"):])
+				found = found || qifTransactionTypes[section]
+			case strings.HasPrefix(header, "!ACCOUNT"):
+				section = "ACCOUNT"
+			}
+			continue
+		}
+
+		if section == "ACCOUNT" {
+			// In the account block N is the account name, not the check number.
+			if s[0] == 'N' {
+				st.Account = strings.TrimSpace(s[1:])
+			}
+			if s[0] == '^' {
+				section = ""
+			}
+			continue
+		}
+		if !qifTransactionTypes[section] {
+			continue
+		}
+
+		if s[0] == '^' {
+			finish()
+			continue
+		}
+		if trn == nil {
+			trn = &Transaction{Line: line}
+		}
+
+		value := strings.TrimSpace(s[1:])
+		switch s[0] {
+		case 'D':
+			date, err := parseQIFDate(value, dateLayouts)
+			if err != nil {
+				trn.Error = err.Error()
+			}
+			trn.Date = date
+		case 'T', 'U':
+			amount, err := parseAmount(value)
+			if err != nil {
+				trn.Error = err.Error()
+			}
+			trn.Amount = amount
+		case 'P':
+			trn.Payee = value
+		case 'M':
+			trn.Memo = value
+		case 'L':
+			trn.Category = value
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	finish()
+
+	if !found {
+		return nil, errors.New("qif: no bank, cash or card transactions found")
+	}
+
+	st.fillMissingIDs(FormatQIF)
+	return st, nil
+}
+
+func parseQIFDate(value string, layouts []string) (time.Time, error) {
+	// Quicken pads one-digit years after the apostrophe with a space: 1/5' 4.
+	value = strings.ReplaceAll(value, "' ", "'0")
+	for _, layout := range layouts {
+		if date, err := time.Parse(layout, value); err == nil {
+			return date, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date %q", value)
+}
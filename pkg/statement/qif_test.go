@@ -0,0 +1,79 @@
+package statement
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseQIF(t *testing.T) {
+	const data = `!Account
+NChecking
+TBank
+^
+!Type:Bank
+D1/5'24
+T-1,234.56
+PMagnit
+MFood
+LGroceries
+^
+D02/01/2024
+T100.00
+PSalary
+^
+D02/01/2024
+T100.00
+PSalary
+^
+Dyesterday
+T5
+^
+!Type:Cat
+NGroceries
+^
+`
+
+	st, err := ParseQIF(strings.NewReader(data), nil)
+	if err != nil {
+		t.Fatalf("ParseQIF: %v", err)
+	}
+	if st.Account != "Checking" {
+		t.Errorf("account = %q, want Checking", st.Account)
+	}
+	if len(st.Transactions) != 4 {
+		t.Fatalf("got %d transactions, want 4", len(st.Transactions))
+	}
+
+	first := st.Transactions[0]
+	if first.Date.Format("2006-01-02") != "2024-01-05" || first.Amount != -1234.56 ||
+		first.Payee != "Magnit" || first.Memo != "Food" || first.Category != "Groceries" {
+		t.Errorf("first transaction = %+v", first)
+	}
+	if got := st.Transactions[1].Date.Format("2006-01-02"); got != "2024-02-01" {
+		t.Errorf("MM/DD/YYYY date = %s, want 2024-02-01", got)
+	}
+
+	// Equal transactions within one statement must not be merged.
+	if st.Transactions[1].ID == st.Transactions[2].ID {
+		t.Errorf("equal transactions got the same id %q", st.Transactions[1].ID)
+	}
+	if st.Transactions[3].Error == "" {
+		t.Error("transaction with an invalid date has no error")
+	}
+}
+
+func TestParseQIFDateLayouts(t *testing.T) {
+	st, err := ParseQIF(strings.NewReader("!Type:CCard\nD05.01.2024\nT-10\n^\n"), []string{"02.01.2006"})
+	if err != nil {
+		t.Fatalf("ParseQIF: %v", err)
+	}
+	if len(st.Transactions) != 1 || st.Transactions[0].Date.Format("2006-01-02") != "2024-01-05" {
+		t.Errorf("transactions = %+v, want one on 2024-01-05", st.Transactions)
+	}
+}
+
+func TestParseQIFWithoutTransactions(t *testing.T) {
+	if _, err := ParseQIF(strings.NewReader("!Type:Cat\nNGroceries\n^\n"), nil); err == nil {
+		t.Error("ParseQIF of a category list succeeded")
+	}
+}
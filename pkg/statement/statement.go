@@ -0,0 +1,161 @@
+// Package statement parses bank statements (OFX, QIF, camt.053, MT940) into a common list of transactions.
+package statement
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/encoding/charmap"
+)
+
+// Formats of the statements.
+const (
+	FormatOFX     = "ofx"
+	FormatQIF     = "qif"
+	FormatCAMT053 = "camt053"
+	FormatMT940   = "mt940"
+)
+
+var ErrUnknownFormat = errors.New("unknown statement format")
+
+// Transaction is a single statement entry. Negative amount is a debit (expense), positive is a credit (income).
+type Transaction struct {
+	// Line is the line of the file where the transaction starts.
+	Line int
+	// ID is the bank transaction id (FITID). For formats without ids it is derived from the transaction contents.
+	ID string
+	// Date is the posting (booking) date.
+	Date time.Time
+	// ValueDate is the value date, if the format provides it.
+	ValueDate time.Time
+	Amount    float64
+	Currency  string
+	// Payee is the counterparty name.
+	Payee string
+	Memo  string
+	// Reference is the payment reference (end-to-end id, customer reference).
+	Reference string
+	Category  string
+	// Error is set when the transaction can't be parsed. The other fields may be incomplete then.
+	Error string
+}
+
+// Balance is a statement balance at the given date.
+type Balance struct {
+	Amount   float64
+	Currency string
+	Date     time.Time
+}
+
+// Statement is a parsed bank statement.
+type Statement struct {
+	// Account is the account number from the statement, if the format provides it.
+	Account  string
+	Currency string
+	// ClosingBalance is the ledger balance at the end of the statement, if the format provides it.
+	ClosingBalance *Balance
+	Transactions   []Transaction
+}
+
+// Detect guesses the statement format by its contents.
+func Detect(data []byte) (string, error) {
+	head := bytes.TrimLeft(bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF}), " \t\r\n")
+	upper := bytes.ToUpper(head[:min(len(head), 1024)])
+
+	switch {
+	case bytes.HasPrefix(upper, []byte("OFXHEADER")), bytes.Contains(upper, []byte("<OFX")):
+		return FormatOFX, nil
+	case bytes.Contains(upper, []byte("CAMT.053")), bytes.Contains(upper, []byte("<BKTOCSTMRSTMT")):
+		return FormatCAMT053, nil
+	case bytes.HasPrefix(upper, []byte("{1:")), bytes.Contains(upper, []byte(":20:")) && bytes.Contains(upper, []byte(":25:")):
+		return FormatMT940, nil
+	case bytes.HasPrefix(upper, []byte("!TYPE")), bytes.HasPrefix(upper, []byte("!ACCOUNT")), bytes.HasPrefix(upper, []byte("!OPTION")):
+		return FormatQIF, nil
+	}
+	return "", ErrUnknownFormat
+}
+
+// Parse parses the statement of the given format. dateLayouts are used only by the formats
+// with ambiguous dates (QIF); nil means the common layouts.
+func Parse(format string, r io.Reader, dateLayouts []string) (*Statement, error) {
+	switch strings.ToLower(format) {
+	case FormatOFX:
+		return ParseOFX(r)
+	case FormatQIF:
+		return ParseQIF(r, dateLayouts)
+	case FormatCAMT053, "camt.053":
+		return ParseCAMT053(r)
+	case FormatMT940:
+		return ParseMT940(r)
+	}
+	return nil, fmt.Errorf("%w: %q", ErrUnknownFormat, format)
+}
+
+// decode returns the file as a string. Files that are not valid UTF-8 are treated as Windows-1251,
+// which is what most Russian banks use for their exports.
+func decode(data []byte) (string, error) {
+	data = bytes.TrimPrefix(data, []byte{0xEF, 0xBB, 0xBF})
+	if utf8.Valid(data) {
+		return string(data), nil
+	}
+	decoded, err := charmap.Windows1251.NewDecoder().Bytes(data)
+	if err != nil {
+		return "", err
+	}
+	return string(decoded), nil
+}
+
+// parseAmount parses amounts written with either a decimal point or a decimal comma.
+func parseAmount(raw string) (float64, error) {
+	s := strings.Map(func(r rune) rune {
+		if r == ' ' || r == '\u00a0' || r == '\'' {
+			return -1
+		}
+		return r
+	}, strings.TrimSpace(raw))
+
+	if strings.Contains(s, ".") {
+		s = strings.ReplaceAll(s, ",", "")
+	} else {
+		s = strings.ReplaceAll(s, ",", ".")
+	}
+
+	value, err := strconv.ParseFloat(s, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount %q", raw)
+	}
+	return value, nil
+}
+
+// fillMissingIDs derives ids for the transactions without a bank id. Equal transactions
+// within one statement get a sequence suffix, so they are not merged.
+func (s *Statement) fillMissingIDs(prefix string) {
+	seen := make(map[string]int)
+	for i := range s.Transactions {
+		t := &s.Transactions[i]
+		if t.ID != "" || t.Error != "" {
+			continue
+		}
+
+		key := fmt.Sprintf("%s|%.2f|%s|%s|%s", t.Date.Format("2006-01-02"), t.Amount, t.Payee, t.Memo, t.Category)
+		if t.Reference != "" {
+			key += "|" + t.Reference
+		}
+		sum := sha1.Sum([]byte(key))
+		id := prefix + ":" + hex.EncodeToString(sum[:10])
+
+		seen[id]++
+		if n := seen[id]; n > 1 {
+			id = fmt.Sprintf("%s#%d", id, n)
+		}
+		t.ID = id
+	}
+}
@@ -0,0 +1,72 @@
+package statement
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    string
+		wantErr error
+	}{
+		{name: "ofx sgml", data: "OFXHEADER:100\nDATA:OFXSGML\n\n<OFX>", want: FormatOFX},
+		{name: "ofx xml with bom", data: "\xEF\xBB\xBF<?xml version=\"1.0\"?>\n<?OFX OFXHEADER=\"200\"?>\n<OFX>", want: FormatOFX},
+		{name: "camt.053", data: `<?xml version="1.0"?><Document xmlns="urn:iso:std:iso:20022:tech:xsd:camt.053.001.02">`, want: FormatCAMT053},
+		{name: "mt940 envelope", data: "{1:F01BANKBEBBAXXX0000000000}{2:I940BANKBEBBXXXXN}{4:\n:20:STMT", want: FormatMT940},
+		{name: "mt940 without envelope", data: ":20:STMT\n:25:123456789\n", want: FormatMT940},
+		{name: "qif", data: "!Type:Bank\nD01/05/2024\n", want: FormatQIF},
+		{name: "qif account", data: "\n!Account\nNChecking\n", want: FormatQIF},
+		{name: "csv", data: "date,amount\n2024-01-05,10\n", wantErr: ErrUnknownFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Detect([]byte(tt.data))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Detect() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("Detect() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAmount(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    float64
+		wantErr bool
+	}{
+		{raw: "1234.56", want: 1234.56},
+		{raw: "-12.5", want: -12.5},
+		{raw: "1234,56", want: 1234.56},
+		{raw: "1 234,56", want: 1234.56},
+		{raw: "1 234,56", want: 1234.56},
+		{raw: "1,234.56", want: 1234.56},
+		{raw: "1'000.00", want: 1000},
+		{raw: "200,", want: 200},
+		{raw: "", wantErr: true},
+		{raw: "abc", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parseAmount(tt.raw)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("parseAmount(%q) = %v, want error", tt.raw, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseAmount(%q): %v", tt.raw, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parseAmount(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}